@@ -0,0 +1,356 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of search results requested per page by the
+// search iterators when no limit is given
+const DefaultPageSize = 20
+
+// ArtistOptions tune the details of an artist
+type ArtistOptions struct {
+	// Dedupe groups the releases of the same song in the top songs
+	Dedupe bool
+	// Canonical is the song kept for each group: "plays" or "earliest"
+	Canonical string
+}
+
+// SearchOptions tune a search
+type SearchOptions struct {
+	// Page is the page to fetch, from 1
+	Page int
+	// Limit is the number of results per page, at most 50
+	Limit int
+	// Dedupe groups the releases of the same song, for song searches
+	Dedupe bool
+	// Canonical is the song kept for each group: "plays" or "earliest"
+	Canonical string
+	// Scripts are the query variants searched too: "latin", "phonetic",
+	// "devanagari", "all" or "none". The instance default applies when nil.
+	Scripts []string
+}
+
+// AutocompleteOptions tune autocomplete suggestions
+type AutocompleteOptions struct {
+	// Limit is the number of suggestions, at most 10
+	Limit int
+	// Dedupe groups the releases of the same song
+	Dedupe bool
+	// Canonical is the song kept for each group: "plays" or "earliest"
+	Canonical string
+	// Scripts are the query variants searched too, as in SearchOptions
+	Scripts []string
+}
+
+// MatchOptions tune the matching of a track
+type MatchOptions struct {
+	// Threshold is the minimum score of a match, between 0 and 1
+	Threshold float64
+	// Limit is the number of candidates returned, at most 20
+	Limit int
+}
+
+// ImportOptions tune a playlist import
+type ImportOptions struct {
+	// Format is csv, m3u, xspf, jspf or json; detected when empty
+	Format string
+	// Threshold is the minimum confidence of a match, between 0 and 1
+	Threshold float64
+	// Alternatives is the number of other candidates reported per track
+	Alternatives int
+}
+
+// Health checks that the instance is running
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.url("/health", nil), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &health, nil
+}
+
+// Song returns the details of a song
+func (c *Client) Song(ctx context.Context, id string) (*Song, error) {
+	return c.firstSong(ctx, "/song/"+url.PathEscape(id))
+}
+
+// SongByToken returns the details of a song from the token of its URL
+func (c *Client) SongByToken(ctx context.Context, token string) (*Song, error) {
+	return c.firstSong(ctx, "/songs/"+url.PathEscape(token))
+}
+
+// firstSong returns the song of a route answering with a list of one song
+func (c *Client) firstSong(ctx context.Context, path string) (*Song, error) {
+	var songs []Song
+	if err := c.getJSON(ctx, path, nil, &songs); err != nil {
+		return nil, err
+	}
+	if len(songs) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Message: "Song not found"}
+	}
+	return &songs[0], nil
+}
+
+// Album returns an album with its songs
+func (c *Client) Album(ctx context.Context, id string) (*Album, error) {
+	var album Album
+	if err := c.getJSON(ctx, "/album/"+url.PathEscape(id), nil, &album); err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// AlbumByToken returns an album from the token of its URL
+func (c *Client) AlbumByToken(ctx context.Context, token string) (*Album, error) {
+	// The name of the album is returned as its title
+	var album struct {
+		Album
+		Title string `json:"title"`
+	}
+	if err := c.getJSON(ctx, "/albums/"+url.PathEscape(token), nil, &album); err != nil {
+		return nil, err
+	}
+	if album.Name == "" {
+		album.Name = album.Title
+	}
+	return &album.Album, nil
+}
+
+// Artist returns an artist with their top songs and albums
+func (c *Client) Artist(ctx context.Context, id string, opts ArtistOptions) (*Artist, error) {
+	query := url.Values{}
+	setBool(query, "dedupe", opts.Dedupe)
+	setString(query, "canonical", opts.Canonical)
+
+	var artist Artist
+	if err := c.getJSON(ctx, "/artist/"+url.PathEscape(id), query, &artist); err != nil {
+		return nil, err
+	}
+	return &artist, nil
+}
+
+// Playlist returns a playlist with its songs, from its ID or the token of
+// its URL. Songs only carry their ID when the playlist lists its contents
+// without details.
+func (c *Client) Playlist(ctx context.Context, id string) (*Playlist, error) {
+	// The name of the playlist is returned as its title, unless it is
+	// resolved from its contents
+	var playlist struct {
+		Playlist
+		Title string `json:"title"`
+	}
+	if err := c.getJSON(ctx, "/playlists/"+url.PathEscape(id), nil, &playlist); err != nil {
+		return nil, err
+	}
+	if playlist.Name == "" {
+		playlist.Name = playlist.Title
+	}
+	return &playlist.Playlist, nil
+}
+
+// Lyrics returns the lyrics of a song
+func (c *Client) Lyrics(ctx context.Context, id string) (*Lyrics, error) {
+	var lyrics Lyrics
+	if err := c.getJSON(ctx, "/lyrics/"+url.PathEscape(id), nil, &lyrics); err != nil {
+		return nil, err
+	}
+	return &lyrics, nil
+}
+
+// LyricsText returns the lyrics of a song as plain text
+func (c *Client) LyricsText(ctx context.Context, id string) (string, error) {
+	return c.getText(ctx, "/lyrics/"+url.PathEscape(id), url.Values{"format": {"txt"}})
+}
+
+// LyricsLRC returns the synced lyrics of a song as an LRC file. Songs
+// without synced lyrics fail with status 406.
+func (c *Client) LyricsLRC(ctx context.Context, id string) (string, error) {
+	return c.getText(ctx, "/lyrics/"+url.PathEscape(id), url.Values{"format": {"lrc"}})
+}
+
+// SearchLyrics searches the lyrics indexed by the instance for a line or
+// fragment. A limit of 0 returns up to 10 songs.
+func (c *Client) SearchLyrics(ctx context.Context, query string, limit int) (*LyricsSearch, error) {
+	params := url.Values{"q": {query}}
+	setInt(params, "limit", limit)
+
+	var result LyricsSearch
+	if err := c.getJSON(ctx, "/lyrics/search", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SearchSongs searches songs
+func (c *Client) SearchSongs(ctx context.Context, query string, opts SearchOptions) (*models.Page[Song], error) {
+	return search[Song](ctx, c, "song", query, opts)
+}
+
+// SearchAlbums searches albums
+func (c *Client) SearchAlbums(ctx context.Context, query string, opts SearchOptions) (*models.Page[Album], error) {
+	return search[Album](ctx, c, "album", query, opts)
+}
+
+// SearchArtists searches artists
+func (c *Client) SearchArtists(ctx context.Context, query string, opts SearchOptions) (*models.Page[ArtistRef], error) {
+	return search[ArtistRef](ctx, c, "artist", query, opts)
+}
+
+// SearchPlaylists searches playlists
+func (c *Client) SearchPlaylists(ctx context.Context, query string, opts SearchOptions) (*models.Page[Playlist], error) {
+	return search[Playlist](ctx, c, "playlist", query, opts)
+}
+
+// SearchAllSongs iterates over the songs found by a search, page by page,
+// starting at opts.Page. Iteration stops after the first error.
+func (c *Client) SearchAllSongs(ctx context.Context, query string, opts SearchOptions) iter.Seq2[Song, error] {
+	return searchAll(ctx, c, "song", query, opts, func(song Song) string { return song.ID })
+}
+
+// SearchAllAlbums iterates over the albums found by a search, page by page
+func (c *Client) SearchAllAlbums(ctx context.Context, query string, opts SearchOptions) iter.Seq2[Album, error] {
+	return searchAll(ctx, c, "album", query, opts, func(album Album) string { return album.ID })
+}
+
+// SearchAllArtists iterates over the artists found by a search, page by page
+func (c *Client) SearchAllArtists(ctx context.Context, query string, opts SearchOptions) iter.Seq2[ArtistRef, error] {
+	return searchAll(ctx, c, "artist", query, opts, func(artist ArtistRef) string { return artist.ID })
+}
+
+// SearchAllPlaylists iterates over the playlists found by a search, page by page
+func (c *Client) SearchAllPlaylists(ctx context.Context, query string, opts SearchOptions) iter.Seq2[Playlist, error] {
+	return searchAll(ctx, c, "playlist", query, opts, func(playlist Playlist) string { return playlist.ID })
+}
+
+// search fetches one page of search results of a type
+func search[T any](ctx context.Context, c *Client, searchType, query string, opts SearchOptions) (*models.Page[T], error) {
+	params := url.Values{"q": {query}, "type": {searchType}}
+	setInt(params, "page", opts.Page)
+	setInt(params, "limit", opts.Limit)
+	setBool(params, "dedupe", opts.Dedupe)
+	setString(params, "canonical", opts.Canonical)
+	if opts.Scripts != nil {
+		params.Set("scripts", strings.Join(opts.Scripts, ","))
+	}
+
+	var page models.Page[T]
+	if err := c.getJSON(ctx, "/search", params, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// searchAll iterates over the results of a search. Results already seen on
+// a previous page are skipped, and iteration ends with the first page that
+// adds nothing or once every result of the search was seen.
+func searchAll[T any](ctx context.Context, c *Client, searchType, query string, opts SearchOptions, id func(T) string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if opts.Page < 1 {
+			opts.Page = 1
+		}
+		if opts.Limit < 1 {
+			opts.Limit = DefaultPageSize
+		}
+
+		seen := map[string]bool{}
+		for ; ; opts.Page++ {
+			page, err := search[T](ctx, c, searchType, query, opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			added := 0
+			for _, result := range page.Results {
+				if seen[id(result)] {
+					continue
+				}
+				seen[id(result)] = true
+				added++
+				if !yield(result, nil) {
+					return
+				}
+			}
+			if added == 0 || len(seen) >= page.Total {
+				return
+			}
+		}
+	}
+}
+
+// Autocomplete returns the songs suggested for a partial query
+func (c *Client) Autocomplete(ctx context.Context, query string, opts AutocompleteOptions) ([]Suggestion, error) {
+	params := url.Values{"q": {query}}
+	setInt(params, "limit", opts.Limit)
+	setBool(params, "dedupe", opts.Dedupe)
+	setString(params, "canonical", opts.Canonical)
+	if opts.Scripts != nil {
+		params.Set("scripts", strings.Join(opts.Scripts, ","))
+	}
+
+	var result struct {
+		Results []Suggestion `json:"results"`
+	}
+	if err := c.getJSON(ctx, "/search/autocomplete", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// Match finds the songs that best match a track
+func (c *Client) Match(ctx context.Context, track match.Track, opts MatchOptions) (*MatchResult, error) {
+	params := url.Values{"title": {track.Title}}
+	setString(params, "artist", strings.Join(track.Artists, ", "))
+	setString(params, "album", track.Album)
+	setInt(params, "duration", track.Duration)
+	if opts.Threshold > 0 {
+		params.Set("threshold", strconv.FormatFloat(opts.Threshold, 'f', -1, 64))
+	}
+	setInt(params, "limit", opts.Limit)
+
+	var result MatchResult
+	if err := c.getJSON(ctx, "/match", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Import matches the tracks of a CSV, M3U/M3U8, XSPF, JSPF or JSON playlist
+// to songs. Imports are not retried.
+func (c *Client) Import(ctx context.Context, playlist []byte, opts ImportOptions) (*ImportResult, error) {
+	params := url.Values{}
+	setString(params, "format", opts.Format)
+	if opts.Threshold > 0 {
+		params.Set("threshold", strconv.FormatFloat(opts.Threshold, 'f', -1, 64))
+	}
+	setInt(params, "alternatives", opts.Alternatives)
+
+	resp, err := c.do(ctx, http.MethodPost, c.url("/import", params), playlist, http.Header{"Content-Type": {"text/plain"}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ImportResult
+	if err := decodeEnvelope(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
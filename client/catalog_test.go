@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSearchSongsPage(t *testing.T) {
+	c, _ := newTestClient(t, nil)
+
+	page, err := c.SearchSongs(context.Background(), "tum hi ho", SearchOptions{Page: 2, Limit: 10, Scripts: []string{"none"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != searchTotal || len(page.Results) != 10 {
+		t.Fatalf("page has %d of %d results, want 10 of %d", len(page.Results), page.Total, searchTotal)
+	}
+	if first := page.Results[0]; first.ID != "song-11" || first.Name != "Song 11" {
+		t.Errorf("first result = %+v, want song-11", first)
+	}
+}
+
+func TestSearchAllSongs(t *testing.T) {
+	c, fake := newTestClient(t, nil)
+
+	var ids []string
+	for song, err := range c.SearchAllSongs(context.Background(), "tum hi ho", SearchOptions{Limit: 20, Scripts: []string{"none"}}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, song.ID)
+	}
+	if len(ids) != searchTotal {
+		t.Fatalf("got %d songs, want %d", len(ids), searchTotal)
+	}
+	for i, id := range ids {
+		if want := fmt.Sprintf("song-%d", i+1); id != want {
+			t.Fatalf("song %d = %s, want %s", i, id, want)
+		}
+	}
+	if n := fake.searches.Load(); n != 3 {
+		t.Errorf("searched %d pages, want 3", n)
+	}
+}
+
+func TestSearchAllSongsStop(t *testing.T) {
+	c, fake := newTestClient(t, nil)
+
+	count := 0
+	for _, err := range c.SearchAllSongs(context.Background(), "tum hi ho", SearchOptions{Page: 2, Limit: 10, Scripts: []string{"none"}}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count++; count == 15 {
+			break
+		}
+	}
+	if n := fake.searches.Load(); n != 2 {
+		t.Errorf("searched %d pages for 15 songs from page 2, want 2", n)
+	}
+}
+
+func TestSearchAllSongsError(t *testing.T) {
+	c, fake := newTestClient(t, nil)
+	c.MaxRetries = 0
+
+	fake.failures.Store(1)
+	var errs []error
+	for _, err := range c.SearchAllSongs(context.Background(), "tum hi ho", SearchOptions{Scripts: []string{"none"}}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrServer) {
+		t.Errorf("errors = %v, want one ErrServer", errs)
+	}
+}
@@ -0,0 +1,243 @@
+// Package client is a Go client for the JioSaavn API served by this module.
+// It decodes the {success, data} envelope of every route into typed models,
+// retries idempotent requests that fail transiently and reports error
+// payloads as *APIError values, which match ErrNotFound and the other
+// sentinel errors with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRetries is the number of times a failed request is retried
+const DefaultMaxRetries = 3
+
+// DefaultRetryBackoff is the delay before the first retry; it doubles with
+// every further attempt
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// maxRetryDelay caps the delay between two attempts, including delays asked
+// for with Retry-After
+const maxRetryDelay = 30 * time.Second
+
+// Client calls a JioSaavn API instance. Its fields may be changed before the
+// first request; a Client is safe for concurrent use afterwards.
+type Client struct {
+	// BaseURL is the root of the API, such as "http://localhost:8080"
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient when nil
+	HTTPClient *http.Client
+	// MaxRetries is the number of times GET and HEAD requests are retried
+	// after a network error, a 429 or a 5xx response. 0 disables retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled for every
+	// further attempt, unless the response carries a Retry-After header
+	RetryBackoff time.Duration
+	// UserAgent is sent with every request when set
+	UserAgent string
+}
+
+// New returns a client for the API at baseURL with the default retry policy
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		MaxRetries:   DefaultMaxRetries,
+		RetryBackoff: DefaultRetryBackoff,
+	}
+}
+
+// envelope is the body of every JSON response of the API
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+	Reason  string          `json:"reason"`
+}
+
+// getJSON sends a GET request and decodes the data of the envelope into out
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, c.url(path, query), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeEnvelope(resp, out)
+}
+
+// getText sends a GET request and returns the body of the response
+func (c *Client) getText(ctx context.Context, path string, query url.Values) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.url(path, query), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return string(body), nil
+}
+
+// decodeEnvelope decodes the data of a successful response into out
+func decodeEnvelope(resp *http.Response, out interface{}) error {
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !env.Success {
+		return &APIError{StatusCode: resp.StatusCode, Message: env.Error, Reason: env.Reason}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("failed to parse response data: %w", err)
+	}
+	return nil
+}
+
+// url returns the URL of a route of the API
+func (c *Client) url(path string, query url.Values) string {
+	target := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
+
+// do sends a request, retrying it when it is idempotent and fails
+// transiently. Responses with an error status are returned as *APIError,
+// with the body closed; other responses are returned open.
+func (c *Client) do(ctx context.Context, method, target string, body []byte, header http.Header) (*http.Response, error) {
+	retries := c.MaxRetries
+	if method != http.MethodGet && method != http.MethodHead {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reader)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+
+		resp, err := c.httpClient().Do(req)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		var delay time.Duration
+		if err == nil {
+			err = readAPIError(resp)
+			delay = retryAfter(resp.Header.Get("Retry-After"))
+		}
+		if attempt >= retries || !retryable(err) {
+			return nil, err
+		}
+		if delay == 0 {
+			delay = c.backoff(attempt)
+		}
+
+		timer := time.NewTimer(min(delay, maxRetryDelay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// backoff returns the delay before a retry: RetryBackoff doubled for every
+// previous retry, with up to 50% of jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	if c.RetryBackoff <= 0 {
+		return 0
+	}
+	delay := c.RetryBackoff << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryable reports whether a failed attempt may succeed when repeated:
+// network errors, rate limiting and server errors other than 501
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			(apiErr.StatusCode >= 500 && apiErr.StatusCode != http.StatusNotImplemented)
+	}
+	return err != nil
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// setString sets a query parameter when value is not empty
+func setString(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+// setInt sets a query parameter when value is positive
+func setInt(query url.Values, name string, value int) {
+	if value > 0 {
+		query.Set(name, strconv.Itoa(value))
+	}
+}
+
+// setBool sets a query parameter to true when value is set
+func setBool(query url.Values, name string, value bool) {
+	if value {
+		query.Set(name, "true")
+	}
+}
@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jioSaavnAPI/config"
+	"jioSaavnAPI/routes"
+	"jioSaavnAPI/services"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchTotal is the number of songs the fake upstream finds for any query
+const searchTotal = 45
+
+// fakeUpstream answers the upstream methods used by the tests: song s1, and
+// searchTotal songs song-1 to song-45 for every search, paged with p and n.
+// While failures is positive, calls fail with a 500 and decrement it.
+type fakeUpstream struct {
+	failures atomic.Int32
+	searches atomic.Int32
+}
+
+func (u *fakeUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if u.failures.Add(-1) >= 0 {
+		http.Error(w, "upstream down", http.StatusInternalServerError)
+		return
+	}
+	u.failures.Store(0)
+
+	query := r.URL.Query()
+	switch query.Get("__call") {
+	case "song.getDetails":
+		songs := map[string]interface{}{}
+		if query.Get("pids") == "s1" {
+			songs["s1"] = map[string]interface{}{
+				"id":                 "s1",
+				"song":               "Tum Hi Ho",
+				"album":              "Aashiqui 2",
+				"albumid":            "a1",
+				"year":               "2013",
+				"duration":           "262",
+				"language":           "hindi",
+				"primary_artists":    "Arijit Singh",
+				"primary_artists_id": "459320",
+				"image":              "https://c.saavncdn.com/s1-150x150.jpg",
+			}
+		}
+		json.NewEncoder(w).Encode(songs)
+
+	case "search.getResults":
+		u.searches.Add(1)
+		page, _ := strconv.Atoi(query.Get("p"))
+		limit, _ := strconv.Atoi(query.Get("n"))
+		start := min((page-1)*limit, searchTotal)
+		results := []map[string]interface{}{}
+		for i := start; i < min(start+limit, searchTotal); i++ {
+			results = append(results, map[string]interface{}{
+				"id":    fmt.Sprintf("song-%d", i+1),
+				"title": fmt.Sprintf("Song %d", i+1),
+				"type":  "song",
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total": searchTotal, "start": start + 1, "results": results})
+
+	default:
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}
+}
+
+// newTestClient serves the API routes over a fake upstream and returns a
+// client of them. wrap, when not nil, wraps the router.
+func newTestClient(t *testing.T, wrap func(http.Handler) http.Handler) (*Client, *fakeUpstream) {
+	t.Helper()
+	fake := &fakeUpstream{}
+	upstream := httptest.NewServer(fake)
+	t.Cleanup(upstream.Close)
+
+	previous := config.LoadConfig().JioSaavnBaseURL
+	if err := config.Override(map[string]string{"JIOSAAVN_BASE_URL": upstream.URL}); err != nil {
+		t.Fatal(err)
+	}
+	services.Configure()
+	t.Cleanup(func() {
+		config.Override(map[string]string{"JIOSAAVN_BASE_URL": previous})
+		services.Configure()
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.RegisterRoutes(r)
+	var handler http.Handler = r
+	if wrap != nil {
+		handler = wrap(r)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := New(server.URL)
+	c.RetryBackoff = time.Millisecond
+	return c, fake
+}
+
+// countRequests wraps a handler and counts the requests it receives
+func countRequests(count *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestSong(t *testing.T) {
+	c, _ := newTestClient(t, nil)
+
+	song, err := c.Song(context.Background(), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.ID != "s1" || song.Name != "Tum Hi Ho" || song.Year != "2013" || song.Duration != 262 {
+		t.Errorf("song = %+v", song)
+	}
+	if song.Album.ID != "a1" || song.Album.Name != "Aashiqui 2" {
+		t.Errorf("album = %+v", song.Album)
+	}
+	if song.Image.Largest() == "" {
+		t.Errorf("song has no image")
+	}
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+		err    error
+	}{
+		{"data", http.StatusOK, `{"success": true, "data": {"id": "s1", "name": "Tum Hi Ho"}}`, "Tum Hi Ho", nil},
+		{"no data", http.StatusOK, `{"success": true}`, "", nil},
+		{"failure", http.StatusNotFound, `{"success": false, "error": "Song not found"}`, "", ErrNotFound},
+		{"failure with ok status", http.StatusOK, `{"success": false, "error": "Invalid quality"}`, "", &APIError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+			var song Song
+			err := decodeEnvelope(resp, &song)
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatalf("err = %v", err)
+			case tt.err == ErrNotFound && !errors.Is(err, ErrNotFound):
+				t.Fatalf("err = %v, want ErrNotFound", err)
+			case tt.err != nil:
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+					t.Fatalf("err = %#v, want *APIError with status %d", err, tt.status)
+				}
+			}
+			if song.Name != tt.want {
+				t.Errorf("name = %q, want %q", song.Name, tt.want)
+			}
+		})
+	}
+
+	resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("<html>"))}
+	if err := decodeEnvelope(resp, nil); err == nil || !strings.Contains(err.Error(), "failed to parse response") {
+		t.Errorf("invalid JSON: err = %v", err)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	var count atomic.Int32
+	c, _ := newTestClient(t, countRequests(&count))
+	ctx := context.Background()
+
+	_, err := c.Song(ctx, "unknown")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "Song not found" {
+		t.Errorf("err = %+v", apiErr)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrServer) {
+		t.Errorf("404 should match ErrNotFound only")
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("404 was requested %d times, want once", n)
+	}
+
+	if _, err := c.SearchSongs(ctx, "", SearchOptions{}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("search without query: err = %v, want ErrBadRequest", err)
+	}
+
+	for status, sentinel := range map[int]error{
+		http.StatusBadRequest:          ErrBadRequest,
+		http.StatusForbidden:           ErrForbidden,
+		http.StatusNotFound:            ErrNotFound,
+		http.StatusGone:                ErrLinkExpired,
+		http.StatusTooManyRequests:     ErrRateLimited,
+		http.StatusInternalServerError: ErrServer,
+		http.StatusBadGateway:          ErrServer,
+	} {
+		err := &APIError{StatusCode: status}
+		if !errors.Is(err, sentinel) {
+			t.Errorf("status %d does not match %v", status, sentinel)
+		}
+		if status != http.StatusNotFound && errors.Is(err, ErrNotFound) {
+			t.Errorf("status %d matches ErrNotFound", status)
+		}
+	}
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	var count atomic.Int32
+	c, fake := newTestClient(t, countRequests(&count))
+	ctx := context.Background()
+
+	// The API answers 500 while upstream fails
+	fake.failures.Store(2)
+	song, err := c.Song(ctx, "s1")
+	if err != nil {
+		t.Fatalf("Song after 2 failures: %v", err)
+	}
+	if song.ID != "s1" {
+		t.Errorf("song = %+v", song)
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+
+	count.Store(0)
+	fake.failures.Store(10)
+	_, err = c.Song(ctx, "s1")
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("err = %v, want ErrServer", err)
+	}
+	if n := count.Load(); n != DefaultMaxRetries+1 {
+		t.Errorf("requests = %d, want %d", n, DefaultMaxRetries+1)
+	}
+
+	count.Store(0)
+	fake.failures.Store(1)
+	c.MaxRetries = 0
+	if _, err := c.Song(ctx, "s1"); !errors.Is(err, ErrServer) {
+		t.Errorf("without retries: err = %v, want ErrServer", err)
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("without retries: requests = %d, want 1", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var count atomic.Int32
+	limited := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"success": false, "error": "Too many requests"}`)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	c, _ := newTestClient(t, limited)
+
+	start := time.Now()
+	if _, err := c.Song(context.Background(), "s1"); err != nil {
+		t.Fatalf("Song after 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s of Retry-After", elapsed)
+	}
+	if n := count.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+
+	// A cancelled context stops waiting for the retry
+	count.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Song(ctx, "s1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":                              0,
+		"0":                             0,
+		"3":                             3 * time.Second,
+		"soon":                          0,
+		"Mon, 02 Jan 2006 15:04:05 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := retryAfter(value); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(future); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %v, want about a minute", future, got)
+	}
+}
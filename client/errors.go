@@ -0,0 +1,134 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors matched by *APIError values with errors.Is, by status code
+var (
+	// ErrBadRequest is matched by 400 responses, such as a missing query or
+	// an invalid quality
+	ErrBadRequest = errors.New("bad request")
+	// ErrForbidden is matched by 403 responses, such as a download link with
+	// a wrong signature
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is matched by 404 responses: unknown songs, albums,
+	// artists, playlists and lyrics, and media that is not available
+	ErrNotFound = errors.New("not found")
+	// ErrLinkExpired is matched by 410 responses to expired download links
+	ErrLinkExpired = errors.New("link expired")
+	// ErrRateLimited is matched by 429 responses
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServer is matched by 5xx responses, usually an upstream failure
+	ErrServer = errors.New("server error")
+)
+
+// APIError is an error response of the API
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Message is the error of the {"success": false, "error": ...} payload,
+	// or the body of a response that is not JSON
+	Message string
+	// Reason is the media failure reason reported by the media routes, such
+	// as "unavailable" or "tokenized_url"
+	Reason string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf("jiosaavn api: %d: %s (%s)", e.StatusCode, message, e.Reason)
+	}
+	return fmt.Sprintf("jiosaavn api: %d: %s", e.StatusCode, message)
+}
+
+// Is matches the sentinel error of the status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrLinkExpired:
+		return e.StatusCode == http.StatusGone
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 64 << 10
+
+// readAPIError reads and closes an error response
+func readAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var payload struct {
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+		apiErr.Reason = payload.Reason
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+// GraphQLError is an error reported by the /graphql endpoint
+type GraphQLError struct {
+	Message   string `json:"message"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations,omitempty"`
+	Path []interface{} `json:"path,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// GraphQLErrors are the errors of a GraphQL response. They are returned
+// together with any data that could be resolved.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// SubsonicError is a failed response of the Subsonic API
+type SubsonicError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *SubsonicError) Error() string {
+	return fmt.Sprintf("subsonic: error %d: %s", e.Code, e.Message)
+}
+
+// Is matches ErrNotFound for the "data not found" error code
+func (e *SubsonicError) Is(target error) bool {
+	return target == ErrNotFound && e.Code == 70
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Media is an audio file, archive or export being downloaded. The caller
+// must close its body.
+type Media struct {
+	Body io.ReadCloser
+	// StatusCode is 206 for partial content
+	StatusCode    int
+	ContentType   string
+	ContentLength int64
+	Header        http.Header
+}
+
+// Filename returns the file name suggested by the Content-Disposition
+// header, or "" when there is none
+func (m *Media) Filename() string {
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// StreamOptions tune a stream
+type StreamOptions struct {
+	// Quality is the bitrate in kbps: 12, 48, 96, 160 or 320. The closest
+	// lower bitrate is streamed when it is not available.
+	Quality string
+	// Range is a byte range, such as "bytes=0-1023"
+	Range string
+	// Expires and Signature are those of a signed stream link, required
+	// when the instance signs its links
+	Expires   string
+	Signature string
+}
+
+// DownloadOptions tune a download
+type DownloadOptions struct {
+	// Quality is the bitrate in kbps: 12, 48, 96, 160 or 320
+	Quality string
+	// Tagged embeds the metadata, lyrics and cover art into the M4A
+	Tagged bool
+	// Expires and Signature are those of a signed download link, required
+	// when the instance signs its links
+	Expires   string
+	Signature string
+}
+
+// ExportOptions tune the export of an album or playlist
+type ExportOptions struct {
+	// Format is m3u8, xspf, jspf or csv
+	Format string
+	// Location is the location of the tracks: "stream" or "download"
+	Location string
+}
+
+// Stream streams the audio of a song through the instance
+func (c *Client) Stream(ctx context.Context, id string, opts StreamOptions) (*Media, error) {
+	header := http.Header{}
+	if opts.Range != "" {
+		header.Set("Range", opts.Range)
+	}
+	return c.media(ctx, http.MethodGet, c.url("/stream/"+url.PathEscape(id), streamQuery(opts)), header)
+}
+
+// StreamInfo returns the headers of the stream of a song, such as its
+// length and the quality selected, without its body. The range is ignored.
+func (c *Client) StreamInfo(ctx context.Context, id string, opts StreamOptions) (*Media, error) {
+	return c.media(ctx, http.MethodHead, c.url("/stream/"+url.PathEscape(id), streamQuery(opts)), nil)
+}
+
+func streamQuery(opts StreamOptions) url.Values {
+	query := url.Values{}
+	setString(query, "quality", strings.TrimSuffix(opts.Quality, "kbps"))
+	setString(query, "exp", opts.Expires)
+	setString(query, "sig", opts.Signature)
+	return query
+}
+
+// Download downloads the audio file of a song, following the redirect to
+// the CDN when the instance does not proxy downloads
+func (c *Client) Download(ctx context.Context, id string, opts DownloadOptions) (*Media, error) {
+	query := url.Values{}
+	setString(query, "quality", strings.TrimSuffix(opts.Quality, "kbps"))
+	setBool(query, "tagged", opts.Tagged)
+	setString(query, "exp", opts.Expires)
+	setString(query, "sig", opts.Signature)
+	return c.media(ctx, http.MethodGet, c.url("/download/"+url.PathEscape(id), query), nil)
+}
+
+// Open downloads a URL returned by the API, such as one of the download
+// URLs of a song, which may point at the instance or at the CDN
+func (c *Client) Open(ctx context.Context, rawURL string) (*Media, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if !target.IsAbs() {
+		rawURL = strings.TrimRight(c.BaseURL, "/") + rawURL
+	}
+	return c.media(ctx, http.MethodGet, rawURL, nil)
+}
+
+// AlbumZip downloads the tagged songs of an album as a ZIP archive. quality
+// is the bitrate in kbps, 320 when empty.
+func (c *Client) AlbumZip(ctx context.Context, id, quality string) (*Media, error) {
+	query := url.Values{}
+	setString(query, "quality", strings.TrimSuffix(quality, "kbps"))
+	return c.media(ctx, http.MethodGet, c.url("/album/"+url.PathEscape(id)+"/download.zip", query), nil)
+}
+
+// PlaylistZip downloads the tagged songs of a playlist as a ZIP archive
+func (c *Client) PlaylistZip(ctx context.Context, id, quality string) (*Media, error) {
+	query := url.Values{}
+	setString(query, "quality", strings.TrimSuffix(quality, "kbps"))
+	return c.media(ctx, http.MethodGet, c.url("/playlists/"+url.PathEscape(id)+"/download.zip", query), nil)
+}
+
+// ExportAlbum exports an album as a playlist file
+func (c *Client) ExportAlbum(ctx context.Context, id string, opts ExportOptions) (string, error) {
+	return c.getText(ctx, "/album/"+url.PathEscape(id), exportQuery(opts))
+}
+
+// ExportPlaylist exports a playlist as a playlist file
+func (c *Client) ExportPlaylist(ctx context.Context, id string, opts ExportOptions) (string, error) {
+	return c.getText(ctx, "/playlists/"+url.PathEscape(id), exportQuery(opts))
+}
+
+func exportQuery(opts ExportOptions) url.Values {
+	query := url.Values{"format": {opts.Format}}
+	setString(query, "location", opts.Location)
+	return query
+}
+
+// SongHLS returns an HLS playlist of a song: the master playlist when
+// quality is empty, else the media playlist of that bitrate. source is
+// "proxy" (the default) or "direct" for CDN segment URLs, which instances
+// that sign their links refuse.
+func (c *Client) SongHLS(ctx context.Context, id, quality, source string) (string, error) {
+	return c.hls(ctx, "/hls/song/", id, quality, source)
+}
+
+// AlbumHLS returns an HLS playlist of an album, as SongHLS does for a song
+func (c *Client) AlbumHLS(ctx context.Context, id, quality, source string) (string, error) {
+	return c.hls(ctx, "/hls/album/", id, quality, source)
+}
+
+func (c *Client) hls(ctx context.Context, prefix, id, quality, source string) (string, error) {
+	path := prefix + url.PathEscape(id) + ".m3u8"
+	if quality != "" {
+		path = prefix + url.PathEscape(id) + "/" + url.PathEscape(strings.TrimSuffix(quality, "kbps")) + ".m3u8"
+	}
+	query := url.Values{}
+	setString(query, "source", source)
+	return c.getText(ctx, path, query)
+}
+
+// media sends a request for a file and returns its open body
+func (c *Client) media(ctx context.Context, method, target string, header http.Header) (*Media, error) {
+	resp, err := c.do(ctx, method, target, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return &Media{
+		Body:          resp.Body,
+		StatusCode:    resp.StatusCode,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		Header:        resp.Header,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/models"
+)
+
+// The models of the catalog are those of jioSaavnAPI/models, which the saavn
+// library shares
+type (
+	Image       = models.Image
+	Images      = models.Images
+	DownloadURL = models.DownloadURL
+	ArtistRef   = models.ArtistRef
+	Artists     = models.Artists
+	AlbumRef    = models.AlbumRef
+	Song        = models.Song
+	Album       = models.Album
+	Artist      = models.Artist
+	Playlist    = models.Playlist
+	LyricsLine  = models.LyricsLine
+	Lyrics      = models.Lyrics
+	Suggestion  = models.Suggestion
+)
+
+// LyricsMatch is a song whose lyrics match a lyrics search
+type LyricsMatch struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Artists string `json:"artists"`
+	// Line is the best matching line and LineNumber its number, from 1
+	Line       string `json:"line"`
+	LineNumber int    `json:"lineNumber"`
+	// Highlight is the line with the matched words wrapped in <em> tags
+	Highlight string `json:"highlight"`
+	Score     int    `json:"score"`
+}
+
+// LyricsSearch is the result of a lyrics search
+type LyricsSearch struct {
+	Total int `json:"total"`
+	// Indexed is the number of songs in the lyrics index of the instance
+	Indexed int           `json:"indexed"`
+	Results []LyricsMatch `json:"results"`
+}
+
+// MatchCandidate is a song scored against a track description
+type MatchCandidate struct {
+	Song Song `json:"song"`
+	// Score is between 0 and 1
+	Score     float64                  `json:"score"`
+	Breakdown map[string]match.Feature `json:"breakdown"`
+	Penalties []string                 `json:"penalties"`
+}
+
+// MatchResult is the result of matching a track
+type MatchResult struct {
+	Input match.Track `json:"input"`
+	// Match is the best candidate, nil when none scored above the threshold
+	Match      *MatchCandidate  `json:"match"`
+	Candidates []MatchCandidate `json:"candidates"`
+}
+
+// ImportCandidate is a song considered for an imported track
+type ImportCandidate struct {
+	Song       Song                     `json:"song"`
+	Confidence float64                  `json:"confidence"`
+	Breakdown  map[string]match.Feature `json:"breakdown"`
+	Penalties  []string                 `json:"penalties"`
+}
+
+// ImportMatch is the outcome of resolving one imported track
+type ImportMatch struct {
+	Index        int               `json:"index"`
+	Input        match.Track       `json:"input"`
+	Match        *ImportCandidate  `json:"match"`
+	Alternatives []ImportCandidate `json:"alternatives"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// ImportResult is the outcome of importing a playlist
+type ImportResult struct {
+	Format    string        `json:"format"`
+	Total     int           `json:"total"`
+	Matched   int           `json:"matched"`
+	Tracks    []ImportMatch `json:"tracks"`
+	Unmatched []ImportMatch `json:"unmatched"`
+}
+
+// Health is the response of the health check
+type Health struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
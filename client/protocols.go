@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jioSaavnAPI/dlna"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SubsonicVersion is the Subsonic API version sent with Subsonic requests
+const SubsonicVersion = "1.16.1"
+
+// GraphQL runs a GraphQL query and decodes its data into out. When some
+// fields fail, the data that could be resolved is decoded and the errors are
+// returned as GraphQLErrors. Queries are sent with POST and are not retried.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	resp, err := c.do(ctx, http.MethodPost, c.url("/graphql", nil), body, http.Header{"Content-Type": {"application/json"}})
+	if err != nil {
+		// Queries rejected before execution are answered with 400 and the
+		// errors, without data
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && json.Unmarshal([]byte(apiErr.Message), &result) == nil && len(result.Errors) > 0 {
+			return result.Errors
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if out != nil && len(result.Data) > 0 && string(result.Data) != "null" {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("failed to parse response data: %w", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+	return nil
+}
+
+// SubsonicAuth returns the parameters authenticating a Subsonic request with
+// a salted token, to be merged into the parameters given to Subsonic
+func SubsonicAuth(user, password string) url.Values {
+	b := make([]byte, 8)
+	rand.Read(b)
+	salt := hex.EncodeToString(b)
+	sum := md5.Sum([]byte(password + salt))
+	return url.Values{"u": {user}, "t": {hex.EncodeToString(sum[:])}, "s": {salt}}
+}
+
+// Subsonic calls a method of the Subsonic API, such as "search3" or
+// "getAlbum", and decodes the subsonic-response object into out. params
+// must carry the credentials, see SubsonicAuth. Failed responses are
+// returned as *SubsonicError.
+func (c *Client) Subsonic(ctx context.Context, method string, params url.Values, out interface{}) error {
+	query := url.Values{}
+	for name, values := range params {
+		query[name] = values
+	}
+	query.Set("f", "json")
+	if query.Get("v") == "" {
+		query.Set("v", SubsonicVersion)
+	}
+	if query.Get("c") == "" {
+		query.Set("c", "jioSaavnAPI-client")
+	}
+
+	var result struct {
+		Response json.RawMessage `json:"subsonic-response"`
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.url("/rest/"+url.PathEscape(method), query), nil, nil)
+	if err != nil {
+		// Unknown methods are answered with 404 and a failed response
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && json.Unmarshal([]byte(apiErr.Message), &result) == nil && result.Response != nil {
+			return subsonicFailure(result.Response)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := subsonicFailure(result.Response); err != nil {
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(result.Response, out); err != nil {
+			return fmt.Errorf("failed to parse response data: %w", err)
+		}
+	}
+	return nil
+}
+
+// subsonicFailure returns the error of a failed subsonic-response object
+func subsonicFailure(response json.RawMessage) error {
+	var status struct {
+		Status string         `json:"status"`
+		Error  *SubsonicError `json:"error"`
+	}
+	if err := json.Unmarshal(response, &status); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if status.Status == "ok" {
+		return nil
+	}
+	if status.Error == nil {
+		return &SubsonicError{Message: "request failed"}
+	}
+	return status.Error
+}
+
+// dlnaServiceTypes are the UPnP service types of the DLNA services, by name
+var dlnaServiceTypes = map[string]string{
+	"ContentDirectory":  dlna.ContentDirectoryType,
+	"ConnectionManager": dlna.ConnectionManagerType,
+}
+
+// DLNADescription returns the UPnP device description of the media server
+func (c *Client) DLNADescription(ctx context.Context) (string, error) {
+	return c.getText(ctx, "/dlna/description.xml", nil)
+}
+
+// DLNAServiceDescription returns the description of a UPnP service:
+// "ContentDirectory" or "ConnectionManager"
+func (c *Client) DLNAServiceDescription(ctx context.Context, service string) (string, error) {
+	return c.getText(ctx, "/dlna/scpd/"+url.PathEscape(service)+".xml", nil)
+}
+
+// DLNAControl invokes a SOAP action of a UPnP service, such as Browse on
+// "ContentDirectory", and returns the arguments of the response
+func (c *Client) DLNAControl(ctx context.Context, service, action string, args []dlna.Arg) (map[string]string, error) {
+	serviceType, ok := dlnaServiceTypes[service]
+	if !ok {
+		return nil, fmt.Errorf("unknown DLNA service %q", service)
+	}
+	return dlna.Call(ctx, c.url("/dlna/control/"+service, nil), serviceType, action, args)
+}
+
+// DLNASubscribe subscribes a callback URL to the events of a UPnP service
+// and returns the subscription ID. A zero timeout leaves the duration of the
+// subscription to the server.
+func (c *Client) DLNASubscribe(ctx context.Context, service, callback string, timeout time.Duration) (string, error) {
+	header := http.Header{"Callback": {"<" + callback + ">"}, "Nt": {"upnp:event"}}
+	if timeout > 0 {
+		header.Set("Timeout", "Second-"+strconv.Itoa(int(timeout.Seconds())))
+	}
+	resp, err := c.do(ctx, "SUBSCRIBE", c.url("/dlna/event/"+url.PathEscape(service), nil), nil, header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("SID"), nil
+}
+
+// DLNAUnsubscribe cancels a subscription to the events of a UPnP service
+func (c *Client) DLNAUnsubscribe(ctx context.Context, service, sid string) error {
+	resp, err := c.do(ctx, "UNSUBSCRIBE", c.url("/dlna/event/"+url.PathEscape(service), nil), nil, http.Header{"Sid": {sid}})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
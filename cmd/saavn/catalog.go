@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jioSaavnAPI/config"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// runSearch searches the catalog
+func runSearch(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("search")
+	output := outputFlag(fs)
+	searchType := fs.String("type", saavn.KindSong, "what to search: song, album, artist or playlist")
+	scriptsFlag := fs.String("scripts", strings.Join(config.LoadConfig().SearchScripts, ","), "query variants to search too, comma-separated: latin, phonetic, devanagari, all or none")
+	limit := fs.Int("limit", 0, "maximum number of results, 0 for all")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	scripts, err := match.ParseScripts(*scriptsFlag)
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(fs.Args(), " ")
+	var v view
+	switch *searchType {
+	case saavn.KindSong:
+		page, err := lib.SearchSongs(ctx, query, scripts)
+		if err != nil {
+			return err
+		}
+		page.Results = limitResults(page.Results, *limit)
+		v = view{value: page, items: items(page.Results), tables: []table{songTable("", page.Results)}}
+
+	case saavn.KindAlbum:
+		page, err := lib.SearchAlbums(ctx, query, scripts)
+		if err != nil {
+			return err
+		}
+		page.Results = limitResults(page.Results, *limit)
+		v = view{value: page, items: items(page.Results), tables: []table{albumTable("", page.Results)}}
+
+	case saavn.KindArtist:
+		page, err := lib.SearchArtists(ctx, query, scripts)
+		if err != nil {
+			return err
+		}
+		page.Results = limitResults(page.Results, *limit)
+		t := table{header: []string{"#", "ID", "NAME", "DESCRIPTION", "URL"}}
+		for i, artist := range page.Results {
+			t.rows = append(t.rows, []string{strconv.Itoa(i + 1), artist.ID, artist.Name, artist.Description, artist.URL})
+		}
+		v = view{value: page, items: items(page.Results), tables: []table{t}}
+
+	case saavn.KindPlaylist:
+		page, err := lib.SearchPlaylists(ctx, query, scripts)
+		if err != nil {
+			return err
+		}
+		page.Results = limitResults(page.Results, *limit)
+		t := table{header: []string{"#", "ID", "NAME", "SONGS", "LANGUAGE", "URL"}}
+		for i, playlist := range page.Results {
+			t.rows = append(t.rows, []string{strconv.Itoa(i + 1), playlist.ID, playlist.Name, strconv.Itoa(playlist.SongCount), playlist.Language, playlist.URL})
+		}
+		v = view{value: page, items: items(page.Results), tables: []table{t}}
+
+	default:
+		return fmt.Errorf("unknown search type %q, expected song, album, artist or playlist", *searchType)
+	}
+	return v.print(os.Stdout, *output)
+}
+
+// limitResults keeps the first limit results, or all of them when limit is 0
+func limitResults[T any](results []T, limit int) []T {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+// runSong shows songs
+func runSong(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("song")
+	output := outputFlag(fs)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	songs := []saavn.Song{}
+	for _, arg := range fs.Args() {
+		song, err := lookupSong(ctx, lib, arg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		songs = append(songs, *song)
+	}
+
+	v := view{value: songs, items: items(songs), tables: []table{songTable("", songs)}}
+	if len(songs) == 1 {
+		v.value = songs[0]
+		v.heading = []string{songs[0].Name + " · " + songs[0].URL}
+	}
+	return v.print(os.Stdout, *output)
+}
+
+// runAlbum shows an album with its songs
+func runAlbum(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("album")
+	output := outputFlag(fs)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	album, err := lookupAlbum(ctx, lib, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	heading := []string{
+		joinNonEmpty(" · ", album.Name, artistNames(album.Artists.Primary), album.Year, album.Language),
+		fmt.Sprintf("%d songs · %s", len(album.Songs), album.URL),
+	}
+	v := view{value: album, items: items(album.Songs), heading: heading, tables: []table{songTable("", album.Songs)}}
+	return v.print(os.Stdout, *output)
+}
+
+// runArtist shows an artist with their top songs and albums
+func runArtist(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("artist")
+	output := outputFlag(fs)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	artist, err := lookupArtist(ctx, lib, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	heading := []string{
+		joinNonEmpty(" · ", artist.Name, artist.DominantLanguage, artist.DominantType),
+		joinNonEmpty(" · ", strconv.FormatInt(artist.FollowerCount, 10)+" followers", artist.URL),
+	}
+	v := view{
+		value:   artist,
+		items:   items(artist.TopSongs),
+		heading: heading,
+		tables: []table{
+			songTable("Top songs", artist.TopSongs),
+			albumTable("Top albums", artist.TopAlbums),
+		},
+	}
+	return v.print(os.Stdout, *output)
+}
+
+// runPlaylist shows a playlist with its songs
+func runPlaylist(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("playlist")
+	output := outputFlag(fs)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	playlist, err := lookupPlaylist(ctx, lib, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	heading := []string{
+		joinNonEmpty(" · ", playlist.Name, playlist.Description),
+		fmt.Sprintf("%d songs · %s", playlist.SongCount, playlist.URL),
+	}
+	v := view{value: playlist, items: items(playlist.Songs), heading: heading, tables: []table{songTable("", playlist.Songs)}}
+	return v.print(os.Stdout, *output)
+}
+
+// runLyrics prints the lyrics of a song
+func runLyrics(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("lyrics")
+	output := outputFlag(fs)
+	lrc := fs.Bool("lrc", false, "print time-synced lyrics as an LRC file")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	id, err := songID(ctx, lib, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Upstream does not report missing lyrics consistently, so the song is
+	// checked first
+	songData, err := lib.FetchSong(ctx, id)
+	if err != nil {
+		return err
+	}
+	if utils.GetString(songData, "has_lyrics") != "true" {
+		return errors.New("lyrics not available for this song")
+	}
+	lyrics, err := lib.Lyrics(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(lyrics.Lines) == 0 {
+		return errors.New("lyrics not available for this song")
+	}
+
+	if *output != outputTable {
+		return view{value: lyrics, items: items(lyrics.Lines)}.print(os.Stdout, *output)
+	}
+	if *lrc {
+		if !lyrics.Synced {
+			return errors.New("time-synced lyrics are not available for this song")
+		}
+		fmt.Print(lyrics.LRC(
+			utils.GetString(songData, "song"),
+			utils.GetString(songData, "primary_artists"),
+			utils.GetString(songData, "album"),
+			utils.GetInt(songData, "duration"),
+		))
+		return nil
+	}
+	fmt.Println(lyrics.Text())
+	return nil
+}
+
+// resolved is a catalog entry a URL was resolved to
+type resolved struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// runResolve resolves JioSaavn URLs to their entries
+func runResolve(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("resolve")
+	output := outputFlag(fs)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	entries := []resolved{}
+	for _, arg := range fs.Args() {
+		entry, err := resolveURL(ctx, lib, arg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		entries = append(entries, *entry)
+	}
+
+	t := table{header: []string{"TYPE", "ID", "NAME", "URL"}}
+	for _, entry := range entries {
+		t.rows = append(t.rows, []string{entry.Type, entry.ID, entry.Name, entry.URL})
+	}
+	v := view{value: entries, items: items(entries), tables: []table{t}}
+	if len(entries) == 1 {
+		v.value = entries[0]
+	}
+	return v.print(os.Stdout, *output)
+}
+
+// resolveURL looks up the entry of a JioSaavn URL
+func resolveURL(ctx context.Context, lib *saavn.Client, rawURL string) (*resolved, error) {
+	kind, token, err := saavn.ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case saavn.KindSong:
+		song, err := lib.SongByToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &resolved{kind, song.ID, song.Name, song.URL}, nil
+	case saavn.KindAlbum:
+		album, err := lib.AlbumByToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &resolved{kind, album.ID, album.Name, album.URL}, nil
+	case saavn.KindArtist:
+		artist, err := lib.ArtistByToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &resolved{kind, artist.ID, artist.Name, artist.URL}, nil
+	default:
+		playlist, err := lib.PlaylistByToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &resolved{kind, playlist.ID, playlist.Name, playlist.URL}, nil
+	}
+}
+
+// isURL reports whether an argument is a URL rather than an ID
+func isURL(arg string) bool {
+	return strings.Contains(arg, "/")
+}
+
+// isNumeric reports whether an argument is a numeric ID, as those of albums,
+// artists and playlists are, rather than the token of a URL
+func isNumeric(arg string) bool {
+	_, err := strconv.ParseUint(arg, 10, 64)
+	return err == nil
+}
+
+// urlToken returns the token of a URL of the given kind
+func urlToken(arg, want string) (string, error) {
+	kind, token, err := saavn.ParseURL(arg)
+	if err != nil {
+		return "", err
+	}
+	if kind != want {
+		return "", fmt.Errorf("URL of a %s, not of a %s", kind, want)
+	}
+	return token, nil
+}
+
+// lookupSong returns a song from its ID or URL
+func lookupSong(ctx context.Context, lib *saavn.Client, arg string) (*saavn.Song, error) {
+	if !isURL(arg) {
+		return lib.Song(ctx, arg)
+	}
+	token, err := urlToken(arg, saavn.KindSong)
+	if err != nil {
+		return nil, err
+	}
+	return lib.SongByToken(ctx, token)
+}
+
+// songID returns the ID of a song from its ID or URL
+func songID(ctx context.Context, lib *saavn.Client, arg string) (string, error) {
+	if !isURL(arg) {
+		return arg, nil
+	}
+	song, err := lookupSong(ctx, lib, arg)
+	if err != nil {
+		return "", err
+	}
+	return song.ID, nil
+}
+
+// lookupAlbum returns an album from its ID, URL or the token of its URL
+func lookupAlbum(ctx context.Context, lib *saavn.Client, arg string) (*saavn.Album, error) {
+	if isNumeric(arg) {
+		return lib.Album(ctx, arg)
+	}
+	token := arg
+	if isURL(arg) {
+		var err error
+		if token, err = urlToken(arg, saavn.KindAlbum); err != nil {
+			return nil, err
+		}
+	}
+	// Albums resolved from a token only carry the IDs of their songs
+	album, err := lib.AlbumByToken(ctx, token)
+	if err != nil || album.ID == "" {
+		return album, err
+	}
+	return lib.Album(ctx, album.ID)
+}
+
+// lookupArtist returns an artist from their ID, URL or the token of their URL
+func lookupArtist(ctx context.Context, lib *saavn.Client, arg string) (*saavn.Artist, error) {
+	if isNumeric(arg) {
+		return lib.Artist(ctx, arg)
+	}
+	token := arg
+	if isURL(arg) {
+		var err error
+		if token, err = urlToken(arg, saavn.KindArtist); err != nil {
+			return nil, err
+		}
+	}
+	return lib.ArtistByToken(ctx, token)
+}
+
+// lookupPlaylist returns a playlist from its ID, URL or the token of its URL
+func lookupPlaylist(ctx context.Context, lib *saavn.Client, arg string) (*saavn.Playlist, error) {
+	if isNumeric(arg) {
+		return lib.Playlist(ctx, arg)
+	}
+	token := arg
+	if isURL(arg) {
+		var err error
+		if token, err = urlToken(arg, saavn.KindPlaylist); err != nil {
+			return nil, err
+		}
+	}
+	// Playlists resolved from a token may only carry the IDs of their songs
+	playlist, err := lib.PlaylistByToken(ctx, token)
+	if err != nil || !isNumeric(playlist.ID) {
+		return playlist, err
+	}
+	return lib.Playlist(ctx, playlist.ID)
+}
+
+// joinNonEmpty joins the values that are not empty
+func joinNonEmpty(sep string, values ...string) string {
+	kept := []string{}
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultTemplate is the default template of the names of downloaded files
+const defaultTemplate = "{artist} - {title}"
+
+// download is a song to download, with its track number in the album or
+// playlist it was requested from
+type download struct {
+	id    string
+	track int
+}
+
+// downloadResult is the outcome of downloading a song
+type downloadResult struct {
+	ID      string `json:"id"`
+	Title   string `json:"title,omitempty"`
+	Quality string `json:"quality,omitempty"`
+	File    string `json:"file,omitempty"`
+	// Status is "downloaded", "resumed", "skipped" or "failed"
+	Status string `json:"status"`
+	Bytes  int64  `json:"bytes"`
+	Error  string `json:"error,omitempty"`
+}
+
+// downloader downloads the audio of songs into files named by a template
+type downloader struct {
+	lib      *saavn.Client
+	http     *http.Client
+	quality  string
+	dir      string
+	template string
+	resume   bool
+	force    bool
+
+	// claimed holds the files being downloaded by the run
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+// runDownload downloads songs, albums and playlists
+func runDownload(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("download")
+	output := outputFlag(fs)
+	quality := fs.String("quality", "320", "bitrate in kbps: "+strings.Join(saavn.Qualities, ", ")+"; the closest available one is used")
+	dir := fs.String("dir", ".", "directory to download into")
+	template := fs.String("template", defaultTemplate, "file name template, without extension, using {id}, {title}, {artist}, {album}, {year}, {track} and {quality}; / creates subdirectories")
+	concurrency := fs.Int("concurrency", 4, "number of songs downloaded at once")
+	resume := fs.Bool("resume", true, "resume partial downloads left by an interrupted run")
+	force := fs.Bool("force", false, "download songs again when their file exists")
+	idType := fs.String("type", saavn.KindSong, "what bare IDs are: song, album or playlist")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if !isQuality(*quality) {
+		return fmt.Errorf("unknown quality %q, expected one of %s", *quality, strings.Join(saavn.Qualities, ", "))
+	}
+	if *concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+
+	downloads := []download{}
+	for _, arg := range fs.Args() {
+		songs, err := expandDownload(ctx, lib, arg, *idType)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		downloads = append(downloads, songs...)
+	}
+
+	d := &downloader{
+		lib: lib,
+		// Downloads are only bounded by the context, as large files take
+		// longer than the timeout of the library
+		http:     &http.Client{},
+		quality:  *quality,
+		dir:      *dir,
+		template: *template,
+		resume:   *resume,
+		force:    *force,
+	}
+
+	jobs := make(chan download)
+	results := make(chan downloadResult)
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency && i < len(downloads); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- d.download(ctx, job)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, job := range downloads {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results are reported as songs finish, so a table is printed at the end
+	failed := 0
+	all := []downloadResult{}
+	for result := range results {
+		if result.Status == "failed" {
+			failed++
+		}
+		all = append(all, result)
+		switch *output {
+		case outputNDJSON:
+			if err := (view{items: items([]downloadResult{result})}).print(os.Stdout, *output); err != nil {
+				return err
+			}
+		case outputTable:
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", len(all), len(downloads), result.Status, describe(result))
+		}
+	}
+
+	switch *output {
+	case outputJSON:
+		if err := (view{value: all}).print(os.Stdout, *output); err != nil {
+			return err
+		}
+	case outputTable:
+		t := table{header: []string{"ID", "TITLE", "QUALITY", "STATUS", "SIZE", "FILE"}}
+		for _, result := range all {
+			t.rows = append(t.rows, []string{result.ID, result.Title, result.Quality, result.Status, size(result.Bytes), result.File})
+		}
+		if err := (view{tables: []table{t}}).print(os.Stdout, *output); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, len(downloads))
+	}
+	return nil
+}
+
+// isQuality reports whether a bitrate is served by the CDN
+func isQuality(quality string) bool {
+	for _, q := range saavn.Qualities {
+		if q == quality {
+			return true
+		}
+	}
+	return false
+}
+
+// expandDownload returns the songs to download for an argument: a song, or
+// the songs of an album or playlist, by ID or URL. Bare IDs are of idType.
+func expandDownload(ctx context.Context, lib *saavn.Client, arg, idType string) ([]download, error) {
+	kind := idType
+	if isURL(arg) {
+		var err error
+		if kind, _, err = saavn.ParseURL(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	var songs []saavn.Song
+	switch kind {
+	case saavn.KindSong:
+		id, err := songID(ctx, lib, arg)
+		if err != nil {
+			return nil, err
+		}
+		return []download{{id: id}}, nil
+	case saavn.KindAlbum:
+		album, err := lookupAlbum(ctx, lib, arg)
+		if err != nil {
+			return nil, err
+		}
+		songs = album.Songs
+	case saavn.KindPlaylist:
+		playlist, err := lookupPlaylist(ctx, lib, arg)
+		if err != nil {
+			return nil, err
+		}
+		songs = playlist.Songs
+	default:
+		return nil, fmt.Errorf("cannot download a %s, expected a song, album or playlist", kind)
+	}
+
+	downloads := make([]download, 0, len(songs))
+	for i, song := range songs {
+		downloads = append(downloads, download{id: song.ID, track: i + 1})
+	}
+	return downloads, nil
+}
+
+// download downloads the audio of a song
+func (d *downloader) download(ctx context.Context, job download) downloadResult {
+	result := downloadResult{ID: job.id, Status: "failed"}
+
+	media, err := d.lib.ResolveMedia(ctx, job.id, d.quality)
+	if media != nil {
+		result.Title = html.UnescapeString(utils.GetString(media.Song, "song"))
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Quality = media.Quality
+	result.File = filepath.Join(d.dir, d.fileName(media, job.track)+".m4a")
+
+	// Songs listed twice, or whose names expand to the same file, are only
+	// downloaded once
+	if !d.claim(result.File) {
+		result.Status = "skipped"
+		return result
+	}
+	if _, err := os.Stat(result.File); err == nil && !d.force {
+		result.Status = "skipped"
+		return result
+	}
+	if err := os.MkdirAll(filepath.Dir(result.File), 0o755); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// The .part file is named after the song and quality, so that a run
+	// with another quality or template does not resume another file
+	part := result.File + "." + sanitize(job.id) + "-" + media.Quality + ".part"
+	resumed, written, err := d.fetch(ctx, media.URL, result.File, part)
+	result.Bytes = written
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "downloaded"
+	if resumed {
+		result.Status = "resumed"
+	}
+	return result
+}
+
+// claim reserves a file for a download, reporting false when another
+// download of the run already reserved it
+func (d *downloader) claim(file string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.claimed[file] {
+		return false
+	}
+	if d.claimed == nil {
+		d.claimed = map[string]bool{}
+	}
+	d.claimed[file] = true
+	return true
+}
+
+// fetch downloads a URL into file through a part file, resuming the one left
+// by an earlier run when the server still serves the same content. The
+// validator of the content is stored next to the part file, and sent with
+// If-Range so that a changed file is downloaded again. It returns whether the
+// download was resumed and the number of bytes written.
+func (d *downloader) fetch(ctx context.Context, mediaURL, file, part string) (bool, int64, error) {
+	validatorFile := part + ".validator"
+	var offset int64
+	var validator string
+	if info, err := os.Stat(part); err == nil && d.resume {
+		// Without a validator, the part file may belong to another version
+		// of the file and is not resumed
+		if data, err := os.ReadFile(validatorFile); err == nil && len(data) > 0 {
+			offset, validator = info.Size(), string(data)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	expected := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			// The part file cannot be trusted anymore, so the next run
+			// starts over
+			removeFiles(part, validatorFile)
+			return false, 0, fmt.Errorf("media server returned range %q when resuming at byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags |= os.O_APPEND
+		expected = total
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part file holds the whole file when it has its size, and
+		// otherwise is larger than the file, so the download starts over
+		if _, total, err := parseContentRange(resp.Header.Get("Content-Range")); err == nil && total == offset {
+			removeFiles(validatorFile)
+			return true, 0, os.Rename(part, file)
+		}
+		resp.Body.Close()
+		if err := removeFiles(part, validatorFile); err != nil {
+			return false, 0, err
+		}
+		return d.fetch(ctx, mediaURL, file, part)
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range or the file changed, so the download
+		// starts over
+		offset = 0
+		flags |= os.O_TRUNC
+		if err := writeValidator(validatorFile, rangeValidator(resp.Header)); err != nil {
+			return false, 0, err
+		}
+	default:
+		return false, 0, fmt.Errorf("media server returned status: %d", resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return false, 0, err
+	}
+	written, err := io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && expected >= 0 && offset+written != expected {
+		err = fmt.Errorf("media server sent %d of %d bytes", offset+written, expected)
+	}
+	if err != nil {
+		// The part file is kept for the next run to resume
+		return false, written, err
+	}
+	removeFiles(validatorFile)
+	return offset > 0, written, os.Rename(part, file)
+}
+
+// rangeValidator returns the validator of a response that If-Range accepts:
+// its strong ETag, or else its Last-Modified date
+func rangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// writeValidator stores the validator of a part file, removing the stored
+// one when the server sent none
+func writeValidator(file, validator string) error {
+	if validator == "" {
+		return removeFiles(file)
+	}
+	return os.WriteFile(file, []byte(validator), 0o644)
+}
+
+// removeFiles removes files, ignoring those that do not exist
+func removeFiles(files ...string) error {
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseContentRange parses a Content-Range header, "bytes first-last/total"
+// or "bytes */total", returning the first byte, or -1 for the latter, and the
+// total size, or -1 when unknown
+func parseContentRange(value string) (start, total int64, err error) {
+	spec, ok := strings.CutPrefix(value, "bytes ")
+	byteRange, size, found := strings.Cut(spec, "/")
+	if !ok || !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil || total < 0 {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+		}
+	}
+	if byteRange == "*" {
+		return -1, total, nil
+	}
+	first, last, found := strings.Cut(byteRange, "-")
+	start, err = strconv.ParseInt(first, 10, 64)
+	end, endErr := strconv.ParseInt(last, 10, 64)
+	if !found || err != nil || endErr != nil || start < 0 || end < start || (total >= 0 && end >= total) {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	return start, total, nil
+}
+
+// fileName expands the template for a song
+func (d *downloader) fileName(media *saavn.Media, track int) string {
+	song := media.Song
+	track = max(track, 0)
+	values := map[string]string{
+		"id":      utils.GetString(song, "id"),
+		"title":   utils.GetString(song, "song"),
+		"artist":  utils.GetString(song, "primary_artists"),
+		"album":   utils.GetString(song, "album"),
+		"year":    utils.GetString(song, "year"),
+		"track":   fmt.Sprintf("%02d", track),
+		"quality": media.Quality,
+	}
+	if track == 0 {
+		values["track"] = ""
+	}
+
+	// Separators in the template create subdirectories, while those in the
+	// values are replaced
+	segments := strings.Split(d.template, "/")
+	for i, segment := range segments {
+		for key, value := range values {
+			segment = strings.ReplaceAll(segment, "{"+key+"}", sanitize(html.UnescapeString(value)))
+		}
+		segments[i] = strings.TrimSpace(segment)
+		if segments[i] == "" || segments[i] == "." || segments[i] == ".." {
+			segments[i] = "_"
+		}
+	}
+	return filepath.Join(segments...)
+}
+
+// sanitize replaces the characters that are not allowed in file names
+func sanitize(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// describe returns the title of a downloaded song, or its ID, with the file
+// or error
+func describe(result downloadResult) string {
+	name := result.Title
+	if name == "" {
+		name = result.ID
+	}
+	switch {
+	case result.Error != "":
+		return name + ": " + result.Error
+	case result.File != "":
+		return name + " → " + result.File
+	}
+	return name
+}
+
+// size formats a number of bytes
+func size(bytes int64) string {
+	switch {
+	case bytes <= 0:
+		return ""
+	case bytes < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
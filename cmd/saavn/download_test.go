@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"jioSaavnAPI/saavn"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAudio is the content served by the fake CDN
+var testAudio = bytes.Repeat([]byte("0123456789abcdef"), 1024)
+
+// testCDN is a fake CDN serving testAudio with an ETag, recording the range
+// headers of the last request
+type testCDN struct {
+	*httptest.Server
+	etag     string
+	requests atomic.Int32
+
+	mu      sync.Mutex
+	rng     string
+	ifRange string
+}
+
+func newTestCDN(t *testing.T) *testCDN {
+	t.Helper()
+	cdn := &testCDN{etag: `"v2"`}
+	cdn.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdn.requests.Add(1)
+		cdn.mu.Lock()
+		cdn.rng, cdn.ifRange = r.Header.Get("Range"), r.Header.Get("If-Range")
+		cdn.mu.Unlock()
+		w.Header().Set("ETag", cdn.etag)
+		http.ServeContent(w, r, "song.mp4", time.Time{}, bytes.NewReader(testAudio))
+	}))
+	t.Cleanup(cdn.Close)
+	return cdn
+}
+
+// headers returns the range headers of the last request
+func (cdn *testCDN) headers() (string, string) {
+	cdn.mu.Lock()
+	defer cdn.mu.Unlock()
+	return cdn.rng, cdn.ifRange
+}
+
+func TestFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		part      []byte
+		validator string
+		resume    bool
+		// wantRange is the Range header sent, and wantResumed whether the
+		// part file was resumed
+		wantRange   string
+		wantResumed bool
+		wantWritten int
+	}{
+		{
+			name:        "new download",
+			resume:      true,
+			wantWritten: len(testAudio),
+		},
+		{
+			name:        "resumed",
+			part:        testAudio[:1000],
+			validator:   `"v2"`,
+			resume:      true,
+			wantRange:   "bytes=1000-",
+			wantResumed: true,
+			wantWritten: len(testAudio) - 1000,
+		},
+		{
+			name:        "file changed",
+			part:        bytes.Repeat([]byte("x"), 1000),
+			validator:   `"v1"`,
+			resume:      true,
+			wantRange:   "bytes=1000-",
+			wantWritten: len(testAudio),
+		},
+		{
+			name:        "no validator",
+			part:        bytes.Repeat([]byte("x"), 1000),
+			resume:      true,
+			wantWritten: len(testAudio),
+		},
+		{
+			name:        "resume disabled",
+			part:        testAudio[:1000],
+			validator:   `"v2"`,
+			wantWritten: len(testAudio),
+		},
+		{
+			name:        "part file complete",
+			part:        testAudio,
+			validator:   `"v2"`,
+			resume:      true,
+			wantRange:   "bytes=" + strconv.Itoa(len(testAudio)) + "-",
+			wantResumed: true,
+		},
+		{
+			name:      "part file larger than the file",
+			part:      append(append([]byte{}, testAudio...), "extra"...),
+			validator: `"v2"`,
+			resume:    true,
+			// The download starts over without a range after the 416
+			// response
+			wantWritten: len(testAudio),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cdn := newTestCDN(t)
+			dir := t.TempDir()
+			file := filepath.Join(dir, "song.m4a")
+			part := file + ".id-320.part"
+			if tt.part != nil {
+				if err := os.WriteFile(part, tt.part, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.validator != "" {
+				if err := os.WriteFile(part+".validator", []byte(tt.validator), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d := &downloader{http: cdn.Client(), resume: tt.resume}
+			resumed, written, err := d.fetch(context.Background(), cdn.URL, file, part)
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if resumed != tt.wantResumed || written != int64(tt.wantWritten) {
+				t.Errorf("resumed = %v with %d bytes written, want %v with %d", resumed, written, tt.wantResumed, tt.wantWritten)
+			}
+			if rng, ifRange := cdn.headers(); rng != tt.wantRange || (rng != "" && ifRange != tt.validator) {
+				t.Errorf("Range = %q, If-Range = %q, want %q and %q", rng, ifRange, tt.wantRange, tt.validator)
+			}
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, testAudio) {
+				t.Errorf("file has %d bytes that differ from the %d served", len(data), len(testAudio))
+			}
+			for _, leftover := range []string{part, part + ".validator"} {
+				if _, err := os.Stat(leftover); err == nil {
+					t.Errorf("%s left behind", filepath.Base(leftover))
+				}
+			}
+		})
+	}
+}
+
+func TestFetchInterrupted(t *testing.T) {
+	// The server sends half of the file, then closes the connection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(testAudio)))
+		w.Write(testAudio[:len(testAudio)/2])
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "song.m4a")
+	part := file + ".id-320.part"
+	d := &downloader{http: server.Client(), resume: true}
+	if _, _, err := d.fetch(context.Background(), server.URL, file, part); err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+	if _, err := os.Stat(file); err == nil {
+		t.Error("interrupted download renamed into place")
+	}
+	if validator, err := os.ReadFile(part + ".validator"); err != nil || string(validator) != `"v2"` {
+		t.Errorf("validator = %q, %v, want the ETag", validator, err)
+	}
+
+	// The next run resumes the part file
+	cdn := newTestCDN(t)
+	resumed, _, err := d.fetch(context.Background(), cdn.URL, file, part)
+	if err != nil || !resumed {
+		t.Fatalf("resumed = %v, %v", resumed, err)
+	}
+	if data, _ := os.ReadFile(file); !bytes.Equal(data, testAudio) {
+		t.Error("resumed file differs from the one served")
+	}
+}
+
+func TestFetchContentRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+	}{
+		{"range at another offset", http.StatusPartialContent, "bytes 0-99/16384"},
+		{"malformed range", http.StatusPartialContent, "bytes 1000-"},
+		{"no range", http.StatusPartialContent, ""},
+		// A 416 response for a part file of another size is retried from
+		// the start, which the server refuses again
+		{"unsatisfiable range of another size", http.StatusRequestedRangeNotSatisfiable, "bytes */500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Content-Range", tt.header)
+				}
+				w.WriteHeader(tt.status)
+				w.Write(testAudio[:100])
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			file := filepath.Join(dir, "song.m4a")
+			part := file + ".id-320.part"
+			os.WriteFile(part, testAudio[:1000], 0o644)
+			os.WriteFile(part+".validator", []byte(`"v2"`), 0o644)
+
+			d := &downloader{http: server.Client(), resume: true}
+			if _, _, err := d.fetch(context.Background(), server.URL, file, part); err == nil {
+				t.Fatal("no error")
+			}
+			if _, err := os.Stat(file); err == nil {
+				t.Error("file renamed into place")
+			}
+			// The part file cannot be trusted, so the next run starts over
+			if _, err := os.Stat(part + ".validator"); err == nil {
+				t.Error("validator kept")
+			}
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-0/1", 0, 1, true},
+		{"bytes 100-199/*", 100, -1, true},
+		{"bytes */200", -1, 200, true},
+		{"bytes 100-199/150", 0, 0, false},
+		{"bytes 199-100/200", 0, 0, false},
+		{"bytes 100-/200", 0, 0, false},
+		{"items 0-9/10", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, err := parseContentRange(tt.value)
+		if (err == nil) != tt.ok || start != tt.start || total != tt.total {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d and ok %v", tt.value, start, total, err, tt.start, tt.total, tt.ok)
+		}
+	}
+}
+
+// newTestLibrary returns a client of a fake upstream whose songs are served
+// by cdn, through the auth-token endpoint
+func newTestLibrary(t *testing.T, cdn *testCDN) *saavn.Client {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch query.Get("__call") {
+		case "song.getDetails":
+			id := query.Get("pids")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				id: map[string]interface{}{
+					"id":                  id,
+					"song":                "Tum Hi Ho",
+					"primary_artists":     "Arijit Singh",
+					"album":               "Aashiqui 2",
+					"encrypted_media_url": "tokenized-" + id,
+					"320kbps":             "true",
+				},
+			})
+		case "song.generateAuthToken":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth_url": cdn.URL + "/song_" + query.Get("bitrate") + ".mp4",
+				"status":   "success",
+			})
+		default:
+			http.Error(w, "unexpected call", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	lib := saavn.NewClient(upstream.URL)
+	lib.HTTPClient = upstream.Client()
+	return lib
+}
+
+func TestDownloadDedupe(t *testing.T) {
+	cdn := newTestCDN(t)
+	dir := t.TempDir()
+	d := &downloader{
+		lib:      newTestLibrary(t, cdn),
+		http:     cdn.Client(),
+		quality:  "320",
+		dir:      dir,
+		template: defaultTemplate,
+		resume:   true,
+		force:    true,
+	}
+
+	// The same song twice, and another song whose name expands to the same
+	// file, are downloaded at once
+	jobs := []download{{id: "s1"}, {id: "s1"}, {id: "s2"}}
+	results := make([]downloadResult, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = d.download(context.Background(), job)
+		}()
+	}
+	wg.Wait()
+
+	statuses := map[string]int{}
+	for _, result := range results {
+		statuses[result.Status]++
+		if want := filepath.Join(dir, "Arijit Singh - Tum Hi Ho.m4a"); result.File != want {
+			t.Errorf("%s downloaded into %q, want %q", result.ID, result.File, want)
+		}
+		if result.Error != "" {
+			t.Errorf("%s: %s", result.ID, result.Error)
+		}
+	}
+	if statuses["downloaded"] != 1 || statuses["skipped"] != 2 {
+		t.Errorf("statuses = %v, want one download and two skipped", statuses)
+	}
+	if got := cdn.requests.Load(); got != 1 {
+		t.Errorf("CDN requested %d times, want 1", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Arijit Singh - Tum Hi Ho.m4a"))
+	if err != nil || !bytes.Equal(data, testAudio) {
+		t.Errorf("downloaded file differs from the one served: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".part") || strings.HasSuffix(entry.Name(), ".validator") {
+			t.Errorf("%s left behind", entry.Name())
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	media := &saavn.Media{
+		Quality: "160",
+		Song: map[string]interface{}{
+			"id":              "abc",
+			"song":            "Tum Hi Ho",
+			"primary_artists": "Arijit Singh",
+			"album":           "AC/DC: Live?",
+			"year":            "2013",
+		},
+	}
+	tests := []struct {
+		template string
+		track    int
+		want     string
+	}{
+		{defaultTemplate, 0, "Arijit Singh - Tum Hi Ho"},
+		{"{album}/{track} {title} [{quality}]", 3, filepath.Join("AC_DC_ Live_", "03 Tum Hi Ho [160]")},
+		{"{track}/{id}", 0, filepath.Join("_", "abc")},
+		{"../{year}", 0, filepath.Join("_", "2013")},
+	}
+	for _, tt := range tests {
+		d := &downloader{template: tt.template}
+		if got := d.fileName(media, tt.track); got != tt.want {
+			t.Errorf("fileName(%q, %d) = %q, want %q", tt.template, tt.track, got, tt.want)
+		}
+	}
+}
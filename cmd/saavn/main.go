@@ -0,0 +1,130 @@
+// Command saavn searches and browses the JioSaavn catalog and downloads
+// songs from the command line, using the saavn library directly instead of
+// a running API server.
+//
+// Usage:
+//
+//	saavn <command> [flags] [arguments]
+//
+// The upstream API and the media decryption key are those configured for
+// the server by JIOSAAVN_BASE_URL and DECRYPTION_KEY.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"jioSaavnAPI/config"
+	"jioSaavnAPI/saavn"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// command is a subcommand of saavn
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, lib *saavn.Client, args []string) error
+}
+
+// commands are the subcommands, set by init as their flag sets print their
+// usage from it
+var commands []command
+
+func init() {
+	commands = []command{
+		{"search", "[flags] <query>", "search songs, albums, artists or playlists", runSearch},
+		{"song", "[flags] <id | url>...", "show songs", runSong},
+		{"album", "[flags] <id | url>", "show an album and its songs", runAlbum},
+		{"artist", "[flags] <id | url>", "show an artist with their top songs and albums", runArtist},
+		{"playlist", "[flags] <id | url>", "show a playlist and its songs", runPlaylist},
+		{"lyrics", "[flags] <song id | url>", "print the lyrics of a song", runLyrics},
+		{"resolve", "[flags] <url>...", "resolve JioSaavn URLs to their type, ID and name", runResolve},
+		{"download", "[flags] <id | url>...", "download songs, albums and playlists", runDownload},
+		{"tui", "[flags]", "browse the catalog in the terminal", runTUI},
+	}
+}
+
+// errUsage is returned by commands called with wrong arguments, after
+// printing their usage
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command named by args[0] and returns the exit code
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		cfg := config.LoadConfig()
+		lib := saavn.NewClient(cfg.JioSaavnBaseURL)
+		lib.DecryptionKey = cfg.DecryptionKey
+		err := cmd.run(ctx, lib, args[1:])
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "saavn %s: %v\n", cmd.name, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "saavn: unknown command %q\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: saavn <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun saavn <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of a command, with its usage line
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(fs.Output(), "Usage: saavn %s %s\n", cmd.name, cmd.args)
+				fmt.Fprintf(fs.Output(), "%s%s.\n", strings.ToUpper(cmd.summary[:1]), cmd.summary[1:])
+			}
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of a command and checks that it got at least
+// min arguments
+func parseArgs(fs *flag.FlagSet, args []string, min int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < min {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
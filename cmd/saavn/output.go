@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"jioSaavnAPI/saavn"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// Output formats
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// outputFlag adds the -output flag, shortened -o, to a flag set
+func outputFlag(fs *flag.FlagSet) *string {
+	format := outputTable
+	usage := "output format: table, json or ndjson"
+	fs.StringVar(&format, "output", format, usage)
+	fs.StringVar(&format, "o", format, "shorthand for -output")
+	return &format
+}
+
+// checkOutput validates an output format
+func checkOutput(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputNDJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, expected table, json or ndjson", format)
+}
+
+// table is a table printed with aligned columns
+type table struct {
+	// title is printed above the table
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// view is what a command prints in each output format: value as JSON, items
+// as NDJSON lines, one per item, and the tables with their heading lines
+type view struct {
+	value   interface{}
+	items   []interface{}
+	heading []string
+	tables  []table
+}
+
+// print writes the view in the given output format
+func (v view) print(w io.Writer, format string) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v.value)
+	case outputNDJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, item := range v.items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, line := range v.heading {
+		fmt.Fprintln(w, line)
+	}
+	for i, t := range v.tables {
+		if i > 0 || len(v.heading) > 0 {
+			fmt.Fprintln(w)
+		}
+		if t.title != "" {
+			fmt.Fprintln(w, t.title)
+		}
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for j, cell := range row {
+				// The last column, where URLs and file names go, is kept
+				// whole so that it can be copied
+				if j < len(row)-1 {
+					cell = truncate(cell, 40)
+				}
+				cells[j] = cell
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// items converts a slice to the items of a view
+func items[T any](values []T) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// truncate shortens s to max runes, ending it with an ellipsis
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
+
+// songHeader is the header of the tables of songs
+var songHeader = []string{"#", "ID", "TITLE", "ARTISTS", "ALBUM", "DURATION", "YEAR"}
+
+// songTable returns a table of songs
+func songTable(title string, songs []saavn.Song) table {
+	t := table{title: title, header: songHeader}
+	for i, song := range songs {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(i + 1),
+			song.ID,
+			song.Name,
+			artistNames(song.Artists.Primary),
+			song.Album.Name,
+			duration(song.Duration),
+			song.Year,
+		})
+	}
+	return t
+}
+
+// albumTable returns a table of albums
+func albumTable(title string, albums []saavn.Album) table {
+	t := table{title: title, header: []string{"#", "ID", "NAME", "ARTISTS", "YEAR", "LANGUAGE", "URL"}}
+	for i, album := range albums {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(i + 1),
+			album.ID,
+			album.Name,
+			artistNames(album.Artists.Primary),
+			album.Year,
+			album.Language,
+			album.URL,
+		})
+	}
+	return t
+}
+
+// artistNames joins the names of artists
+func artistNames(artists []saavn.ArtistRef) string {
+	names := make([]string, len(artists))
+	for i, artist := range artists {
+		names[i] = artist.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// duration formats a duration in seconds as m:ss
+func duration(seconds int) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
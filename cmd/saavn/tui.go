@@ -0,0 +1,695 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jioSaavnAPI/config"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/saavn"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// runTUI browses the catalog in a terminal UI
+func runTUI(ctx context.Context, lib *saavn.Client, args []string) error {
+	fs := newFlagSet("tui")
+	scriptsFlag := fs.String("scripts", strings.Join(config.LoadConfig().SearchScripts, ","), "query variants to search too, comma-separated: latin, phonetic, devanagari, all or none")
+	quality := fs.String("quality", "320", "bitrate in kbps of copied media URLs and downloads: "+strings.Join(saavn.Qualities, ", "))
+	dir := fs.String("dir", ".", "directory to download into")
+	template := fs.String("template", defaultTemplate, "file name template of downloads, as for the download command")
+	concurrency := fs.Int("concurrency", 2, "number of songs downloaded at once")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	scripts, err := match.ParseScripts(*scriptsFlag)
+	if err != nil {
+		return err
+	}
+	if !isQuality(*quality) {
+		return fmt.Errorf("unknown quality %q, expected one of %s", *quality, strings.Join(saavn.Qualities, ", "))
+	}
+	if *concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	t := &tui{
+		ctx:     ctx,
+		out:     os.Stdout,
+		lib:     lib,
+		scripts: scripts,
+		quality: *quality,
+		downloader: &downloader{
+			lib:      lib,
+			http:     &http.Client{},
+			quality:  *quality,
+			dir:      *dir,
+			template: *template,
+			resume:   true,
+		},
+		jobs:     make(chan queuedJob),
+		finished: make(chan downloadMsg),
+		search:   newSearchScreen(),
+	}
+	t.stack = []screen{t.search}
+	for i := 0; i < *concurrency; i++ {
+		go t.downloadWorker()
+	}
+
+	program := tea.NewProgram(t, tea.WithOutput(t.out), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := program.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		return err
+	}
+
+	if unfinished := t.activeDownloads(); unfinished > 0 {
+		fmt.Fprintf(os.Stderr, "%s not finished; queue them again to resume them\n", countOf(unfinished, "download"))
+	}
+	return nil
+}
+
+// tui is the state of the terminal UI
+type tui struct {
+	ctx     context.Context
+	lib     *saavn.Client
+	scripts []string
+	quality string
+	// out is the output of the program, where the clipboard is set
+	out io.Writer
+
+	// stack holds the screens drilled down into, the search first
+	stack  []screen
+	search *searchScreen
+	status string
+	failed bool
+	// quitting is set when quitting was asked for while downloading
+	quitting bool
+
+	downloader *downloader
+	downloads  []*queued
+	jobs       chan queuedJob
+	finished   chan downloadMsg
+
+	width, height int
+}
+
+// queued is a song in the download queue
+type queued struct {
+	job   download
+	title string
+	// status is "queued", "downloading", then that of the result
+	status string
+	result downloadResult
+}
+
+// queuedJob is a download sent to the workers, with its index in the queue
+type queuedJob struct {
+	index int
+	job   download
+}
+
+// downloadMsg reports that a download started or finished
+type downloadMsg struct {
+	index  int
+	done   bool
+	result downloadResult
+}
+
+// openedMsg carries a screen loaded for the user to drill down into
+type openedMsg struct {
+	screen screen
+	err    error
+}
+
+// statusMsg is shown on the status line, as an error when err is set
+type statusMsg struct {
+	text string
+	err  error
+}
+
+// copyMsg carries text to copy to the clipboard of the terminal
+type copyMsg struct {
+	what string
+	text string
+}
+
+// enqueuedMsg carries songs to add to the download queue
+type enqueuedMsg struct {
+	jobs   []download
+	titles []string
+	err    error
+}
+
+func (t *tui) Init() tea.Cmd {
+	return tea.Batch(tea.SetWindowTitle("saavn"), t.waitDownload())
+}
+
+func (t *tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+		return t, nil
+
+	case tea.KeyMsg:
+		return t, t.key(msg)
+
+	case searchTickMsg:
+		return t, t.search.search(t, msg)
+
+	case searchResultMsg:
+		t.search.results(t, msg)
+		return t, nil
+
+	case openedMsg:
+		if msg.err != nil {
+			t.setError(msg.err)
+			return t, nil
+		}
+		t.setStatus("")
+		t.stack = append(t.stack, msg.screen)
+		return t, nil
+
+	case statusMsg:
+		if msg.err != nil {
+			t.setError(msg.err)
+		} else {
+			t.setStatus(msg.text)
+		}
+		return t, nil
+
+	case copyMsg:
+		t.setClipboard(msg.what, msg.text)
+		return t, nil
+
+	case enqueuedMsg:
+		if msg.err != nil {
+			t.setError(msg.err)
+			return t, nil
+		}
+		return t, t.enqueue(msg.jobs, msg.titles)
+
+	case downloadMsg:
+		q := t.downloads[msg.index]
+		if !msg.done {
+			q.status = "downloading"
+			return t, t.waitDownload()
+		}
+		q.status, q.result = msg.result.Status, msg.result
+		if q.title == "" {
+			q.title = msg.result.Title
+		}
+		if msg.result.Status == "failed" {
+			t.setError(fmt.Errorf("download of %s failed: %s", q.title, msg.result.Error))
+		}
+		return t, t.waitDownload()
+	}
+	return t, nil
+}
+
+// key handles a key press
+func (t *tui) key(msg tea.KeyMsg) tea.Cmd {
+	top := t.stack[len(t.stack)-1]
+	typing := top == t.search && t.search.input.Focused()
+
+	switch msg.String() {
+	case "ctrl+c":
+		return t.quit()
+	case "q":
+		if typing {
+			break
+		}
+		if len(t.stack) == 1 {
+			return t.quit()
+		}
+		t.back()
+		return nil
+	case "esc", "backspace", "left", "h":
+		if typing || top == t.search {
+			break
+		}
+		t.back()
+		return nil
+	case "Q":
+		if typing {
+			break
+		}
+		if _, ok := top.(*queueScreen); !ok {
+			t.stack = append(t.stack, &queueScreen{})
+		}
+		return nil
+	}
+
+	t.quitting = false
+	return top.update(t, msg)
+}
+
+// back returns to the previous screen
+func (t *tui) back() {
+	if len(t.stack) > 1 {
+		t.stack = t.stack[:len(t.stack)-1]
+	}
+	t.setStatus("")
+}
+
+// quit quits, asking for confirmation when songs are being downloaded
+func (t *tui) quit() tea.Cmd {
+	if active := t.activeDownloads(); active > 0 && !t.quitting {
+		t.quitting = true
+		t.setStatus(countOf(active, "download") + " not finished. Press ctrl+c or q again to quit.")
+		return nil
+	}
+	return tea.Quit
+}
+
+// countOf returns a count of a noun, such as "1 song" or "2 songs"
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// activeDownloads returns the number of downloads queued or in progress
+func (t *tui) activeDownloads() int {
+	active := 0
+	for _, q := range t.downloads {
+		if q.status == "queued" || q.status == "downloading" {
+			active++
+		}
+	}
+	return active
+}
+
+func (t *tui) setStatus(text string) {
+	t.status, t.failed = text, false
+}
+
+func (t *tui) setError(err error) {
+	t.status, t.failed = err.Error(), true
+}
+
+// bodyHeight is the number of lines screens are rendered on
+func (t *tui) bodyHeight() int {
+	return max(t.height-3, 1)
+}
+
+func (t *tui) View() string {
+	if t.width == 0 {
+		return ""
+	}
+	titles := make([]string, len(t.stack))
+	for i, s := range t.stack {
+		titles[i] = s.title()
+	}
+	header := "saavn › " + strings.Join(titles, " › ")
+	if active := t.activeDownloads(); active > 0 {
+		header += fmt.Sprintf("  ⇣ %d", active)
+	}
+
+	top := t.stack[len(t.stack)-1]
+	body := top.render(t, t.width, t.bodyHeight())
+	// Pad the body so that the status and help lines stay at the bottom
+	if lines := strings.Count(body, "\n") + 1; lines < t.bodyHeight() {
+		body += strings.Repeat("\n", t.bodyHeight()-lines)
+	}
+
+	status := ansi.Truncate(t.status, t.width, "…")
+	if t.failed {
+		status = errorStyle.Render(status)
+	}
+	help := top.help()
+	if top != t.search || !t.search.input.Focused() {
+		help += " · Q downloads · q back"
+	}
+	return strings.Join([]string{
+		titleStyle.Render(ansi.Truncate(header, max(t.width-2, 1), "…")),
+		body,
+		status,
+		dimStyle.Render(ansi.Truncate(help, t.width, "…")),
+	}, "\n")
+}
+
+// action runs the action bound to a key for an entry, and reports whether
+// the key is bound to one
+func (t *tui) action(key string, e entry) (tea.Cmd, bool) {
+	switch key {
+	case "enter":
+		return t.open(e), true
+	case "l":
+		return t.lyrics(e), true
+	case "a":
+		return t.album(e), true
+	case "r":
+		return t.artist(e), true
+	case "u":
+		if e.url == "" {
+			return statusCmd("", errors.New("no URL for "+e.title)), true
+		}
+		return t.copy("URL of "+e.title, e.url), true
+	case "m":
+		return t.copyMedia(e), true
+	case "J":
+		return t.copyJSON(e), true
+	case "d":
+		return t.download(e), true
+	}
+	return nil, false
+}
+
+// load returns the command loading a screen, showing what is loaded meanwhile
+func (t *tui) load(what string, load func(ctx context.Context) (screen, error)) tea.Cmd {
+	t.setStatus("Loading " + what + "…")
+	return func() tea.Msg {
+		s, err := load(t.ctx)
+		return openedMsg{screen: s, err: err}
+	}
+}
+
+// open drills down into an entry
+func (t *tui) open(e entry) tea.Cmd {
+	return t.load(e.title, func(ctx context.Context) (screen, error) {
+		return t.fetchScreen(ctx, e)
+	})
+}
+
+// fetchScreen fetches the screen of an entry
+func (t *tui) fetchScreen(ctx context.Context, e entry) (screen, error) {
+	switch e.kind {
+	case saavn.KindSong:
+		song, err := t.lib.Song(ctx, e.id)
+		if err != nil {
+			return nil, err
+		}
+		return newTextScreen(song.Name, songEntry(*song), songDetails(song)), nil
+
+	case saavn.KindAlbum:
+		album, err := lookupAlbum(ctx, t.lib, e.id)
+		if err != nil {
+			return nil, err
+		}
+		s := &listScreen{name: album.Name, songs: album.Songs}
+		s.list.add("", songEntries(album.Songs))
+		return s, nil
+
+	case saavn.KindArtist:
+		artist, err := lookupArtist(ctx, t.lib, e.id)
+		if err != nil {
+			return nil, err
+		}
+		s := &listScreen{name: artist.Name, songs: artist.TopSongs}
+		s.list.add("Top songs", songEntries(artist.TopSongs))
+		albums := make([]entry, len(artist.TopAlbums))
+		for i, album := range artist.TopAlbums {
+			albums[i] = albumEntry(album)
+		}
+		s.list.add("Top albums", albums)
+		return s, nil
+
+	case saavn.KindPlaylist:
+		playlist, err := lookupPlaylist(ctx, t.lib, e.id)
+		if err != nil {
+			return nil, err
+		}
+		s := &listScreen{name: playlist.Name, songs: playlist.Songs}
+		s.list.add("", songEntries(playlist.Songs))
+		return s, nil
+	}
+	return nil, fmt.Errorf("cannot open a %s", e.kind)
+}
+
+// openURL drills down into the entry of a JioSaavn URL
+func (t *tui) openURL(kind, token string) tea.Cmd {
+	if kind == saavn.KindSong {
+		return t.load("song", func(ctx context.Context) (screen, error) {
+			song, err := t.lib.SongByToken(ctx, token)
+			if err != nil {
+				return nil, err
+			}
+			// Songs resolved from a token lack some details
+			if song, err = t.lib.Song(ctx, song.ID); err != nil {
+				return nil, err
+			}
+			return newTextScreen(song.Name, songEntry(*song), songDetails(song)), nil
+		})
+	}
+	// Albums, artists and playlists are looked up by the token of their URL
+	return t.open(entry{kind: kind, id: token, title: kind})
+}
+
+// songEntries returns the entries of songs
+func songEntries(songs []saavn.Song) []entry {
+	entries := make([]entry, len(songs))
+	for i, song := range songs {
+		entries[i] = songEntry(song)
+	}
+	return entries
+}
+
+// lyrics shows the lyrics of a song
+func (t *tui) lyrics(e entry) tea.Cmd {
+	if e.kind != saavn.KindSong {
+		return nil
+	}
+	return t.load("lyrics of "+e.title, func(ctx context.Context) (screen, error) {
+		lyrics, err := t.lib.Lyrics(ctx, e.id)
+		if err != nil {
+			return nil, err
+		}
+		if len(lyrics.Lines) == 0 {
+			return nil, errors.New("no lyrics for " + e.title)
+		}
+		s := newTextScreen("Lyrics", e, lyricsText(lyrics))
+		s.lyrics = true
+		return s, nil
+	})
+}
+
+// album shows the album of a song
+func (t *tui) album(e entry) tea.Cmd {
+	if e.kind == saavn.KindAlbum {
+		return t.open(e)
+	}
+	if e.kind != saavn.KindSong {
+		return nil
+	}
+	return t.load("album of "+e.title, func(ctx context.Context) (screen, error) {
+		song, err := t.lib.Song(ctx, e.id)
+		if err != nil {
+			return nil, err
+		}
+		if song.Album.ID == "" {
+			return nil, errors.New("no album for " + e.title)
+		}
+		return t.fetchScreen(ctx, entry{kind: saavn.KindAlbum, id: song.Album.ID, title: song.Album.Name})
+	})
+}
+
+// artist shows the primary artist of a song or album
+func (t *tui) artist(e entry) tea.Cmd {
+	if e.kind == saavn.KindArtist {
+		return t.open(e)
+	}
+	if e.kind != saavn.KindSong && e.kind != saavn.KindAlbum {
+		return nil
+	}
+	return t.load("artist of "+e.title, func(ctx context.Context) (screen, error) {
+		var artists []saavn.ArtistRef
+		if e.kind == saavn.KindSong {
+			song, err := t.lib.Song(ctx, e.id)
+			if err != nil {
+				return nil, err
+			}
+			artists = song.Artists.Primary
+		} else {
+			album, err := lookupAlbum(ctx, t.lib, e.id)
+			if err != nil {
+				return nil, err
+			}
+			artists = album.Artists.Primary
+		}
+		if len(artists) == 0 || artists[0].ID == "" {
+			return nil, errors.New("no artist for " + e.title)
+		}
+		return t.fetchScreen(ctx, entry{kind: saavn.KindArtist, id: artists[0].ID, title: artists[0].Name})
+	})
+}
+
+// statusCmd returns the command showing a status or error
+func statusCmd(text string, err error) tea.Cmd {
+	return func() tea.Msg {
+		return statusMsg{text: text, err: err}
+	}
+}
+
+// copy returns the command copying text to the clipboard of the terminal
+func (t *tui) copy(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return copyMsg{what: what, text: text}
+	}
+}
+
+// setClipboard copies text to the clipboard of the terminal with the OSC 52
+// escape sequence, which works over SSH. It is written from Update, on the
+// event loop where the program writes its own control sequences, rather than
+// from a command racing the renderer. Terminals that do not support it ignore
+// it, so the text is also shown on the status line.
+func (t *tui) setClipboard(what, text string) {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	if _, err := seq.WriteTo(t.out); err != nil {
+		t.setError(err)
+		return
+	}
+	shown := text
+	if strings.Contains(shown, "\n") {
+		shown = fmt.Sprintf("%d bytes", len(text))
+	}
+	t.setStatus("Copied " + what + ": " + shown)
+}
+
+// copyMedia copies the media URL of a song, in the selected quality or the
+// closest available one
+func (t *tui) copyMedia(e entry) tea.Cmd {
+	if e.kind != saavn.KindSong {
+		return statusCmd("", errors.New("media URLs are only available for songs"))
+	}
+	t.setStatus("Resolving the media URL of " + e.title + "…")
+	return func() tea.Msg {
+		media, err := t.lib.ResolveMedia(t.ctx, e.id, t.quality)
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		return t.copy(media.Quality+"kbps media URL of "+e.title, media.URL)()
+	}
+}
+
+// copyJSON copies the JSON of an entry, as the API serves it
+func (t *tui) copyJSON(e entry) tea.Cmd {
+	t.setStatus("Fetching " + e.title + "…")
+	return func() tea.Msg {
+		var value interface{}
+		var err error
+		switch e.kind {
+		case saavn.KindSong:
+			value, err = t.lib.Song(t.ctx, e.id)
+		case saavn.KindAlbum:
+			value, err = lookupAlbum(t.ctx, t.lib, e.id)
+		case saavn.KindArtist:
+			value, err = lookupArtist(t.ctx, t.lib, e.id)
+		case saavn.KindPlaylist:
+			value, err = lookupPlaylist(t.ctx, t.lib, e.id)
+		}
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		return t.copy("JSON of "+e.title, string(data))()
+	}
+}
+
+// download queues the download of a song, or of the songs of an album or
+// playlist
+func (t *tui) download(e entry) tea.Cmd {
+	switch e.kind {
+	case saavn.KindSong:
+		return t.enqueue([]download{{id: e.id}}, []string{e.title})
+	case saavn.KindAlbum, saavn.KindPlaylist:
+		t.setStatus("Listing the songs of " + e.title + "…")
+		return func() tea.Msg {
+			jobs, err := expandDownload(t.ctx, t.lib, e.id, e.kind)
+			return enqueuedMsg{jobs: jobs, titles: make([]string, len(jobs)), err: err}
+		}
+	}
+	return statusCmd("", errors.New("artists cannot be downloaded; open them to download their songs"))
+}
+
+// enqueueSongs queues the download of songs, numbered by their position
+func (t *tui) enqueueSongs(songs []saavn.Song) tea.Cmd {
+	jobs := make([]download, len(songs))
+	titles := make([]string, len(songs))
+	for i, song := range songs {
+		jobs[i] = download{id: song.ID, track: i + 1}
+		titles[i] = song.Name
+	}
+	return t.enqueue(jobs, titles)
+}
+
+// enqueue adds downloads to the queue
+func (t *tui) enqueue(jobs []download, titles []string) tea.Cmd {
+	if len(jobs) == 0 {
+		return statusCmd("", errors.New("nothing to download"))
+	}
+	sent := make([]queuedJob, len(jobs))
+	for i, job := range jobs {
+		sent[i] = queuedJob{index: len(t.downloads), job: job}
+		t.downloads = append(t.downloads, &queued{job: job, title: titles[i], status: "queued"})
+	}
+	t.setStatus("Queued " + countOf(len(jobs), "download") + ". Press Q to see the queue.")
+	return func() tea.Msg {
+		for _, job := range sent {
+			select {
+			case t.jobs <- job:
+			case <-t.ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// downloadWorker downloads the songs of the queue
+func (t *tui) downloadWorker() {
+	for {
+		select {
+		case job := <-t.jobs:
+			if !t.report(downloadMsg{index: job.index}) {
+				return
+			}
+			result := t.downloader.download(t.ctx, job.job)
+			if !t.report(downloadMsg{index: job.index, done: true, result: result}) {
+				return
+			}
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// report sends the progress of a download to the UI, reporting false once
+// the UI is closed
+func (t *tui) report(msg downloadMsg) bool {
+	select {
+	case t.finished <- msg:
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
+
+// waitDownload returns the command waiting for the progress of a download
+func (t *tui) waitDownload() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-t.finished:
+			return msg
+		case <-t.ctx.Done():
+			return nil
+		}
+	}
+}
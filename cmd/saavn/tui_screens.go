@@ -0,0 +1,591 @@
+package main
+
+import (
+	"fmt"
+	"jioSaavnAPI/models"
+	"jioSaavnAPI/saavn"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// screen is a view of the browser, stacked as the user drills down
+type screen interface {
+	// title is shown in the breadcrumb
+	title() string
+	// help lists the keys of the screen
+	help() string
+	update(t *tui, msg tea.KeyMsg) tea.Cmd
+	render(t *tui, width, height int) string
+}
+
+// entry is a song, album, artist or playlist listed by a screen
+type entry struct {
+	kind     string
+	id       string
+	title    string
+	subtitle string
+	url      string
+}
+
+func songEntry(song saavn.Song) entry {
+	return entry{
+		kind:     saavn.KindSong,
+		id:       song.ID,
+		title:    song.Name,
+		subtitle: joinNonEmpty(" · ", artistNames(song.Artists.Primary), song.Album.Name, duration(song.Duration)),
+		url:      song.URL,
+	}
+}
+
+func suggestionEntry(suggestion saavn.Suggestion) entry {
+	return entry{kind: saavn.KindSong, id: suggestion.ID, title: suggestion.Title, subtitle: suggestion.Description, url: suggestion.URL}
+}
+
+func albumEntry(album saavn.Album) entry {
+	return entry{
+		kind:     saavn.KindAlbum,
+		id:       album.ID,
+		title:    album.Name,
+		subtitle: joinNonEmpty(" · ", artistNames(album.Artists.Primary), album.Year, album.Language),
+		url:      album.URL,
+	}
+}
+
+func artistEntry(artist saavn.ArtistRef) entry {
+	return entry{kind: saavn.KindArtist, id: artist.ID, title: artist.Name, subtitle: artist.Description, url: artist.URL}
+}
+
+func playlistEntry(playlist saavn.Playlist) entry {
+	return entry{
+		kind:     saavn.KindPlaylist,
+		id:       playlist.ID,
+		title:    playlist.Name,
+		subtitle: joinNonEmpty(" · ", countOf(playlist.SongCount, "song"), playlist.Language),
+		url:      playlist.URL,
+	}
+}
+
+// listRow is an entry of a list, or the header of a section
+type listRow struct {
+	header string
+	entry  *entry
+}
+
+// listView is a scrolling list of entries with a cursor
+type listView struct {
+	rows   []listRow
+	cursor int
+	offset int
+}
+
+// add appends a section of entries, under a header when it is not empty
+func (l *listView) add(header string, entries []entry) {
+	if len(entries) == 0 {
+		return
+	}
+	if header != "" {
+		l.rows = append(l.rows, listRow{header: header})
+	}
+	for i := range entries {
+		l.rows = append(l.rows, listRow{entry: &entries[i]})
+	}
+	l.move(0)
+}
+
+// reset replaces the entries of the list
+func (l *listView) reset(entries []entry) {
+	*l = listView{}
+	l.add("", entries)
+}
+
+// selected returns the entry under the cursor, or nil for an empty list
+func (l *listView) selected() *entry {
+	if l.cursor < len(l.rows) {
+		return l.rows[l.cursor].entry
+	}
+	return nil
+}
+
+// move moves the cursor by delta entries, skipping headers
+func (l *listView) move(delta int) {
+	if len(l.rows) == 0 {
+		return
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	cursor := min(max(l.cursor+delta, 0), len(l.rows)-1)
+	for cursor >= 0 && cursor < len(l.rows) && l.rows[cursor].entry == nil {
+		cursor += step
+	}
+	if cursor < 0 || cursor >= len(l.rows) {
+		// Only headers lie that way
+		return
+	}
+	l.cursor = cursor
+}
+
+// handleKey moves the cursor for the navigation keys and reports whether the
+// key was one of them
+func (l *listView) handleKey(key string, height int) bool {
+	switch key {
+	case "up", "k":
+		l.move(-1)
+	case "down", "j":
+		l.move(1)
+	case "pgup", "ctrl+b":
+		l.move(-max(height-1, 1))
+	case "pgdown", "ctrl+f":
+		l.move(max(height-1, 1))
+	case "home", "g":
+		l.cursor = 0
+		l.move(0)
+	case "end", "G":
+		l.cursor = len(l.rows) - 1
+		l.move(0)
+	default:
+		return false
+	}
+	return true
+}
+
+// render renders the rows around the cursor
+func (l *listView) render(width, height int, focused bool) string {
+	if height <= 0 {
+		return ""
+	}
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+height {
+		l.offset = l.cursor - height + 1
+	}
+	// Keep the header of the first section visible at the top
+	if l.offset == 1 && l.rows[0].entry == nil {
+		l.offset = 0
+	}
+
+	lines := []string{}
+	for i := l.offset; i < len(l.rows) && len(lines) < height; i++ {
+		row := l.rows[i]
+		if row.entry == nil {
+			lines = append(lines, headerStyle.Render(ansi.Truncate(row.header, width, "…")))
+			continue
+		}
+		line := " " + row.entry.title
+		if row.entry.subtitle != "" {
+			line += "  " + dimStyle.Render(row.entry.subtitle)
+		}
+		line = ansi.Truncate(line, width, "…")
+		if i == l.cursor && focused {
+			line = selectedStyle.Render(ansi.Strip(line) + strings.Repeat(" ", max(width-ansi.StringWidth(line), 0)))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listScreen lists the songs of an album or playlist, or the top songs and
+// albums of an artist
+type listScreen struct {
+	name string
+	// songs are the songs downloaded by D
+	songs []saavn.Song
+	list  listView
+}
+
+func (s *listScreen) title() string {
+	return s.name
+}
+
+func (s *listScreen) help() string {
+	return "enter open · l lyrics · a album · r artist · u copy URL · m copy media URL · J copy JSON · d download · D download all"
+}
+
+func (s *listScreen) update(t *tui, msg tea.KeyMsg) tea.Cmd {
+	if s.list.handleKey(msg.String(), t.bodyHeight()) {
+		return nil
+	}
+	if msg.String() == "D" {
+		return t.enqueueSongs(s.songs)
+	}
+	if selected := s.list.selected(); selected != nil {
+		cmd, _ := t.action(msg.String(), *selected)
+		return cmd
+	}
+	return nil
+}
+
+func (s *listScreen) render(t *tui, width, height int) string {
+	if len(s.list.rows) == 0 {
+		return dimStyle.Render("Nothing here")
+	}
+	return s.list.render(width, height, true)
+}
+
+// searchKinds are the kinds of entries searched, switched with tab. Songs
+// are suggested as the query is typed, the others searched.
+var searchKinds = []string{saavn.KindSong, saavn.KindAlbum, saavn.KindArtist, saavn.KindPlaylist}
+
+// searchScreen searches the catalog as the query is typed
+type searchScreen struct {
+	input textinput.Model
+	kind  int
+	list  listView
+	// seq numbers the queries, so that stale results are dropped
+	seq      int
+	query    string
+	searched string
+}
+
+func newSearchScreen() *searchScreen {
+	input := textinput.New()
+	input.Placeholder = "Search, or paste a JioSaavn URL"
+	input.Prompt = "› "
+	input.Focus()
+	return &searchScreen{input: input}
+}
+
+func (s *searchScreen) title() string {
+	return "Search"
+}
+
+func (s *searchScreen) help() string {
+	if s.input.Focused() {
+		return "type to search · tab " + s.nextKind() + "s · enter/↓ results · ctrl+c quit"
+	}
+	return "enter open · l lyrics · a album · r artist · u copy URL · m copy media URL · J copy JSON · d download · / search"
+}
+
+// nextKind is the kind searched after tab
+func (s *searchScreen) nextKind() string {
+	return searchKinds[(s.kind+1)%len(searchKinds)]
+}
+
+// searchTickMsg is sent once typing pauses
+type searchTickMsg struct {
+	seq int
+}
+
+// searchResultMsg carries the results of a search
+type searchResultMsg struct {
+	seq     int
+	entries []entry
+	err     error
+}
+
+func (s *searchScreen) update(t *tui, msg tea.KeyMsg) tea.Cmd {
+	if !s.input.Focused() {
+		switch msg.String() {
+		case "/", "esc":
+			return s.input.Focus()
+		case "up", "k":
+			if s.list.cursor == 0 {
+				return s.input.Focus()
+			}
+		}
+		if s.list.handleKey(msg.String(), t.bodyHeight()-2) {
+			return nil
+		}
+		if selected := s.list.selected(); selected != nil {
+			cmd, _ := t.action(msg.String(), *selected)
+			return cmd
+		}
+		return nil
+	}
+
+	switch msg.String() {
+	case "tab", "shift+tab":
+		if msg.String() == "tab" {
+			s.kind = (s.kind + 1) % len(searchKinds)
+		} else {
+			s.kind = (s.kind + len(searchKinds) - 1) % len(searchKinds)
+		}
+		s.list.reset(nil)
+		s.searched = ""
+		return s.schedule(0)
+	case "enter", "down":
+		query := strings.TrimSpace(s.input.Value())
+		if kind, token, err := saavn.ParseURL(query); err == nil && msg.String() == "enter" {
+			return t.openURL(kind, token)
+		}
+		if len(s.list.rows) > 0 {
+			s.input.Blur()
+		}
+		return nil
+	case "esc":
+		s.input.SetValue("")
+		s.list.reset(nil)
+		s.searched = ""
+		return nil
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	if strings.TrimSpace(s.input.Value()) != s.query {
+		return tea.Batch(cmd, s.schedule(250))
+	}
+	return cmd
+}
+
+// schedule runs the search for the query once typing pauses for delay
+// milliseconds
+func (s *searchScreen) schedule(delay int) tea.Cmd {
+	s.seq++
+	s.query = strings.TrimSpace(s.input.Value())
+	seq := s.seq
+	return tea.Tick(time.Duration(delay)*time.Millisecond, func(time.Time) tea.Msg {
+		return searchTickMsg{seq: seq}
+	})
+}
+
+// search returns the command searching the query, when it is still current
+func (s *searchScreen) search(t *tui, msg searchTickMsg) tea.Cmd {
+	if msg.seq != s.seq {
+		return nil
+	}
+	query, kind, seq := s.query, searchKinds[s.kind], s.seq
+	if query == "" {
+		s.list.reset(nil)
+		s.searched = ""
+		return nil
+	}
+	if _, _, err := saavn.ParseURL(query); err == nil {
+		s.list.reset(nil)
+		s.searched = "Press enter to open the URL"
+		return nil
+	}
+
+	return func() tea.Msg {
+		var entries []entry
+		var err error
+		switch kind {
+		case saavn.KindSong:
+			var suggestions []saavn.Suggestion
+			if suggestions, err = t.lib.Autocomplete(t.ctx, query, t.scripts); err == nil {
+				for _, suggestion := range suggestions {
+					entries = append(entries, suggestionEntry(suggestion))
+				}
+			}
+		case saavn.KindAlbum:
+			var page *models.Page[saavn.Album]
+			if page, err = t.lib.SearchAlbums(t.ctx, query, t.scripts); err == nil {
+				for _, album := range page.Results {
+					entries = append(entries, albumEntry(album))
+				}
+			}
+		case saavn.KindArtist:
+			var page *models.Page[saavn.ArtistRef]
+			if page, err = t.lib.SearchArtists(t.ctx, query, t.scripts); err == nil {
+				for _, artist := range page.Results {
+					entries = append(entries, artistEntry(artist))
+				}
+			}
+		case saavn.KindPlaylist:
+			var page *models.Page[saavn.Playlist]
+			if page, err = t.lib.SearchPlaylists(t.ctx, query, t.scripts); err == nil {
+				for _, playlist := range page.Results {
+					entries = append(entries, playlistEntry(playlist))
+				}
+			}
+		}
+		return searchResultMsg{seq: seq, entries: entries, err: err}
+	}
+}
+
+// results shows the results of a search, when it is still current
+func (s *searchScreen) results(t *tui, msg searchResultMsg) {
+	if msg.seq != s.seq {
+		return
+	}
+	if msg.err != nil {
+		t.setError(msg.err)
+		return
+	}
+	s.list.reset(msg.entries)
+	s.searched = countOf(len(msg.entries), searchKinds[s.kind])
+	if len(msg.entries) == 0 {
+		s.searched = "No " + searchKinds[s.kind] + "s found"
+	}
+}
+
+func (s *searchScreen) render(t *tui, width, height int) string {
+	kinds := make([]string, len(searchKinds))
+	for i, kind := range searchKinds {
+		kinds[i] = kind + "s"
+		if i == s.kind {
+			kinds[i] = headerStyle.Render("[" + kinds[i] + "]")
+		} else {
+			kinds[i] = dimStyle.Render(kinds[i])
+		}
+	}
+	lines := []string{
+		ansi.Truncate(s.input.View(), width, "…"),
+		strings.Join(kinds, " ") + "  " + dimStyle.Render(s.searched),
+	}
+	if body := s.list.render(width, height-len(lines), !s.input.Focused()); body != "" {
+		lines = append(lines, body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textScreen shows the details or lyrics of a song
+type textScreen struct {
+	name     string
+	song     entry
+	lyrics   bool
+	viewport viewport.Model
+	content  string
+}
+
+func newTextScreen(name string, song entry, content string) *textScreen {
+	return &textScreen{name: name, song: song, viewport: viewport.New(0, 0), content: content}
+}
+
+func (s *textScreen) title() string {
+	return s.name
+}
+
+func (s *textScreen) help() string {
+	return "↑/↓ scroll · l lyrics · a album · r artist · u copy URL · m copy media URL · J copy JSON · d download"
+}
+
+func (s *textScreen) update(t *tui, msg tea.KeyMsg) tea.Cmd {
+	// The song is already open, and its lyrics when they are shown
+	if msg.String() == "enter" || (s.lyrics && msg.String() == "l") {
+		return nil
+	}
+	// The keys of the song take precedence over those of the viewport
+	if cmd, ok := t.action(msg.String(), s.song); ok {
+		return cmd
+	}
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return cmd
+}
+
+func (s *textScreen) render(t *tui, width, height int) string {
+	if s.viewport.Width != width || s.viewport.Height != height {
+		s.viewport.Width, s.viewport.Height = width, height
+		s.viewport.SetContent(lipgloss.NewStyle().Width(width).Render(s.content))
+	}
+	return s.viewport.View()
+}
+
+// songDetails returns the details shown for a song
+func songDetails(song *saavn.Song) string {
+	qualities := make([]string, len(song.DownloadURLs))
+	for i, download := range song.DownloadURLs {
+		qualities[i] = download.Quality
+	}
+	hasLyrics := "no"
+	if song.HasLyrics {
+		hasLyrics = "yes"
+	}
+	fields := [][2]string{
+		{"Artists", artistNames(song.Artists.Primary)},
+		{"Featuring", artistNames(song.Artists.Featured)},
+		{"Album", song.Album.Name},
+		{"Year", song.Year},
+		{"Released", song.ReleaseDate},
+		{"Duration", duration(song.Duration)},
+		{"Language", song.Language},
+		{"Label", song.Label},
+		{"Plays", strconv.FormatInt(song.PlayCount, 10)},
+		{"Lyrics", hasLyrics},
+		{"Qualities", strings.Join(qualities, ", ")},
+		{"ID", song.ID},
+		{"URL", song.URL},
+		{"Copyright", song.Copyright},
+	}
+	lines := []string{headerStyle.Render(song.Name), ""}
+	for _, field := range fields {
+		if field[1] != "" {
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("%-10s", field[0]))+field[1])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lyricsText returns the lyrics shown for a song, with the time of each line
+// when they are synced
+func lyricsText(lyrics *saavn.Lyrics) string {
+	lines := make([]string, 0, len(lyrics.Lines)+2)
+	for _, line := range lyrics.Lines {
+		if line.Time != nil {
+			seconds := int(*line.Time)
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("%2d:%02d  ", seconds/60, seconds%60))+line.Text)
+			continue
+		}
+		lines = append(lines, line.Text)
+	}
+	if lyrics.Copyright != "" {
+		lines = append(lines, "", dimStyle.Render(lyrics.Copyright))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// queueScreen shows the download queue
+type queueScreen struct {
+	offset int
+}
+
+func (s *queueScreen) title() string {
+	return "Downloads"
+}
+
+func (s *queueScreen) help() string {
+	return "↑/↓ scroll · interrupted downloads resume on the next run"
+}
+
+func (s *queueScreen) update(t *tui, msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		s.offset = max(s.offset-1, 0)
+	case "down", "j":
+		s.offset = min(s.offset+1, max(len(t.downloads)-1, 0))
+	}
+	return nil
+}
+
+func (s *queueScreen) render(t *tui, width, height int) string {
+	if len(t.downloads) == 0 {
+		return dimStyle.Render("No downloads queued. Press d on a song, album or playlist to queue it.")
+	}
+	lines := []string{}
+	for _, q := range t.downloads[min(s.offset, len(t.downloads)):] {
+		if len(lines) >= height {
+			break
+		}
+		name := q.title
+		if name == "" {
+			name = q.job.id
+		}
+		detail := q.result.File
+		if q.result.Error != "" {
+			detail = q.result.Error
+		}
+		status := fmt.Sprintf("%-11s", q.status)
+		if q.status == "failed" {
+			status = errorStyle.Render(status)
+		}
+		lines = append(lines, ansi.Truncate(status+name+"  "+dimStyle.Render(joinNonEmpty(" · ", size(q.result.Bytes), detail)), width, "…"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"jioSaavnAPI/dlna"
+	"jioSaavnAPI/services"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runCommand runs the subcommand named by args[0] and returns the exit code.
+// The server is run when no subcommand is given.
+func runCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServeCommand(args)
+	}
+
+	switch args[0] {
+	case "serve":
+		return runServeCommand(args[1:])
+	case "config":
+		return runConfigCommand(args[1:])
+	case "cache":
+		return runCacheCommand(args[1:])
+	case "healthcheck":
+		return runHealthcheckCommand(args[1:])
+	case "import":
+		return runImportCommand(args[1:])
+	case "dlna":
+		return runDLNACommand(args[1:])
+	case "help", "-h", "-help", "--help":
+		usage()
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "jioSaavnAPI: unknown command %q\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: jioSaavnAPI [command] [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	fmt.Fprintln(os.Stderr, "  serve            run the API server (the default)")
+	fmt.Fprintln(os.Stderr, "  config print     print the settings and where each was taken from")
+	fmt.Fprintln(os.Stderr, "  config validate  check the settings")
+	fmt.Fprintln(os.Stderr, "  cache stats      show the caches of a running server")
+	fmt.Fprintln(os.Stderr, "  cache purge      empty the caches of a running server")
+	fmt.Fprintln(os.Stderr, "  healthcheck      check that a running server is healthy, for container probes")
+	fmt.Fprintln(os.Stderr, "  import           match a playlist file against the catalog")
+	fmt.Fprintln(os.Stderr, "  dlna             discover and browse DLNA media servers")
+	fmt.Fprintln(os.Stderr, "\nRun jioSaavnAPI <command> -h for the flags of a command.")
+}
+
+// runImportCommand matches a playlist file against the catalog and prints the result as JSON
+func runImportCommand(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jioSaavnAPI import [flags] <playlist file | ->")
+		fmt.Fprintln(fs.Output(), "Matches the tracks of a CSV, M3U/M3U8, XSPF, JSPF or JSON playlist to JioSaavn songs.")
+		fs.PrintDefaults()
+	}
+	defaults := services.DefaultImportOptions()
+	format := fs.String("format", "", "playlist format: csv, m3u, xspf, jspf, json (detected when empty)")
+	threshold := fs.Float64("threshold", defaults.Threshold, "minimum confidence for a match, between 0 and 1")
+	alternatives := fs.Int("alternatives", defaults.Alternatives, "number of alternative candidates per track")
+	summary := fs.Bool("summary", false, "print one line per track instead of JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	filename := fs.Arg(0)
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		filename = ""
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+
+	if *format == "" {
+		*format = services.DetectImportFormat(filename, data)
+	}
+	result, err := services.ImportPlaylist(context.Background(), data, *format, services.ImportOptions{
+		Threshold:    *threshold,
+		Alternatives: *alternatives,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+
+	if *summary {
+		for _, track := range result.Tracks {
+			input := track.Input.Title
+			if len(track.Input.Artists) > 0 {
+				input = strings.Join(track.Input.Artists, ", ") + " - " + input
+			}
+			if track.Match == nil {
+				fmt.Printf("%3d  %-5s  %s  (no match)\n", track.Index+1, "-", input)
+				continue
+			}
+			fmt.Printf("%3d  %.3f  %s  ->  %v [%v]\n", track.Index+1, track.Match.Confidence, input, track.Match.Song["name"], track.Match.Song["id"])
+		}
+		fmt.Printf("%d of %d tracks matched\n", result.Matched, result.Total)
+		return 0
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+	return 0
+}
+
+// runDLNACommand is a minimal UPnP control point for checking the DLNA media
+// server, or any other, from the command line
+func runDLNACommand(args []string) int {
+	fs := flag.NewFlagSet("dlna", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage:")
+		fmt.Fprintln(fs.Output(), "  jioSaavnAPI dlna [flags] discover")
+		fmt.Fprintln(fs.Output(), "  jioSaavnAPI dlna [flags] browse <description URL> [object ID]")
+		fmt.Fprintln(fs.Output(), "  jioSaavnAPI dlna [flags] search <description URL> <query>")
+		fmt.Fprintln(fs.Output(), "Discovers media servers over SSDP and browses or searches their content directory.")
+		fs.PrintDefaults()
+	}
+	wait := fs.Duration("wait", 3*time.Second, "how long discover waits for answers")
+	target := fs.String("target", dlna.DeviceType, "search target of discover, e.g. ssdp:all")
+	start := fs.Int("start", 0, "index of the first object returned by browse and search")
+	count := fs.Int("count", 0, "number of objects returned by browse and search, 0 for all")
+	metadata := fs.Bool("metadata", false, "describe the object itself instead of listing its children")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "discover":
+		devices, err := dlna.Discover(ctx, *target, *wait)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "dlna:", err)
+			return 1
+		}
+		for _, device := range devices {
+			fmt.Printf("%s  %s  %s\n", device.Location, device.Target, device.Server)
+		}
+		fmt.Printf("%d devices found\n", len(devices))
+		return 0
+
+	case "browse", "search":
+		if fs.NArg() < 2 || (fs.Arg(0) == "search" && fs.NArg() < 3) {
+			fs.Usage()
+			return 2
+		}
+		controlURL, err := dlna.ControlURL(ctx, fs.Arg(1), dlna.ContentDirectoryType)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "dlna:", err)
+			return 1
+		}
+
+		page := []dlna.Arg{
+			{Name: "Filter", Value: "*"},
+			{Name: "StartingIndex", Value: strconv.Itoa(*start)},
+			{Name: "RequestedCount", Value: strconv.Itoa(*count)},
+			{Name: "SortCriteria", Value: ""},
+		}
+		var action string
+		var actionArgs []dlna.Arg
+		if fs.Arg(0) == "search" {
+			query := strings.ReplaceAll(strings.Join(fs.Args()[2:], " "), `"`, `\"`)
+			action = "Search"
+			actionArgs = append([]dlna.Arg{
+				{Name: "ContainerID", Value: "0"},
+				{Name: "SearchCriteria", Value: fmt.Sprintf(`dc:title contains "%s"`, query)},
+			}, page...)
+		} else {
+			objectID, flag := "0", "BrowseDirectChildren"
+			if fs.NArg() > 2 {
+				objectID = fs.Arg(2)
+			}
+			if *metadata {
+				flag = "BrowseMetadata"
+			}
+			action = "Browse"
+			actionArgs = append([]dlna.Arg{
+				{Name: "ObjectID", Value: objectID},
+				{Name: "BrowseFlag", Value: flag},
+			}, page...)
+		}
+
+		result, err := dlna.Call(ctx, controlURL, dlna.ContentDirectoryType, action, actionArgs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "dlna:", err)
+			return 1
+		}
+		objects, err := dlna.ParseDIDL(result["Result"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "dlna:", err)
+			return 1
+		}
+		for _, o := range objects {
+			if o.IsContainer() {
+				fmt.Printf("[+] %-32s  %s\n", o.ID, o.Title)
+				continue
+			}
+			line := fmt.Sprintf("    %-32s  %s", o.ID, o.Title)
+			if o.Artist != "" {
+				line += " - " + o.Artist
+			}
+			if o.Resource != nil {
+				line += "  " + o.Resource.URL
+			}
+			fmt.Println(line)
+		}
+		fmt.Printf("%s of %s objects\n", result["NumberReturned"], result["TotalMatches"])
+		return 0
+	}
+
+	fs.Usage()
+	return 2
+}
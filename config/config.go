@@ -1,26 +1,391 @@
 package config
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Config struct {
 	ServerPort      string
 	JioSaavnBaseURL string
 	DecryptionKey   string
+
+	// Lyrics search index
+	LyricsIndexPath      string
+	LyricsIndexWarm      bool
+	LyricsIndexPlaylists []string
+
+	// Media URL resolution
+	MediaVerify    bool
+	MediaVerifyTTL time.Duration
+	MediaDebug     bool
+
+	// Streaming proxy bandwidth cap per stream in kbps, 0 for unlimited
+	StreamBandwidthKbps int
+
+	// Signed download links. Links are only signed when a secret is set.
+	PublicBaseURL         string
+	DownloadSigningSecret string
+	DownloadLinkTTL       time.Duration
+	DownloadMode          string
+
+	// Number of tracks fetched in parallel when building album and playlist ZIP bundles
+	BundleConcurrency int
+
+	// Playlist import: parallel track lookups and the largest accepted playlist
+	ImportConcurrency int
+	ImportMaxTracks   int
+
+	// Song picked to represent a group of duplicates with ?dedupe=true: "plays" or "earliest"
+	DedupeCanonical string
+
+	// Query variants tried alongside each search: latin, phonetic, devanagari
+	SearchScripts []string
+
+	// Subsonic API: "user:password" pairs allowed to sign in, and playlist IDs
+	// listed next to the charts
+	SubsonicUsers     []string
+	SubsonicPlaylists []string
+
+	// DLNA media server announced over SSDP on the local network
+	DLNAEnabled      bool
+	DLNAFriendlyName string
+
+	// TCP address of the MPD protocol server, e.g. ":6600"; disabled when empty
+	MPDAddr string
+
+	// Limits on /graphql queries: the estimated number of objects a query may
+	// resolve and how deeply its selections may nest
+	GraphQLMaxComplexity int
+	GraphQLMaxDepth      int
+
+	// Port of the gRPC server, e.g. "9090"; disabled when empty
+	GRPCPort string
+
+	// URL pinged every KeepAliveInterval so hosts such as Render do not idle
+	// the instance; disabled when empty
+	KeepAliveURL      string
+	KeepAliveInterval time.Duration
+
+	// Bearer token required by the /cache admin routes. Without one, they only
+	// answer requests from the loopback interface.
+	AdminToken string
+
+	// ConfigFile is the file settings were read from, if any
+	ConfigFile string
+
+	settings []Setting
+	problems []error
+}
+
+// Sources of settings, from the lowest precedence to the highest
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting is the raw value of a setting, named by its environment variable,
+// and the source it was taken from
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	// Secret settings are redacted when printed
+	Secret bool
 }
 
+// secretSettings are the settings holding credentials
+var secretSettings = map[string]bool{
+	"DOWNLOAD_SIGNING_SECRET": true,
+	"SUBSONIC_USERS":          true,
+	"ADMIN_TOKEN":             true,
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   *Config
+	// flagValues are the settings given on the command line
+	flagValues = map[string]string{}
+)
+
+// LoadConfig returns the configuration shared by all packages, loaded on the
+// first call. Settings are taken from command-line flags, then environment
+// variables, then the file named by CONFIG_FILE, then the defaults.
 func LoadConfig() *Config {
-	return &Config{
-		ServerPort:      getEnv("SERVER_PORT", "8080"),
-		JioSaavnBaseURL: getEnv("JIOSAAVN_BASE_URL", "https://www.jiosaavn.com/api.php"),
-		DecryptionKey:   getEnv("DECRYPTION_KEY", "38346591"),
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared == nil {
+		shared = load()
+	}
+	return shared
+}
+
+// Override sets settings given on the command line, by the name of their
+// environment variable, and reloads the shared configuration in place so
+// that the packages holding it see the new values. CONFIG_FILE names the
+// config file. It must be called before the configuration is used by other
+// goroutines.
+func Override(values map[string]string) error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	for key, value := range values {
+		flagValues[key] = value
+	}
+	cfg := load()
+	for key := range values {
+		if !cfg.known(key) {
+			return fmt.Errorf("unknown setting %s", key)
+		}
+	}
+	if shared == nil {
+		shared = cfg
+	} else {
+		*shared = *cfg
+	}
+	return cfg.fileErr()
+}
+
+// Settings returns the raw value and source of every setting
+func (c *Config) Settings() []Setting {
+	return c.settings
+}
+
+// known reports whether key names a setting
+func (c *Config) known(key string) bool {
+	for _, setting := range c.settings {
+		if setting.Key == key {
+			return true
+		}
 	}
+	return false
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// fileErr returns the error met reading the config file, if any
+func (c *Config) fileErr() error {
+	for _, err := range c.problems {
+		if _, ok := err.(*FileError); ok {
+			return err
+		}
 	}
+	return nil
+}
+
+// FileError is an error reading the config file
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("config file %s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+func load() *Config {
+	l := &loader{flags: flagValues, file: map[string]string{}}
+	configFile := l.str("CONFIG_FILE", "")
+	if configFile != "" {
+		l.readFile(configFile)
+	}
+
+	cfg := &Config{
+		ServerPort:            l.str("SERVER_PORT", "8080"),
+		JioSaavnBaseURL:       l.str("JIOSAAVN_BASE_URL", "https://www.jiosaavn.com/api.php"),
+		DecryptionKey:         l.str("DECRYPTION_KEY", "38346591"),
+		LyricsIndexPath:       l.str("LYRICS_INDEX_PATH", "data/lyrics_index.json"),
+		LyricsIndexWarm:       l.bool("LYRICS_INDEX_WARM", false),
+		LyricsIndexPlaylists:  l.list("LYRICS_INDEX_PLAYLISTS"),
+		MediaVerify:           l.bool("MEDIA_VERIFY", false),
+		MediaVerifyTTL:        l.duration("MEDIA_VERIFY_TTL", 6*time.Hour),
+		MediaDebug:            l.bool("MEDIA_DEBUG", false),
+		StreamBandwidthKbps:   l.int("STREAM_BANDWIDTH_KBPS", 0),
+		PublicBaseURL:         strings.TrimSuffix(l.str("PUBLIC_BASE_URL", ""), "/"),
+		DownloadSigningSecret: l.str("DOWNLOAD_SIGNING_SECRET", ""),
+		DownloadLinkTTL:       l.duration("DOWNLOAD_LINK_TTL", time.Hour),
+		DownloadMode:          l.str("DOWNLOAD_MODE", "redirect"),
+		BundleConcurrency:     l.int("BUNDLE_CONCURRENCY", 4),
+		ImportConcurrency:     l.int("IMPORT_CONCURRENCY", 4),
+		ImportMaxTracks:       l.int("IMPORT_MAX_TRACKS", 500),
+		DedupeCanonical:       l.str("DEDUPE_CANONICAL", "plays"),
+		SearchScripts:         l.list("SEARCH_SCRIPTS"),
+		SubsonicUsers:         l.list("SUBSONIC_USERS"),
+		SubsonicPlaylists:     l.list("SUBSONIC_PLAYLISTS"),
+		DLNAEnabled:           l.bool("DLNA_ENABLED", false),
+		DLNAFriendlyName:      l.str("DLNA_FRIENDLY_NAME", "JioSaavn"),
+		MPDAddr:               l.str("MPD_ADDR", ""),
+		GraphQLMaxComplexity:  l.int("GRAPHQL_MAX_COMPLEXITY", 1000),
+		GraphQLMaxDepth:       l.int("GRAPHQL_MAX_DEPTH", 8),
+		GRPCPort:              l.str("GRPC_PORT", ""),
+		KeepAliveURL:          l.str("KEEP_ALIVE_URL", ""),
+		KeepAliveInterval:     l.duration("KEEP_ALIVE_INTERVAL", 12*time.Minute),
+		AdminToken:            l.str("ADMIN_TOKEN", ""),
+		ConfigFile:            configFile,
+	}
+
+	// Settings of the file that no setting was read from are likely typos
+	for _, key := range l.fileKeys {
+		if !l.read[key] {
+			l.problems = append(l.problems, fmt.Errorf("%s: unknown setting in config file", key))
+		}
+	}
+	cfg.settings = l.settings
+	cfg.problems = l.problems
+	return cfg
+}
+
+// loader reads settings from their sources, recording where each was taken
+// from and the values that could not be parsed
+type loader struct {
+	flags map[string]string
+	file  map[string]string
+	// fileKeys are the settings of the file, in order
+	fileKeys []string
+	read     map[string]bool
+	settings []Setting
+	problems []error
+}
+
+// readFile reads a config file of KEY=value lines, as a .env file. Blank
+// lines and lines starting with # are skipped.
+func (l *loader) readFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		l.problems = append(l.problems, &FileError{Path: path, Err: err})
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			l.problems = append(l.problems, &FileError{Path: path, Err: fmt.Errorf("line %d: expected KEY=value", n)})
+			continue
+		}
+		value, err := parseFileValue(strings.TrimSpace(value))
+		if err != nil {
+			l.problems = append(l.problems, &FileError{Path: path, Err: fmt.Errorf("line %d: %v", n, err)})
+			continue
+		}
+		if _, seen := l.file[key]; !seen {
+			l.fileKeys = append(l.fileKeys, key)
+		}
+		l.file[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		l.problems = append(l.problems, &FileError{Path: path, Err: err})
+	}
+}
+
+// parseFileValue returns the value of a line of a config file. Values may be
+// double-quoted, with Go escapes, or single-quoted, taken literally, and
+// unquoted values end at a # comment that follows whitespace.
+func parseFileValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", errors.New("unterminated double-quoted value")
+		}
+		return strconv.Unquote(quoted)
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+	}
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i]), nil
+		}
+	}
+	return value, nil
+}
+
+// lookup returns the raw value of a setting from the source with the highest
+// precedence that sets it. Empty environment variables are ignored.
+func (l *loader) lookup(key, defaultValue string) Setting {
+	if l.read == nil {
+		l.read = map[string]bool{}
+	}
+	l.read[key] = true
+
+	setting := Setting{Key: key, Value: defaultValue, Source: SourceDefault, Secret: secretSettings[key]}
+	if value, ok := l.flags[key]; ok {
+		setting.Value, setting.Source = value, SourceFlag
+	} else if value := os.Getenv(key); value != "" {
+		setting.Value, setting.Source = value, SourceEnv
+	} else if value, ok := l.file[key]; ok {
+		setting.Value, setting.Source = value, SourceFile
+	}
+	l.settings = append(l.settings, setting)
+	return setting
+}
+
+// invalid records a value that could not be parsed, the default being used instead
+func (l *loader) invalid(setting Setting, expected string) {
+	l.problems = append(l.problems, fmt.Errorf("%s: invalid value %q from %s, expected %s", setting.Key, setting.Value, setting.Source, expected))
+}
+
+func (l *loader) str(key, defaultValue string) string {
+	return l.lookup(key, defaultValue).Value
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	setting := l.lookup(key, strconv.Itoa(defaultValue))
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil {
+		l.invalid(setting, "an integer")
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	setting := l.lookup(key, strconv.FormatBool(defaultValue))
+	switch strings.ToLower(setting.Value) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	l.invalid(setting, "true or false")
 	return defaultValue
 }
+
+func (l *loader) list(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(l.lookup(key, "").Value, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	setting := l.lookup(key, defaultValue.String())
+	value, err := time.ParseDuration(setting.Value)
+	if err != nil || value <= 0 {
+		l.invalid(setting, "a positive duration such as 90s or 6h")
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"crypto/des"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the settings, returning one error per problem: values that
+// could not be parsed, errors reading the config file, unknown settings in
+// it, and values the server cannot run with. It checks that the decryption
+// key is a valid DES key but not that it decrypts JioSaavn media URLs.
+func (c *Config) Validate() []error {
+	errs := append([]error{}, c.problems...)
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	check(validatePort("SERVER_PORT", c.ServerPort))
+	if c.GRPCPort != "" {
+		check(validatePort("GRPC_PORT", c.GRPCPort))
+		if c.GRPCPort == c.ServerPort {
+			check(fmt.Errorf("GRPC_PORT: port %s is already used by SERVER_PORT", c.GRPCPort))
+		}
+	}
+	if c.MPDAddr != "" {
+		_, port, err := net.SplitHostPort(c.MPDAddr)
+		if err == nil {
+			err = validatePort("MPD_ADDR", port)
+		} else {
+			err = fmt.Errorf("MPD_ADDR: %v", err)
+		}
+		check(err)
+	}
+
+	check(validateURL("JIOSAAVN_BASE_URL", c.JioSaavnBaseURL))
+	if c.PublicBaseURL != "" {
+		check(validateURL("PUBLIC_BASE_URL", c.PublicBaseURL))
+	}
+	if c.KeepAliveURL != "" {
+		check(validateURL("KEEP_ALIVE_URL", c.KeepAliveURL))
+	}
+
+	// Media URLs are encrypted with DES in ECB mode, whose keys are 8 bytes
+	if len(c.DecryptionKey) != des.BlockSize {
+		check(fmt.Errorf("DECRYPTION_KEY: DES keys are %d bytes, got %d", des.BlockSize, len(c.DecryptionKey)))
+	} else if _, err := des.NewCipher([]byte(c.DecryptionKey)); err != nil {
+		check(fmt.Errorf("DECRYPTION_KEY: %v", err))
+	}
+
+	check(validateOneOf("DOWNLOAD_MODE", c.DownloadMode, "redirect", "proxy"))
+	check(validateOneOf("DEDUPE_CANONICAL", c.DedupeCanonical, "plays", "earliest"))
+	for _, pair := range c.SubsonicUsers {
+		if user, _, ok := strings.Cut(pair, ":"); !ok || user == "" {
+			check(errors.New("SUBSONIC_USERS: expected user:password pairs"))
+			break
+		}
+	}
+
+	check(validateMin("STREAM_BANDWIDTH_KBPS", c.StreamBandwidthKbps, 0))
+	check(validateMin("BUNDLE_CONCURRENCY", c.BundleConcurrency, 1))
+	check(validateMin("IMPORT_CONCURRENCY", c.ImportConcurrency, 1))
+	check(validateMin("IMPORT_MAX_TRACKS", c.ImportMaxTracks, 1))
+	check(validateMin("GRAPHQL_MAX_COMPLEXITY", c.GraphQLMaxComplexity, 1))
+	check(validateMin("GRAPHQL_MAX_DEPTH", c.GraphQLMaxDepth, 1))
+	return errs
+}
+
+func validatePort(key, value string) error {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%s: invalid port %q", key, value)
+	}
+	return nil
+}
+
+func validateURL(key, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: %q is not an absolute http or https URL", key, value)
+	}
+	return nil
+}
+
+func validateOneOf(key, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: invalid value %q, expected %s", key, value, strings.Join(allowed, " or "))
+}
+
+func validateMin(key string, value, min int) error {
+	if value < min {
+		return fmt.Errorf("%s: must be at least %d, got %d", key, min, value)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package dlna
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UPnP classes of the objects served by the content directory
+const (
+	ClassFolder   = "object.container.storageFolder"
+	ClassAlbum    = "object.container.album.musicAlbum"
+	ClassPlaylist = "object.container.playlistContainer"
+	ClassTrack    = "object.item.audioItem.musicTrack"
+)
+
+// Object is a container or item of the content directory
+type Object struct {
+	ID       string
+	ParentID string
+	Title    string
+	Class    string
+	// ChildCount is only reported for containers, and only when known (>= 0)
+	ChildCount  int
+	Searchable  bool
+	Artist      string
+	Album       string
+	AlbumArtURI string
+	TrackNumber int
+	Resource    *Resource
+}
+
+// Resource is the media behind an item
+type Resource struct {
+	URL          string
+	ProtocolInfo string
+	// Duration in seconds, omitted when 0
+	Duration int
+}
+
+// IsContainer reports whether the object is a container
+func (o Object) IsContainer() bool {
+	return strings.HasPrefix(o.Class, "object.container")
+}
+
+// DIDL renders objects as a DIDL-Lite document, as returned in the Result of
+// Browse and Search
+func DIDL(objects []Object) string {
+	var b strings.Builder
+	b.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/">`)
+	for _, o := range objects {
+		tag := "item"
+		if o.IsContainer() {
+			tag = "container"
+		}
+		fmt.Fprintf(&b, `<%s id="%s" parentID="%s" restricted="1"`, tag, escape(o.ID), escape(o.ParentID))
+		if o.IsContainer() {
+			if o.ChildCount >= 0 {
+				fmt.Fprintf(&b, ` childCount="%d"`, o.ChildCount)
+			}
+			fmt.Fprintf(&b, ` searchable="%d"`, boolInt(o.Searchable))
+		}
+		b.WriteString(">")
+
+		fmt.Fprintf(&b, "<dc:title>%s</dc:title>", escape(o.Title))
+		fmt.Fprintf(&b, "<upnp:class>%s</upnp:class>", o.Class)
+		if o.Artist != "" {
+			fmt.Fprintf(&b, "<dc:creator>%s</dc:creator><upnp:artist>%s</upnp:artist>", escape(o.Artist), escape(o.Artist))
+		}
+		if o.Album != "" {
+			fmt.Fprintf(&b, "<upnp:album>%s</upnp:album>", escape(o.Album))
+		}
+		if o.AlbumArtURI != "" {
+			fmt.Fprintf(&b, `<upnp:albumArtURI dlna:profileID="JPEG_TN">%s</upnp:albumArtURI>`, escape(o.AlbumArtURI))
+		}
+		if o.TrackNumber > 0 {
+			fmt.Fprintf(&b, "<upnp:originalTrackNumber>%d</upnp:originalTrackNumber>", o.TrackNumber)
+		}
+		if r := o.Resource; r != nil {
+			fmt.Fprintf(&b, `<res protocolInfo="%s"`, escape(r.ProtocolInfo))
+			if r.Duration > 0 {
+				fmt.Fprintf(&b, ` duration="%d:%02d:%02d.000"`, r.Duration/3600, r.Duration/60%60, r.Duration%60)
+			}
+			fmt.Fprintf(&b, ">%s</res>", escape(r.URL))
+		}
+
+		fmt.Fprintf(&b, "</%s>", tag)
+	}
+	b.WriteString("</DIDL-Lite>")
+	return b.String()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// didlObject is a container or item element of a DIDL-Lite document
+type didlObject struct {
+	XMLName    xml.Name
+	ID         string `xml:"id,attr"`
+	ParentID   string `xml:"parentID,attr"`
+	ChildCount string `xml:"childCount,attr"`
+	Searchable string `xml:"searchable,attr"`
+	Title      string `xml:"title"`
+	Class      string `xml:"class"`
+	Artist     string `xml:"artist"`
+	Creator    string `xml:"creator"`
+	Album      string `xml:"album"`
+	ArtURI     string `xml:"albumArtURI"`
+	Track      int    `xml:"originalTrackNumber"`
+	Res        []struct {
+		ProtocolInfo string `xml:"protocolInfo,attr"`
+		Duration     string `xml:"duration,attr"`
+		URL          string `xml:",chardata"`
+	} `xml:"res"`
+}
+
+// ParseDIDL reads the objects of a DIDL-Lite document, keeping the first
+// resource of each item
+func ParseDIDL(document string) ([]Object, error) {
+	var doc struct {
+		Objects []didlObject `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("invalid DIDL-Lite: %w", err)
+	}
+
+	objects := make([]Object, 0, len(doc.Objects))
+	for _, d := range doc.Objects {
+		o := Object{
+			ID:          d.ID,
+			ParentID:    d.ParentID,
+			Title:       d.Title,
+			Class:       d.Class,
+			ChildCount:  -1,
+			Searchable:  d.Searchable == "1" || d.Searchable == "true",
+			Artist:      d.Artist,
+			Album:       d.Album,
+			AlbumArtURI: d.ArtURI,
+			TrackNumber: d.Track,
+		}
+		if o.Artist == "" {
+			o.Artist = d.Creator
+		}
+		if n, err := strconv.Atoi(d.ChildCount); err == nil {
+			o.ChildCount = n
+		}
+		if len(d.Res) > 0 {
+			o.Resource = &Resource{
+				URL:          strings.TrimSpace(d.Res[0].URL),
+				ProtocolInfo: d.Res[0].ProtocolInfo,
+				Duration:     parseDuration(d.Res[0].Duration),
+			}
+		}
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
+
+// parseDuration converts a DIDL-Lite H+:MM:SS[.F+] duration to whole seconds
+func parseDuration(duration string) int {
+	duration, _, _ = strings.Cut(duration, ".")
+	seconds := 0
+	for _, part := range strings.Split(duration, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
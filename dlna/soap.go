@@ -0,0 +1,137 @@
+package dlna
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// UPnP error codes returned in SOAP faults
+const (
+	ErrInvalidAction = 401
+	ErrInvalidArgs   = 402
+	ErrActionFailed  = 501
+	ErrNoSuchObject  = 701
+)
+
+// Arg is a named argument of a SOAP action or response. Arguments are kept in
+// order since UPnP defines the order of response arguments.
+type Arg struct {
+	Name  string
+	Value string
+}
+
+// Action is a SOAP action call
+type Action struct {
+	Name string
+	Args map[string]string
+}
+
+// soapEnvelope is the envelope of a SOAP request or response with a single
+// action element in its body
+type soapEnvelope struct {
+	Body struct {
+		Action struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// ReadAction parses a SOAP action call
+func ReadAction(r io.Reader) (Action, error) {
+	var env soapEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return Action{}, fmt.Errorf("invalid SOAP request: %w", err)
+	}
+	action := Action{Name: env.Body.Action.XMLName.Local, Args: map[string]string{}}
+	if action.Name == "" {
+		return Action{}, errors.New("invalid SOAP request: missing action")
+	}
+	for _, arg := range env.Body.Action.Args {
+		action.Args[arg.XMLName.Local] = arg.Value
+	}
+	return action, nil
+}
+
+// WriteResponse writes the SOAP response of an action
+func WriteResponse(w io.Writer, serviceType, action string, args []Arg) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<u:%sResponse xmlns:u="%s">`, action, serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(&b, "<%s>%s</%s>", arg.Name, escape(arg.Value), arg.Name)
+	}
+	fmt.Fprintf(&b, "</u:%sResponse>", action)
+	return writeEnvelope(w, b.String())
+}
+
+// WriteFault writes a UPnP error as a SOAP fault
+func WriteFault(w io.Writer, code int, description string) error {
+	return writeEnvelope(w, fmt.Sprintf(
+		`<s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+			`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode><errorDescription>%s</errorDescription></UPnPError>`+
+			`</detail></s:Fault>`, code, escape(description)))
+}
+
+func writeEnvelope(w io.Writer, body string) error {
+	_, err := fmt.Fprintf(w, `%s<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>%s</s:Body></s:Envelope>`,
+		xml.Header, body)
+	return err
+}
+
+// Call invokes a SOAP action on a control URL and returns the response arguments
+func Call(ctx context.Context, controlURL, serviceType, action string, args []Arg) (map[string]string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<u:%s xmlns:u="%s">`, action, serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(&b, "<%s>%s</%s>", arg.Name, escape(arg.Value), arg.Name)
+	}
+	fmt.Fprintf(&b, "</u:%s>", action)
+	var body bytes.Buffer
+	writeEnvelope(&body, b.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, controlURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPACTION", fmt.Sprintf(`"%s#%s"`, serviceType, action))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var fault struct {
+			Code        int    `xml:"Body>Fault>detail>UPnPError>errorCode"`
+			Description string `xml:"Body>Fault>detail>UPnPError>errorDescription"`
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&fault); err != nil || fault.Code == 0 {
+			return nil, fmt.Errorf("%s failed with status %d", action, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("%s failed with UPnP error %d: %s", action, fault.Code, fault.Description)
+	}
+
+	result, err := ReadAction(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return result.Args, nil
+}
+
+// escape escapes text for use in XML character data and attributes
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,234 @@
+// Package dlna implements the parts of UPnP needed to serve the catalog as a
+// DLNA MediaServer: SSDP discovery, SOAP control messages, DIDL-Lite metadata
+// and the device and service descriptions.
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSDP multicast group and the UPnP types announced by the media server
+const (
+	SSDPAddress           = "239.255.255.250:1900"
+	DeviceType            = "urn:schemas-upnp-org:device:MediaServer:1"
+	ContentDirectoryType  = "urn:schemas-upnp-org:service:ContentDirectory:1"
+	ConnectionManagerType = "urn:schemas-upnp-org:service:ConnectionManager:1"
+)
+
+// Advertiser announces a device over SSDP and answers M-SEARCH requests
+type Advertiser struct {
+	// UUID identifies the device, without the "uuid:" prefix
+	UUID string
+	// Location is the URL of the device description
+	Location string
+	// Server is sent in the SERVER header, e.g. "Linux/5.0 UPnP/1.0 jioSaavnAPI/1.0"
+	Server string
+	// MaxAge is how long announcements stay valid; they are repeated at half that interval
+	MaxAge time.Duration
+}
+
+// notificationTypes returns the NT (or ST) and USN pairs announced for the device
+func (a *Advertiser) notificationTypes() [][2]string {
+	uuid := "uuid:" + a.UUID
+	return [][2]string{
+		{"upnp:rootdevice", uuid + "::upnp:rootdevice"},
+		{uuid, uuid},
+		{DeviceType, uuid + "::" + DeviceType},
+		{ContentDirectoryType, uuid + "::" + ContentDirectoryType},
+		{ConnectionManagerType, uuid + "::" + ConnectionManagerType},
+	}
+}
+
+// Run announces the device until ctx is done, then says goodbye. It returns
+// an error when the SSDP sockets cannot be opened.
+func (a *Advertiser) Run(ctx context.Context) error {
+	if a.MaxAge <= 0 {
+		a.MaxAge = 30 * time.Minute
+	}
+	group, err := net.ResolveUDPAddr("udp4", SSDPAddress)
+	if err != nil {
+		return err
+	}
+	listener, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return fmt.Errorf("failed to join SSDP group: %w", err)
+	}
+	defer listener.Close()
+	sender, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return fmt.Errorf("failed to open SSDP socket: %w", err)
+	}
+	defer sender.Close()
+
+	go a.answerSearches(ctx, listener, sender)
+
+	a.notify(sender, group, "ssdp:alive")
+	ticker := time.NewTicker(a.MaxAge / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.notify(sender, group, "ssdp:alive")
+		case <-ctx.Done():
+			a.notify(sender, group, "ssdp:byebye")
+			return nil
+		}
+	}
+}
+
+// notify multicasts a NOTIFY message for every notification type
+func (a *Advertiser) notify(conn *net.UDPConn, group *net.UDPAddr, nts string) {
+	for _, nt := range a.notificationTypes() {
+		var b strings.Builder
+		b.WriteString("NOTIFY * HTTP/1.1\r\n")
+		fmt.Fprintf(&b, "HOST: %s\r\n", SSDPAddress)
+		fmt.Fprintf(&b, "NT: %s\r\n", nt[0])
+		fmt.Fprintf(&b, "NTS: %s\r\n", nts)
+		fmt.Fprintf(&b, "USN: %s\r\n", nt[1])
+		if nts == "ssdp:alive" {
+			fmt.Fprintf(&b, "CACHE-CONTROL: max-age=%d\r\n", int(a.MaxAge.Seconds()))
+			fmt.Fprintf(&b, "LOCATION: %s\r\n", a.Location)
+			fmt.Fprintf(&b, "SERVER: %s\r\n", a.Server)
+		}
+		b.WriteString("\r\n")
+		if _, err := conn.WriteTo([]byte(b.String()), group); err != nil {
+			log.Printf("⚠️ SSDP: failed to send %s: %v", nts, err)
+			return
+		}
+	}
+}
+
+// answerSearches replies to M-SEARCH requests received on the multicast group
+func (a *Advertiser) answerSearches(ctx context.Context, listener, sender *net.UDPConn) {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := listener.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf[:n])))
+		if err != nil || req.Method != "M-SEARCH" || req.Header.Get("MAN") != `"ssdp:discover"` {
+			continue
+		}
+
+		target := req.Header.Get("ST")
+		var matches [][2]string
+		for _, nt := range a.notificationTypes() {
+			if target == "ssdp:all" || target == nt[0] {
+				matches = append(matches, nt)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		// Spread replies over the MX seconds the client waits, as the spec asks
+		mx, _ := strconv.Atoi(req.Header.Get("MX"))
+		delay := time.Duration(rand.Int63n(int64(min(max(mx, 1), 5)) * int64(time.Second)))
+		time.AfterFunc(delay, func() {
+			for _, nt := range matches {
+				a.reply(sender, from, nt)
+			}
+		})
+	}
+}
+
+// reply answers a search with one matching notification type
+func (a *Advertiser) reply(conn *net.UDPConn, to *net.UDPAddr, nt [2]string) {
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 200 OK\r\n")
+	fmt.Fprintf(&b, "CACHE-CONTROL: max-age=%d\r\n", int(a.MaxAge.Seconds()))
+	fmt.Fprintf(&b, "DATE: %s\r\n", time.Now().UTC().Format(http.TimeFormat))
+	b.WriteString("EXT:\r\n")
+	fmt.Fprintf(&b, "LOCATION: %s\r\n", a.Location)
+	fmt.Fprintf(&b, "SERVER: %s\r\n", a.Server)
+	fmt.Fprintf(&b, "ST: %s\r\n", nt[0])
+	fmt.Fprintf(&b, "USN: %s\r\n", nt[1])
+	b.WriteString("Content-Length: 0\r\n\r\n")
+	conn.WriteTo([]byte(b.String()), to)
+}
+
+// Device is an SSDP search response
+type Device struct {
+	Location string `json:"location"`
+	Target   string `json:"st"`
+	USN      string `json:"usn"`
+	Server   string `json:"server"`
+}
+
+// Discover multicasts an M-SEARCH for target (e.g. DeviceType or "ssdp:all")
+// and collects the responses received within wait
+func Discover(ctx context.Context, target string, wait time.Duration) ([]Device, error) {
+	group, err := net.ResolveUDPAddr("udp4", SSDPAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	mx := max(int(wait.Seconds()), 1)
+	search := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: %d\r\nST: %s\r\n\r\n", SSDPAddress, mx, target)
+	if _, err := conn.WriteTo([]byte(search), group); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	devices := []Device{}
+	seen := map[string]bool{}
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// The read deadline ends the search
+			return devices, nil
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		device := Device{
+			Location: resp.Header.Get("LOCATION"),
+			Target:   resp.Header.Get("ST"),
+			USN:      resp.Header.Get("USN"),
+			Server:   resp.Header.Get("SERVER"),
+		}
+		if !seen[device.USN] {
+			seen[device.USN] = true
+			devices = append(devices, device)
+		}
+	}
+}
+
+// LocalIP returns the address of the interface used to reach the SSDP group,
+// which is the address other devices on the LAN can reach this host at
+func LocalIP() (string, error) {
+	conn, err := net.Dial("udp4", SSDPAddress)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
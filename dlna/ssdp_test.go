@@ -0,0 +1,147 @@
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAdvertiser() *Advertiser {
+	return &Advertiser{
+		UUID:     "4d696e69-444c-164e-9d41-b827eb000001",
+		Location: "http://192.0.2.1:8080/dlna/description.xml",
+		Server:   "Linux/1.0 UPnP/1.0 jioSaavnAPI/1.0",
+		MaxAge:   30 * time.Minute,
+	}
+}
+
+// listenLoopback opens a UDP socket on the loopback interface
+func listenLoopback(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readMessages reads the SSDP messages received on conn until it stays quiet
+// for wait, parsing each with parse
+func readMessages(t *testing.T, conn *net.UDPConn, wait time.Duration, parse func(*bufio.Reader) (http.Header, error)) []http.Header {
+	t.Helper()
+	var headers []http.Header
+	buf := make([]byte, 2048)
+	for {
+		conn.SetReadDeadline(time.Now().Add(wait))
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return headers
+		}
+		header, err := parse(bufio.NewReader(bytes.NewReader(buf[:n])))
+		if err != nil {
+			t.Fatalf("invalid SSDP message %q: %v", buf[:n], err)
+		}
+		headers = append(headers, header)
+	}
+}
+
+func parseResponse(r *bufio.Reader) (http.Header, error) {
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
+
+func parseNotify(r *bufio.Reader) (http.Header, error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Method", req.Method)
+	return req.Header, nil
+}
+
+func TestAdvertiserAnswersSearches(t *testing.T) {
+	a := newTestAdvertiser()
+	listener, sender, client := listenLoopback(t), listenLoopback(t), listenLoopback(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.answerSearches(ctx, listener, sender)
+
+	search := func(target string) []http.Header {
+		msg := "M-SEARCH * HTTP/1.1\r\nHOST: " + SSDPAddress + "\r\nMAN: \"ssdp:discover\"\r\nMX: 1\r\nST: " + target + "\r\n\r\n"
+		if _, err := client.WriteTo([]byte(msg), listener.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+		return readMessages(t, client, 1500*time.Millisecond, parseResponse)
+	}
+
+	replies := search(DeviceType)
+	if len(replies) != 1 {
+		t.Fatalf("search for %s got %d replies, want 1", DeviceType, len(replies))
+	}
+	reply := replies[0]
+	if got := reply.Get("ST"); got != DeviceType {
+		t.Errorf("ST = %q, want %q", got, DeviceType)
+	}
+	if got, want := reply.Get("USN"), "uuid:"+a.UUID+"::"+DeviceType; got != want {
+		t.Errorf("USN = %q, want %q", got, want)
+	}
+	if got := reply.Get("LOCATION"); got != a.Location {
+		t.Errorf("LOCATION = %q, want %q", got, a.Location)
+	}
+	if got := reply.Get("CACHE-CONTROL"); got != "max-age=1800" {
+		t.Errorf("CACHE-CONTROL = %q, want max-age=1800", got)
+	}
+
+	var targets []string
+	for _, reply := range search("ssdp:all") {
+		targets = append(targets, reply.Get("ST"))
+	}
+	sort.Strings(targets)
+	want := []string{"upnp:rootdevice", "urn:schemas-upnp-org:device:MediaServer:1",
+		"urn:schemas-upnp-org:service:ConnectionManager:1", "urn:schemas-upnp-org:service:ContentDirectory:1", "uuid:" + a.UUID}
+	if strings.Join(targets, " ") != strings.Join(want, " ") {
+		t.Errorf("ssdp:all replies = %v, want %v", targets, want)
+	}
+
+	if replies := search("urn:schemas-upnp-org:device:MediaRenderer:1"); len(replies) != 0 {
+		t.Errorf("search for another device type got %d replies, want none", len(replies))
+	}
+}
+
+func TestAdvertiserNotify(t *testing.T) {
+	a := newTestAdvertiser()
+	sender, group := listenLoopback(t), listenLoopback(t)
+	addr := group.LocalAddr().(*net.UDPAddr)
+
+	a.notify(sender, addr, "ssdp:alive")
+	alive := readMessages(t, group, 200*time.Millisecond, parseNotify)
+	if len(alive) != len(a.notificationTypes()) {
+		t.Fatalf("got %d alive notifications, want %d", len(alive), len(a.notificationTypes()))
+	}
+	for _, header := range alive {
+		if header.Get("Method") != "NOTIFY" || header.Get("NTS") != "ssdp:alive" || header.Get("LOCATION") != a.Location {
+			t.Errorf("alive notification = %v", header)
+		}
+	}
+
+	a.notify(sender, addr, "ssdp:byebye")
+	byebye := readMessages(t, group, 200*time.Millisecond, parseNotify)
+	if len(byebye) != len(a.notificationTypes()) {
+		t.Fatalf("got %d byebye notifications, want %d", len(byebye), len(a.notificationTypes()))
+	}
+	for _, header := range byebye {
+		if header.Get("NTS") != "ssdp:byebye" || header.Get("LOCATION") != "" {
+			t.Errorf("byebye notification = %v", header)
+		}
+	}
+}
@@ -43,6 +43,868 @@ const docTemplate = `{
                         "name": "token",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format: json, m3u8, xspf, jspf, csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "stream",
+                        "description": "Track location in exports: stream or download",
+                        "name": "location",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/album/{id}": {
+            "get": {
+                "description": "Returns detailed information about an album including songs and artists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Get album details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Album ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format: json, m3u8, xspf, jspf, csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "stream",
+                        "description": "Track location in exports: stream or download",
+                        "name": "location",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/album/{id}/download.zip": {
+            "get": {
+                "description": "Streams a ZIP archive with the tagged tracks of an album, cover.jpg, an M3U8 playlist and a manifest.json listing any tracks that could not be downloaded",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Download an album as ZIP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Album ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "320",
+                        "description": "Bitrate in kbps: 12, 48, 96, 160, 320",
+                        "name": "quality",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/artist/{id}": {
+            "get": {
+                "description": "Returns detailed information about an artist including bio, top songs, and albums",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Artists"
+                ],
+                "summary": "Get artist details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Group releases of the same song in topSongs, listing the others under versions",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song kept for each group: plays (most played) or earliest (earliest release)",
+                        "name": "canonical",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cache/purge": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Empties one in-memory cache, or all of them, and returns the number of entries dropped from each. Requires the ADMIN_TOKEN bearer token, or a loopback client when no token is configured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Purge caches",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cache to purge: media; all when empty",
+                        "name": "cache",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the number of entries of each in-memory cache. The media cache holds media availability checks and auth-token URLs. Requires the ADMIN_TOKEN bearer token, or a loopback client when no token is configured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/download/{id}": {
+            "get": {
+                "description": "Redirects to (or proxies, depending on DOWNLOAD_MODE) the media file of a song. With tagged=true the file is always proxied with iTunes metadata written into it. When signed downloads are enabled, exp and sig must be those of a link issued by this API",
+                "produces": [
+                    "audio/mp4"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Download a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "320",
+                        "description": "Bitrate in kbps: 12, 48, 96, 160, 320",
+                        "name": "quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Link expiry as a Unix timestamp (signed links)",
+                        "name": "exp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature (signed links)",
+                        "name": "sig",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Embed title, artists, album, year, track number, copyright, lyrics and cover art into the M4A",
+                        "name": "tagged",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "302": {
+                        "description": "Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "get": {
+                "description": "Executes a GraphQL query over songs, albums, artists, playlists, lyrics and search. Nested objects are resolved from their details, and the details of all objects at one level of the query are fetched together, with songs batched into single song.getDetails calls. Queries estimated to resolve more than GRAPHQL_MAX_COMPLEXITY objects or nested deeper than GRAPHQL_MAX_DEPTH are rejected before execution.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "GraphQL endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GraphQL query, for GET requests",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object of variables, for GET requests",
+                        "name": "variables",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation to execute, for GET requests",
+                        "name": "operationName",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Executes a GraphQL query over songs, albums, artists, playlists, lyrics and search. Nested objects are resolved from their details, and the details of all objects at one level of the query are fetched together, with songs batched into single song.getDetails calls. Queries estimated to resolve more than GRAPHQL_MAX_COMPLEXITY objects or nested deeper than GRAPHQL_MAX_DEPTH are rejected before execution.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "GraphQL endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GraphQL query, for GET requests",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object of variables, for GET requests",
+                        "name": "variables",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation to execute, for GET requests",
+                        "name": "operationName",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/hls/album/{id}.m3u8": {
+            "get": {
+                "description": "/hls/album/{id}.m3u8 returns a master playlist with one variant per quality available for every track; each variant lists the segments of the album tracks in order, separated by discontinuities",
+                "produces": [
+                    "application/vnd.apple.mpegurl"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "HLS playlist for an album",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Album ID, followed by .m3u8",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/hls/song/{id}.m3u8": {
+            "get": {
+                "description": "/hls/song/{id}.m3u8 returns a master playlist with one variant per quality of the media ladder; /hls/song/{id}/{quality}.m3u8 returns the media playlist of one variant. The song is served as fragmented MP4 segments of about 6 seconds from /hls/song/{id}/{quality}/{segment}",
+                "produces": [
+                    "application/vnd.apple.mpegurl"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "HLS playlist for a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID, followed by .m3u8",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/hls/song/{id}/{quality}/{segment}": {
+            "get": {
+                "description": "Serves init.mp4, the fragmented MP4 header of a song at one quality, or {n}.m4s, its n-th segment from 0, as listed in the HLS media playlists. When signed links are enabled, exp and sig must be those of a link issued by this API",
+                "produces": [
+                    "audio/mp4"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "HLS segment of a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bitrate in kbps: 12, 48, 96, 160 or 320",
+                        "name": "quality",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "init.mp4 or {n}.m4s",
+                        "name": "segment",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Link expiry as a Unix timestamp (signed links)",
+                        "name": "exp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature (signed links)",
+                        "name": "sig",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "post": {
+                "description": "Accepts a CSV, M3U/M3U8, XSPF, JSPF or JSON playlist, either as the raw request body or as a multipart \"file\" field, and resolves every track to a JioSaavn song using search and fuzzy scoring on title, artist, album and duration",
+                "consumes": [
+                    "text/plain",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Import a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist format: csv, m3u, xspf, jspf, json (detected when omitted)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.6,
+                        "description": "Minimum confidence for a match, between 0 and 1",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Number of alternative candidates per track",
+                        "name": "alternatives",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/lyrics/search": {
+            "get": {
+                "description": "Full-text search over the lyrics fetched by this instance. Returns matching songs with the best matching line and a highlight",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Search lyrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "A line or fragment of the lyrics",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of results (max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/lyrics/{id}": {
+            "get": {
+                "description": "Returns structured lyrics for a song. Use format=txt for plain text or format=lrc for time-synced LRC output (only when synced lyrics are available)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Lyrics"
+                ],
+                "summary": "Get song lyrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID (a .lrc or .txt suffix selects the output format)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Output format: json, txt, lrc",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -67,6 +929,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -77,26 +946,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/album/{id}": {
+        "/match": {
             "get": {
-                "description": "Returns detailed information about an album including songs and artists",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Searches for a song by title and artist and scores every result against the given title, artists, album and duration. Titles are normalized (\"(From ...)\", feat. credits and version tags are stripped, Indic scripts are transliterated and diacritics folded) and each score comes with a per-feature breakdown.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Albums"
+                    "Match"
                 ],
-                "summary": "Get album details",
+                "summary": "Match a song",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Album ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Song title",
+                        "name": "title",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Artist names, separated by commas, \u0026 or feat.",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Album name",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Duration in seconds",
+                        "name": "duration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.6,
+                        "description": "Minimum score for the best candidate to count as a match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of candidates to return",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -124,9 +1022,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/artist/{id}": {
+        "/playlist/token/{token}": {
             "get": {
-                "description": "Returns detailed information about an artist including bio, top songs, and albums",
+                "description": "Returns minimal playlist information (metadata + song IDs only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,16 +1032,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Artists"
+                    "Playlists"
                 ],
-                "summary": "Get artist details",
+                "summary": "Get playlist details from token",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Artist ID",
-                        "name": "id",
+                        "description": "Playlist Token",
+                        "name": "token",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format: json, m3u8, xspf, jspf, csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "stream",
+                        "description": "Track location in exports: stream or download",
+                        "name": "location",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -161,6 +1073,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -171,34 +1090,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/lyrics/{id}": {
+        "/playlists/{token}/download.zip": {
             "get": {
-                "description": "Returns lyrics for a specific song with proper line breaks",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Streams a ZIP archive with the tagged tracks of a playlist, cover.jpg, an M3U8 playlist and a manifest.json listing any tracks that could not be downloaded",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "Lyrics"
+                    "Media"
                 ],
-                "summary": "Get song lyrics",
+                "summary": "Download a playlist as ZIP",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Lyrics ID (usually same as song ID)",
-                        "name": "id",
+                        "description": "Playlist Token",
+                        "name": "token",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "320",
+                        "description": "Bitrate in kbps: 12, 48, 96, 160, 320",
+                        "name": "quality",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -208,6 +1130,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -218,26 +1147,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/playlist/token/{token}": {
+        "/rest/{method}": {
             "get": {
-                "description": "Returns minimal playlist information (metadata + song IDs only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Subsonic REST API compatibility layer for clients such as DSub, Symfonium and Feishin. Supported methods: ping, getLicense, search3, getAlbum, getArtist, getSong, getCoverArt, stream, getLyrics, getPlaylists and getPlaylist, with or without the .view suffix. Users sign in with token (t and s) or password (p) authentication against SUBSONIC_USERS. Responses are XML, or JSON with f=json.",
                 "produces": [
+                    "text/xml",
                     "application/json"
                 ],
                 "tags": [
-                    "Playlists"
+                    "Subsonic"
                 ],
-                "summary": "Get playlist details from token",
+                "summary": "Subsonic API",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Playlist Token",
-                        "name": "token",
+                        "description": "Method name, e.g. ping or search3.view",
+                        "name": "method",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "u",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authentication token: md5(password + salt)",
+                        "name": "t",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Salt used for the token",
+                        "name": "s",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password, in clear or hex encoded with an enc: prefix",
+                        "name": "p",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "xml",
+                        "description": "Response format: xml, json or jsonp",
+                        "name": "f",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -247,23 +1206,63 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subsonic REST API compatibility layer for clients such as DSub, Symfonium and Feishin. Supported methods: ping, getLicense, search3, getAlbum, getArtist, getSong, getCoverArt, stream, getLyrics, getPlaylists and getPlaylist, with or without the .view suffix. Users sign in with token (t and s) or password (p) authentication against SUBSONIC_USERS. Responses are XML, or JSON with f=json.",
+                "produces": [
+                    "text/xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "Subsonic"
+                ],
+                "summary": "Subsonic API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Method name, e.g. ping or search3.view",
+                        "name": "method",
+                        "in": "path",
+                        "required": true
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "u",
+                        "in": "query",
+                        "required": true
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                    {
+                        "type": "string",
+                        "description": "Authentication token: md5(password + salt)",
+                        "name": "t",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    {
+                        "type": "string",
+                        "description": "Salt used for the token",
+                        "name": "s",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password, in clear or hex encoded with an enc: prefix",
+                        "name": "p",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "xml",
+                        "description": "Response format: xml, json or jsonp",
+                        "name": "f",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -313,6 +1312,24 @@ const docTemplate = `{
                         "description": "Results per page (max 50)",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Group releases of the same song (type song only), listing the others under versions",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song kept for each group: plays (most played) or earliest (earliest release)",
+                        "name": "canonical",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query variants to search too, comma-separated: latin, phonetic, devanagari, all or none",
+                        "name": "scripts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -367,6 +1384,24 @@ const docTemplate = `{
                         "description": "Number of results (max 50)",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Group releases of the same song, listing the others under versions",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song kept for each group: plays (most played) or earliest (earliest release)",
+                        "name": "canonical",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query variants to search too, comma-separated: latin, phonetic, devanagari, all or none",
+                        "name": "scripts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -447,6 +1482,109 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/stream/{id}": {
+            "get": {
+                "description": "Resolves the media URL of a song and proxies it with full HTTP Range support. If the requested quality is not available, the closest lower quality is streamed. When signed links are enabled, exp and sig must be those of a link issued by this API",
+                "produces": [
+                    "audio/mp4"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Stream a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "160",
+                        "description": "Bitrate in kbps: 12, 48, 96, 160, 320, or preview for the 30 second preview",
+                        "name": "quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Link expiry as a Unix timestamp (signed links)",
+                        "name": "exp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature (signed links)",
+                        "name": "sig",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "\"Bearer \" followed by the ADMIN_TOKEN setting",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
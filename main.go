@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"jioSaavnAPI/config"
 	"jioSaavnAPI/middleware"
 	"jioSaavnAPI/routes"
+	"jioSaavnAPI/services"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,9 +36,32 @@ import (
 // @BasePath  /
 
 // @schemes http https
+
+// @securityDefinitions.apikey  AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by the ADMIN_TOKEN setting
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// serve runs the API server and the servers enabled alongside it until the
+// HTTP server fails or the process is interrupted
+func serve(cfg *config.Config) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Load the lyrics search index from disk
+	services.InitLyricsIndex(ctx)
+
+	// Announce the DLNA media server on the local network, if enabled
+	dlnaDone := services.StartDLNA(ctx)
+
+	// Serve the catalog to MPD clients, if enabled
+	mpdDone := services.StartMPD(ctx)
+
+	// Serve the gRPC API on its own port, if enabled
+	grpcDone := services.StartGRPC(ctx)
 
 	// Initialize Gin router
 	r := gin.New()
@@ -60,8 +88,10 @@ func main() {
 		})
 	})
 
-	// 🔄 Start self-ping to keep the Render instance alive
-	keepAlive("https://jiosaavnapi-i3mb.onrender.com/health", 12*time.Minute)
+	// 🔄 Start self-ping to keep hosts such as Render from idling the instance
+	if cfg.KeepAliveURL != "" {
+		keepAlive(cfg.KeepAliveURL, cfg.KeepAliveInterval)
+	}
 
 	// Start server
 	serverAddr := fmt.Sprintf(":%s", cfg.ServerPort)
@@ -69,9 +99,33 @@ func main() {
 	log.Printf("Health check available at http://localhost%s/health", serverAddr)
 	log.Printf("Swagger documentation available at http://localhost%s/swagger/index.html", serverAddr)
 
-	if err := r.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{Addr: serverAddr, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	status := 0
+	select {
+	case err := <-serveErr:
+		log.Printf("Failed to start server: %v", err)
+		status = 1
+	case <-ctx.Done():
+		log.Println("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down server: %v", err)
+		}
 	}
+
+	// Let the DLNA advertiser say goodbye, the MPD listener close and the gRPC
+	// calls finish before exiting
+	stop()
+	<-dlnaDone
+	<-mpdDone
+	<-grpcDone
+	return status
 }
 
 // keepAlive periodically pings the deployed endpoint to prevent Render from idling.
@@ -0,0 +1,244 @@
+// Package match decides whether two song descriptions refer to the same song.
+// It normalizes titles, artists and albums across scripts and spelling
+// variations and returns an explainable score.
+package match
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Track is the description of a song being compared
+type Track struct {
+	Title    string   `json:"title"`
+	Artists  []string `json:"artists,omitempty"`
+	Album    string   `json:"album,omitempty"`
+	Duration int      `json:"duration,omitempty"` // seconds
+}
+
+// Feature is the contribution of one compared attribute to a score
+type Feature struct {
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// Score is the result of comparing two tracks. Score is the weighted mean of
+// the features present in both tracks, between 0 and 1, reduced by any
+// penalties: a different version of the song (remix, unplugged, ...) or a
+// title too different for the other features to make up for.
+type Score struct {
+	Score     float64            `json:"score"`
+	Breakdown map[string]Feature `json:"breakdown"`
+	Penalties []string           `json:"penalties"`
+}
+
+// Weights of the compared features. Features missing from either track are left
+// out and the remaining weights are scaled up accordingly.
+var Weights = map[string]float64{
+	"title":    0.5,
+	"artists":  0.3,
+	"duration": 0.15,
+	"album":    0.05,
+}
+
+const (
+	// Duration differences up to durationTolerance seconds score 1, falling to 0
+	// at durationLimit seconds
+	durationTolerance = 3
+	durationLimit     = 30
+
+	// versionPenalty scales the score of a different version of the same song
+	versionPenalty = 0.8
+	// Below titleGate the score is scaled down in proportion to the title score
+	titleGate = 0.6
+)
+
+// Compare scores how likely it is that a and b are the same song
+func Compare(a, b Track) Score {
+	breakdown := map[string]Feature{}
+	penalties := []string{}
+
+	titleA, titleB := NormalizeTitle(a.Title), NormalizeTitle(b.Title)
+	title := TextSimilarity(titleA, titleB)
+	breakdown["title"] = Feature{
+		Score:  title,
+		Weight: Weights["title"],
+		Detail: fmt.Sprintf("%q vs %q", titleA, titleB),
+	}
+
+	if len(a.Artists) > 0 && len(b.Artists) > 0 {
+		breakdown["artists"] = compareArtists(a.Artists, b.Artists)
+	}
+	if a.Album != "" && b.Album != "" {
+		albumA, albumB := NormalizeAlbum(a.Album), NormalizeAlbum(b.Album)
+		breakdown["album"] = Feature{
+			Score:  TextSimilarity(albumA, albumB),
+			Weight: Weights["album"],
+			Detail: fmt.Sprintf("%q vs %q", albumA, albumB),
+		}
+	}
+	if a.Duration > 0 && b.Duration > 0 {
+		diff := a.Duration - b.Duration
+		if diff < 0 {
+			diff = -diff
+		}
+		breakdown["duration"] = Feature{
+			Score:  DurationSimilarity(a.Duration, b.Duration),
+			Weight: Weights["duration"],
+			Detail: fmt.Sprintf("%ds apart", diff),
+		}
+	}
+
+	var total, weights float64
+	for _, feature := range breakdown {
+		total += feature.Score * feature.Weight
+		weights += feature.Weight
+	}
+	score := total / weights
+
+	versionsA, versionsB := VersionTags(a.Title), VersionTags(b.Title)
+	if describeVersion(versionsA) != describeVersion(versionsB) {
+		score *= versionPenalty
+		penalties = append(penalties, fmt.Sprintf("different versions: %s vs %s (x%g)", describeVersion(versionsA), describeVersion(versionsB), versionPenalty))
+	}
+	if title < titleGate {
+		factor := round(title / titleGate)
+		score *= factor
+		penalties = append(penalties, fmt.Sprintf("titles differ (x%g)", factor))
+	}
+
+	return Score{Score: round(score), Breakdown: breakdown, Penalties: penalties}
+}
+
+// describeVersion names the version of a song from its version tags
+func describeVersion(tags []string) string {
+	if len(tags) == 0 {
+		return "original"
+	}
+	return strings.Join(tags, "+")
+}
+
+// compareArtists scores the overlap of two artist sets. Finding the artists of a
+// in b counts more than the reverse, since catalogs often credit extra artists.
+func compareArtists(a, b []string) Feature {
+	normalize := func(credits []string) []string {
+		names := []string{}
+		for _, credit := range credits {
+			for _, name := range SplitArtists(credit) {
+				if name = NormalizeText(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+		return names
+	}
+	namesA, namesB := normalize(a), normalize(b)
+
+	found := func(names, others []string) (float64, int) {
+		var total float64
+		matched := 0
+		for _, name := range names {
+			best := 0.0
+			for _, other := range others {
+				best = math.Max(best, TextSimilarity(name, other))
+			}
+			if best >= 0.8 {
+				matched++
+			}
+			total += best
+		}
+		return total / float64(max(len(names), 1)), matched
+	}
+	precision, matched := found(namesA, namesB)
+	recall, _ := found(namesB, namesA)
+
+	feature := Feature{
+		Weight: Weights["artists"],
+		Detail: fmt.Sprintf("%d of %d artists found", matched, len(namesA)),
+	}
+	feature.Score = round(0.75*precision + 0.25*recall)
+	return feature
+}
+
+// DurationSimilarity is 1 for durations a few seconds apart, falling linearly
+// to 0 at half a minute apart
+func DurationSimilarity(a, b int) float64 {
+	diff := math.Abs(float64(a - b))
+	switch {
+	case diff <= durationTolerance:
+		return 1
+	case diff >= durationLimit:
+		return 0
+	}
+	return round(1 - (diff-durationTolerance)/(durationLimit-durationTolerance))
+}
+
+// TextSimilarity compares two normalized strings, using the best of a word
+// overlap and edit distance ratios on the text and on its phonetic key
+func TextSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	keyA, keyB := PhoneticKey(a), PhoneticKey(b)
+	if keyA == keyB {
+		// Same pronunciation, different spelling
+		return 0.95
+	}
+	best := math.Max(tokenOverlap(a, b), editRatio(a, b))
+	best = math.Max(best, 0.95*editRatio(keyA, keyB))
+	return round(best)
+}
+
+// tokenOverlap is the Dice coefficient of the word sets of two strings
+func tokenOverlap(a, b string) float64 {
+	setA := map[string]bool{}
+	for _, token := range strings.Fields(a) {
+		setA[token] = true
+	}
+	setB := map[string]bool{}
+	for _, token := range strings.Fields(b) {
+		setB[token] = true
+	}
+	common := 0
+	for token := range setA {
+		if setB[token] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(setA)+len(setB))
+}
+
+// editRatio is 1 minus the Levenshtein distance relative to the longer string
+func editRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// round keeps three decimals, which is all the precision a score needs
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
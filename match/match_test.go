@@ -0,0 +1,185 @@
+package match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tumHiHo := Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}, Album: "Aashiqui 2", Duration: 262}
+
+	tests := []struct {
+		name     string
+		a, b     Track
+		min, max float64
+		// penalty is part of a penalty of the score, or "" for none
+		penalty string
+	}{
+		{
+			name: "same song",
+			a:    tumHiHo,
+			b:    tumHiHo,
+			min:  1, max: 1,
+		},
+		{
+			name: "soundtrack title and album",
+			a:    tumHiHo,
+			b:    Track{Title: `Tum Hi Ho (From "Aashiqui 2")`, Artists: []string{"Arijit Singh"}, Album: "Aashiqui 2 (Original Motion Picture Soundtrack)", Duration: 261},
+			min:  1, max: 1,
+		},
+		{
+			name: "featured artist in the title and credit",
+			a:    Track{Title: "Raataan Lambiyan feat. Jubin Nautiyal", Artists: []string{"Tanishk Bagchi, Jubin Nautiyal & Asees Kaur"}},
+			b:    Track{Title: "Raataan Lambiyan", Artists: []string{"Jubin Nautiyal", "Asees Kaur"}},
+			min:  0.9, max: 1,
+		},
+		{
+			name: "artists credited with featuring",
+			a:    Track{Title: "Excuses", Artists: []string{"AP Dhillon featuring Gurinder Gill"}},
+			b:    Track{Title: "Excuses", Artists: []string{"AP Dhillon", "Gurinder Gill"}},
+			min:  1, max: 1,
+		},
+		{
+			name: "remix",
+			a:    Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}, Duration: 262},
+			b:    Track{Title: "Tum Hi Ho - Remix", Artists: []string{"Arijit Singh", "DJ Chetas"}, Duration: 250},
+			min:  0.6, max: 0.8,
+			penalty: "different versions: original vs remix",
+		},
+		{
+			name: "unplugged version",
+			a:    Track{Title: "Channa Mereya", Artists: []string{"Arijit Singh"}},
+			b:    Track{Title: "Channa Mereya (Unplugged)", Artists: []string{"Arijit Singh"}},
+			min:  0.8, max: 0.8,
+			penalty: "original vs unplugged (x0.8)",
+		},
+		{
+			name:    "female version",
+			a:       Track{Title: "Tujhe Kitna Chahne Lage", Artists: []string{"Arijit Singh"}},
+			b:       Track{Title: "Tujhe Kitna Chahne Lage (Female Version)", Artists: []string{"Shilpa Rao"}},
+			max:     0.8,
+			penalty: "original vs female",
+		},
+		{
+			name: "same version tags",
+			a:    Track{Title: "Kesariya (Remix)"},
+			b:    Track{Title: "Kesariya - Remix"},
+			min:  1, max: 1,
+		},
+		{
+			name: "Devanagari title and artist",
+			a:    Track{Title: "तुम ही हो", Artists: []string{"अरिजीत सिंह"}},
+			b:    Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}},
+			min:  0.85, max: 1,
+		},
+		{
+			name: "Telugu title and artist",
+			a:    Track{Title: "Naatu Naatu", Artists: []string{"Rahul Sipligunj"}},
+			b:    Track{Title: "నాటు నాటు", Artists: []string{"రాహుల్ సిప్లిగంజ్"}},
+			min:  0.9, max: 1,
+		},
+		{
+			name: "Tamil title",
+			a:    Track{Title: "Naattu Naattu"},
+			b:    Track{Title: "நாட்டு நாட்டு"},
+			min:  1, max: 1,
+		},
+		{
+			name: "romanized spelling",
+			a:    Track{Title: "Thum Hee Ho", Artists: []string{"Arijit Singh"}},
+			b:    Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}},
+			min:  0.95, max: 1,
+		},
+		{
+			name: "diacritics",
+			a:    Track{Title: "Beyoncé"},
+			b:    Track{Title: "Beyonce"},
+			min:  1, max: 1,
+		},
+		{
+			name:    "different songs of the artist",
+			a:       Track{Title: "Kesariya", Artists: []string{"Arijit Singh"}, Duration: 268},
+			b:       tumHiHo,
+			max:     0.2,
+			penalty: "titles differ",
+		},
+		{
+			name: "durations far apart",
+			a:    Track{Title: "Tum Hi Ho", Duration: 262},
+			b:    Track{Title: "Tum Hi Ho", Duration: 400},
+			min:  0.75, max: 0.8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Compare(tt.a, tt.b)
+			if score.Score < tt.min || score.Score > tt.max {
+				t.Errorf("score = %g, want between %g and %g (%v, %v)", score.Score, tt.min, tt.max, score.Breakdown, score.Penalties)
+			}
+			penalties := strings.Join(score.Penalties, "; ")
+			if tt.penalty == "" && penalties != "" {
+				t.Errorf("penalties = %q, want none", penalties)
+			}
+			if !strings.Contains(penalties, tt.penalty) {
+				t.Errorf("penalties = %q, want %q", penalties, tt.penalty)
+			}
+		})
+	}
+}
+
+func TestCompareBreakdown(t *testing.T) {
+	a := Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}}
+	b := Track{Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}, Album: "Aashiqui 2", Duration: 262}
+
+	// Features missing from either track are left out of the score
+	score := Compare(a, b)
+	if _, ok := score.Breakdown["album"]; ok {
+		t.Error("album compared although a has none")
+	}
+	if _, ok := score.Breakdown["duration"]; ok {
+		t.Error("duration compared although a has none")
+	}
+	if got := score.Breakdown["artists"].Detail; got != "1 of 1 artists found" {
+		t.Errorf("artists detail = %q", got)
+	}
+	if got := score.Breakdown["title"].Detail; got != `"tum hi ho" vs "tum hi ho"` {
+		t.Errorf("title detail = %q", got)
+	}
+}
+
+func TestDurationSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want float64
+	}{
+		{262, 262, 1},
+		{262, 265, 1},
+		{265, 262, 1},
+		{262, 276, 0.593},
+		{262, 292, 0},
+		{262, 400, 0},
+	}
+	for _, tt := range tests {
+		if got := DurationSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("DurationSimilarity(%d, %d) = %g, want %g", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"tum hi ho", "tum hi ho", 1, 1},
+		{"tum hi ho", "thum hee ho", 0.95, 0.95},
+		{"arijit singh", "arijit", 0.6, 0.8},
+		{"kesariya", "tum hi ho", 0, 0.2},
+		{"", "tum hi ho", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := TextSimilarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("TextSimilarity(%q, %q) = %g, want between %g and %g", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
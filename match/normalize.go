@@ -0,0 +1,155 @@
+package match
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// versionTags are the words that mark a song as a different version of the
+// same title when they appear in brackets or a dashed suffix, as in
+// "Tum Hi Ho (Female Version)" or "Kesariya - Live"
+var versionTags = []string{
+	"remix", "reprise", "unplugged", "acoustic", "live", "lofi", "lo fi",
+	"slowed", "reverb", "sped up", "instrumental", "karaoke", "cover",
+	"mashup", "female", "male", "duet", "sad", "club", "dj",
+}
+
+// strongVersionTags mark a version anywhere in a title, as in "Kesariya Lofi Flip"
+var strongVersionTags = []string{
+	"remix", "reprise", "unplugged", "lofi", "lo fi", "slowed", "reverb",
+	"sped up", "instrumental", "karaoke", "mashup",
+}
+
+var (
+	versionTagPattern       = regexp.MustCompile(`(?i)\b(` + strings.Join(versionTags, "|") + `)\b`)
+	strongVersionTagPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(strongVersionTags, "|") + `)\b`)
+)
+
+var (
+	// bracketed matches "(...)" and "[...]" segments, e.g. (From "Aashiqui 2") or [Remix]
+	bracketed = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`)
+	// dashSuffix matches " - From ...", " - Remix", " - Live at ..." suffixes
+	dashSuffix = regexp.MustCompile(`(?i)\s+[-–—]\s+(from|feat\.?|ft\.?|featuring|with|` + strings.Join(versionTags, "|") + `|version|remaster(ed)?|radio edit|edit)\b.*$`)
+	// featuring matches trailing featured artist credits
+	featuring = regexp.MustCompile(`(?i)\s+(feat\.?|ft\.?|featuring)\s+.*$`)
+	// albumSuffix matches soundtrack and edition suffixes of album names
+	albumSuffix = regexp.MustCompile(`(?i)\s*[-:]?\s*\b(original motion picture soundtrack|original soundtrack|ost|deluxe( edition)?|expanded edition)\b.*$`)
+	// artistSeparators split artist credits into individual names
+	artistSeparators = regexp.MustCompile(`(?i)\s*(?:,|;|&|\bfeaturing\b|\bfeat\b\.?|\bft\b\.?|\bx\b)\s*`)
+)
+
+// FoldDiacritics removes combining marks from Latin text, so "Beyoncé" becomes
+// "Beyonce". Indic vowel signs are combining marks too, so text is folded one
+// rune at a time and only marks following a Latin letter are dropped.
+func FoldDiacritics(s string) string {
+	var b strings.Builder
+	prevLatin := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) && prevLatin {
+			continue
+		}
+		prevLatin = unicode.Is(unicode.Latin, r)
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// NormalizeText folds diacritics, transliterates Indic scripts and reduces s
+// to lowercase letters and digits separated by single spaces
+func NormalizeText(s string) string {
+	s = strings.ToLower(Transliterate(FoldDiacritics(s)))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// NormalizeTitle strips "(From ...)", featured artists, version tags and other
+// bracketed or dashed suffixes from a song title before normalizing it
+func NormalizeTitle(title string) string {
+	stripped := bracketed.ReplaceAllString(title, "")
+	stripped = dashSuffix.ReplaceAllString(stripped, "")
+	stripped = featuring.ReplaceAllString(stripped, "")
+	stripped = strongVersionTagPattern.ReplaceAllString(stripped, "")
+	if normalized := NormalizeText(stripped); normalized != "" {
+		return normalized
+	}
+	// Titles like "Remix" have nothing else to compare on
+	return NormalizeText(title)
+}
+
+// NormalizeAlbum strips soundtrack and edition suffixes from an album name
+func NormalizeAlbum(album string) string {
+	album = bracketed.ReplaceAllString(album, "")
+	return NormalizeText(albumSuffix.ReplaceAllString(album, ""))
+}
+
+// VersionTags returns the sorted version tags of a title, e.g. ["remix"]
+func VersionTags(title string) []string {
+	title = FoldDiacritics(title)
+	found := strongVersionTagPattern.FindAllString(title, -1)
+	segments := bracketed.FindAllString(title, -1)
+	segments = append(segments, dashSuffix.FindString(title))
+	for _, segment := range segments {
+		found = append(found, versionTagPattern.FindAllString(segment, -1)...)
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, tag := range found {
+		tag = strings.ReplaceAll(strings.ToLower(tag), " ", "")
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SplitArtists splits an artist credit such as "A, B & C feat. D" into names
+func SplitArtists(credit string) []string {
+	names := []string{}
+	for _, name := range artistSeparators.Split(credit, -1) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// commonSpellings rewrite romanization variants to their most common spelling
+var commonSpellings = strings.NewReplacer("ee", "i", "oo", "u", "th", "t", "dh", "d", "ph", "f")
+
+// CommonSpelling rewrites normalized romanized text with the most common
+// spelling of long vowels and aspirated consonants, as in "thum hee ho" to
+// "tum hi ho". Unlike PhoneticKey the result is still a readable query.
+func CommonSpelling(normalized string) string {
+	return commonSpellings.Replace(normalized)
+}
+
+// PhoneticKey reduces normalized text to a key that ignores the usual
+// variations in romanized Indian titles: doubled vowels ("aa", "ee"),
+// aspiration ("bh", "dh"), "w"/"v", "z"/"j", "ph"/"f" and repeated letters
+func PhoneticKey(normalized string) string {
+	replacer := strings.NewReplacer(
+		"aa", "a", "ee", "i", "ii", "i", "oo", "u", "uu", "u", "ou", "u",
+		"ph", "f", "w", "v", "z", "j", "q", "k", "y", "i",
+		"kh", "k", "gh", "g", "chh", "c", "ch", "c", "jh", "j",
+		"th", "t", "dh", "d", "bh", "b", "sh", "s",
+	)
+	key := replacer.Replace(normalized)
+
+	var b strings.Builder
+	var prev rune
+	for _, r := range key {
+		if r != prev || r == ' ' {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Tum Hi Ho", "tum hi ho"},
+		{`Tum Hi Ho (From "Aashiqui 2")`, "tum hi ho"},
+		{`Kesariya - From "Brahmastra"`, "kesariya"},
+		{"Raataan Lambiyan feat. Jubin Nautiyal", "raataan lambiyan"},
+		{"Tum Hi Ho ft. Arijit Singh", "tum hi ho"},
+		{"Lut Gaye featuring Jubin Nautiyal", "lut gaye"},
+		{"Tum Hi Ho - Remix", "tum hi ho"},
+		{"Channa Mereya [Unplugged]", "channa mereya"},
+		{"Tujhe Kitna Chahne Lage (Female Version)", "tujhe kitna chahne lage"},
+		{"Apna Bana Le - Live at Mumbai", "apna bana le"},
+		{"Kesariya Lofi Flip", "kesariya flip"},
+		{"Chaiyya Chaiyya - Remastered 2019", "chaiyya chaiyya"},
+		{"Beyoncé", "beyonce"},
+		{"  Dil  Se  Re!! ", "dil se re"},
+		// Titles with nothing else to compare on keep their tags
+		{"Remix", "remix"},
+		{"(Intro)", "intro"},
+		// Indic scripts are romanized
+		{"तुम ही हो", "tum hee ho"},
+		{"ਤੁਮ ਹੀ ਹੋ", "tum hee ho"},
+		{"నాటు నాటు", "naatu naatu"},
+		{"நாட்டு நாட்டு", "naattu naattu"},
+		{"कलंक (Title Track)", "kalank"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.title); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeAlbum(t *testing.T) {
+	tests := []struct {
+		album string
+		want  string
+	}{
+		{"Aashiqui 2", "aashiqui 2"},
+		{"Aashiqui 2 (Original Motion Picture Soundtrack)", "aashiqui 2"},
+		{"Brahmastra - OST", "brahmastra"},
+		{"Jab We Met: Deluxe Edition", "jab we met"},
+		{"Rockstar [Expanded Edition]", "rockstar"},
+		{"आशिकी 2", "aashikee 2"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAlbum(tt.album); got != tt.want {
+			t.Errorf("NormalizeAlbum(%q) = %q, want %q", tt.album, got, tt.want)
+		}
+	}
+}
+
+func TestVersionTags(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Tum Hi Ho", ""},
+		{`Tum Hi Ho (From "Aashiqui 2")`, ""},
+		{"Tum Hi Ho - Remix", "remix"},
+		{"Channa Mereya [Unplugged]", "unplugged"},
+		{"Tujhe Kitna Chahne Lage (Female Version)", "female"},
+		{"Apna Bana Le - Live at Mumbai", "live"},
+		{"Kesariya Lofi Flip", "lofi"},
+		{"Kesariya (Lo Fi)", "lofi"},
+		{"Tere Vaaste (Slowed + Reverb)", "reverb,slowed"},
+		{"Ve Kamleya - Sped Up", "spedup"},
+		{"Raataan Lambiyan (Remix) - Remix", "remix"},
+		// Version words are only tags in brackets or a dashed suffix
+		{"Live Your Life", ""},
+		{"Sad Girlz Luv Money", ""},
+		{"Tum Se Hi (Sad Version)", "sad"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(VersionTags(tt.title), ","); got != tt.want {
+			t.Errorf("VersionTags(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArtists(t *testing.T) {
+	tests := []struct {
+		credit string
+		want   []string
+	}{
+		{"Arijit Singh", []string{"Arijit Singh"}},
+		{"Vishal-Shekhar, Shreya Ghoshal & Arijit Singh feat. Badshah", []string{"Vishal-Shekhar", "Shreya Ghoshal", "Arijit Singh", "Badshah"}},
+		{"Diljit Dosanjh x Sia", []string{"Diljit Dosanjh", "Sia"}},
+		{"A; B ft. C", []string{"A", "B", "C"}},
+		{"AP Dhillon featuring Gurinder Gill", []string{"AP Dhillon", "Gurinder Gill"}},
+		{"Jubin Nautiyal Feat Asees Kaur", []string{"Jubin Nautiyal", "Asees Kaur"}},
+		// Separators are whole words
+		{"Xzibit", []string{"Xzibit"}},
+		{"Feathers, Ftampa", []string{"Feathers", "Ftampa"}},
+		{"Shankar–Ehsaan–Loy", []string{"Shankar–Ehsaan–Loy"}},
+		{"अरिजीत सिंह, श्रेया घोषाल", []string{"अरिजीत सिंह", "श्रेया घोषाल"}},
+		{" , & ", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := SplitArtists(tt.credit); strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SplitArtists(%q) = %q, want %q", tt.credit, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneticKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"tum hi ho", "thum hee ho"},
+		{"raataan lambiyan", "ratan lambian"},
+		{"dil diyan gallan", "dil diyaan gallaan"},
+		{"bhool bhulaiyaa", "bhul bhulaiya"},
+		{"zara zara", "jara jara"},
+	}
+	for _, tt := range tests {
+		if keyA, keyB := PhoneticKey(tt.a), PhoneticKey(tt.b); keyA != keyB {
+			t.Errorf("PhoneticKey(%q) = %q, PhoneticKey(%q) = %q, want the same key", tt.a, keyA, tt.b, keyB)
+		}
+	}
+}
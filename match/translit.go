@@ -0,0 +1,254 @@
+package match
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Indic scripts share the ISCII-derived layout of the Devanagari block, so one
+// table indexed by the offset within a block covers all of them.
+const indicBlockSize = 0x80
+
+// indicScripts maps the first code point of each supported block to its name
+var indicScripts = map[rune]string{
+	0x0900: "devanagari",
+	0x0980: "bengali",
+	0x0A00: "gurmukhi",
+	0x0A80: "gujarati",
+	0x0B00: "oriya",
+	0x0B80: "tamil",
+	0x0C00: "telugu",
+	0x0C80: "kannada",
+	0x0D00: "malayalam",
+}
+
+// indicVowels are the independent vowels
+var indicVowels = map[rune]string{
+	0x05: "a", 0x06: "aa", 0x07: "i", 0x08: "ee", 0x09: "u", 0x0A: "oo",
+	0x0B: "ri", 0x0C: "lri", 0x0D: "e", 0x0E: "e", 0x0F: "e", 0x10: "ai",
+	0x11: "o", 0x12: "o", 0x13: "o", 0x14: "au", 0x60: "rri", 0x61: "lri",
+}
+
+// indicConsonants carry an inherent "a" unless followed by a vowel sign or virama
+var indicConsonants = map[rune]string{
+	0x15: "k", 0x16: "kh", 0x17: "g", 0x18: "gh", 0x19: "n",
+	0x1A: "ch", 0x1B: "chh", 0x1C: "j", 0x1D: "jh", 0x1E: "n",
+	0x1F: "t", 0x20: "th", 0x21: "d", 0x22: "dh", 0x23: "n",
+	0x24: "t", 0x25: "th", 0x26: "d", 0x27: "dh", 0x28: "n", 0x29: "n",
+	0x2A: "p", 0x2B: "ph", 0x2C: "b", 0x2D: "bh", 0x2E: "m",
+	0x2F: "y", 0x30: "r", 0x31: "r", 0x32: "l", 0x33: "l", 0x34: "zh", 0x35: "v",
+	0x36: "sh", 0x37: "sh", 0x38: "s", 0x39: "h",
+	// Consonants with nukta
+	0x58: "q", 0x59: "kh", 0x5A: "g", 0x5B: "z", 0x5C: "r", 0x5D: "rh", 0x5E: "f", 0x5F: "y",
+}
+
+// indicVowelSigns replace the inherent vowel of the preceding consonant
+var indicVowelSigns = map[rune]string{
+	0x3E: "aa", 0x3F: "i", 0x40: "ee", 0x41: "u", 0x42: "oo", 0x43: "ri", 0x44: "rri",
+	0x45: "e", 0x46: "e", 0x47: "e", 0x48: "ai", 0x49: "o", 0x4A: "o", 0x4B: "o", 0x4C: "au",
+	0x57: "au", 0x62: "lri", 0x63: "lri",
+}
+
+// indicOther are signs that stand on their own
+var indicOther = map[rune]string{
+	0x01: "n", 0x02: "n", 0x03: "h", // candrabindu, anusvara, visarga
+	0x4E: "t",                                                        // Bengali khanda ta
+	0x50: "om",                                                       // om
+	0x70: "n",                                                        // Gurmukhi tippi
+	0x7A: "n", 0x7B: "n", 0x7C: "r", 0x7D: "l", 0x7E: "l", 0x7F: "k", // Malayalam chillu letters
+}
+
+const (
+	indicVirama = 0x4D
+	indicNukta  = 0x3C
+)
+
+// indicOffset returns the offset of r within its Indic block
+func indicOffset(r rune) (rune, bool) {
+	base := r - (r-0x0900)%indicBlockSize
+	if _, ok := indicScripts[base]; !ok || r < 0x0900 {
+		return 0, false
+	}
+	return r - base, true
+}
+
+// Script returns the name of the Indic script used in s, "latin" when s has
+// Latin letters only and "" when it has no letters
+func Script(s string) string {
+	latin := false
+	for _, r := range s {
+		if r >= 0x0900 && r < 0x0D80 {
+			if name, ok := indicScripts[r-(r-0x0900)%indicBlockSize]; ok {
+				return name
+			}
+		}
+		if unicode.IsLetter(r) && unicode.Is(unicode.Latin, r) {
+			latin = true
+		}
+	}
+	if latin {
+		return "latin"
+	}
+	return ""
+}
+
+// Transliterate romanizes Indic script text the way song titles are usually
+// written in Latin script ("तुम ही हो" becomes "tum hee ho"). The inherent vowel
+// is dropped at the end of words, as in Hindi. Other text is returned unchanged.
+func Transliterate(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	pending := false // a consonant is waiting for its vowel
+
+	flush := func(next rune) {
+		if !pending {
+			return
+		}
+		pending = false
+		// Word-final schwa deletion: no vowel when the word ends here
+		if offset, ok := indicOffset(next); ok && isIndicLetter(offset) {
+			b.WriteString("a")
+		}
+	}
+
+	for i, r := range runes {
+		offset, ok := indicOffset(r)
+		if !ok {
+			flush(r)
+			b.WriteRune(r)
+			continue
+		}
+
+		switch {
+		case offset == indicNukta:
+			// Changes the consonant's sound slightly; the plain letter is close enough
+		case offset == indicVirama:
+			pending = false
+		case indicVowelSigns[offset] != "":
+			pending = false
+			b.WriteString(indicVowelSigns[offset])
+		case indicConsonants[offset] != "":
+			flush(r)
+			b.WriteString(indicConsonants[offset])
+			pending = true
+		case indicVowels[offset] != "":
+			flush(r)
+			b.WriteString(indicVowels[offset])
+		case offset == 0x01 || offset == 0x02:
+			// A nasal sign right after a consonant still needs its vowel
+			if pending {
+				pending = false
+				b.WriteString("a")
+			}
+			b.WriteString(indicOther[offset])
+		case indicOther[offset] != "":
+			flush(r)
+			b.WriteString(indicOther[offset])
+		case offset >= 0x66 && offset <= 0x6F:
+			flush(r)
+			b.WriteRune('0' + (offset - 0x66))
+		case offset == 0x64 || offset == 0x65:
+			// Danda and double danda end a sentence
+			flush(' ')
+			b.WriteString(" ")
+		default:
+			flush(r)
+		}
+
+		if i == len(runes)-1 {
+			flush(' ')
+		}
+	}
+	return b.String()
+}
+
+// isIndicLetter reports whether an offset is a vowel, consonant or sign that continues a word
+func isIndicLetter(offset rune) bool {
+	return indicConsonants[offset] != "" || indicVowels[offset] != "" ||
+		indicVowelSigns[offset] != "" || indicOther[offset] != "" ||
+		offset == indicVirama || offset == indicNukta
+}
+
+// latinSpelling is a romanized spelling with its Devanagari letter, or for
+// vowels its independent form and vowel sign
+type latinSpelling struct{ latin, letter, sign string }
+
+// latinConsonants map romanized consonants to Devanagari, longest spelling first
+var latinConsonants = []latinSpelling{
+	{latin: "chh", letter: "छ"}, {latin: "kh", letter: "ख"}, {latin: "gh", letter: "घ"},
+	{latin: "ch", letter: "च"}, {latin: "jh", letter: "झ"}, {latin: "th", letter: "थ"},
+	{latin: "dh", letter: "ध"}, {latin: "ph", letter: "फ"}, {latin: "bh", letter: "भ"},
+	{latin: "sh", letter: "श"}, {latin: "k", letter: "क"}, {latin: "g", letter: "ग"},
+	{latin: "j", letter: "ज"}, {latin: "t", letter: "त"}, {latin: "d", letter: "द"},
+	{latin: "n", letter: "न"}, {latin: "p", letter: "प"}, {latin: "f", letter: "फ़"},
+	{latin: "b", letter: "ब"}, {latin: "m", letter: "म"}, {latin: "y", letter: "य"},
+	{latin: "r", letter: "र"}, {latin: "l", letter: "ल"}, {latin: "v", letter: "व"},
+	{latin: "w", letter: "व"}, {latin: "s", letter: "स"}, {latin: "h", letter: "ह"},
+	{latin: "z", letter: "ज़"}, {latin: "q", letter: "क़"}, {latin: "c", letter: "क"},
+	{latin: "x", letter: "क्स"},
+}
+
+// latinVowels map romanized vowels to Devanagari, longest spelling first
+var latinVowels = []latinSpelling{
+	{"aa", "आ", "ा"}, {"ee", "ई", "ी"}, {"ii", "ई", "ी"}, {"oo", "ऊ", "ू"},
+	{"uu", "ऊ", "ू"}, {"ai", "ऐ", "ै"}, {"au", "औ", "ौ"},
+	{"a", "अ", ""}, {"i", "इ", "ि"}, {"u", "उ", "ु"}, {"e", "ए", "े"}, {"o", "ओ", "ो"},
+}
+
+// ToDevanagari writes romanized Hindi in Devanagari, the reverse of
+// Transliterate ("tum hi ho" becomes "तुम ही हो"). Romanization loses
+// information, so the result is a best guess: consonants are dental and
+// word-final vowels are long. Characters other than Latin letters are kept.
+func ToDevanagari(s string) string {
+	var b strings.Builder
+	s = strings.ToLower(s)
+	afterConsonant := false
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		if consonant, ok := latinPrefix(rest, latinConsonants); ok {
+			if afterConsonant {
+				b.WriteString("्")
+			}
+			b.WriteString(consonant.letter)
+			afterConsonant = true
+			i += len(consonant.latin)
+			continue
+		}
+		if vowel, ok := latinPrefix(rest, latinVowels); ok {
+			i += len(vowel.latin)
+			// Word-final short vowels are usually long, as in "hi" (ही) and "kesariya" (केसरिया)
+			if wordEnd := i == len(s) || s[i] < 'a' || s[i] > 'z'; wordEnd {
+				switch vowel.latin {
+				case "i":
+					vowel = latinSpelling{"i", "ई", "ी"}
+				case "a":
+					vowel = latinSpelling{"a", "आ", "ा"}
+				}
+			}
+			if afterConsonant {
+				b.WriteString(vowel.sign)
+			} else {
+				b.WriteString(vowel.letter)
+			}
+			afterConsonant = false
+			continue
+		}
+		afterConsonant = false
+		r, size := utf8.DecodeRuneInString(rest)
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
+
+// latinPrefix returns the first of spellings that s starts with
+func latinPrefix(s string, spellings []latinSpelling) (latinSpelling, bool) {
+	for _, spelling := range spellings {
+		if strings.HasPrefix(s, spelling.latin) {
+			return spelling, true
+		}
+	}
+	return latinSpelling{}, false
+}
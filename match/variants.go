@@ -0,0 +1,83 @@
+package match
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryScripts are the kinds of query variants QueryVariants can generate:
+// "latin" romanizes Indic script queries, "phonetic" normalizes the spelling of
+// romanized queries ("thum hee ho" to "tum hi ho") and "devanagari" writes
+// romanized queries in Devanagari.
+var QueryScripts = []string{"latin", "phonetic", "devanagari"}
+
+// ParseScripts reads a comma-separated list of query scripts. "none" and an
+// empty list select no variants, "all" selects every script.
+func ParseScripts(value string) ([]string, error) {
+	scripts := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "" || name == "none":
+		case name == "all":
+			scripts = append(scripts, QueryScripts...)
+		case containsString(QueryScripts, name):
+			scripts = append(scripts, name)
+		default:
+			return nil, fmt.Errorf("unknown script %q, expected %s", name, strings.Join(QueryScripts, ", "))
+		}
+	}
+	return scripts, nil
+}
+
+// QueryVariants returns the spellings of a search query to try in addition to
+// the query itself, for the given scripts
+func QueryVariants(query string, scripts []string) []string {
+	script := Script(query)
+	latin := query
+	if script != "latin" && script != "" {
+		latin = NormalizeText(query)
+	}
+
+	// Variants differing from the query or each other only in case or
+	// punctuation would return the same results
+	key := func(s string) string {
+		return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+		}), " ")
+	}
+	seen := []string{key(query)}
+	variants := []string{}
+	add := func(variant string) {
+		variant = strings.TrimSpace(variant)
+		if normalized := key(variant); variant != "" && !containsString(seen, normalized) {
+			seen = append(seen, normalized)
+			variants = append(variants, variant)
+		}
+	}
+	for _, name := range scripts {
+		switch name {
+		case "latin":
+			add(latin)
+		case "phonetic":
+			if script == "latin" || latin != query {
+				add(CommonSpelling(NormalizeText(latin)))
+			}
+		case "devanagari":
+			if script == "latin" {
+				add(ToDevanagari(NormalizeText(query)))
+			}
+		}
+	}
+	return variants
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -8,8 +10,10 @@ import (
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Range, If-Range")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+		// Let browsers read the range headers of proxied media, e.g. for waveform analysis
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, Accept-Ranges, X-Media-Quality")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -20,10 +24,47 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-// MethodFilter middleware to only allow GET requests
+// postRoutes are the only routes that accept POST, since they take an uploaded
+// file or a GraphQL query, or change the state of the server
+var postRoutes = map[string]bool{
+	"/import":      true,
+	"/graphql":     true,
+	"/cache/purge": true,
+}
+
+// postPrefixes are route prefixes that accept POST: Subsonic clients may send
+// their parameters as a form, and DLNA control points send SOAP actions
+var postPrefixes = []string{"/rest/", "/dlna/control/"}
+
+// eventPrefix is the route prefix of DLNA event subscriptions, which use the
+// UPnP SUBSCRIBE and UNSUBSCRIBE methods
+const eventPrefix = "/dlna/event/"
+
+// acceptsPost reports whether path is a route that accepts POST
+func acceptsPost(path string) bool {
+	if postRoutes[path] {
+		return true
+	}
+	for _, prefix := range postPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MethodFilter middleware to only allow GET requests (and HEAD for media, POST for uploads)
 func MethodFilter() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.Request.Method != "GET" && c.Request.Method != "OPTIONS" {
+		if c.Request.Method == "POST" && acceptsPost(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if (c.Request.Method == "SUBSCRIBE" || c.Request.Method == "UNSUBSCRIBE") && strings.HasPrefix(c.Request.URL.Path, eventPrefix) {
+			c.Next()
+			return
+		}
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" && c.Request.Method != "OPTIONS" {
 			c.JSON(405, gin.H{"error": "Method not allowed. Only GET requests are supported."})
 			c.Abort()
 			return
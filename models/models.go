@@ -0,0 +1,231 @@
+// Package models holds the catalog models served by the API: songs, albums,
+// artists, playlists, lyrics and search pages. The saavn library builds them
+// from upstream data and the API client decodes them from responses, so the
+// package depends on neither, nor on the server configuration.
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Image is a cover or portrait in one size
+type Image struct {
+	// Quality is the size, such as "500x500"
+	Quality string `json:"quality"`
+	URL     string `json:"url"`
+}
+
+// Images are the sizes of an image, smallest first
+type Images []Image
+
+// UnmarshalJSON accepts a list of images, or the single URL some routes
+// return instead
+func (images *Images) UnmarshalJSON(data []byte) error {
+	var url string
+	if json.Unmarshal(data, &url) == nil {
+		*images = nil
+		if strings.HasPrefix(url, "http") {
+			*images = Images{{URL: url}}
+		}
+		return nil
+	}
+	var list []Image
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*images = list
+	return nil
+}
+
+// Largest returns the URL of the largest size, or "" when there is none
+func (images Images) Largest() string {
+	if len(images) == 0 {
+		return ""
+	}
+	return images[len(images)-1].URL
+}
+
+// DownloadURL is the media URL of a song in one bitrate
+type DownloadURL struct {
+	// Quality is the bitrate, such as "320kbps"
+	Quality string `json:"quality"`
+	URL     string `json:"url"`
+}
+
+// ArtistRef is an artist credited on a song or album, or found by a search
+type ArtistRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Role is the credit, such as "singer", "music" or "lyricist"
+	Role        string `json:"role,omitempty"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Image       Images `json:"image"`
+	Description string `json:"description,omitempty"`
+}
+
+// Artists are the credits of a song or album
+type Artists struct {
+	Primary  []ArtistRef `json:"primary"`
+	Featured []ArtistRef `json:"featured"`
+	// All lists every credited artist once, with their role
+	All []ArtistRef `json:"all"`
+}
+
+// AlbumRef is the album a song was released on
+type AlbumRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Song is a song. Playlists resolved from their contents only carry the IDs
+// of their songs.
+type Song struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Year        string `json:"year"`
+	ReleaseDate string `json:"releaseDate"`
+	// Duration is in seconds
+	Duration        int           `json:"duration"`
+	Label           string        `json:"label"`
+	Copyright       string        `json:"copyright"`
+	ExplicitContent bool          `json:"explicitContent"`
+	PlayCount       int64         `json:"playCount"`
+	Language        string        `json:"language"`
+	HasLyrics       bool          `json:"hasLyrics"`
+	URL             string        `json:"url"`
+	Album           AlbumRef      `json:"album"`
+	Artists         Artists       `json:"artists"`
+	Image           Images        `json:"image"`
+	DownloadURLs    []DownloadURL `json:"downloadUrl"`
+	PreviewURL      string        `json:"previewUrl"`
+	// Versions are the other releases of the song, when duplicates are grouped
+	Versions []Song `json:"versions,omitempty"`
+}
+
+// Album is an album. Search results and the top albums of an artist do not
+// carry songs.
+type Album struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description,omitempty"`
+	Type            string  `json:"type"`
+	Year            string  `json:"year"`
+	Language        string  `json:"language"`
+	ExplicitContent bool    `json:"explicitContent"`
+	PlayCount       int64   `json:"playCount,omitempty"`
+	SongCount       int     `json:"songCount"`
+	URL             string  `json:"url"`
+	Image           Images  `json:"image"`
+	Artists         Artists `json:"artists"`
+	Songs           []Song  `json:"songs"`
+}
+
+// Artist is an artist with their top songs and albums
+type Artist struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	URL              string  `json:"url"`
+	Type             string  `json:"type"`
+	FollowerCount    int64   `json:"followerCount"`
+	FanCount         string  `json:"fanCount"`
+	IsVerified       bool    `json:"isVerified"`
+	DominantLanguage string  `json:"dominantLanguage"`
+	DominantType     string  `json:"dominantType"`
+	Bio              string  `json:"bio"`
+	DOB              string  `json:"dob"`
+	Facebook         string  `json:"fb"`
+	Twitter          string  `json:"twitter"`
+	Wiki             string  `json:"wiki"`
+	Image            Images  `json:"image"`
+	TopSongs         []Song  `json:"topSongs"`
+	TopAlbums        []Album `json:"topAlbums"`
+}
+
+// Playlist is a playlist. Search results do not carry songs.
+type Playlist struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Subtitle        string `json:"subtitle,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Type            string `json:"type"`
+	Language        string `json:"language"`
+	ExplicitContent bool   `json:"explicitContent"`
+	SongCount       int    `json:"songCount"`
+	URL             string `json:"url"`
+	Image           Images `json:"image"`
+	Songs           []Song `json:"songs"`
+}
+
+// LyricsLine is a line of lyrics. Time is the offset in seconds from the
+// start of the song and is only set for synced lyrics.
+type LyricsLine struct {
+	Time *float64 `json:"time,omitempty"`
+	Text string   `json:"text"`
+}
+
+// Lyrics are the lyrics of a song
+type Lyrics struct {
+	ID        string       `json:"id"`
+	Lines     []LyricsLine `json:"lines"`
+	Copyright string       `json:"copyright"`
+	Snippet   string       `json:"snippet"`
+	Synced    bool         `json:"synced"`
+}
+
+// Text returns the lyrics as plain text, one line per row
+func (l Lyrics) Text() string {
+	texts := make([]string, len(l.Lines))
+	for i, line := range l.Lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// LRC renders time-synced lyrics in the LRC format. The song metadata is
+// written as ID tags when present. Lines without a timestamp are skipped.
+func (l Lyrics) LRC(title, artist, album string, duration int) string {
+	var b strings.Builder
+	for _, tag := range [][2]string{{"ti", title}, {"ar", artist}, {"al", album}} {
+		if tag[1] != "" {
+			fmt.Fprintf(&b, "[%s:%s]\n", tag[0], tag[1])
+		}
+	}
+	if duration > 0 {
+		fmt.Fprintf(&b, "[length:%02d:%02d]\n", duration/60, duration%60)
+	}
+
+	for _, line := range l.Lines {
+		if line.Time == nil {
+			continue
+		}
+		centis := int(*line.Time*100 + 0.5)
+		fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", centis/6000, (centis/100)%60, centis%100, line.Text)
+	}
+	return b.String()
+}
+
+// Page is a page of search results
+type Page[T any] struct {
+	// Total is the number of results of the search, on all pages
+	Total   int `json:"total"`
+	Start   int `json:"start"`
+	Results []T `json:"results"`
+}
+
+// Suggestion is a song suggested while typing a search
+type Suggestion struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Album    string `json:"album"`
+	Artists  string `json:"artists"`
+	Image    string `json:"image"`
+	URL      string `json:"url"`
+	Language string `json:"language"`
+	// Description is the artists and album, for display under the title
+	Description string `json:"description"`
+}
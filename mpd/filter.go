@@ -0,0 +1,155 @@
+package mpd
+
+import (
+	"strings"
+)
+
+// Filter is a condition on a tag. Tag "any" matches any tag.
+type Filter struct {
+	Tag   string
+	Op    string // "==", "!=", "contains" or "starts_with"
+	Value string
+}
+
+// Match reports whether a song satisfies the filter. Equality is exact as in
+// MPD's find, the other operators ignore case as in its search.
+func (f Filter) Match(s Song) bool {
+	tags := []string{f.Tag}
+	if strings.EqualFold(f.Tag, "any") {
+		tags = []string{"title", "artist", "album", "date"}
+	}
+	for _, tag := range tags {
+		for _, value := range s.Values(tag) {
+			if f.matchValue(value) {
+				return f.Op != "!="
+			}
+		}
+	}
+	return f.Op == "!="
+}
+
+func (f Filter) matchValue(value string) bool {
+	switch f.Op {
+	case "==", "!=":
+		return value == f.Value
+	case "starts_with":
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(f.Value))
+	}
+	return strings.Contains(strings.ToLower(value), strings.ToLower(f.Value))
+}
+
+// MatchAll reports whether a song satisfies all filters
+func MatchAll(filters []Filter, s Song) bool {
+	for _, f := range filters {
+		if !f.Match(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilters reads the filters of search, find and list. It accepts filter
+// expressions such as "((artist == 'x') AND (album contains 'y'))" as well as
+// the older "TAG VALUE" pairs, which use op. Trailing "sort" and "window"
+// options are returned in options.
+func parseFilters(args []string, op string) (filters []Filter, options map[string]string, err error) {
+	options = map[string]string{}
+	for len(args) > 0 {
+		switch {
+		case strings.HasPrefix(args[0], "("):
+			expression, err := parseExpression(args[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			filters = append(filters, expression...)
+			args = args[1:]
+		case len(args) < 2:
+			return nil, nil, ackf(AckErrorArg, "Missing value for %s", args[0])
+		case strings.EqualFold(args[0], "sort"), strings.EqualFold(args[0], "window"):
+			options[strings.ToLower(args[0])] = args[1]
+			args = args[2:]
+		default:
+			tag := args[0]
+			if !strings.EqualFold(tag, "any") && canonicalTag(tag) == "" {
+				return nil, nil, ackf(AckErrorArg, "Unknown filter type: %s", tag)
+			}
+			filters = append(filters, Filter{Tag: tag, Op: op, Value: args[1]})
+			args = args[2:]
+		}
+	}
+	return filters, options, nil
+}
+
+// parseExpression reads a filter expression: conditions "(TAG OP 'VALUE')",
+// possibly nested in parentheses and joined with AND
+func parseExpression(expr string) ([]Filter, error) {
+	var filters []Filter
+	i := 0
+	skipSpace := func() {
+		for i < len(expr) && expr[i] == ' ' {
+			i++
+		}
+	}
+	word := func() string {
+		start := i
+		for i < len(expr) && expr[i] != ' ' && expr[i] != '(' && expr[i] != ')' {
+			i++
+		}
+		return expr[start:i]
+	}
+
+	for {
+		skipSpace()
+		if i >= len(expr) {
+			return filters, nil
+		}
+		switch {
+		case expr[i] == ')':
+			i++
+			continue
+		case strings.HasPrefix(expr[i:], "AND "):
+			i += 4
+			continue
+		case expr[i] != '(':
+			return nil, ackf(AckErrorArg, "Invalid filter expression: %s", expr)
+		}
+		i++
+		skipSpace()
+		if i < len(expr) && expr[i] == '(' {
+			// A nested group; its conditions are read by the next iterations
+			continue
+		}
+
+		tag := word()
+		skipSpace()
+		op := word()
+		skipSpace()
+		if !strings.EqualFold(tag, "any") && canonicalTag(tag) == "" {
+			return nil, ackf(AckErrorArg, "Unknown filter type: %s", tag)
+		}
+		switch op {
+		case "==", "!=", "contains", "starts_with":
+		case "=~", "!~":
+			return nil, ackf(AckErrorArg, "Regular expressions are not supported")
+		default:
+			return nil, ackf(AckErrorArg, "Unknown filter operator: %s", op)
+		}
+		if i >= len(expr) || (expr[i] != '\'' && expr[i] != '"') {
+			return nil, ackf(AckErrorArg, "Quoted string expected: %s", expr)
+		}
+
+		quote := expr[i]
+		var value strings.Builder
+		for i++; i < len(expr) && expr[i] != quote; i++ {
+			if expr[i] == '\\' && i+1 < len(expr) {
+				i++
+			}
+			value.WriteByte(expr[i])
+		}
+		if i >= len(expr) {
+			return nil, ackf(AckErrorArg, "Closing quote not found: %s", expr)
+		}
+		i++
+		filters = append(filters, Filter{Tag: tag, Op: op, Value: value.String()})
+	}
+}
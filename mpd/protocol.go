@@ -0,0 +1,164 @@
+// Package mpd implements the subset of the MPD text protocol needed to browse
+// a music library and manage a queue: command parsing, filters, the shared
+// queue and the TCP server. The library itself is provided by the caller.
+package mpd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ACK error codes of the MPD protocol
+const (
+	AckErrorArg        = 2
+	AckErrorPassword   = 3
+	AckErrorPermission = 4
+	AckErrorUnknown    = 5
+	AckErrorNoExist    = 50
+	AckErrorSystem     = 52
+)
+
+// Ack is an error reported to the client as an ACK line
+type Ack struct {
+	Code    int
+	Message string
+}
+
+func (a *Ack) Error() string {
+	return a.Message
+}
+
+// ackf returns an Ack with a formatted message
+func ackf(code int, format string, args ...interface{}) *Ack {
+	return &Ack{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Song is a song of the library, identified by its file URI
+type Song struct {
+	File     string
+	Title    string
+	Artists  []string
+	Album    string
+	Date     string
+	Duration int
+}
+
+// Values returns the values of a tag, matched case-insensitively: file,
+// title, artist, albumartist, album or date. Only artists have several values.
+func (s Song) Values(tag string) []string {
+	var value string
+	switch strings.ToLower(tag) {
+	case "file":
+		value = s.File
+	case "title":
+		value = s.Title
+	case "artist", "albumartist":
+		return s.Artists
+	case "album":
+		value = s.Album
+	case "date":
+		value = s.Date
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// Entry is an entry of a directory listing: a subdirectory or a song
+type Entry struct {
+	Directory string
+	Song      *Song
+}
+
+// TagTypes are the tags the library provides, as listed by tagtypes
+var TagTypes = []string{"Artist", "AlbumArtist", "Album", "Title", "Date"}
+
+// canonicalTag returns the spelling of a tag used in responses, or "" for unknown tags
+func canonicalTag(tag string) string {
+	if strings.EqualFold(tag, "file") {
+		return "file"
+	}
+	for _, t := range TagTypes {
+		if strings.EqualFold(tag, t) {
+			return t
+		}
+	}
+	return ""
+}
+
+// writeSong writes the tags of a song, as in search results and directory listings
+func writeSong(w io.Writer, s Song) {
+	fmt.Fprintf(w, "file: %s\n", s.File)
+	for _, tag := range []string{"Title", "Artist", "Album", "Date"} {
+		for _, value := range s.Values(tag) {
+			fmt.Fprintf(w, "%s: %s\n", tag, oneLine(value))
+		}
+	}
+	if s.Duration > 0 {
+		fmt.Fprintf(w, "Time: %d\nduration: %d.000\n", s.Duration, s.Duration)
+	}
+}
+
+// oneLine keeps a value on a single line of the response
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// parseCommand splits a request line into the command name and its arguments.
+// Arguments are separated by spaces and may be double quoted, with backslash
+// escapes inside quotes.
+func parseCommand(line string) (string, []string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				b.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return "", nil, ackf(AckErrorArg, "Missing closing '\"'")
+			}
+			i++
+			args = append(args, b.String())
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			args = append(args, line[start:i])
+		}
+	}
+	if len(args) == 0 {
+		return "", nil, ackf(AckErrorUnknown, "No command given")
+	}
+	return args[0], args[1:], nil
+}
+
+// parseRange parses a queue position "N" or range "START:END", where a
+// missing END means the end of the queue (returned as -1)
+func parseRange(arg string) (start, end int, err error) {
+	from, to, isRange := strings.Cut(arg, ":")
+	if start, err = strconv.Atoi(from); err != nil || start < 0 {
+		return 0, 0, ackf(AckErrorArg, "Integer expected: %s", from)
+	}
+	if !isRange {
+		return start, start + 1, nil
+	}
+	if to == "" {
+		return start, -1, nil
+	}
+	if end, err = strconv.Atoi(to); err != nil || end < start {
+		return 0, 0, ackf(AckErrorArg, "Bad range: %s", arg)
+	}
+	return start, end, nil
+}
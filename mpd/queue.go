@@ -0,0 +1,167 @@
+package mpd
+
+import (
+	"sync"
+)
+
+// QueuedSong is a song of the queue with its position and stable ID
+type QueuedSong struct {
+	Song
+	Pos int
+	ID  int
+
+	// version is the queue version at which the song was added or moved
+	version int
+}
+
+// Queue is the queue shared by all clients of a server
+type Queue struct {
+	mu      sync.Mutex
+	songs   []QueuedSong
+	nextID  int
+	version int
+	changed chan struct{}
+}
+
+// NewQueue returns an empty queue
+func NewQueue() *Queue {
+	return &Queue{nextID: 1, version: 1, changed: make(chan struct{})}
+}
+
+// Version increases with every change of the queue
+func (q *Queue) Version() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.version
+}
+
+// Len returns the number of songs in the queue
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.songs)
+}
+
+// Changed returns a channel that is closed at the next change of the queue
+func (q *Queue) Changed() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.changed
+}
+
+// Add inserts songs at pos, or appends them when pos is negative, and returns their IDs
+func (q *Queue) Add(songs []Song, pos int) ([]int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if pos > len(q.songs) {
+		return nil, ackf(AckErrorArg, "Bad song index")
+	}
+	if pos < 0 {
+		pos = len(q.songs)
+	}
+
+	q.version++
+	added := make([]QueuedSong, len(songs))
+	ids := make([]int, len(songs))
+	for i, song := range songs {
+		added[i] = QueuedSong{Song: song, ID: q.nextID}
+		ids[i] = q.nextID
+		q.nextID++
+	}
+	q.songs = append(q.songs[:pos], append(added, q.songs[pos:]...)...)
+	q.renumberLocked(pos)
+	return ids, nil
+}
+
+// Delete removes the songs at positions start to end, end excluded. A
+// negative end means the end of the queue.
+func (q *Queue) Delete(start, end int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if end < 0 {
+		end = len(q.songs)
+	}
+	if start >= len(q.songs) || end > len(q.songs) {
+		return ackf(AckErrorArg, "Bad song index")
+	}
+	q.version++
+	q.songs = append(q.songs[:start], q.songs[end:]...)
+	q.renumberLocked(start)
+	return nil
+}
+
+// DeleteID removes the song with the given ID
+func (q *Queue) DeleteID(id int) error {
+	q.mu.Lock()
+	for i, song := range q.songs {
+		if song.ID == id {
+			q.mu.Unlock()
+			return q.Delete(i, i+1)
+		}
+	}
+	q.mu.Unlock()
+	return ackf(AckErrorNoExist, "No such song")
+}
+
+// Clear empties the queue
+func (q *Queue) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.version++
+	q.songs = nil
+	q.notifyLocked()
+}
+
+// Songs returns the songs at positions start to end, end excluded. A
+// negative end means the end of the queue.
+func (q *Queue) Songs(start, end int) ([]QueuedSong, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if end < 0 || end > len(q.songs) {
+		end = len(q.songs)
+	}
+	if start > end {
+		return nil, ackf(AckErrorArg, "Bad song index")
+	}
+	return append([]QueuedSong{}, q.songs[start:end]...), nil
+}
+
+// SongByID returns the song with the given ID
+func (q *Queue) SongByID(id int) (QueuedSong, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, song := range q.songs {
+		if song.ID == id {
+			return song, true
+		}
+	}
+	return QueuedSong{}, false
+}
+
+// ChangesSince returns the songs added or moved after the given version
+func (q *Queue) ChangesSince(version int) []QueuedSong {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	changes := []QueuedSong{}
+	for _, song := range q.songs {
+		if song.version > version {
+			changes = append(changes, song)
+		}
+	}
+	return changes
+}
+
+// renumberLocked updates the positions from pos on after an insertion or
+// deletion and wakes up idle clients
+func (q *Queue) renumberLocked(pos int) {
+	for i := pos; i < len(q.songs); i++ {
+		q.songs[i].Pos = i
+		q.songs[i].version = q.version
+	}
+	q.notifyLocked()
+}
+
+func (q *Queue) notifyLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
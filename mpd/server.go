@@ -0,0 +1,555 @@
+package mpd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the MPD protocol version announced to clients
+const ProtocolVersion = "0.23.0"
+
+// Library provides the songs browsed and queued by clients. The context of
+// every call is cancelled when the client disconnects or the server stops.
+type Library interface {
+	// Search returns the songs matching all filters
+	Search(ctx context.Context, filters []Filter) ([]Song, error)
+	// List returns the values of a tag among the songs matching the filters
+	List(ctx context.Context, tag string, filters []Filter) ([]string, error)
+	// LsInfo lists a directory ("" for the root), or describes the song when
+	// uri is a song. Unknown URIs return an Ack with AckErrorNoExist.
+	LsInfo(ctx context.Context, uri string) ([]Entry, error)
+}
+
+// Server serves a library and a shared queue to MPD clients. It does not play
+// anything: clients browse the library and build a queue of stream URLs.
+type Server struct {
+	Library Library
+	Queue   *Queue
+	started time.Time
+}
+
+// command runs a command for the client of ctx, writing its response to w
+type command func(s *Server, ctx context.Context, w io.Writer, args []string) error
+
+// commands are the supported commands, by name
+var commands map[string]command
+
+func init() {
+	// Assigned here since some commands refer to the table
+	commands = map[string]command{
+		"add":            (*Server).add,
+		"addid":          (*Server).addID,
+		"binarylimit":    noop,
+		"clear":          (*Server).clear,
+		"commands":       (*Server).listCommands,
+		"currentsong":    noop,
+		"decoders":       noop,
+		"delete":         (*Server).delete,
+		"deleteid":       (*Server).deleteID,
+		"find":           (*Server).find,
+		"findadd":        (*Server).findAdd,
+		"list":           (*Server).list,
+		"listplaylists":  noop,
+		"lsinfo":         (*Server).lsInfo,
+		"noidle":         noop,
+		"notcommands":    noop,
+		"outputs":        noop,
+		"password":       noop,
+		"ping":           noop,
+		"playlistid":     (*Server).playlistID,
+		"playlistinfo":   (*Server).playlistInfo,
+		"plchanges":      (*Server).plChanges,
+		"plchangesposid": (*Server).plChangesPosID,
+		"search":         (*Server).search,
+		"searchadd":      (*Server).searchAdd,
+		"stats":          (*Server).stats,
+		"status":         (*Server).status,
+		"tagtypes":       (*Server).tagTypes,
+		"urlhandlers":    (*Server).urlHandlers,
+	}
+}
+
+func noop(s *Server, ctx context.Context, w io.Writer, args []string) error {
+	return nil
+}
+
+// NewServer returns a server for a library with an empty queue
+func NewServer(library Library) *Server {
+	return &Server{Library: library, Queue: NewQueue(), started: time.Now()}
+}
+
+// ListenAndServe accepts MPD clients on a TCP address such as ":6600" until
+// ctx is done, like Serve
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts MPD clients on a listener until ctx is done, then closes the
+// listener and the connections of the clients and returns nil
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(ctx, conn)
+	}
+}
+
+// serveConn runs the session of one client. The commands run with a context
+// derived from ctx, cancelled when the client disconnects.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Closing the connection ends the reader, which may be blocked on a line
+	// nobody reads, and the session when the server stops
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer cancel()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- strings.TrimRight(scanner.Text(), "\r")
+		}
+	}()
+	defer func() {
+		cancel()
+		for range lines {
+		}
+	}()
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "OK MPD %s\n", ProtocolVersion)
+	w.Flush()
+
+	var list []string
+	listMode := ""
+	for line := range lines {
+		switch {
+		case listMode != "" && line == "command_list_end":
+			s.runList(ctx, w, list, listMode == "command_list_ok_begin")
+			list, listMode = nil, ""
+		case listMode != "":
+			list = append(list, line)
+			continue
+		case line == "command_list_begin" || line == "command_list_ok_begin":
+			listMode = line
+			continue
+		case line == "close":
+			return
+		case line == "idle" || strings.HasPrefix(line, "idle "):
+			_, subsystems, _ := parseCommand(line)
+			if !s.idle(w, subsystems, lines) {
+				return
+			}
+		default:
+			s.runList(ctx, w, []string{line}, false)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// runList runs a command list, stopping at the first error. With ok set,
+// "list_OK" follows the response of every command.
+func (s *Server) runList(ctx context.Context, w io.Writer, lines []string, ok bool) {
+	for i, line := range lines {
+		name, args, err := parseCommand(line)
+		var out bytes.Buffer
+		if err == nil {
+			err = s.run(ctx, &out, name, args)
+		}
+		if err != nil {
+			var ack *Ack
+			if !errors.As(err, &ack) {
+				if ctx.Err() != nil {
+					// The client went away, or the server is stopping
+					return
+				}
+				log.Printf("⚠️ MPD %s failed: %v", name, err)
+				ack = &Ack{Code: AckErrorSystem, Message: err.Error()}
+			}
+			fmt.Fprintf(w, "ACK [%d@%d] {%s} %s\n", ack.Code, i, name, oneLine(ack.Message))
+			return
+		}
+		w.Write(out.Bytes())
+		if ok {
+			io.WriteString(w, "list_OK\n")
+		}
+	}
+	io.WriteString(w, "OK\n")
+}
+
+// run runs a single command
+func (s *Server) run(ctx context.Context, w io.Writer, name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return ackf(AckErrorUnknown, "unknown command \"%s\"", name)
+	}
+	return cmd(s, ctx, w, args)
+}
+
+// idle waits for a change of the queue, when watching the playlist subsystem,
+// or for noidle. It returns false when the client went away.
+func (s *Server) idle(w *bufio.Writer, subsystems []string, lines <-chan string) bool {
+	watchQueue := len(subsystems) == 0
+	for _, subsystem := range subsystems {
+		watchQueue = watchQueue || subsystem == "playlist"
+	}
+
+	changed := s.Queue.Changed()
+	if !watchQueue {
+		changed = nil
+	}
+	select {
+	case <-changed:
+		io.WriteString(w, "changed: playlist\nOK\n")
+	case line, ok := <-lines:
+		if !ok || line != "noidle" {
+			// Only noidle is allowed while idle
+			return false
+		}
+		io.WriteString(w, "OK\n")
+	}
+	return w.Flush() == nil
+}
+
+func (s *Server) status(ctx context.Context, w io.Writer, args []string) error {
+	fmt.Fprintf(w, "volume: -1\nrepeat: 0\nrandom: 0\nsingle: 0\nconsume: 0\n")
+	fmt.Fprintf(w, "playlist: %d\nplaylistlength: %d\nstate: stop\n", s.Queue.Version(), s.Queue.Len())
+	return nil
+}
+
+func (s *Server) stats(ctx context.Context, w io.Writer, args []string) error {
+	fmt.Fprintf(w, "artists: 0\nalbums: 0\nsongs: 0\nuptime: %d\nplaytime: 0\ndb_playtime: 0\ndb_update: %d\n",
+		int(time.Since(s.started).Seconds()), s.started.Unix())
+	return nil
+}
+
+func (s *Server) listCommands(ctx context.Context, w io.Writer, args []string) error {
+	names := []string{"close", "command_list_begin", "command_list_ok_begin", "command_list_end", "idle"}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "command: %s\n", name)
+	}
+	return nil
+}
+
+func (s *Server) tagTypes(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) > 0 {
+		// tagtypes all, clear, enable and disable are accepted, but every tag is always sent
+		return nil
+	}
+	for _, tag := range TagTypes {
+		fmt.Fprintf(w, "tagtype: %s\n", tag)
+	}
+	return nil
+}
+
+func (s *Server) urlHandlers(ctx context.Context, w io.Writer, args []string) error {
+	io.WriteString(w, "handler: http://\nhandler: https://\n")
+	return nil
+}
+
+func (s *Server) lsInfo(ctx context.Context, w io.Writer, args []string) error {
+	uri := ""
+	if len(args) > 0 {
+		uri = strings.Trim(args[0], "/")
+	}
+	entries, err := s.Library.LsInfo(ctx, uri)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Song != nil {
+			writeSong(w, *entry.Song)
+		} else {
+			fmt.Fprintf(w, "directory: %s\n", entry.Directory)
+		}
+	}
+	return nil
+}
+
+// maxResolvedSongs bounds the songs added to the queue by one add command
+const maxResolvedSongs = 500
+
+// resolve returns the songs of a URI: the song itself, or the songs of a
+// directory. Directories holding other directories, such as the root and the
+// top-level folders, are refused rather than expanded, since every
+// subdirectory costs a library lookup.
+func (s *Server) resolve(ctx context.Context, uri string) ([]Song, error) {
+	entries, err := s.Library.LsInfo(ctx, strings.Trim(uri, "/"))
+	if err != nil {
+		return nil, err
+	}
+	songs := make([]Song, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Song == nil {
+			return nil, ackf(AckErrorArg, "cannot add directory \"%s\", add its subdirectories instead", uri)
+		}
+		songs = append(songs, *entry.Song)
+	}
+	if len(songs) > maxResolvedSongs {
+		return nil, ackf(AckErrorArg, "too many songs in \"%s\": %d, the limit is %d", uri, len(songs), maxResolvedSongs)
+	}
+	return songs, nil
+}
+
+func (s *Server) add(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"add\"")
+	}
+	songs, err := s.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	_, err = s.Queue.Add(songs, -1)
+	return err
+}
+
+func (s *Server) addID(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"addid\"")
+	}
+	pos := -1
+	if len(args) > 1 {
+		var err error
+		if pos, err = strconv.Atoi(args[1]); err != nil || pos < 0 {
+			return ackf(AckErrorArg, "Integer expected: %s", args[1])
+		}
+	}
+	entries, err := s.Library.LsInfo(ctx, strings.Trim(args[0], "/"))
+	if err != nil {
+		return err
+	}
+	if len(entries) != 1 || entries[0].Song == nil {
+		return ackf(AckErrorNoExist, "addid only adds songs")
+	}
+	ids, err := s.Queue.Add([]Song{*entries[0].Song}, pos)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Id: %d\n", ids[0])
+	return nil
+}
+
+func (s *Server) clear(ctx context.Context, w io.Writer, args []string) error {
+	s.Queue.Clear()
+	return nil
+}
+
+func (s *Server) delete(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"delete\"")
+	}
+	start, end, err := parseRange(args[0])
+	if err != nil {
+		return err
+	}
+	return s.Queue.Delete(start, end)
+}
+
+func (s *Server) deleteID(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"deleteid\"")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ackf(AckErrorArg, "Integer expected: %s", args[0])
+	}
+	return s.Queue.DeleteID(id)
+}
+
+func (s *Server) playlistInfo(ctx context.Context, w io.Writer, args []string) error {
+	start, end := 0, -1
+	if len(args) > 0 {
+		var err error
+		if start, end, err = parseRange(args[0]); err != nil {
+			return err
+		}
+		if end == start+1 && start >= s.Queue.Len() {
+			return ackf(AckErrorArg, "Bad song index")
+		}
+	}
+	songs, err := s.Queue.Songs(min(start, s.Queue.Len()), end)
+	if err != nil {
+		return err
+	}
+	writeQueued(w, songs)
+	return nil
+}
+
+func (s *Server) playlistID(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return s.playlistInfo(ctx, w, nil)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ackf(AckErrorArg, "Integer expected: %s", args[0])
+	}
+	song, ok := s.Queue.SongByID(id)
+	if !ok {
+		return ackf(AckErrorNoExist, "No such song")
+	}
+	writeQueued(w, []QueuedSong{song})
+	return nil
+}
+
+func (s *Server) plChanges(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"plchanges\"")
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ackf(AckErrorArg, "Integer expected: %s", args[0])
+	}
+	writeQueued(w, s.Queue.ChangesSince(version))
+	return nil
+}
+
+func (s *Server) plChangesPosID(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"plchangesposid\"")
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ackf(AckErrorArg, "Integer expected: %s", args[0])
+	}
+	for _, song := range s.Queue.ChangesSince(version) {
+		fmt.Fprintf(w, "cpos: %d\nId: %d\n", song.Pos, song.ID)
+	}
+	return nil
+}
+
+func (s *Server) search(ctx context.Context, w io.Writer, args []string) error {
+	songs, err := s.searchSongs(ctx, args, "contains")
+	if err != nil {
+		return err
+	}
+	for _, song := range songs {
+		writeSong(w, song)
+	}
+	return nil
+}
+
+func (s *Server) find(ctx context.Context, w io.Writer, args []string) error {
+	songs, err := s.searchSongs(ctx, args, "==")
+	if err != nil {
+		return err
+	}
+	for _, song := range songs {
+		writeSong(w, song)
+	}
+	return nil
+}
+
+func (s *Server) searchAdd(ctx context.Context, w io.Writer, args []string) error {
+	songs, err := s.searchSongs(ctx, args, "contains")
+	if err != nil {
+		return err
+	}
+	_, err = s.Queue.Add(songs, -1)
+	return err
+}
+
+func (s *Server) findAdd(ctx context.Context, w io.Writer, args []string) error {
+	songs, err := s.searchSongs(ctx, args, "==")
+	if err != nil {
+		return err
+	}
+	_, err = s.Queue.Add(songs, -1)
+	return err
+}
+
+// searchSongs runs a search or find, applying its window option
+func (s *Server) searchSongs(ctx context.Context, args []string, op string) ([]Song, error) {
+	filters, options, err := parseFilters(args, op)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, ackf(AckErrorArg, "Missing filter")
+	}
+	songs, err := s.Library.Search(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if window, ok := options["window"]; ok {
+		start, end, err := parseRange(window)
+		if err != nil {
+			return nil, err
+		}
+		if end < 0 || end > len(songs) {
+			end = len(songs)
+		}
+		songs = songs[min(start, end):end]
+	}
+	return songs, nil
+}
+
+func (s *Server) list(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) < 1 {
+		return ackf(AckErrorArg, "wrong number of arguments for \"list\"")
+	}
+	tag := canonicalTag(args[0])
+	if tag == "" || tag == "file" {
+		return ackf(AckErrorArg, "Unknown tag type: %s", args[0])
+	}
+
+	rest := args[1:]
+	if tag == "Album" && len(rest) == 1 && !strings.HasPrefix(rest[0], "(") {
+		// The legacy "list album ARTIST"
+		rest = []string{"artist", rest[0]}
+	}
+	for len(rest) > 1 && strings.EqualFold(rest[len(rest)-2], "group") {
+		// Grouping is not supported; values are listed flat
+		rest = rest[:len(rest)-2]
+	}
+	filters, _, err := parseFilters(rest, "==")
+	if err != nil {
+		return err
+	}
+
+	values, err := s.Library.List(ctx, tag, filters)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		fmt.Fprintf(w, "%s: %s\n", tag, oneLine(value))
+	}
+	return nil
+}
+
+// writeQueued writes songs of the queue with their position and ID
+func writeQueued(w io.Writer, songs []QueuedSong) {
+	for _, song := range songs {
+		writeSong(w, song.Song)
+		fmt.Fprintf(w, "Pos: %d\nId: %d\n", song.Pos, song.ID)
+	}
+}
@@ -0,0 +1,459 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSongs = []Song{
+	{File: "http://mpd.test/stream/s1", Title: "Tum Hi Ho", Artists: []string{"Arijit Singh"}, Album: "Aashiqui 2", Date: "2013", Duration: 262},
+	{File: "http://mpd.test/stream/s2", Title: "Sun Raha Hai", Artists: []string{"Ankit Tiwari"}, Album: "Aashiqui 2", Date: "2013", Duration: 390},
+	{File: "http://mpd.test/stream/s3", Title: "Kesariya", Artists: []string{"Arijit Singh", "Pritam"}, Album: "Brahmastra", Date: "2022", Duration: 268},
+}
+
+// testLibrary serves testSongs in an "Albums" folder. Searches for "block"
+// wait for their context to be cancelled and send its error to cancelled.
+type testLibrary struct {
+	searching chan struct{}
+	cancelled chan error
+}
+
+func newTestLibrary() *testLibrary {
+	return &testLibrary{searching: make(chan struct{}, 1), cancelled: make(chan error, 1)}
+}
+
+func (l *testLibrary) Search(ctx context.Context, filters []Filter) ([]Song, error) {
+	for _, f := range filters {
+		if f.Value == "block" {
+			l.searching <- struct{}{}
+			<-ctx.Done()
+			l.cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+	}
+	var songs []Song
+	for _, song := range testSongs {
+		if MatchAll(filters, song) {
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+func (l *testLibrary) List(ctx context.Context, tag string, filters []Filter) ([]string, error) {
+	songs, err := l.Search(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	values := []string{}
+	for _, song := range songs {
+		for _, value := range song.Values(tag) {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func (l *testLibrary) LsInfo(ctx context.Context, uri string) ([]Entry, error) {
+	switch uri {
+	case "":
+		return []Entry{{Directory: "Albums"}}, nil
+	case "Albums":
+		return []Entry{{Directory: "Albums/Aashiqui 2"}, {Directory: "Albums/Brahmastra"}}, nil
+	}
+	var entries []Entry
+	for i, song := range testSongs {
+		if uri == song.File || uri == "Albums/"+song.Album {
+			entries = append(entries, Entry{Song: &testSongs[i]})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, &Ack{Code: AckErrorNoExist, Message: "No such directory"}
+	}
+	return entries, nil
+}
+
+// testClient is a client connected to a server over an in-memory pipe
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// connect starts a session of server and reads its greeting. The session
+// ends with the test.
+func connect(t *testing.T, server *Server) *testClient {
+	t.Helper()
+	conn, serverConn := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.serveConn(ctx, serverConn)
+	}()
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		<-done
+	})
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	c := &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	if greeting := c.readLine(); greeting != "OK MPD "+ProtocolVersion {
+		t.Fatalf("greeting = %q", greeting)
+	}
+	return c
+}
+
+func (c *testClient) readLine() string {
+	c.t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+	return strings.TrimSuffix(line, "\n")
+}
+
+// send writes request lines
+func (c *testClient) send(lines ...string) {
+	c.t.Helper()
+	if _, err := c.conn.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+// response reads the lines of a response up to its final OK or ACK line,
+// which is returned last
+func (c *testClient) response() []string {
+	c.t.Helper()
+	var lines []string
+	for {
+		line := c.readLine()
+		lines = append(lines, line)
+		if line == "OK" || strings.HasPrefix(line, "ACK ") {
+			return lines
+		}
+	}
+}
+
+// command sends request lines and reads the response
+func (c *testClient) command(lines ...string) []string {
+	c.t.Helper()
+	c.send(lines...)
+	return c.response()
+}
+
+// values returns the values of the response lines with a key
+func values(lines []string, key string) []string {
+	var found []string
+	for _, line := range lines {
+		if value, ok := strings.CutPrefix(line, key+": "); ok {
+			found = append(found, value)
+		}
+	}
+	return found
+}
+
+// status returns the final line of a response
+func status(lines []string) string {
+	return lines[len(lines)-1]
+}
+
+func TestSearchAndFind(t *testing.T) {
+	c := connect(t, NewServer(newTestLibrary()))
+
+	tests := []struct {
+		request string
+		files   []string
+	}{
+		{`search title "tum hi"`, []string{"http://mpd.test/stream/s1"}},
+		{`search artist arijit`, []string{"http://mpd.test/stream/s1", "http://mpd.test/stream/s3"}},
+		{`search "((artist contains 'arijit') AND (album != 'Brahmastra'))"`, []string{"http://mpd.test/stream/s1"}},
+		{`search any 2013 window 1:`, []string{"http://mpd.test/stream/s2"}},
+		{`find album "Aashiqui 2"`, []string{"http://mpd.test/stream/s1", "http://mpd.test/stream/s2"}},
+		{`find album "aashiqui 2"`, nil},
+		{`find "(title starts_with 'kes')"`, []string{"http://mpd.test/stream/s3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.request, func(t *testing.T) {
+			lines := c.command(tt.request)
+			if status(lines) != "OK" {
+				t.Fatalf("response = %q", lines)
+			}
+			if got := strings.Join(values(lines, "file"), ","); got != strings.Join(tt.files, ",") {
+				t.Errorf("files = %s, want %s", got, strings.Join(tt.files, ","))
+			}
+		})
+	}
+
+	lines := c.command(`search title kesariya`)
+	want := []string{
+		"file: http://mpd.test/stream/s3", "Title: Kesariya", "Artist: Arijit Singh", "Artist: Pritam",
+		"Album: Brahmastra", "Date: 2022", "Time: 268", "duration: 268.000", "OK",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("response = %q, want %q", lines, want)
+	}
+
+	failures := []struct {
+		request string
+		ack     string
+	}{
+		{`search`, "ACK [2@0] {search} Missing filter"},
+		{`search "(title =~ 'tum')"`, "ACK [2@0] {search} Regular expressions are not supported"},
+		{`find genre pop`, "ACK [2@0] {find} Unknown filter type: genre"},
+		{`search title "tum`, `ACK [2@0] {} Missing closing '"'`},
+	}
+	for _, tt := range failures {
+		if got := status(c.command(tt.request)); got != tt.ack {
+			t.Errorf("%s: got %q, want %q", tt.request, got, tt.ack)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	c := connect(t, NewServer(newTestLibrary()))
+
+	tests := []struct {
+		request string
+		key     string
+		values  []string
+	}{
+		{`list album`, "Album", []string{"Aashiqui 2", "Brahmastra"}},
+		{`list artist`, "Artist", []string{"Ankit Tiwari", "Arijit Singh", "Pritam"}},
+		{`list album "Ankit Tiwari"`, "Album", []string{"Aashiqui 2"}},
+		{`list title album Brahmastra`, "Title", []string{"Kesariya"}},
+		{`list date "(artist == 'Arijit Singh')" group album`, "Date", []string{"2013", "2022"}},
+	}
+	for _, tt := range tests {
+		lines := c.command(tt.request)
+		if got := strings.Join(values(lines, tt.key), ","); got != strings.Join(tt.values, ",") || status(lines) != "OK" {
+			t.Errorf("%s: response = %q, want %s", tt.request, lines, strings.Join(tt.values, ","))
+		}
+	}
+
+	for _, request := range []string{`list file`, `list genre`, `list`} {
+		if got := status(c.command(request)); !strings.HasPrefix(got, "ACK [2@0] {list}") {
+			t.Errorf("%s: got %q, want an argument error", request, got)
+		}
+	}
+}
+
+func TestLsInfo(t *testing.T) {
+	c := connect(t, NewServer(newTestLibrary()))
+
+	if got := c.command(`lsinfo`); strings.Join(got, "|") != "directory: Albums|OK" {
+		t.Errorf("root = %q", got)
+	}
+	if got := values(c.command(`lsinfo "/Albums/"`), "directory"); strings.Join(got, ",") != "Albums/Aashiqui 2,Albums/Brahmastra" {
+		t.Errorf("Albums = %q", got)
+	}
+	if got := values(c.command(`lsinfo "Albums/Aashiqui 2"`), "Title"); strings.Join(got, ",") != "Tum Hi Ho,Sun Raha Hai" {
+		t.Errorf("Albums/Aashiqui 2 = %q", got)
+	}
+	if got := status(c.command(`lsinfo Unknown`)); got != "ACK [50@0] {lsinfo} No such directory" {
+		t.Errorf("unknown directory = %q", got)
+	}
+}
+
+func TestAddAndPlaylistID(t *testing.T) {
+	server := NewServer(newTestLibrary())
+	c := connect(t, server)
+
+	if got := c.command(`add "Albums/Aashiqui 2"`); status(got) != "OK" {
+		t.Fatalf("add = %q", got)
+	}
+	lines := c.command(`addid http://mpd.test/stream/s3 0`)
+	if got := values(lines, "Id"); len(got) != 1 || got[0] != "3" {
+		t.Fatalf("addid = %q, want Id 3", lines)
+	}
+
+	lines = c.command(`playlistinfo`)
+	if got := strings.Join(values(lines, "Title"), ","); got != "Kesariya,Tum Hi Ho,Sun Raha Hai" {
+		t.Errorf("queue = %s", got)
+	}
+	if got := strings.Join(values(lines, "Pos"), ","); got != "0,1,2" {
+		t.Errorf("positions = %s", got)
+	}
+
+	lines = c.command(`playlistid 2`)
+	if got := values(lines, "Title"); len(got) != 1 || got[0] != "Sun Raha Hai" || strings.Join(values(lines, "Pos"), "") != "2" {
+		t.Errorf("playlistid 2 = %q", lines)
+	}
+	if got := len(values(c.command(`playlistid`), "Id")); got != 3 {
+		t.Errorf("playlistid lists %d songs, want 3", got)
+	}
+
+	failures := []struct {
+		request string
+		ack     string
+	}{
+		{`playlistid 99`, "ACK [50@0] {playlistid} No such song"},
+		{`playlistid x`, "ACK [2@0] {playlistid} Integer expected: x"},
+		{`add ""`, `ACK [2@0] {add} cannot add directory "", add its subdirectories instead`},
+		{`add Albums`, `ACK [2@0] {add} cannot add directory "Albums", add its subdirectories instead`},
+		{`add Unknown`, "ACK [50@0] {add} No such directory"},
+		{`addid "Albums/Aashiqui 2"`, "ACK [50@0] {addid} addid only adds songs"},
+		{`add`, `ACK [2@0] {add} wrong number of arguments for "add"`},
+	}
+	for _, tt := range failures {
+		if got := status(c.command(tt.request)); got != tt.ack {
+			t.Errorf("%s: got %q, want %q", tt.request, got, tt.ack)
+		}
+	}
+	if n := server.Queue.Len(); n != 3 {
+		t.Errorf("queue has %d songs after the failed commands, want 3", n)
+	}
+}
+
+func TestCommandLists(t *testing.T) {
+	server := NewServer(newTestLibrary())
+	c := connect(t, server)
+
+	lines := c.command("command_list_begin", `add http://mpd.test/stream/s1`, `ping`, `playlistid 1`, "command_list_end")
+	if got := strings.Join(lines, "|"); !strings.HasPrefix(got, "file: http://mpd.test/stream/s1|") || !strings.HasSuffix(got, "|Pos: 0|Id: 1|OK") || strings.Contains(got, "list_OK") {
+		t.Errorf("command_list_begin = %q", lines)
+	}
+
+	lines = c.command("command_list_ok_begin", `ping`, `add http://mpd.test/stream/s2`, `status`, "command_list_end")
+	if got := len(values(lines, "playlistlength")); got != 1 {
+		t.Errorf("status missing from %q", lines)
+	}
+	var oks int
+	for _, line := range lines {
+		if line == "list_OK" {
+			oks++
+		}
+	}
+	if oks != 3 || status(lines) != "OK" {
+		t.Errorf("command_list_ok_begin = %q, want 3 list_OK then OK", lines)
+	}
+
+	// The list stops at the failing command, numbered from 0
+	lines = c.command("command_list_ok_begin", `add http://mpd.test/stream/s3`, `lsinfo Unknown`, `clear`, "command_list_end")
+	if got := strings.Join(lines, "|"); got != "list_OK|ACK [50@1] {lsinfo} No such directory" {
+		t.Errorf("failing list = %q", lines)
+	}
+	if n := server.Queue.Len(); n != 3 {
+		t.Errorf("queue has %d songs, want 3: the clear after the error must not run", n)
+	}
+
+	// Commands after the list run on their own
+	if got := c.command(`ping`); strings.Join(got, "|") != "OK" {
+		t.Errorf("ping after list = %q", got)
+	}
+}
+
+func TestIdle(t *testing.T) {
+	server := NewServer(newTestLibrary())
+	watcher := connect(t, server)
+	other := connect(t, server)
+
+	// noidle ends an idle with nothing to report
+	if got := watcher.command(`idle`, `noidle`); strings.Join(got, "|") != "OK" {
+		t.Errorf("idle, noidle = %q", got)
+	}
+	if got := watcher.command(`idle player`, `noidle`); strings.Join(got, "|") != "OK" {
+		t.Errorf("idle player, noidle = %q", got)
+	}
+
+	// A change of the queue by another client ends the idle. The watcher may
+	// not be idle yet when the first song is added, so songs are added until
+	// it reports the change.
+	watcher.send(`idle playlist`)
+	changed := make(chan []string, 1)
+	go func() {
+		changed <- watcher.response()
+	}()
+	var lines []string
+	for lines == nil {
+		other.command(`add http://mpd.test/stream/s1`)
+		select {
+		case lines = <-changed:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if got := strings.Join(lines, "|"); got != "changed: playlist|OK" {
+		t.Errorf("idle playlist = %q", got)
+	}
+
+	// Any other command while idle ends the session
+	watcher.send(`idle`, `status`)
+	if _, err := watcher.r.ReadString('\n'); err == nil {
+		t.Error("session still open after a command while idle")
+	}
+}
+
+func TestDisconnectCancelsCommands(t *testing.T) {
+	library := newTestLibrary()
+	c := connect(t, NewServer(library))
+
+	c.send(`search title block`)
+	<-library.searching
+	c.conn.Close()
+
+	select {
+	case err := <-library.cancelled:
+		if err != context.Canceled {
+			t.Errorf("search ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("search not cancelled after the client disconnected")
+	}
+}
+
+func TestServeStops(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback TCP: %v", err)
+	}
+	library := newTestLibrary()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- NewServer(library).Serve(ctx, listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	if greeting, err := r.ReadString('\n'); err != nil || !strings.HasPrefix(greeting, "OK MPD ") {
+		t.Fatalf("greeting = %q, %v", greeting, err)
+	}
+	conn.Write([]byte("search title block\n"))
+	<-library.searching
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve still running after ctx was cancelled")
+	}
+	if err := <-library.cancelled; err != context.Canceled {
+		t.Errorf("search ended with %v, want context.Canceled", err)
+	}
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("connection still open after the server stopped")
+	}
+	if _, err := net.Dial("tcp", listener.Addr().String()); err == nil {
+		t.Error("listener still accepting after the server stopped")
+	}
+}
@@ -14,12 +14,14 @@ func RegisterRoutes(r *gin.Engine) {
 	r.GET("/songs/:token/", services.GetSongFromTokenHandler)
 	
 	// Lyrics routes
+	r.GET("/lyrics/search", services.LyricsSearchHandler)
 	r.GET("/lyrics/:id", services.GetLyricsHandler)
 	r.GET("/lyrics/:id/", services.GetLyricsHandler)
 	
 	// Album routes
 	r.GET("/album/:id", services.GetAlbumHandler)
 	r.GET("/album/:id/", services.GetAlbumHandler)
+	r.GET("/album/:id/download.zip", services.AlbumZipHandler)
 	r.GET("/albums/:token", services.GetAlbumFromTokenHandler)
 	r.GET("/albums/:token/", services.GetAlbumFromTokenHandler)
 	
@@ -30,7 +32,45 @@ func RegisterRoutes(r *gin.Engine) {
 	// Playlist routes
 	r.GET("/playlists/:token", services.GetPlaylistFromTokenHandler)
 	r.GET("/playlists/:token/", services.GetPlaylistFromTokenHandler)
+	r.GET("/playlists/:token/download.zip", services.PlaylistZipHandler)
 	
+	// Media routes
+	r.GET("/stream/:id", services.StreamHandler)
+	r.HEAD("/stream/:id", services.StreamHandler)
+	r.GET("/download/:id", services.DownloadHandler)
+
+	// HLS routes
+	r.GET("/hls/song/:id", services.HLSSongHandler)
+	r.GET("/hls/song/:id/:quality", services.HLSSongHandler)
+	r.GET("/hls/song/:id/:quality/:segment", services.HLSSegmentHandler)
+	r.GET("/hls/album/:id", services.HLSAlbumHandler)
+	r.GET("/hls/album/:id/:quality", services.HLSAlbumHandler)
+
+	// Match routes
+	r.GET("/match", services.MatchHandler)
+
+	// Subsonic API
+	r.GET("/rest/:method", services.SubsonicHandler)
+	r.POST("/rest/:method", services.SubsonicHandler)
+
+	// DLNA media server
+	r.GET("/dlna/description.xml", services.DLNADescriptionHandler)
+	r.GET("/dlna/scpd/:service", services.DLNASCPDHandler)
+	r.POST("/dlna/control/:service", services.DLNAControlHandler)
+	r.Handle("SUBSCRIBE", "/dlna/event/:service", services.DLNAEventHandler)
+	r.Handle("UNSUBSCRIBE", "/dlna/event/:service", services.DLNAEventHandler)
+
+	// GraphQL
+	r.GET("/graphql", services.GraphQLHandler)
+	r.POST("/graphql", services.GraphQLHandler)
+
+	// Admin routes
+	r.GET("/cache/stats", services.CacheStatsHandler)
+	r.POST("/cache/purge", services.CachePurgeHandler)
+
+	// Import routes
+	r.POST("/import", services.ImportHandler)
+
 	// Search routes
 	r.GET("/search", services.FullSearchHandler)
 	r.GET("/search/autocomplete", services.AutocompleteHandler)
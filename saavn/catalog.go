@@ -0,0 +1,224 @@
+package saavn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jioSaavnAPI/models"
+	"jioSaavnAPI/utils"
+)
+
+// Song returns the details of a song
+func (c *Client) Song(ctx context.Context, id string) (*Song, error) {
+	raw, err := c.FetchSong(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Song](utils.FormatSongDetailed(raw))
+}
+
+// Songs returns the details of several songs, in the order of ids. IDs
+// unknown upstream are left out.
+func (c *Client) Songs(ctx context.Context, ids []string) ([]Song, error) {
+	raw, err := c.FetchSongs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	songs := make([]Song, 0, len(raw))
+	for _, id := range ids {
+		songData, ok := raw[id]
+		if !ok {
+			continue
+		}
+		song, err := convert[Song](utils.FormatSongDetailed(songData))
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, *song)
+	}
+	return songs, nil
+}
+
+// SongByToken returns the details of a song from the token of its URL
+func (c *Client) SongByToken(ctx context.Context, token string) (*Song, error) {
+	raw, err := c.FetchSongByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Song](utils.FormatSongFromToken(raw))
+}
+
+// Album returns an album with its songs
+func (c *Client) Album(ctx context.Context, id string) (*Album, error) {
+	raw, err := c.FetchAlbum(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Album](utils.FormatAlbum(raw))
+}
+
+// AlbumByToken returns an album from the token of its URL
+func (c *Client) AlbumByToken(ctx context.Context, token string) (*Album, error) {
+	raw, err := c.FetchAlbumByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	formatted := utils.FormatAlbumFromToken(raw["list"])
+	if formatted["name"] == "" && utils.GetInt(formatted, "songCount") == 0 {
+		return nil, ErrAlbumNotFound
+	}
+
+	// The name of the album is formatted as its title
+	album, err := convert[struct {
+		Album
+		Title string `json:"title"`
+	}](formatted)
+	if err != nil {
+		return nil, err
+	}
+	if album.Name == "" {
+		album.Name = album.Title
+	}
+	return &album.Album, nil
+}
+
+// Artist returns an artist with their top songs and albums
+func (c *Client) Artist(ctx context.Context, id string) (*Artist, error) {
+	raw, err := c.FetchArtist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Artist](utils.FormatArtistDetails(raw))
+}
+
+// ArtistByToken returns an artist from the token of their URL
+func (c *Client) ArtistByToken(ctx context.Context, token string) (*Artist, error) {
+	raw, err := c.FetchArtistByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Artist](utils.FormatArtistDetails(raw))
+}
+
+// Playlist returns a playlist with its songs, from its ID
+func (c *Client) Playlist(ctx context.Context, id string) (*Playlist, error) {
+	raw, err := c.FetchPlaylistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[Playlist](FormatPlaylistDetails(raw))
+}
+
+// PlaylistByToken returns a playlist from the token of its URL. Songs only
+// carry their ID when the playlist lists its contents without details.
+func (c *Client) PlaylistByToken(ctx context.Context, token string) (*Playlist, error) {
+	raw, err := c.FetchPlaylist(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	formatted := FormatPlaylist(raw)
+	if formatted == nil {
+		return nil, ErrPlaylistNotFound
+	}
+
+	// The name of the playlist is formatted as its title, unless it is
+	// resolved from its contents
+	playlist, err := convert[struct {
+		Playlist
+		Title string `json:"title"`
+	}](formatted)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Name == "" {
+		playlist.Name = playlist.Title
+	}
+	return &playlist.Playlist, nil
+}
+
+// Lyrics returns the lyrics of a song. Their Lines are empty when the song
+// has no lyrics.
+func (c *Client) Lyrics(ctx context.Context, id string) (*Lyrics, error) {
+	raw, err := c.FetchLyrics(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lyrics := utils.FormatLyrics(id, raw)
+	return &lyrics, nil
+}
+
+// SearchSongs returns the first 20 songs found by a search, most played
+// first, with the songs found by the variants of the query for the given
+// scripts (see FetchSearch)
+func (c *Client) SearchSongs(ctx context.Context, query string, scripts []string) (*models.Page[Song], error) {
+	return search[Song](ctx, c, "song", query, scripts, utils.FormatSongSearch)
+}
+
+// SearchAlbums returns the first 20 albums found by a search
+func (c *Client) SearchAlbums(ctx context.Context, query string, scripts []string) (*models.Page[Album], error) {
+	return search[Album](ctx, c, "album", query, scripts, func(raw map[string]interface{}) map[string]interface{} {
+		return utils.FormatAlbumSearch(raw)
+	})
+}
+
+// SearchArtists returns the first 20 artists found by a search
+func (c *Client) SearchArtists(ctx context.Context, query string, scripts []string) (*models.Page[ArtistRef], error) {
+	return search[ArtistRef](ctx, c, "artist", query, scripts, func(raw map[string]interface{}) map[string]interface{} {
+		return utils.FormatArtistSearch(raw)
+	})
+}
+
+// SearchPlaylists returns the first 20 playlists found by a search
+func (c *Client) SearchPlaylists(ctx context.Context, query string, scripts []string) (*models.Page[Playlist], error) {
+	return search[Playlist](ctx, c, "playlist", query, scripts, func(raw map[string]interface{}) map[string]interface{} {
+		return utils.FormatPlaylistSearch(raw)
+	})
+}
+
+// search runs a search and converts its results formatted by format
+func search[T any](ctx context.Context, c *Client, searchType, query string, scripts []string, format func(map[string]interface{}) map[string]interface{}) (*models.Page[T], error) {
+	raw, err := c.FetchSearch(ctx, query, searchType, scripts)
+	if err != nil {
+		return nil, err
+	}
+	c.mediaResolver().DecryptSongMedia(raw)
+	return convert[models.Page[T]](format(raw)["data"])
+}
+
+// Autocomplete returns the songs suggested for a query being typed, the top
+// match first, with those suggested for the variants of the query for the
+// given scripts
+func (c *Client) Autocomplete(ctx context.Context, query string, scripts []string) ([]Suggestion, error) {
+	raw, err := c.FetchSuggestions(ctx, query, scripts)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := convert[[]Suggestion](raw)
+	if err != nil {
+		return nil, err
+	}
+	return *suggestions, nil
+}
+
+// convert converts formatted data to its model, through the JSON served by
+// the API
+func convert[T any](formatted interface{}) (*T, error) {
+	data, err := json.Marshal(formatted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode formatted data: %w", err)
+	}
+	var model T
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to decode formatted data: %w", err)
+	}
+	return &model, nil
+}
@@ -0,0 +1,398 @@
+package saavn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/utils"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// songDetailsBatchSize is the number of song IDs requested per song.getDetails call
+const songDetailsBatchSize = 50
+
+// FetchSong returns the raw song.getDetails entry of a song
+func (c *Client) FetchSong(ctx context.Context, id string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "song.getDetails", url.Values{"cc": {"in"}, "pids": {id}}, &raw); err != nil {
+		return nil, err
+	}
+	songData, ok := raw[id].(map[string]interface{})
+	if !ok {
+		return nil, ErrSongNotFound
+	}
+	return songData, nil
+}
+
+// FetchSongs returns the raw song.getDetails entries of several songs, keyed
+// by song ID. IDs missing from the upstream response are left out.
+func (c *Client) FetchSongs(ctx context.Context, ids []string) (map[string]map[string]interface{}, error) {
+	songs := map[string]map[string]interface{}{}
+	for start := 0; start < len(ids); start += songDetailsBatchSize {
+		end := min(start+songDetailsBatchSize, len(ids))
+		var raw map[string]interface{}
+		if err := c.get(ctx, "song.getDetails", url.Values{"cc": {"in"}, "pids": {strings.Join(ids[start:end], ",")}}, &raw); err != nil {
+			return nil, err
+		}
+		for _, id := range ids[start:end] {
+			if songData, ok := raw[id].(map[string]interface{}); ok {
+				songs[id] = songData
+			}
+		}
+	}
+	return songs, nil
+}
+
+// FetchSongByToken returns the raw webapi.get entry of a song, from the token
+// of its perma URL
+func (c *Client) FetchSongByToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	var response struct {
+		Songs []map[string]interface{} `json:"songs"`
+	}
+	if err := c.get(ctx, "webapi.get", web6(url.Values{"token": {token}, "type": {"song"}, "includeMetaTags": {"0"}}), &response); err != nil {
+		return nil, err
+	}
+	if len(response.Songs) == 0 {
+		return nil, ErrSongNotFound
+	}
+	return response.Songs[0], nil
+}
+
+// FetchAlbum returns the raw content.getAlbumDetails data of an album
+func (c *Client) FetchAlbum(ctx context.Context, id string) (map[string]interface{}, error) {
+	if id == "" {
+		return nil, ErrAlbumNotFound
+	}
+	var raw map[string]interface{}
+	if err := c.get(ctx, "content.getAlbumDetails", url.Values{"cc": {"in"}, "albumid": {id}}, &raw); err != nil {
+		return nil, err
+	}
+
+	albumData, ok := raw["data"].(map[string]interface{})
+	if !ok {
+		if _, hasTitle := raw["title"]; !hasTitle {
+			return nil, ErrAlbumNotFound
+		}
+		albumData = raw
+	}
+	if len(albumData) == 0 {
+		return nil, ErrAlbumNotFound
+	}
+	return albumData, nil
+}
+
+// FetchAlbumByToken returns the raw webapi.get response of an album, from
+// the token of its perma URL. Its "list" holds the songs, each embedding the
+// album metadata.
+func (c *Client) FetchAlbumByToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "webapi.get", web6(url.Values{"token": {token}, "type": {"album"}, "includeMetaTags": {"0"}}), &raw); err != nil {
+		return nil, err
+	}
+	if _, hasList := raw["list"]; !hasList {
+		return nil, ErrAlbumNotFound
+	}
+	return raw, nil
+}
+
+// FetchPlaylist returns the raw webapi.get response of a playlist, from the
+// token of its perma URL, with its first 50 songs
+func (c *Client) FetchPlaylist(ctx context.Context, token string) (map[string]interface{}, error) {
+	params := url.Values{"token": {token}, "type": {"playlist"}, "p": {"1"}, "n": {"50"}, "includeMetaTags": {"0"}}
+	var raw map[string]interface{}
+	if err := c.get(ctx, "webapi.get", web6(params), &raw); err != nil {
+		return nil, err
+	}
+	if _, hasList := raw["list"]; !hasList {
+		if _, hasInfo := raw["more_info"]; !hasInfo {
+			return nil, ErrPlaylistNotFound
+		}
+	}
+	return raw, nil
+}
+
+// FetchPlaylistByID returns the raw playlist.getDetails response of a playlist
+func (c *Client) FetchPlaylistByID(ctx context.Context, id string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "playlist.getDetails", web6(url.Values{"listid": {id}}), &raw); err != nil {
+		return nil, err
+	}
+	if utils.GetString(raw, "id") == "" && raw["list"] == nil {
+		return nil, ErrPlaylistNotFound
+	}
+	return raw, nil
+}
+
+// FetchArtist returns the raw artist.getArtistPageDetails data of an artist
+func (c *Client) FetchArtist(ctx context.Context, id string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "artist.getArtistPageDetails", url.Values{"cc": {"in"}, "artistId": {id}}, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || utils.GetString(raw, "name") == "" {
+		return nil, ErrArtistNotFound
+	}
+	return raw, nil
+}
+
+// FetchArtistByToken returns the raw artist.getArtistPageDetails data of an
+// artist, from the token of its perma URL
+func (c *Client) FetchArtistByToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "webapi.get", web6(url.Values{"token": {token}, "type": {"artist"}, "includeMetaTags": {"0"}}), &raw); err != nil {
+		return nil, err
+	}
+	id := utils.GetString(raw, "artistId")
+	if id == "" {
+		return nil, ErrArtistNotFound
+	}
+	return c.FetchArtist(ctx, id)
+}
+
+// FetchArtistAlbums returns the raw entries of one page of the albums of an
+// artist, pages counting from 0, with the total number of albums
+func (c *Client) FetchArtistAlbums(ctx context.Context, id string, page, count int) ([]map[string]interface{}, int, error) {
+	params := url.Values{
+		"artistId":   {id},
+		"page":       {strconv.Itoa(page)},
+		"n_album":    {strconv.Itoa(count)},
+		"category":   {""},
+		"sort_order": {""},
+	}
+	var raw map[string]interface{}
+	if err := c.get(ctx, "artist.getArtistMoreAlbum", web6(params), &raw); err != nil {
+		return nil, 0, err
+	}
+	topAlbums, ok := raw["topAlbums"].(map[string]interface{})
+	if !ok {
+		if page == 0 {
+			return nil, 0, ErrArtistNotFound
+		}
+		return nil, 0, nil
+	}
+
+	list, _ := topAlbums["albums"].([]interface{})
+	albums := make([]map[string]interface{}, 0, len(list))
+	for _, entry := range list {
+		if album, ok := entry.(map[string]interface{}); ok {
+			albums = append(albums, album)
+		}
+	}
+	return albums, utils.GetInt(topAlbums, "total"), nil
+}
+
+// FetchLyrics returns the raw lyrics.getLyrics response of a song. Upstream
+// does not report missing lyrics consistently; check the has_lyrics field of
+// the song first.
+func (c *Client) FetchLyrics(ctx context.Context, id string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "lyrics.getLyrics", web6(url.Values{"lyrics_id": {id}}), &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// searchCalls are the upstream search methods, by search type
+var searchCalls = map[string]string{
+	"song":     "search.getResults",
+	"album":    "search.getAlbumResults",
+	"artist":   "search.getArtistResults",
+	"playlist": "search.getPlaylistResults",
+}
+
+// FetchSearch returns the raw data of the first 20 results of a search.
+// searchType is song, album, artist or playlist; other types search songs.
+//
+// The transliterated and respelled variants of the query for the given
+// scripts (see match.QueryVariants) are searched too, in parallel, and their
+// results are merged after those of the query itself, dropping repeated IDs.
+func (c *Client) FetchSearch(ctx context.Context, query, searchType string, scripts []string) (map[string]interface{}, error) {
+	return c.FetchSearchPage(ctx, query, searchType, 1, 20, scripts)
+}
+
+// FetchSearchPage is FetchSearch for the page-th page, from 1, of limit
+// results. The variants of the query are searched for the same page.
+func (c *Client) FetchSearchPage(ctx context.Context, query, searchType string, page, limit int, scripts []string) (map[string]interface{}, error) {
+	if query == "" {
+		return nil, errors.New("missing query parameter")
+	}
+
+	queries := append([]string{query}, match.QueryVariants(query, scripts)...)
+	results, err := searchVariants(queries, func(q string) (map[string]interface{}, error) {
+		return c.fetchSearchPage(ctx, q, searchType, page, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+
+	merged := results[0]
+	items, _ := merged["results"].([]interface{})
+	seen := map[string]bool{}
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			seen[utils.GetString(itemMap, "id")] = true
+		}
+	}
+	added := 0
+	for _, result := range results[1:] {
+		extra, _ := result["results"].([]interface{})
+		for _, item := range extra {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok || seen[utils.GetString(itemMap, "id")] {
+				continue
+			}
+			seen[utils.GetString(itemMap, "id")] = true
+			items = append(items, item)
+			added++
+		}
+	}
+	merged["results"] = items
+	merged["total"] = utils.GetInt(merged, "total") + added
+	return merged, nil
+}
+
+// fetchSearchPage returns the raw data of one page of a search
+func (c *Client) fetchSearchPage(ctx context.Context, query, searchType string, page, limit int) (map[string]interface{}, error) {
+	call, ok := searchCalls[searchType]
+	if !ok {
+		call = searchCalls["song"]
+	}
+	params := url.Values{"p": {strconv.Itoa(page)}, "q": {query}, "n": {strconv.Itoa(limit)}}
+	var raw map[string]interface{}
+	if err := c.get(ctx, call, web6(params), &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch search results: %w", err)
+	}
+
+	// The results are usually wrapped in a data object
+	if dataObj, ok := raw["data"].(map[string]interface{}); ok {
+		return dataObj, nil
+	}
+	return raw, nil
+}
+
+// FetchSuggestions returns the songs suggested for a query being typed,
+// formatted with FormatSuggestion, the top match first. The variants of the
+// query for the given scripts are queried too, their songs following those
+// of the query itself.
+func (c *Client) FetchSuggestions(ctx context.Context, query string, scripts []string) ([]map[string]interface{}, error) {
+	queries := append([]string{query}, match.QueryVariants(query, scripts)...)
+	results, err := searchVariants(queries, func(q string) ([]map[string]interface{}, error) {
+		return c.fetchAutocompleteSongs(ctx, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	songs := []map[string]interface{}{}
+	seen := map[string]bool{}
+	for _, result := range results {
+		for _, song := range result {
+			if id := utils.GetString(song, "id"); !seen[id] {
+				seen[id] = true
+				songs = append(songs, song)
+			}
+		}
+	}
+	return songs, nil
+}
+
+// fetchAutocompleteSongs returns the songs of an autocomplete.get call
+func (c *Client) fetchAutocompleteSongs(ctx context.Context, query string) ([]map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := c.get(ctx, "autocomplete.get", url.Values{"query": {query}, "type": {"song"}}, &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch autocomplete results: %w", err)
+	}
+
+	songs := []map[string]interface{}{}
+
+	// First check topquery for best match
+	if topQuery, ok := raw["topquery"].(map[string]interface{}); ok {
+		if topData, ok := topQuery["data"].([]interface{}); ok && len(topData) > 0 {
+			if topSong, ok := topData[0].(map[string]interface{}); ok && topSong["type"] == "song" {
+				songs = append(songs, FormatSuggestion(topSong))
+			}
+		}
+	}
+
+	// Then get songs from songs section
+	if songsSection, ok := raw["songs"].(map[string]interface{}); ok {
+		if songsData, ok := songsSection["data"].([]interface{}); ok {
+			for _, song := range songsData {
+				if songMap, ok := song.(map[string]interface{}); ok {
+					songs = append(songs, FormatSuggestion(songMap))
+				}
+			}
+		}
+	}
+	return songs, nil
+}
+
+// FetchCharts returns the raw content.getCharts entries, one per chart playlist
+func (c *Client) FetchCharts(ctx context.Context) ([]map[string]interface{}, error) {
+	var raw []map[string]interface{}
+	if err := c.get(ctx, "content.getCharts", web6(url.Values{}), &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// FetchContentList returns the raw entries of a content.* listing, such as
+// content.getAlbums or content.getFeaturedPlaylists, which upstream returns
+// either as an array or under "data"
+func (c *Client) FetchContentList(ctx context.Context, call string, params url.Values) ([]map[string]interface{}, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	var raw json.RawMessage
+	if err := c.get(ctx, call, web6(params), &raw); err != nil {
+		return nil, err
+	}
+
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		return entries, nil
+	}
+	var wrapped struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return wrapped.Data, nil
+}
+
+// searchVariants runs fetch for every query in parallel and returns the results
+// of the queries that succeeded, in order. It fails only when all of them fail.
+func searchVariants[T any](queries []string, fetch func(query string) (T, error)) ([]T, error) {
+	results := make([]T, len(queries))
+	errs := make([]error, len(queries))
+
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fetch(query)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := make([]T, 0, len(queries))
+	for i, result := range results {
+		if errs[i] == nil {
+			succeeded = append(succeeded, result)
+		}
+	}
+	if len(succeeded) == 0 {
+		return nil, errs[0]
+	}
+	return succeeded, nil
+}
@@ -0,0 +1,119 @@
+package saavn
+
+import (
+	"fmt"
+	"jioSaavnAPI/utils"
+	"strings"
+)
+
+// FormatPlaylist formats the webapi.get response of a playlist token. When
+// its list has no songs, the playlist is built from the comma-separated song
+// IDs of more_info.contents, with the songs carrying only their IDs. It
+// returns nil when the response has neither.
+func FormatPlaylist(raw map[string]interface{}) map[string]interface{} {
+	var result map[string]interface{}
+
+	// Try "list" field first (contains song objects with metadata)
+	if list, ok := raw["list"]; ok {
+		result = utils.FormatPlaylistFromToken(list)
+	}
+	if result != nil && utils.GetInt(result, "songCount") > 0 {
+		return result
+	}
+
+	// Fallback to "more_info.contents" (contains comma-separated song IDs)
+	moreInfo, _ := raw["more_info"].(map[string]interface{})
+	contents, ok := moreInfo["contents"]
+	if !ok {
+		return result
+	}
+	minimalSongs := utils.FormatPlaylistFromContents(contents)
+	songs := make([]map[string]string, len(minimalSongs))
+	for i, song := range minimalSongs {
+		songs[i] = map[string]string{"id": song.ID}
+	}
+
+	// Build minimal playlist response from root-level fields
+	return map[string]interface{}{
+		"id":        utils.GetString(raw, "id"),
+		"name":      utils.GetString(raw, "title"),
+		"type":      "playlist",
+		"image":     utils.BuildImageArray(utils.GetString(raw, "image")),
+		"url":       utils.GetString(raw, "perma_url"),
+		"language":  utils.GetString(raw, "language"),
+		"songCount": len(songs),
+		"songs":     songs,
+	}
+}
+
+// FormatPlaylistDetails formats the playlist.getDetails response of a playlist
+func FormatPlaylistDetails(raw map[string]interface{}) map[string]interface{} {
+	list, _ := raw["list"].([]interface{})
+	songs := make([]map[string]interface{}, 0, len(list))
+	for _, entry := range list {
+		if song, ok := entry.(map[string]interface{}); ok {
+			songs = append(songs, utils.FormatSearchSong(song))
+		}
+	}
+
+	description := utils.GetString(raw, "header_desc")
+	if description == "" {
+		description = utils.GetString(raw, "subtitle")
+	}
+	return map[string]interface{}{
+		"id":          utils.GetString(raw, "id"),
+		"name":        utils.GetString(raw, "title"),
+		"description": description,
+		"songCount":   PlaylistSongCount(raw),
+		"url":         utils.GetString(raw, "perma_url"),
+		"image":       utils.BuildImageArray(utils.GetString(raw, "image")),
+		"songs":       songs,
+	}
+}
+
+// PlaylistSongCount returns the number of songs of a raw playlist, which
+// upstream reports in different fields depending on the method
+func PlaylistSongCount(raw map[string]interface{}) int {
+	if count := utils.GetInt(raw, "count"); count != 0 {
+		return count
+	}
+	moreInfo, _ := raw["more_info"].(map[string]interface{})
+	return max(utils.GetInt(moreInfo, "song_count"), utils.GetInt(raw, "list_count"))
+}
+
+// FormatSuggestion formats an autocomplete song with only the fields needed
+// to display it while typing
+func FormatSuggestion(data map[string]interface{}) map[string]interface{} {
+	moreInfo, _ := data["more_info"].(map[string]interface{})
+
+	album := utils.GetString(data, "album")
+
+	// Get image - prefer larger size
+	imageURL := utils.GetString(data, "image")
+	imageURL = strings.Replace(imageURL, "50x50", "150x150", 1)
+
+	primaryArtists := utils.GetString(moreInfo, "primary_artists")
+	if primaryArtists == "" {
+		primaryArtists = utils.GetString(data, "primary_artists")
+	}
+	singers := utils.GetString(moreInfo, "singers")
+	if singers == "" {
+		singers = primaryArtists
+	}
+
+	language := utils.GetString(moreInfo, "language")
+	if language == "" {
+		language = utils.GetString(data, "language")
+	}
+
+	return map[string]interface{}{
+		"id":          utils.GetString(data, "id"),
+		"title":       strings.TrimSpace(utils.GetString(data, "title")),
+		"album":       strings.TrimSpace(album),
+		"artists":     strings.TrimSpace(singers),
+		"image":       imageURL,
+		"url":         utils.GetString(data, "url"),
+		"language":    language,
+		"description": fmt.Sprintf("%s · %s", strings.TrimSpace(singers), strings.TrimSpace(album)),
+	}
+}
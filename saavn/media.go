@@ -0,0 +1,61 @@
+package saavn
+
+import (
+	"context"
+	"fmt"
+	"jioSaavnAPI/utils"
+)
+
+// Qualities are the bitrates in kbps served by the CDN, lowest first
+var Qualities = utils.MediaQualities
+
+// Media is the resolved audio of a song
+type Media struct {
+	// URL is the CDN URL of the audio, an M4A file
+	URL string
+	// Quality is the bitrate selected in kbps, which differs from the one
+	// requested when that one is not available
+	Quality string
+	// Song is the raw song.getDetails entry of the song
+	Song map[string]interface{}
+}
+
+// ResolveMedia resolves the CDN URL of the audio of a song for a bitrate in
+// kbps, such as "160". When that bitrate is not available, the closest lower
+// one is selected, then the closest higher one.
+//
+// When the media URL cannot be decrypted with DecryptionKey, it is requested
+// from the auth-token endpoint of the upstream API at BaseURL. The requests
+// are cancelled with ctx.
+func (c *Client) ResolveMedia(ctx context.Context, id, quality string) (*Media, error) {
+	songData, err := c.FetchSong(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	media := c.mediaResolver().ResolveContext(ctx, utils.GetString(songData, "encrypted_media_url"), utils.GetString(songData, "320kbps") == "true")
+	mediaURL, selected := utils.SelectMediaURL(media.DownloadURLs, quality)
+	if mediaURL == "" {
+		if media.Err != nil {
+			return &Media{Song: songData}, fmt.Errorf("%w: %w", ErrMediaUnavailable, media.Err)
+		}
+		return &Media{Song: songData}, ErrMediaUnavailable
+	}
+	return &Media{URL: mediaURL, Quality: selected, Song: songData}, nil
+}
+
+// mediaResolver returns the media resolver of the settings of the client
+func (c *Client) mediaResolver() *utils.MediaResolver {
+	key := c.DecryptionKey
+	if key == "" {
+		key = DefaultDecryptionKey
+	}
+	return &utils.MediaResolver{
+		DecryptionKey: key,
+		BaseURL:       c.baseURL(),
+		HTTPClient:    c.httpClient(),
+		Verify:        c.VerifyMedia,
+		VerifyTTL:     c.VerifyTTL,
+		Debug:         c.MediaDebug,
+	}
+}
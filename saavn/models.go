@@ -0,0 +1,21 @@
+package saavn
+
+import "jioSaavnAPI/models"
+
+// The typed models returned by the client are those of jioSaavnAPI/models,
+// which the API client shares
+type (
+	Image       = models.Image
+	Images      = models.Images
+	DownloadURL = models.DownloadURL
+	ArtistRef   = models.ArtistRef
+	Artists     = models.Artists
+	AlbumRef    = models.AlbumRef
+	Song        = models.Song
+	Album       = models.Album
+	Artist      = models.Artist
+	Playlist    = models.Playlist
+	LyricsLine  = models.LyricsLine
+	Lyrics      = models.Lyrics
+	Suggestion  = models.Suggestion
+)
@@ -0,0 +1,118 @@
+// Package saavn is the JioSaavn library behind the API. It calls the upstream
+// endpoints, formats their responses and resolves media URLs without an HTTP
+// server, so that CLIs, workers and other programs can embed it.
+//
+// The Fetch methods return the raw upstream data, which the formatters of
+// jioSaavnAPI/utils turn into the JSON served by the API. The other methods
+// return typed models.
+//
+// Media URLs are decrypted with the DecryptionKey of the client, and resolved
+// with its BaseURL and HTTPClient when they cannot be decrypted. The package
+// reads no configuration: the settings of a client are its fields, and the
+// raw data of the Fetch methods is formatted with the defaults of
+// utils.Settings, without signed links.
+package saavn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseURL is the upstream API endpoint
+const DefaultBaseURL = "https://www.jiosaavn.com/api.php"
+
+// DefaultDecryptionKey is the DES key of the media URLs of the website
+const DefaultDecryptionKey = "38346591"
+
+// Errors returned when upstream has no entry for the requested ID or token
+var (
+	ErrSongNotFound     = errors.New("song not found")
+	ErrAlbumNotFound    = errors.New("album not found")
+	ErrArtistNotFound   = errors.New("artist not found")
+	ErrPlaylistNotFound = errors.New("playlist not found")
+	// ErrMediaUnavailable is returned when a song has no playable media URL.
+	// It wraps the *utils.MediaError of the resolution when there is one.
+	ErrMediaUnavailable = errors.New("media not available")
+)
+
+// Client calls the upstream JioSaavn API
+type Client struct {
+	// BaseURL is the upstream API endpoint, DefaultBaseURL when empty
+	BaseURL string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// DecryptionKey decrypts the media URLs, DefaultDecryptionKey when empty
+	DecryptionKey string
+	// VerifyMedia checks the media URLs with HEAD requests before
+	// ResolveMedia selects one, caching the results for VerifyTTL
+	VerifyMedia bool
+	VerifyTTL   time.Duration
+	// MediaDebug logs the media resolutions that fail
+	MediaDebug bool
+}
+
+// NewClient returns a client of the upstream API at baseURL, with a timeout
+// of 30 seconds per request
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:       baseURL,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+		DecryptionKey: DefaultDecryptionKey,
+	}
+}
+
+// get calls an upstream method and decodes its JSON response into out
+func (c *Client) get(ctx context.Context, call string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(call, params), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", call, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// url returns the URL of an upstream method call
+func (c *Client) url(call string, params url.Values) string {
+	query := url.Values{"__call": {call}, "_format": {"json"}, "_marker": {"0"}}
+	for name, values := range params {
+		query[name] = values
+	}
+	return c.baseURL() + "?" + query.Encode()
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return c.BaseURL
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// web6 are the parameters of the api_version 4 methods used by the website
+func web6(params url.Values) url.Values {
+	params.Set("api_version", "4")
+	params.Set("ctx", "web6dot0")
+	return params
+}
@@ -0,0 +1,53 @@
+package saavn
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Kinds of catalog entries
+const (
+	KindSong     = "song"
+	KindAlbum    = "album"
+	KindArtist   = "artist"
+	KindPlaylist = "playlist"
+)
+
+// ErrUnsupportedURL is returned by ParseURL for URLs that are not the perma
+// URL of a song, album, artist or playlist
+var ErrUnsupportedURL = errors.New("not a JioSaavn song, album, artist or playlist URL")
+
+// urlKinds are the kinds of entries, by the first segment of their path
+var urlKinds = map[string]string{
+	"song":     KindSong,
+	"album":    KindAlbum,
+	"artist":   KindArtist,
+	"featured": KindPlaylist,
+	"s":        KindPlaylist,
+}
+
+// ParseURL returns the kind and token of the entry of a JioSaavn perma URL,
+// such as https://www.jiosaavn.com/song/tum-hi-ho/EToxUyFpcwQ. Pass the token
+// to SongByToken, AlbumByToken, ArtistByToken or PlaylistByToken.
+func ParseURL(rawURL string) (kind, token string, err error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", ErrUnsupportedURL
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host != "jiosaavn.com" && host != "saavn.com" {
+		return "", "", ErrUnsupportedURL
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	kind, ok := urlKinds[segments[0]]
+	// User playlists are shared as /s/playlist/<user>/<name>/<token>
+	if !ok || len(segments) < 3 || (segments[0] == "s" && segments[1] != "playlist") {
+		return "", "", ErrUnsupportedURL
+	}
+	return kind, segments[len(segments)-1], nil
+}
@@ -0,0 +1,6 @@
+// Package saavnpb holds the gRPC service definition of the catalog and the
+// code generated from it. After editing saavn.proto, regenerate with protoc,
+// protoc-gen-go and protoc-gen-go-grpc installed.
+package saavnpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative saavn.proto
@@ -0,0 +1,1709 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: saavn.proto
+
+package saavnpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchType int32
+
+const (
+	SearchType_SEARCH_TYPE_UNSPECIFIED SearchType = 0
+	SearchType_SEARCH_TYPE_SONG        SearchType = 1
+	SearchType_SEARCH_TYPE_ALBUM       SearchType = 2
+	SearchType_SEARCH_TYPE_ARTIST      SearchType = 3
+	SearchType_SEARCH_TYPE_PLAYLIST    SearchType = 4
+)
+
+// Enum value maps for SearchType.
+var (
+	SearchType_name = map[int32]string{
+		0: "SEARCH_TYPE_UNSPECIFIED",
+		1: "SEARCH_TYPE_SONG",
+		2: "SEARCH_TYPE_ALBUM",
+		3: "SEARCH_TYPE_ARTIST",
+		4: "SEARCH_TYPE_PLAYLIST",
+	}
+	SearchType_value = map[string]int32{
+		"SEARCH_TYPE_UNSPECIFIED": 0,
+		"SEARCH_TYPE_SONG":        1,
+		"SEARCH_TYPE_ALBUM":       2,
+		"SEARCH_TYPE_ARTIST":      3,
+		"SEARCH_TYPE_PLAYLIST":    4,
+	}
+)
+
+func (x SearchType) Enum() *SearchType {
+	p := new(SearchType)
+	*p = x
+	return p
+}
+
+func (x SearchType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SearchType) Descriptor() protoreflect.EnumDescriptor {
+	return file_saavn_proto_enumTypes[0].Descriptor()
+}
+
+func (SearchType) Type() protoreflect.EnumType {
+	return &file_saavn_proto_enumTypes[0]
+}
+
+func (x SearchType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SearchType.Descriptor instead.
+func (SearchType) EnumDescriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{0}
+}
+
+type Image struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quality       string                 `protobuf:"bytes,1,opt,name=quality,proto3" json:"quality,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	mi := &file_saavn_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Image) GetQuality() string {
+	if x != nil {
+		return x.Quality
+	}
+	return ""
+}
+
+func (x *Image) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type DownloadUrl struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quality       string                 `protobuf:"bytes,1,opt,name=quality,proto3" json:"quality,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadUrl) Reset() {
+	*x = DownloadUrl{}
+	mi := &file_saavn_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadUrl) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadUrl) ProtoMessage() {}
+
+func (x *DownloadUrl) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadUrl.ProtoReflect.Descriptor instead.
+func (*DownloadUrl) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DownloadUrl) GetQuality() string {
+	if x != nil {
+		return x.Quality
+	}
+	return ""
+}
+
+func (x *DownloadUrl) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type ArtistRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	Url           string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Images        []*Image               `protobuf:"bytes,5,rep,name=images,proto3" json:"images,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtistRef) Reset() {
+	*x = ArtistRef{}
+	mi := &file_saavn_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtistRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtistRef) ProtoMessage() {}
+
+func (x *ArtistRef) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtistRef.ProtoReflect.Descriptor instead.
+func (*ArtistRef) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ArtistRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ArtistRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ArtistRef) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ArtistRef) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ArtistRef) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+type AlbumRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlbumRef) Reset() {
+	*x = AlbumRef{}
+	mi := &file_saavn_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlbumRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlbumRef) ProtoMessage() {}
+
+func (x *AlbumRef) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlbumRef.ProtoReflect.Descriptor instead.
+func (*AlbumRef) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AlbumRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AlbumRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AlbumRef) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type Song struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Year            string                 `protobuf:"bytes,3,opt,name=year,proto3" json:"year,omitempty"`
+	ReleaseDate     string                 `protobuf:"bytes,4,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`
+	Duration        int32                  `protobuf:"varint,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Label           string                 `protobuf:"bytes,6,opt,name=label,proto3" json:"label,omitempty"`
+	Copyright       string                 `protobuf:"bytes,7,opt,name=copyright,proto3" json:"copyright,omitempty"`
+	ExplicitContent bool                   `protobuf:"varint,8,opt,name=explicit_content,json=explicitContent,proto3" json:"explicit_content,omitempty"`
+	PlayCount       int64                  `protobuf:"varint,9,opt,name=play_count,json=playCount,proto3" json:"play_count,omitempty"`
+	Language        string                 `protobuf:"bytes,10,opt,name=language,proto3" json:"language,omitempty"`
+	HasLyrics       bool                   `protobuf:"varint,11,opt,name=has_lyrics,json=hasLyrics,proto3" json:"has_lyrics,omitempty"`
+	Url             string                 `protobuf:"bytes,12,opt,name=url,proto3" json:"url,omitempty"`
+	Album           *AlbumRef              `protobuf:"bytes,13,opt,name=album,proto3" json:"album,omitempty"`
+	PrimaryArtists  []*ArtistRef           `protobuf:"bytes,14,rep,name=primary_artists,json=primaryArtists,proto3" json:"primary_artists,omitempty"`
+	FeaturedArtists []*ArtistRef           `protobuf:"bytes,15,rep,name=featured_artists,json=featuredArtists,proto3" json:"featured_artists,omitempty"`
+	AllArtists      []*ArtistRef           `protobuf:"bytes,16,rep,name=all_artists,json=allArtists,proto3" json:"all_artists,omitempty"`
+	Images          []*Image               `protobuf:"bytes,17,rep,name=images,proto3" json:"images,omitempty"`
+	DownloadUrls    []*DownloadUrl         `protobuf:"bytes,18,rep,name=download_urls,json=downloadUrls,proto3" json:"download_urls,omitempty"`
+	PreviewUrl      string                 `protobuf:"bytes,19,opt,name=preview_url,json=previewUrl,proto3" json:"preview_url,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Song) Reset() {
+	*x = Song{}
+	mi := &file_saavn_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Song) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Song) ProtoMessage() {}
+
+func (x *Song) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Song.ProtoReflect.Descriptor instead.
+func (*Song) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Song) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Song) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Song) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *Song) GetReleaseDate() string {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return ""
+}
+
+func (x *Song) GetDuration() int32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *Song) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Song) GetCopyright() string {
+	if x != nil {
+		return x.Copyright
+	}
+	return ""
+}
+
+func (x *Song) GetExplicitContent() bool {
+	if x != nil {
+		return x.ExplicitContent
+	}
+	return false
+}
+
+func (x *Song) GetPlayCount() int64 {
+	if x != nil {
+		return x.PlayCount
+	}
+	return 0
+}
+
+func (x *Song) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Song) GetHasLyrics() bool {
+	if x != nil {
+		return x.HasLyrics
+	}
+	return false
+}
+
+func (x *Song) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Song) GetAlbum() *AlbumRef {
+	if x != nil {
+		return x.Album
+	}
+	return nil
+}
+
+func (x *Song) GetPrimaryArtists() []*ArtistRef {
+	if x != nil {
+		return x.PrimaryArtists
+	}
+	return nil
+}
+
+func (x *Song) GetFeaturedArtists() []*ArtistRef {
+	if x != nil {
+		return x.FeaturedArtists
+	}
+	return nil
+}
+
+func (x *Song) GetAllArtists() []*ArtistRef {
+	if x != nil {
+		return x.AllArtists
+	}
+	return nil
+}
+
+func (x *Song) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *Song) GetDownloadUrls() []*DownloadUrl {
+	if x != nil {
+		return x.DownloadUrls
+	}
+	return nil
+}
+
+func (x *Song) GetPreviewUrl() string {
+	if x != nil {
+		return x.PreviewUrl
+	}
+	return ""
+}
+
+type Album struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Year            string                 `protobuf:"bytes,3,opt,name=year,proto3" json:"year,omitempty"`
+	Language        string                 `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"`
+	ExplicitContent bool                   `protobuf:"varint,5,opt,name=explicit_content,json=explicitContent,proto3" json:"explicit_content,omitempty"`
+	SongCount       int32                  `protobuf:"varint,6,opt,name=song_count,json=songCount,proto3" json:"song_count,omitempty"`
+	Url             string                 `protobuf:"bytes,7,opt,name=url,proto3" json:"url,omitempty"`
+	Images          []*Image               `protobuf:"bytes,8,rep,name=images,proto3" json:"images,omitempty"`
+	PrimaryArtists  []*ArtistRef           `protobuf:"bytes,9,rep,name=primary_artists,json=primaryArtists,proto3" json:"primary_artists,omitempty"`
+	Songs           []*Song                `protobuf:"bytes,10,rep,name=songs,proto3" json:"songs,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Album) Reset() {
+	*x = Album{}
+	mi := &file_saavn_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Album) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Album) ProtoMessage() {}
+
+func (x *Album) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Album.ProtoReflect.Descriptor instead.
+func (*Album) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Album) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Album) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Album) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *Album) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Album) GetExplicitContent() bool {
+	if x != nil {
+		return x.ExplicitContent
+	}
+	return false
+}
+
+func (x *Album) GetSongCount() int32 {
+	if x != nil {
+		return x.SongCount
+	}
+	return 0
+}
+
+func (x *Album) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Album) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *Album) GetPrimaryArtists() []*ArtistRef {
+	if x != nil {
+		return x.PrimaryArtists
+	}
+	return nil
+}
+
+func (x *Album) GetSongs() []*Song {
+	if x != nil {
+		return x.Songs
+	}
+	return nil
+}
+
+type Artist struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Url              string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Images           []*Image               `protobuf:"bytes,4,rep,name=images,proto3" json:"images,omitempty"`
+	FollowerCount    int64                  `protobuf:"varint,5,opt,name=follower_count,json=followerCount,proto3" json:"follower_count,omitempty"`
+	IsVerified       bool                   `protobuf:"varint,6,opt,name=is_verified,json=isVerified,proto3" json:"is_verified,omitempty"`
+	DominantLanguage string                 `protobuf:"bytes,7,opt,name=dominant_language,json=dominantLanguage,proto3" json:"dominant_language,omitempty"`
+	DominantType     string                 `protobuf:"bytes,8,opt,name=dominant_type,json=dominantType,proto3" json:"dominant_type,omitempty"`
+	Bio              string                 `protobuf:"bytes,9,opt,name=bio,proto3" json:"bio,omitempty"`
+	TopSongs         []*Song                `protobuf:"bytes,10,rep,name=top_songs,json=topSongs,proto3" json:"top_songs,omitempty"`
+	TopAlbums        []*Album               `protobuf:"bytes,11,rep,name=top_albums,json=topAlbums,proto3" json:"top_albums,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Artist) Reset() {
+	*x = Artist{}
+	mi := &file_saavn_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artist) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artist) ProtoMessage() {}
+
+func (x *Artist) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artist.ProtoReflect.Descriptor instead.
+func (*Artist) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Artist) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Artist) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Artist) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Artist) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *Artist) GetFollowerCount() int64 {
+	if x != nil {
+		return x.FollowerCount
+	}
+	return 0
+}
+
+func (x *Artist) GetIsVerified() bool {
+	if x != nil {
+		return x.IsVerified
+	}
+	return false
+}
+
+func (x *Artist) GetDominantLanguage() string {
+	if x != nil {
+		return x.DominantLanguage
+	}
+	return ""
+}
+
+func (x *Artist) GetDominantType() string {
+	if x != nil {
+		return x.DominantType
+	}
+	return ""
+}
+
+func (x *Artist) GetBio() string {
+	if x != nil {
+		return x.Bio
+	}
+	return ""
+}
+
+func (x *Artist) GetTopSongs() []*Song {
+	if x != nil {
+		return x.TopSongs
+	}
+	return nil
+}
+
+func (x *Artist) GetTopAlbums() []*Album {
+	if x != nil {
+		return x.TopAlbums
+	}
+	return nil
+}
+
+type Playlist struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	SongCount     int32                  `protobuf:"varint,4,opt,name=song_count,json=songCount,proto3" json:"song_count,omitempty"`
+	Url           string                 `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	Images        []*Image               `protobuf:"bytes,6,rep,name=images,proto3" json:"images,omitempty"`
+	Songs         []*Song                `protobuf:"bytes,7,rep,name=songs,proto3" json:"songs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Playlist) Reset() {
+	*x = Playlist{}
+	mi := &file_saavn_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Playlist) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Playlist) ProtoMessage() {}
+
+func (x *Playlist) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Playlist.ProtoReflect.Descriptor instead.
+func (*Playlist) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Playlist) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Playlist) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Playlist) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Playlist) GetSongCount() int32 {
+	if x != nil {
+		return x.SongCount
+	}
+	return 0
+}
+
+func (x *Playlist) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Playlist) GetImages() []*Image {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *Playlist) GetSongs() []*Song {
+	if x != nil {
+		return x.Songs
+	}
+	return nil
+}
+
+type LyricsLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *float64               `protobuf:"fixed64,1,opt,name=time,proto3,oneof" json:"time,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LyricsLine) Reset() {
+	*x = LyricsLine{}
+	mi := &file_saavn_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LyricsLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LyricsLine) ProtoMessage() {}
+
+func (x *LyricsLine) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LyricsLine.ProtoReflect.Descriptor instead.
+func (*LyricsLine) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LyricsLine) GetTime() float64 {
+	if x != nil && x.Time != nil {
+		return *x.Time
+	}
+	return 0
+}
+
+func (x *LyricsLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type Lyrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Lines         []*LyricsLine          `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`
+	Synced        bool                   `protobuf:"varint,3,opt,name=synced,proto3" json:"synced,omitempty"`
+	Copyright     string                 `protobuf:"bytes,4,opt,name=copyright,proto3" json:"copyright,omitempty"`
+	Snippet       string                 `protobuf:"bytes,5,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Lyrics) Reset() {
+	*x = Lyrics{}
+	mi := &file_saavn_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Lyrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lyrics) ProtoMessage() {}
+
+func (x *Lyrics) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lyrics.ProtoReflect.Descriptor instead.
+func (*Lyrics) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Lyrics) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Lyrics) GetLines() []*LyricsLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *Lyrics) GetSynced() bool {
+	if x != nil {
+		return x.Synced
+	}
+	return false
+}
+
+func (x *Lyrics) GetCopyright() string {
+	if x != nil {
+		return x.Copyright
+	}
+	return ""
+}
+
+func (x *Lyrics) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+type GetSongRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSongRequest) Reset() {
+	*x = GetSongRequest{}
+	mi := &file_saavn_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSongRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSongRequest) ProtoMessage() {}
+
+func (x *GetSongRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSongRequest.ProtoReflect.Descriptor instead.
+func (*GetSongRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetSongRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type BatchGetSongsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetSongsRequest) Reset() {
+	*x = BatchGetSongsRequest{}
+	mi := &file_saavn_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetSongsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetSongsRequest) ProtoMessage() {}
+
+func (x *BatchGetSongsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetSongsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetSongsRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BatchGetSongsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type BatchGetSongsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Songs         []*Song                `protobuf:"bytes,1,rep,name=songs,proto3" json:"songs,omitempty"`
+	MissingIds    []string               `protobuf:"bytes,2,rep,name=missing_ids,json=missingIds,proto3" json:"missing_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetSongsResponse) Reset() {
+	*x = BatchGetSongsResponse{}
+	mi := &file_saavn_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetSongsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetSongsResponse) ProtoMessage() {}
+
+func (x *BatchGetSongsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetSongsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetSongsResponse) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchGetSongsResponse) GetSongs() []*Song {
+	if x != nil {
+		return x.Songs
+	}
+	return nil
+}
+
+func (x *BatchGetSongsResponse) GetMissingIds() []string {
+	if x != nil {
+		return x.MissingIds
+	}
+	return nil
+}
+
+type GetAlbumRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAlbumRequest) Reset() {
+	*x = GetAlbumRequest{}
+	mi := &file_saavn_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAlbumRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAlbumRequest) ProtoMessage() {}
+
+func (x *GetAlbumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAlbumRequest.ProtoReflect.Descriptor instead.
+func (*GetAlbumRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetAlbumRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetArtistRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetArtistRequest) Reset() {
+	*x = GetArtistRequest{}
+	mi := &file_saavn_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetArtistRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArtistRequest) ProtoMessage() {}
+
+func (x *GetArtistRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArtistRequest.ProtoReflect.Descriptor instead.
+func (*GetArtistRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetArtistRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetPlaylistRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPlaylistRequest) Reset() {
+	*x = GetPlaylistRequest{}
+	mi := &file_saavn_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlaylistRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlaylistRequest) ProtoMessage() {}
+
+func (x *GetPlaylistRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlaylistRequest.ProtoReflect.Descriptor instead.
+func (*GetPlaylistRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetPlaylistRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetLyricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLyricsRequest) Reset() {
+	*x = GetLyricsRequest{}
+	mi := &file_saavn_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLyricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLyricsRequest) ProtoMessage() {}
+
+func (x *GetLyricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLyricsRequest.ProtoReflect.Descriptor instead.
+func (*GetLyricsRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetLyricsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Types         []SearchType           `protobuf:"varint,2,rep,packed,name=types,proto3,enum=saavn.v1.SearchType" json:"types,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_saavn_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetTypes() []SearchType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Songs         []*Song                `protobuf:"bytes,1,rep,name=songs,proto3" json:"songs,omitempty"`
+	Albums        []*Album               `protobuf:"bytes,2,rep,name=albums,proto3" json:"albums,omitempty"`
+	Artists       []*ArtistRef           `protobuf:"bytes,3,rep,name=artists,proto3" json:"artists,omitempty"`
+	Playlists     []*Playlist            `protobuf:"bytes,4,rep,name=playlists,proto3" json:"playlists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_saavn_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SearchResponse) GetSongs() []*Song {
+	if x != nil {
+		return x.Songs
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetAlbums() []*Album {
+	if x != nil {
+		return x.Albums
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetArtists() []*ArtistRef {
+	if x != nil {
+		return x.Artists
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetPlaylists() []*Playlist {
+	if x != nil {
+		return x.Playlists
+	}
+	return nil
+}
+
+type ArtistDiscographyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArtistId      string                 `protobuf:"bytes,1,opt,name=artist_id,json=artistId,proto3" json:"artist_id,omitempty"`
+	IncludeSongs  bool                   `protobuf:"varint,2,opt,name=include_songs,json=includeSongs,proto3" json:"include_songs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtistDiscographyRequest) Reset() {
+	*x = ArtistDiscographyRequest{}
+	mi := &file_saavn_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtistDiscographyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtistDiscographyRequest) ProtoMessage() {}
+
+func (x *ArtistDiscographyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_saavn_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtistDiscographyRequest.ProtoReflect.Descriptor instead.
+func (*ArtistDiscographyRequest) Descriptor() ([]byte, []int) {
+	return file_saavn_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ArtistDiscographyRequest) GetArtistId() string {
+	if x != nil {
+		return x.ArtistId
+	}
+	return ""
+}
+
+func (x *ArtistDiscographyRequest) GetIncludeSongs() bool {
+	if x != nil {
+		return x.IncludeSongs
+	}
+	return false
+}
+
+var File_saavn_proto protoreflect.FileDescriptor
+
+const file_saavn_proto_rawDesc = "" +
+	"\n" +
+	"\vsaavn.proto\x12\bsaavn.v1\"3\n" +
+	"\x05Image\x12\x18\n" +
+	"\aquality\x18\x01 \x01(\tR\aquality\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"9\n" +
+	"\vDownloadUrl\x12\x18\n" +
+	"\aquality\x18\x01 \x01(\tR\aquality\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"~\n" +
+	"\tArtistRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12'\n" +
+	"\x06images\x18\x05 \x03(\v2\x0f.saavn.v1.ImageR\x06images\"@\n" +
+	"\bAlbumRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\"\xac\x05\n" +
+	"\x04Song\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04year\x18\x03 \x01(\tR\x04year\x12!\n" +
+	"\frelease_date\x18\x04 \x01(\tR\vreleaseDate\x12\x1a\n" +
+	"\bduration\x18\x05 \x01(\x05R\bduration\x12\x14\n" +
+	"\x05label\x18\x06 \x01(\tR\x05label\x12\x1c\n" +
+	"\tcopyright\x18\a \x01(\tR\tcopyright\x12)\n" +
+	"\x10explicit_content\x18\b \x01(\bR\x0fexplicitContent\x12\x1d\n" +
+	"\n" +
+	"play_count\x18\t \x01(\x03R\tplayCount\x12\x1a\n" +
+	"\blanguage\x18\n" +
+	" \x01(\tR\blanguage\x12\x1d\n" +
+	"\n" +
+	"has_lyrics\x18\v \x01(\bR\thasLyrics\x12\x10\n" +
+	"\x03url\x18\f \x01(\tR\x03url\x12(\n" +
+	"\x05album\x18\r \x01(\v2\x12.saavn.v1.AlbumRefR\x05album\x12<\n" +
+	"\x0fprimary_artists\x18\x0e \x03(\v2\x13.saavn.v1.ArtistRefR\x0eprimaryArtists\x12>\n" +
+	"\x10featured_artists\x18\x0f \x03(\v2\x13.saavn.v1.ArtistRefR\x0ffeaturedArtists\x124\n" +
+	"\vall_artists\x18\x10 \x03(\v2\x13.saavn.v1.ArtistRefR\n" +
+	"allArtists\x12'\n" +
+	"\x06images\x18\x11 \x03(\v2\x0f.saavn.v1.ImageR\x06images\x12:\n" +
+	"\rdownload_urls\x18\x12 \x03(\v2\x15.saavn.v1.DownloadUrlR\fdownloadUrls\x12\x1f\n" +
+	"\vpreview_url\x18\x13 \x01(\tR\n" +
+	"previewUrl\"\xc4\x02\n" +
+	"\x05Album\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04year\x18\x03 \x01(\tR\x04year\x12\x1a\n" +
+	"\blanguage\x18\x04 \x01(\tR\blanguage\x12)\n" +
+	"\x10explicit_content\x18\x05 \x01(\bR\x0fexplicitContent\x12\x1d\n" +
+	"\n" +
+	"song_count\x18\x06 \x01(\x05R\tsongCount\x12\x10\n" +
+	"\x03url\x18\a \x01(\tR\x03url\x12'\n" +
+	"\x06images\x18\b \x03(\v2\x0f.saavn.v1.ImageR\x06images\x12<\n" +
+	"\x0fprimary_artists\x18\t \x03(\v2\x13.saavn.v1.ArtistRefR\x0eprimaryArtists\x12$\n" +
+	"\x05songs\x18\n" +
+	" \x03(\v2\x0e.saavn.v1.SongR\x05songs\"\xf0\x02\n" +
+	"\x06Artist\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12'\n" +
+	"\x06images\x18\x04 \x03(\v2\x0f.saavn.v1.ImageR\x06images\x12%\n" +
+	"\x0efollower_count\x18\x05 \x01(\x03R\rfollowerCount\x12\x1f\n" +
+	"\vis_verified\x18\x06 \x01(\bR\n" +
+	"isVerified\x12+\n" +
+	"\x11dominant_language\x18\a \x01(\tR\x10dominantLanguage\x12#\n" +
+	"\rdominant_type\x18\b \x01(\tR\fdominantType\x12\x10\n" +
+	"\x03bio\x18\t \x01(\tR\x03bio\x12+\n" +
+	"\ttop_songs\x18\n" +
+	" \x03(\v2\x0e.saavn.v1.SongR\btopSongs\x12.\n" +
+	"\n" +
+	"top_albums\x18\v \x03(\v2\x0f.saavn.v1.AlbumR\ttopAlbums\"\xd0\x01\n" +
+	"\bPlaylist\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"song_count\x18\x04 \x01(\x05R\tsongCount\x12\x10\n" +
+	"\x03url\x18\x05 \x01(\tR\x03url\x12'\n" +
+	"\x06images\x18\x06 \x03(\v2\x0f.saavn.v1.ImageR\x06images\x12$\n" +
+	"\x05songs\x18\a \x03(\v2\x0e.saavn.v1.SongR\x05songs\"B\n" +
+	"\n" +
+	"LyricsLine\x12\x17\n" +
+	"\x04time\x18\x01 \x01(\x01H\x00R\x04time\x88\x01\x01\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04textB\a\n" +
+	"\x05_time\"\x94\x01\n" +
+	"\x06Lyrics\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x05lines\x18\x02 \x03(\v2\x14.saavn.v1.LyricsLineR\x05lines\x12\x16\n" +
+	"\x06synced\x18\x03 \x01(\bR\x06synced\x12\x1c\n" +
+	"\tcopyright\x18\x04 \x01(\tR\tcopyright\x12\x18\n" +
+	"\asnippet\x18\x05 \x01(\tR\asnippet\" \n" +
+	"\x0eGetSongRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"(\n" +
+	"\x14BatchGetSongsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"^\n" +
+	"\x15BatchGetSongsResponse\x12$\n" +
+	"\x05songs\x18\x01 \x03(\v2\x0e.saavn.v1.SongR\x05songs\x12\x1f\n" +
+	"\vmissing_ids\x18\x02 \x03(\tR\n" +
+	"missingIds\"!\n" +
+	"\x0fGetAlbumRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\"\n" +
+	"\x10GetArtistRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"$\n" +
+	"\x12GetPlaylistRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\"\n" +
+	"\x10GetLyricsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"g\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12*\n" +
+	"\x05types\x18\x02 \x03(\x0e2\x14.saavn.v1.SearchTypeR\x05types\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\xc0\x01\n" +
+	"\x0eSearchResponse\x12$\n" +
+	"\x05songs\x18\x01 \x03(\v2\x0e.saavn.v1.SongR\x05songs\x12'\n" +
+	"\x06albums\x18\x02 \x03(\v2\x0f.saavn.v1.AlbumR\x06albums\x12-\n" +
+	"\aartists\x18\x03 \x03(\v2\x13.saavn.v1.ArtistRefR\aartists\x120\n" +
+	"\tplaylists\x18\x04 \x03(\v2\x12.saavn.v1.PlaylistR\tplaylists\"\\\n" +
+	"\x18ArtistDiscographyRequest\x12\x1b\n" +
+	"\tartist_id\x18\x01 \x01(\tR\bartistId\x12#\n" +
+	"\rinclude_songs\x18\x02 \x01(\bR\fincludeSongs*\x88\x01\n" +
+	"\n" +
+	"SearchType\x12\x1b\n" +
+	"\x17SEARCH_TYPE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10SEARCH_TYPE_SONG\x10\x01\x12\x15\n" +
+	"\x11SEARCH_TYPE_ALBUM\x10\x02\x12\x16\n" +
+	"\x12SEARCH_TYPE_ARTIST\x10\x03\x12\x18\n" +
+	"\x14SEARCH_TYPE_PLAYLIST\x10\x042\x86\x04\n" +
+	"\x05Saavn\x123\n" +
+	"\aGetSong\x12\x18.saavn.v1.GetSongRequest\x1a\x0e.saavn.v1.Song\x12P\n" +
+	"\rBatchGetSongs\x12\x1e.saavn.v1.BatchGetSongsRequest\x1a\x1f.saavn.v1.BatchGetSongsResponse\x126\n" +
+	"\bGetAlbum\x12\x19.saavn.v1.GetAlbumRequest\x1a\x0f.saavn.v1.Album\x129\n" +
+	"\tGetArtist\x12\x1a.saavn.v1.GetArtistRequest\x1a\x10.saavn.v1.Artist\x12?\n" +
+	"\vGetPlaylist\x12\x1c.saavn.v1.GetPlaylistRequest\x1a\x12.saavn.v1.Playlist\x129\n" +
+	"\tGetLyrics\x12\x1a.saavn.v1.GetLyricsRequest\x1a\x10.saavn.v1.Lyrics\x12;\n" +
+	"\x06Search\x12\x17.saavn.v1.SearchRequest\x1a\x18.saavn.v1.SearchResponse\x12J\n" +
+	"\x11ArtistDiscography\x12\".saavn.v1.ArtistDiscographyRequest\x1a\x0f.saavn.v1.Album0\x01B\x15Z\x13jioSaavnAPI/saavnpbb\x06proto3"
+
+var (
+	file_saavn_proto_rawDescOnce sync.Once
+	file_saavn_proto_rawDescData []byte
+)
+
+func file_saavn_proto_rawDescGZIP() []byte {
+	file_saavn_proto_rawDescOnce.Do(func() {
+		file_saavn_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_saavn_proto_rawDesc), len(file_saavn_proto_rawDesc)))
+	})
+	return file_saavn_proto_rawDescData
+}
+
+var file_saavn_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_saavn_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_saavn_proto_goTypes = []any{
+	(SearchType)(0),                  // 0: saavn.v1.SearchType
+	(*Image)(nil),                    // 1: saavn.v1.Image
+	(*DownloadUrl)(nil),              // 2: saavn.v1.DownloadUrl
+	(*ArtistRef)(nil),                // 3: saavn.v1.ArtistRef
+	(*AlbumRef)(nil),                 // 4: saavn.v1.AlbumRef
+	(*Song)(nil),                     // 5: saavn.v1.Song
+	(*Album)(nil),                    // 6: saavn.v1.Album
+	(*Artist)(nil),                   // 7: saavn.v1.Artist
+	(*Playlist)(nil),                 // 8: saavn.v1.Playlist
+	(*LyricsLine)(nil),               // 9: saavn.v1.LyricsLine
+	(*Lyrics)(nil),                   // 10: saavn.v1.Lyrics
+	(*GetSongRequest)(nil),           // 11: saavn.v1.GetSongRequest
+	(*BatchGetSongsRequest)(nil),     // 12: saavn.v1.BatchGetSongsRequest
+	(*BatchGetSongsResponse)(nil),    // 13: saavn.v1.BatchGetSongsResponse
+	(*GetAlbumRequest)(nil),          // 14: saavn.v1.GetAlbumRequest
+	(*GetArtistRequest)(nil),         // 15: saavn.v1.GetArtistRequest
+	(*GetPlaylistRequest)(nil),       // 16: saavn.v1.GetPlaylistRequest
+	(*GetLyricsRequest)(nil),         // 17: saavn.v1.GetLyricsRequest
+	(*SearchRequest)(nil),            // 18: saavn.v1.SearchRequest
+	(*SearchResponse)(nil),           // 19: saavn.v1.SearchResponse
+	(*ArtistDiscographyRequest)(nil), // 20: saavn.v1.ArtistDiscographyRequest
+}
+var file_saavn_proto_depIdxs = []int32{
+	1,  // 0: saavn.v1.ArtistRef.images:type_name -> saavn.v1.Image
+	4,  // 1: saavn.v1.Song.album:type_name -> saavn.v1.AlbumRef
+	3,  // 2: saavn.v1.Song.primary_artists:type_name -> saavn.v1.ArtistRef
+	3,  // 3: saavn.v1.Song.featured_artists:type_name -> saavn.v1.ArtistRef
+	3,  // 4: saavn.v1.Song.all_artists:type_name -> saavn.v1.ArtistRef
+	1,  // 5: saavn.v1.Song.images:type_name -> saavn.v1.Image
+	2,  // 6: saavn.v1.Song.download_urls:type_name -> saavn.v1.DownloadUrl
+	1,  // 7: saavn.v1.Album.images:type_name -> saavn.v1.Image
+	3,  // 8: saavn.v1.Album.primary_artists:type_name -> saavn.v1.ArtistRef
+	5,  // 9: saavn.v1.Album.songs:type_name -> saavn.v1.Song
+	1,  // 10: saavn.v1.Artist.images:type_name -> saavn.v1.Image
+	5,  // 11: saavn.v1.Artist.top_songs:type_name -> saavn.v1.Song
+	6,  // 12: saavn.v1.Artist.top_albums:type_name -> saavn.v1.Album
+	1,  // 13: saavn.v1.Playlist.images:type_name -> saavn.v1.Image
+	5,  // 14: saavn.v1.Playlist.songs:type_name -> saavn.v1.Song
+	9,  // 15: saavn.v1.Lyrics.lines:type_name -> saavn.v1.LyricsLine
+	5,  // 16: saavn.v1.BatchGetSongsResponse.songs:type_name -> saavn.v1.Song
+	0,  // 17: saavn.v1.SearchRequest.types:type_name -> saavn.v1.SearchType
+	5,  // 18: saavn.v1.SearchResponse.songs:type_name -> saavn.v1.Song
+	6,  // 19: saavn.v1.SearchResponse.albums:type_name -> saavn.v1.Album
+	3,  // 20: saavn.v1.SearchResponse.artists:type_name -> saavn.v1.ArtistRef
+	8,  // 21: saavn.v1.SearchResponse.playlists:type_name -> saavn.v1.Playlist
+	11, // 22: saavn.v1.Saavn.GetSong:input_type -> saavn.v1.GetSongRequest
+	12, // 23: saavn.v1.Saavn.BatchGetSongs:input_type -> saavn.v1.BatchGetSongsRequest
+	14, // 24: saavn.v1.Saavn.GetAlbum:input_type -> saavn.v1.GetAlbumRequest
+	15, // 25: saavn.v1.Saavn.GetArtist:input_type -> saavn.v1.GetArtistRequest
+	16, // 26: saavn.v1.Saavn.GetPlaylist:input_type -> saavn.v1.GetPlaylistRequest
+	17, // 27: saavn.v1.Saavn.GetLyrics:input_type -> saavn.v1.GetLyricsRequest
+	18, // 28: saavn.v1.Saavn.Search:input_type -> saavn.v1.SearchRequest
+	20, // 29: saavn.v1.Saavn.ArtistDiscography:input_type -> saavn.v1.ArtistDiscographyRequest
+	5,  // 30: saavn.v1.Saavn.GetSong:output_type -> saavn.v1.Song
+	13, // 31: saavn.v1.Saavn.BatchGetSongs:output_type -> saavn.v1.BatchGetSongsResponse
+	6,  // 32: saavn.v1.Saavn.GetAlbum:output_type -> saavn.v1.Album
+	7,  // 33: saavn.v1.Saavn.GetArtist:output_type -> saavn.v1.Artist
+	8,  // 34: saavn.v1.Saavn.GetPlaylist:output_type -> saavn.v1.Playlist
+	10, // 35: saavn.v1.Saavn.GetLyrics:output_type -> saavn.v1.Lyrics
+	19, // 36: saavn.v1.Saavn.Search:output_type -> saavn.v1.SearchResponse
+	6,  // 37: saavn.v1.Saavn.ArtistDiscography:output_type -> saavn.v1.Album
+	30, // [30:38] is the sub-list for method output_type
+	22, // [22:30] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
+}
+
+func init() { file_saavn_proto_init() }
+func file_saavn_proto_init() {
+	if File_saavn_proto != nil {
+		return
+	}
+	file_saavn_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_saavn_proto_rawDesc), len(file_saavn_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_saavn_proto_goTypes,
+		DependencyIndexes: file_saavn_proto_depIdxs,
+		EnumInfos:         file_saavn_proto_enumTypes,
+		MessageInfos:      file_saavn_proto_msgTypes,
+	}.Build()
+	File_saavn_proto = out.File
+	file_saavn_proto_goTypes = nil
+	file_saavn_proto_depIdxs = nil
+}
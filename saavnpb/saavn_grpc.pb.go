@@ -0,0 +1,391 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: saavn.proto
+
+package saavnpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Saavn_GetSong_FullMethodName           = "/saavn.v1.Saavn/GetSong"
+	Saavn_BatchGetSongs_FullMethodName     = "/saavn.v1.Saavn/BatchGetSongs"
+	Saavn_GetAlbum_FullMethodName          = "/saavn.v1.Saavn/GetAlbum"
+	Saavn_GetArtist_FullMethodName         = "/saavn.v1.Saavn/GetArtist"
+	Saavn_GetPlaylist_FullMethodName       = "/saavn.v1.Saavn/GetPlaylist"
+	Saavn_GetLyrics_FullMethodName         = "/saavn.v1.Saavn/GetLyrics"
+	Saavn_Search_FullMethodName            = "/saavn.v1.Saavn/Search"
+	Saavn_ArtistDiscography_FullMethodName = "/saavn.v1.Saavn/ArtistDiscography"
+)
+
+// SaavnClient is the client API for Saavn service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SaavnClient interface {
+	GetSong(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error)
+	BatchGetSongs(ctx context.Context, in *BatchGetSongsRequest, opts ...grpc.CallOption) (*BatchGetSongsResponse, error)
+	GetAlbum(ctx context.Context, in *GetAlbumRequest, opts ...grpc.CallOption) (*Album, error)
+	GetArtist(ctx context.Context, in *GetArtistRequest, opts ...grpc.CallOption) (*Artist, error)
+	GetPlaylist(ctx context.Context, in *GetPlaylistRequest, opts ...grpc.CallOption) (*Playlist, error)
+	GetLyrics(ctx context.Context, in *GetLyricsRequest, opts ...grpc.CallOption) (*Lyrics, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	ArtistDiscography(ctx context.Context, in *ArtistDiscographyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Album], error)
+}
+
+type saavnClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSaavnClient(cc grpc.ClientConnInterface) SaavnClient {
+	return &saavnClient{cc}
+}
+
+func (c *saavnClient) GetSong(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, Saavn_GetSong_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) BatchGetSongs(ctx context.Context, in *BatchGetSongsRequest, opts ...grpc.CallOption) (*BatchGetSongsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetSongsResponse)
+	err := c.cc.Invoke(ctx, Saavn_BatchGetSongs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) GetAlbum(ctx context.Context, in *GetAlbumRequest, opts ...grpc.CallOption) (*Album, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Album)
+	err := c.cc.Invoke(ctx, Saavn_GetAlbum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) GetArtist(ctx context.Context, in *GetArtistRequest, opts ...grpc.CallOption) (*Artist, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Artist)
+	err := c.cc.Invoke(ctx, Saavn_GetArtist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) GetPlaylist(ctx context.Context, in *GetPlaylistRequest, opts ...grpc.CallOption) (*Playlist, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Playlist)
+	err := c.cc.Invoke(ctx, Saavn_GetPlaylist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) GetLyrics(ctx context.Context, in *GetLyricsRequest, opts ...grpc.CallOption) (*Lyrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Lyrics)
+	err := c.cc.Invoke(ctx, Saavn_GetLyrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Saavn_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saavnClient) ArtistDiscography(ctx context.Context, in *ArtistDiscographyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Album], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Saavn_ServiceDesc.Streams[0], Saavn_ArtistDiscography_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ArtistDiscographyRequest, Album]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Saavn_ArtistDiscographyClient = grpc.ServerStreamingClient[Album]
+
+// SaavnServer is the server API for Saavn service.
+// All implementations must embed UnimplementedSaavnServer
+// for forward compatibility.
+type SaavnServer interface {
+	GetSong(context.Context, *GetSongRequest) (*Song, error)
+	BatchGetSongs(context.Context, *BatchGetSongsRequest) (*BatchGetSongsResponse, error)
+	GetAlbum(context.Context, *GetAlbumRequest) (*Album, error)
+	GetArtist(context.Context, *GetArtistRequest) (*Artist, error)
+	GetPlaylist(context.Context, *GetPlaylistRequest) (*Playlist, error)
+	GetLyrics(context.Context, *GetLyricsRequest) (*Lyrics, error)
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	ArtistDiscography(*ArtistDiscographyRequest, grpc.ServerStreamingServer[Album]) error
+	mustEmbedUnimplementedSaavnServer()
+}
+
+// UnimplementedSaavnServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSaavnServer struct{}
+
+func (UnimplementedSaavnServer) GetSong(context.Context, *GetSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSong not implemented")
+}
+func (UnimplementedSaavnServer) BatchGetSongs(context.Context, *BatchGetSongsRequest) (*BatchGetSongsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetSongs not implemented")
+}
+func (UnimplementedSaavnServer) GetAlbum(context.Context, *GetAlbumRequest) (*Album, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAlbum not implemented")
+}
+func (UnimplementedSaavnServer) GetArtist(context.Context, *GetArtistRequest) (*Artist, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArtist not implemented")
+}
+func (UnimplementedSaavnServer) GetPlaylist(context.Context, *GetPlaylistRequest) (*Playlist, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlaylist not implemented")
+}
+func (UnimplementedSaavnServer) GetLyrics(context.Context, *GetLyricsRequest) (*Lyrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLyrics not implemented")
+}
+func (UnimplementedSaavnServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedSaavnServer) ArtistDiscography(*ArtistDiscographyRequest, grpc.ServerStreamingServer[Album]) error {
+	return status.Errorf(codes.Unimplemented, "method ArtistDiscography not implemented")
+}
+func (UnimplementedSaavnServer) mustEmbedUnimplementedSaavnServer() {}
+func (UnimplementedSaavnServer) testEmbeddedByValue()               {}
+
+// UnsafeSaavnServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SaavnServer will
+// result in compilation errors.
+type UnsafeSaavnServer interface {
+	mustEmbedUnimplementedSaavnServer()
+}
+
+func RegisterSaavnServer(s grpc.ServiceRegistrar, srv SaavnServer) {
+	// If the following call pancis, it indicates UnimplementedSaavnServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Saavn_ServiceDesc, srv)
+}
+
+func _Saavn_GetSong_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).GetSong(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_GetSong_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).GetSong(ctx, req.(*GetSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_BatchGetSongs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetSongsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).BatchGetSongs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_BatchGetSongs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).BatchGetSongs(ctx, req.(*BatchGetSongsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_GetAlbum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAlbumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).GetAlbum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_GetAlbum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).GetAlbum(ctx, req.(*GetAlbumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_GetArtist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArtistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).GetArtist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_GetArtist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).GetArtist(ctx, req.(*GetArtistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_GetPlaylist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlaylistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).GetPlaylist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_GetPlaylist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).GetPlaylist(ctx, req.(*GetPlaylistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_GetLyrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLyricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).GetLyrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_GetLyrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).GetLyrics(ctx, req.(*GetLyricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaavnServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Saavn_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaavnServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Saavn_ArtistDiscography_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ArtistDiscographyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SaavnServer).ArtistDiscography(m, &grpc.GenericServerStream[ArtistDiscographyRequest, Album]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Saavn_ArtistDiscographyServer = grpc.ServerStreamingServer[Album]
+
+// Saavn_ServiceDesc is the grpc.ServiceDesc for Saavn service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Saavn_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "saavn.v1.Saavn",
+	HandlerType: (*SaavnServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSong",
+			Handler:    _Saavn_GetSong_Handler,
+		},
+		{
+			MethodName: "BatchGetSongs",
+			Handler:    _Saavn_BatchGetSongs_Handler,
+		},
+		{
+			MethodName: "GetAlbum",
+			Handler:    _Saavn_GetAlbum_Handler,
+		},
+		{
+			MethodName: "GetArtist",
+			Handler:    _Saavn_GetArtist_Handler,
+		},
+		{
+			MethodName: "GetPlaylist",
+			Handler:    _Saavn_GetPlaylist_Handler,
+		},
+		{
+			MethodName: "GetLyrics",
+			Handler:    _Saavn_GetLyrics_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Saavn_Search_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ArtistDiscography",
+			Handler:       _Saavn_ArtistDiscography_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "saavn.proto",
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"jioSaavnAPI/config"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/services"
+	"jioSaavnAPI/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// settingFlags are the flags of a command that override settings, collected
+// by the name of their environment variable. Flags take precedence over
+// environment variables, which take precedence over the config file.
+type settingFlags map[string]string
+
+// newSettingFlags adds the -config and -set flags to a flag set
+func newSettingFlags(fs *flag.FlagSet) settingFlags {
+	settings := settingFlags{}
+	settings.add(fs, "config", "CONFIG_FILE", "read settings from a `file` of KEY=value lines")
+	fs.Func("set", "override a setting, as `KEY=value` named by its environment variable; repeatable", func(value string) error {
+		key, value, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return errors.New("expected KEY=value")
+		}
+		settings[strings.TrimSpace(key)] = value
+		return nil
+	})
+	return settings
+}
+
+// add adds a flag overriding the setting named key
+func (settings settingFlags) add(fs *flag.FlagSet, name, key, usage string) {
+	fs.Func(name, usage+" ("+key+")", func(value string) error {
+		settings[key] = value
+		return nil
+	})
+}
+
+// addBool adds a boolean flag overriding the setting named key
+func (settings settingFlags) addBool(fs *flag.FlagSet, name, key, usage string) {
+	fs.BoolFunc(name, usage+" ("+key+")", func(value string) error {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return err
+		}
+		settings[key] = value
+		return nil
+	})
+}
+
+// load applies the settings and returns the configuration
+func (settings settingFlags) load(name string) (*config.Config, bool) {
+	if err := config.Override(settings); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return nil, false
+	}
+	services.Configure()
+	return config.LoadConfig(), true
+}
+
+// runServeCommand runs the API server
+func runServeCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jioSaavnAPI [serve] [flags]")
+		fmt.Fprintln(fs.Output(), "Runs the API server, and the gRPC, MPD and DLNA servers when they are enabled.")
+		fs.PrintDefaults()
+	}
+	settings := newSettingFlags(fs)
+	settings.add(fs, "port", "SERVER_PORT", "`port` of the HTTP server")
+	settings.add(fs, "grpc-port", "GRPC_PORT", "`port` of the gRPC server; disabled when empty")
+	settings.add(fs, "mpd-addr", "MPD_ADDR", "TCP `address` of the MPD server; disabled when empty")
+	settings.addBool(fs, "dlna", "DLNA_ENABLED", "announce the DLNA media server on the local network")
+	settings.add(fs, "public-url", "PUBLIC_BASE_URL", "public `URL` of the server, used to build absolute links")
+	settings.add(fs, "keep-alive-url", "KEEP_ALIVE_URL", "`URL` pinged to keep the instance from idling; disabled when empty")
+	settings.add(fs, "keep-alive-interval", "KEEP_ALIVE_INTERVAL", "`interval` between keep-alive pings")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, ok := settings.load("serve")
+	if !ok {
+		return 1
+	}
+	// The server still starts with invalid settings, which fall back to
+	// their defaults, as it always has
+	for _, err := range cfg.Validate() {
+		log.Printf("⚠️ Config: %v", err)
+	}
+	if cfg.ConfigFile != "" {
+		log.Printf("Settings read from %s", cfg.ConfigFile)
+	}
+	return serve(cfg)
+}
+
+// runConfigCommand prints or validates the settings
+func runConfigCommand(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "print":
+			return runConfigPrintCommand(args[1:])
+		case "validate":
+			return runConfigValidateCommand(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  jioSaavnAPI config print [flags]")
+	fmt.Fprintln(os.Stderr, "  jioSaavnAPI config validate [flags]")
+	return 2
+}
+
+// runConfigPrintCommand prints every setting with its source, as a config file
+func runConfigPrintCommand(args []string) int {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jioSaavnAPI config print [flags]")
+		fmt.Fprintln(fs.Output(), "Prints the settings as a config file, each with the source it was taken from: flag, env, file or default.")
+		fs.PrintDefaults()
+	}
+	settings := newSettingFlags(fs)
+	asJSON := fs.Bool("json", false, "print the settings as JSON")
+	showSecrets := fs.Bool("show-secrets", false, "print secret settings instead of redacting them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, ok := settings.load("config print")
+	if !ok {
+		return 1
+	}
+
+	printed := append([]config.Setting{}, cfg.Settings()...)
+	for i, setting := range printed {
+		if setting.Secret && setting.Value != "" && !*showSecrets {
+			printed[i].Value = "REDACTED"
+		}
+	}
+
+	if *asJSON {
+		type jsonSetting struct {
+			Key    string `json:"key"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		}
+		list := make([]jsonSetting, len(printed))
+		for i, setting := range printed {
+			list[i] = jsonSetting{setting.Key, setting.Value, setting.Source}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			fmt.Fprintln(os.Stderr, "config print:", err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, setting := range printed {
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", setting.Key, quoteSetting(setting.Value), setting.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "config print:", err)
+		return 1
+	}
+	return 0
+}
+
+// quoteSetting quotes a value that would not read back as is from a config file
+func quoteSetting(value string) string {
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "#\"'\\\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// runConfigValidateCommand checks the settings, exiting with 1 when one is invalid
+func runConfigValidateCommand(args []string) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jioSaavnAPI config validate [flags]")
+		fmt.Fprintln(fs.Output(), "Checks the settings, and with -online that the decryption key decrypts the media URL of a song.")
+		fs.PrintDefaults()
+	}
+	settings := newSettingFlags(fs)
+	online := fs.Bool("online", false, "fetch a song from the upstream API and decrypt its media URL")
+	songID := fs.String("song", "3IoDK8qI", "`ID` of the song fetched by -online")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the -online check")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, ok := settings.load("config validate")
+	if !ok {
+		return 1
+	}
+
+	errs := cfg.Validate()
+	if _, err := match.ParseScripts(strings.Join(cfg.SearchScripts, ",")); err != nil {
+		errs = append(errs, fmt.Errorf("SEARCH_SCRIPTS: %v", err))
+	}
+	if *online && len(errs) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		if err := checkDecryption(ctx, cfg, *songID); err != nil {
+			errs = append(errs, fmt.Errorf("DECRYPTION_KEY: %v", err))
+		}
+	}
+
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%d invalid settings\n", len(errs))
+		return 1
+	}
+	fmt.Println("settings are valid")
+	return 0
+}
+
+// checkDecryption checks that the decryption key decrypts the media URL of a
+// song served by the upstream API
+func checkDecryption(ctx context.Context, cfg *config.Config, id string) error {
+	songData, err := saavn.NewClient(cfg.JioSaavnBaseURL).FetchSong(ctx, id)
+	if err != nil {
+		return fmt.Errorf("could not fetch song %s to check the key: %w", id, err)
+	}
+	encrypted := utils.GetString(songData, "encrypted_media_url")
+	if encrypted == "" {
+		return fmt.Errorf("song %s has no encrypted media URL to check the key with", id)
+	}
+	if _, err := utils.DecryptMediaURL(encrypted); err != nil {
+		return fmt.Errorf("does not decrypt the media URL of song %s: %w", id, err)
+	}
+	return nil
+}
+
+// serverFlags are the flags of the commands that call a running server
+type serverFlags struct {
+	settings settingFlags
+	url      *string
+	timeout  *time.Duration
+}
+
+// newServerFlags adds the flags of the commands that call a running server
+func newServerFlags(fs *flag.FlagSet) serverFlags {
+	return serverFlags{
+		settings: newSettingFlags(fs),
+		url:      fs.String("url", "", "base `URL` of the server; http://localhost:SERVER_PORT when empty"),
+		timeout:  fs.Duration("timeout", 5*time.Second, "request timeout"),
+	}
+}
+
+// baseURL returns the base URL of the server to call
+func (f serverFlags) baseURL(cfg *config.Config) string {
+	if *f.url != "" {
+		return strings.TrimSuffix(*f.url, "/")
+	}
+	return "http://localhost:" + cfg.ServerPort
+}
+
+// runCacheCommand shows or purges the caches of a running server
+func runCacheCommand(args []string) int {
+	fs := flag.NewFlagSet("cache", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage:")
+		fmt.Fprintln(fs.Output(), "  jioSaavnAPI cache stats [flags]")
+		fmt.Fprintln(fs.Output(), "  jioSaavnAPI cache purge [flags]")
+		fmt.Fprintln(fs.Output(), "Shows or empties the in-memory caches of a running server, authenticating with ADMIN_TOKEN.")
+		fs.PrintDefaults()
+	}
+	server := newServerFlags(fs)
+	token := fs.String("token", "", "admin `token`; ADMIN_TOKEN when empty")
+	cache := fs.String("cache", "", "`name` of the cache purge empties; all when empty")
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "purge") {
+		fs.Usage()
+		return 2
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	cfg, ok := server.settings.load("cache")
+	if !ok {
+		return 1
+	}
+	if *token == "" {
+		*token = cfg.AdminToken
+	}
+
+	method, path := http.MethodGet, "/cache/stats"
+	if action == "purge" {
+		method, path = http.MethodPost, "/cache/purge"
+		if *cache != "" {
+			path += "?cache=" + *cache
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *server.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, server.baseURL(cfg)+path, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cache:", err)
+		return 1
+	}
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+
+	var body struct {
+		Success bool                       `json:"success"`
+		Error   string                     `json:"error"`
+		Data    map[string]json.RawMessage `json:"data"`
+	}
+	if err := doJSON(req, &body); err != nil {
+		fmt.Fprintln(os.Stderr, "cache:", err)
+		return 1
+	}
+	if !body.Success {
+		fmt.Fprintln(os.Stderr, "cache:", body.Error)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if action == "purge" {
+		var purged map[string]int
+		if err := json.Unmarshal(body.Data["purged"], &purged); err != nil {
+			fmt.Fprintln(os.Stderr, "cache:", err)
+			return 1
+		}
+		fmt.Fprintln(tw, "CACHE\tPURGED")
+		for name, count := range purged {
+			fmt.Fprintf(tw, "%s\t%d\n", name, count)
+		}
+	} else {
+		fmt.Fprintln(tw, "CACHE\tENTRIES\tEXPIRED\tTTL")
+		for name, raw := range body.Data {
+			var stats utils.MediaCacheStats
+			if err := json.Unmarshal(raw, &stats); err != nil {
+				fmt.Fprintln(os.Stderr, "cache:", err)
+				return 1
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", name, stats.Entries, stats.Expired, stats.TTL)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "cache:", err)
+		return 1
+	}
+	return 0
+}
+
+// runHealthcheckCommand checks the /health route of a running server and
+// exits with 1 when it is not healthy, for container health checks
+func runHealthcheckCommand(args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jioSaavnAPI healthcheck [flags]")
+		fmt.Fprintln(fs.Output(), "Exits with 0 when a running server reports that it is healthy and 1 otherwise.")
+		fs.PrintDefaults()
+	}
+	server := newServerFlags(fs)
+	quiet := fs.Bool("quiet", false, "print nothing when the server is healthy")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, ok := server.settings.load("healthcheck")
+	if !ok {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *server.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.baseURL(cfg)+"/health", nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		return 1
+	}
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := doJSON(req, &health); err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		return 1
+	}
+	if health.Status != "ok" {
+		fmt.Fprintf(os.Stderr, "healthcheck: server status is %q\n", health.Status)
+		return 1
+	}
+	if !*quiet {
+		fmt.Println("ok")
+	}
+	return 0
+}
+
+// doJSON sends a request and decodes its JSON response, which may be an
+// error payload. Responses that are not JSON are errors.
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("server returned status %d with a response that is not JSON", resp.StatusCode)
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
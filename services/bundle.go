@@ -0,0 +1,478 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBundleTrackSize bounds how much of a single track is buffered while a bundle is built
+const maxBundleTrackSize = 100 << 20
+
+// bundle describes the tracks of an album or playlist ZIP download
+type bundle struct {
+	ID          string
+	Type        string
+	Name        string
+	AlbumArtist string
+	CoverURL    string
+	SongIDs     []string
+}
+
+// bundleTrack is the result of fetching one track of a bundle
+type bundleTrack struct {
+	Position int
+	ID       string
+	Name     string
+	Artist   string
+	Duration int
+	Quality  string
+	File     string
+	Data     []byte
+	Err      error
+}
+
+// bundleManifest is written to manifest.json at the end of every bundle
+type bundleManifest struct {
+	ID          string                  `json:"id"`
+	Type        string                  `json:"type"`
+	Name        string                  `json:"name"`
+	Quality     string                  `json:"quality"`
+	CreatedAt   string                  `json:"createdAt"`
+	TrackCount  int                     `json:"trackCount"`
+	Downloaded  int                     `json:"downloaded"`
+	Tracks      []bundleManifestTrack   `json:"tracks"`
+	Failed      []bundleManifestFailure `json:"failed"`
+	CoverFailed bool                    `json:"coverFailed,omitempty"`
+}
+
+type bundleManifestTrack struct {
+	Position int    `json:"position"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Quality  string `json:"quality"`
+}
+
+type bundleManifestFailure struct {
+	Position int    `json:"position"`
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Error    string `json:"error"`
+}
+
+// AlbumZipHandler downloads all songs of an album as a ZIP archive
+// @Summary      Download an album as ZIP
+// @Description  Streams a ZIP archive with the tagged tracks of an album, cover.jpg, an M3U8 playlist and a manifest.json listing any tracks that could not be downloaded
+// @Tags         Media
+// @Produce      application/zip
+// @Param        id       path      string  true   "Album ID"
+// @Param        quality  query     string  false  "Bitrate in kbps: 12, 48, 96, 160, 320" default(320)
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /album/{id}/download.zip [get]
+func AlbumZipHandler(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing album ID",
+		})
+		return
+	}
+
+	albumData, err := library.FetchAlbum(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch album"
+		if errors.Is(err, saavn.ErrAlbumNotFound) {
+			status = http.StatusNotFound
+			message = "Album not found"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	b := bundle{
+		ID:          id,
+		Type:        "album",
+		Name:        html.UnescapeString(strings.TrimSpace(utils.GetString(albumData, "title"))),
+		AlbumArtist: html.UnescapeString(utils.GetString(albumData, "primary_artists")),
+		CoverURL:    bundleCoverURL(utils.GetString(albumData, "image")),
+	}
+	if b.Name == "" {
+		b.Name = html.UnescapeString(strings.TrimSpace(utils.GetString(albumData, "name")))
+	}
+	if songs, ok := albumData["songs"].([]interface{}); ok {
+		for _, s := range songs {
+			if songMap, ok := s.(map[string]interface{}); ok {
+				b.SongIDs = append(b.SongIDs, utils.GetString(songMap, "id"))
+			}
+		}
+	}
+
+	serveBundle(c, b)
+}
+
+// PlaylistZipHandler downloads all songs of a playlist as a ZIP archive
+// @Summary      Download a playlist as ZIP
+// @Description  Streams a ZIP archive with the tagged tracks of a playlist, cover.jpg, an M3U8 playlist and a manifest.json listing any tracks that could not be downloaded
+// @Tags         Media
+// @Produce      application/zip
+// @Param        token    path      string  true   "Playlist Token"
+// @Param        quality  query     string  false  "Bitrate in kbps: 12, 48, 96, 160, 320" default(320)
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /playlists/{token}/download.zip [get]
+func PlaylistZipHandler(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing token",
+		})
+		return
+	}
+
+	raw, err := library.FetchPlaylist(c.Request.Context(), token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to fetch playlist"
+		if errors.Is(err, saavn.ErrPlaylistNotFound) {
+			status = http.StatusNotFound
+			message = "Playlist not found"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	b := bundle{
+		ID:       utils.GetString(raw, "id"),
+		Type:     "playlist",
+		Name:     html.UnescapeString(strings.TrimSpace(utils.GetString(raw, "title"))),
+		CoverURL: bundleCoverURL(utils.GetString(raw, "image")),
+		SongIDs:  playlistSongIDs(raw),
+	}
+	if b.ID == "" {
+		b.ID = token
+	}
+
+	serveBundle(c, b)
+}
+
+// playlistSongIDs returns the song IDs of a raw webapi.get playlist, falling
+// back to more_info.contents when the list is empty
+func playlistSongIDs(raw map[string]interface{}) []string {
+	ids := []string{}
+	if list, ok := raw["list"].([]interface{}); ok {
+		for _, item := range list {
+			if songMap, ok := item.(map[string]interface{}); ok {
+				if id := utils.GetString(songMap, "id"); id != "" {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	if len(ids) > 0 {
+		return ids
+	}
+
+	if moreInfo, ok := raw["more_info"].(map[string]interface{}); ok {
+		for _, song := range utils.FormatPlaylistFromContents(moreInfo["contents"]) {
+			if id := strings.TrimSpace(song.ID); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// bundleCoverURL returns the 500x500 variant of an album or playlist image
+func bundleCoverURL(imageURL string) string {
+	images := utils.BuildImageArray(imageURL)
+	if len(images) == 0 {
+		return ""
+	}
+	return images[len(images)-1]["url"]
+}
+
+// serveBundle streams the ZIP archive of a bundle. Tracks are fetched
+// concurrently, at most BUNDLE_CONCURRENCY at a time, and written in order as
+// they become ready. Failed tracks are listed in manifest.json instead of
+// aborting the download.
+func serveBundle(c *gin.Context, b bundle) {
+	quality := strings.TrimSuffix(c.DefaultQuery("quality", "320"), "kbps")
+	if !validQuality(quality) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid quality. Use one of: " + strings.Join(utils.MediaQualities, ", "),
+		})
+		return
+	}
+	if len(b.SongIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "No songs to download",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	folder := sanitizeFilename(b.Name)
+	if b.Name == "" {
+		folder = sanitizeFilename(b.Type + " " + b.ID)
+	}
+
+	covers := &coverCache{images: map[string][]byte{}}
+	cover := covers.get(ctx, b.CoverURL)
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": folder + ".zip"}))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	manifest := bundleManifest{
+		ID:         b.ID,
+		Type:       b.Type,
+		Name:       b.Name,
+		Quality:    quality + "kbps",
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		TrackCount: len(b.SongIDs),
+		Tracks:     []bundleManifestTrack{},
+		Failed:     []bundleManifestFailure{},
+	}
+
+	if len(cover) > 0 {
+		if err := writeZipFile(zw, folder+"/cover.jpg", cover, zip.Store); err != nil {
+			c.Error(err)
+			return
+		}
+	} else {
+		manifest.CoverFailed = true
+	}
+
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n")
+
+	for track := range fetchBundleTracks(ctx, b, quality, covers) {
+		if ctx.Err() != nil {
+			return
+		}
+		if track.Err != nil {
+			manifest.Failed = append(manifest.Failed, bundleManifestFailure{
+				Position: track.Position,
+				ID:       track.ID,
+				Name:     track.Name,
+				Error:    track.Err.Error(),
+			})
+			continue
+		}
+
+		// Audio is already compressed, so it is stored as is
+		if err := writeZipFile(zw, folder+"/"+track.File, track.Data, zip.Store); err != nil {
+			c.Error(err)
+			return
+		}
+		manifest.Downloaded++
+		manifest.Tracks = append(manifest.Tracks, bundleManifestTrack{
+			Position: track.Position,
+			ID:       track.ID,
+			Name:     track.Name,
+			File:     track.File,
+			Quality:  track.Quality + "kbps",
+		})
+		fmt.Fprintf(&playlist, "#EXTINF:%d,%s - %s\n%s\n", track.Duration, track.Artist, track.Name, track.File)
+	}
+
+	manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := writeZipFile(zw, folder+"/"+folder+".m3u8", []byte(playlist.String()), zip.Deflate); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := writeZipFile(zw, folder+"/manifest.json", manifestJSON, zip.Deflate); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// fetchBundleTracks fetches the tracks of a bundle and delivers them in order.
+// A slot is only released once a track has been consumed, so at most
+// BundleConcurrency tracks are downloading or buffered at any time.
+func fetchBundleTracks(ctx context.Context, b bundle, quality string, covers *coverCache) <-chan bundleTrack {
+	concurrency := cfg.BundleConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+	results := make([]chan bundleTrack, len(b.SongIDs))
+	for i := range results {
+		results[i] = make(chan bundleTrack, 1)
+	}
+
+	go func() {
+		for i, id := range b.SongIDs {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, id string) {
+				results[i] <- fetchBundleTrack(ctx, b, i+1, id, quality, covers)
+			}(i, id)
+		}
+	}()
+
+	out := make(chan bundleTrack)
+	go func() {
+		defer close(out)
+		for _, result := range results {
+			var track bundleTrack
+			select {
+			case track = <-result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- track:
+			case <-ctx.Done():
+				return
+			}
+			<-slots
+		}
+	}()
+	return out
+}
+
+// fetchBundleTrack downloads and tags a single track of a bundle
+func fetchBundleTrack(ctx context.Context, b bundle, position int, id, quality string, covers *coverCache) bundleTrack {
+	track := bundleTrack{Position: position, ID: id}
+
+	mediaURL, selected, songData, err := resolveSongMedia(ctx, id, quality)
+	if err != nil {
+		if reason := utils.MediaFailure(err); reason != "" {
+			err = fmt.Errorf("%w (%s)", err, reason)
+		}
+		track.Err = err
+		if songData != nil {
+			track.Name = html.UnescapeString(utils.GetString(songData, "song"))
+		}
+		return track
+	}
+
+	song := utils.FormatSongDetailed(songData)
+	tags := songTags(song)
+	tags.TrackNumber = position
+	tags.TrackTotal = len(b.SongIDs)
+	if b.Type == "album" {
+		tags.AlbumArtist = b.AlbumArtist
+	}
+	tags.Cover = covers.get(ctx, songCoverURL(song))
+	tags.Lyrics = songLyricsText(ctx, song)
+
+	track.Name = tags.Title
+	track.Artist = tags.Artist
+	track.Duration = utils.GetInt(song, "duration")
+	track.Quality = selected
+	track.File = bundleTrackFilename(position, len(b.SongIDs), tags)
+
+	body, _, err := openTaggedMedia(ctx, mediaURL, tags)
+	if err != nil {
+		track.Err = err
+		return track
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxBundleTrackSize+1))
+	if err != nil {
+		track.Err = err
+		return track
+	}
+	if len(data) > maxBundleTrackSize {
+		track.Err = errors.New("track is too large")
+		return track
+	}
+	track.Data = data
+	return track
+}
+
+// bundleTrackFilename builds a "NN - Artist - Title.m4a" name, padding the
+// position so files sort in track order
+func bundleTrackFilename(position, total int, tags utils.M4ATags) string {
+	name := tags.Title
+	if tags.Artist != "" {
+		name = tags.Artist + " - " + name
+	}
+	width := len(strconv.Itoa(total))
+	if width < 2 {
+		width = 2
+	}
+	return fmt.Sprintf("%0*d - %s.m4a", width, position, sanitizeFilename(name))
+}
+
+// writeZipFile adds a file to a ZIP archive
+func writeZipFile(zw *zip.Writer, name string, data []byte, method uint16) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, bytes.NewReader(data))
+	return err
+}
+
+// coverCache shares cover art between the tracks of a bundle, which usually
+// have the same image
+type coverCache struct {
+	mu     sync.Mutex
+	images map[string][]byte
+}
+
+func (cc *coverCache) get(ctx context.Context, imageURL string) []byte {
+	if imageURL == "" {
+		return nil
+	}
+	cc.mu.Lock()
+	cover, ok := cc.images[imageURL]
+	cc.mu.Unlock()
+	if ok {
+		return cover
+	}
+
+	cover = fetchCoverArt(ctx, imageURL)
+	cc.mu.Lock()
+	cc.images[imageURL] = cover
+	cc.mu.Unlock()
+	return cover
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"crypto/subtle"
+	"jioSaavnAPI/utils"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheNames are the caches that can be purged
+var cacheNames = []string{"media"}
+
+// CacheStatsHandler reports the size of the in-memory caches
+// @Summary      Cache statistics
+// @Description  Returns the number of entries of each in-memory cache. The media cache holds media availability checks and auth-token URLs. Requires the ADMIN_TOKEN bearer token, or a loopback client when no token is configured
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /cache/stats [get]
+func CacheStatsHandler(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"media": utils.MediaCache(),
+		},
+	})
+}
+
+// CachePurgeHandler empties the in-memory caches
+// @Summary      Purge caches
+// @Description  Empties one in-memory cache, or all of them, and returns the number of entries dropped from each. Requires the ADMIN_TOKEN bearer token, or a loopback client when no token is configured
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        cache  query     string  false  "Cache to purge: media; all when empty"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /cache/purge [post]
+func CachePurgeHandler(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+	name := c.Query("cache")
+	if name != "" && name != "media" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unknown cache. Use one of: " + strings.Join(cacheNames, ", "),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"purged": gin.H{"media": utils.PurgeMediaCache()},
+		},
+	})
+}
+
+// authorizeAdmin checks the bearer token of an admin request, or that it
+// comes from the loopback interface when no token is configured, responding
+// with an error and returning false otherwise
+func authorizeAdmin(c *gin.Context) bool {
+	if cfg.AdminToken == "" {
+		// The remote address is checked rather than the client IP, which
+		// forwarding headers can set
+		host, _, _ := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return true
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Admin routes are only served to localhost unless ADMIN_TOKEN is set",
+		})
+		return false
+	}
+
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminToken)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Missing or invalid admin token",
+		})
+		return false
+	}
+	return true
+}
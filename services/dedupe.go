@@ -0,0 +1,149 @@
+package services
+
+import (
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// canonicalRules rank the songs of a duplicate group; the first song is kept
+var canonicalRules = map[string]func(a, b map[string]interface{}) bool{
+	// Most played first, the earliest release breaking ties
+	"plays": func(a, b map[string]interface{}) bool {
+		if pa, pb := utils.GetInt(a, "playCount"), utils.GetInt(b, "playCount"); pa != pb {
+			return pa > pb
+		}
+		return releasedBefore(a, b)
+	},
+	// Earliest release first, the most played breaking ties
+	"earliest": func(a, b map[string]interface{}) bool {
+		if ra, rb := songRelease(a), songRelease(b); ra != rb {
+			return releasedBefore(a, b)
+		}
+		return utils.GetInt(a, "playCount") > utils.GetInt(b, "playCount")
+	},
+}
+
+// dedupeDurationTolerance is the largest difference in seconds between two
+// releases of the same recording
+const dedupeDurationTolerance = 5
+
+// dedupeParams reads the dedupe and canonical query parameters, responding with
+// 400 and returning ok false when dedupe is on and canonical names an unknown rule
+func dedupeParams(c *gin.Context) (dedupe bool, rule string, ok bool) {
+	if dedupe, _ = strconv.ParseBool(c.Query("dedupe")); !dedupe {
+		return false, "", true
+	}
+	rule = c.DefaultQuery("canonical", cfg.DedupeCanonical)
+	if _, known := canonicalRules[rule]; !known {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid canonical rule, expected plays or earliest",
+		})
+		return false, "", false
+	}
+	return dedupe, rule, true
+}
+
+// dedupeSongs groups releases of the same recording, identified by normalized
+// title, version, primary artists and duration. Each group is replaced by its
+// canonical song according to rule, with the other releases in "versions".
+// Groups keep the position of their first song.
+func dedupeSongs(songs []map[string]interface{}, rule string) []map[string]interface{} {
+	type group struct {
+		key      string
+		duration int
+		songs    []map[string]interface{}
+	}
+	var groups []*group
+
+	for _, song := range songs {
+		key, duration := songDedupeKey(song)
+		var found *group
+		for _, g := range groups {
+			if g.key != key {
+				continue
+			}
+			diff := g.duration - duration
+			if diff < 0 {
+				diff = -diff
+			}
+			if g.duration == 0 || duration == 0 || diff <= dedupeDurationTolerance {
+				found = g
+				break
+			}
+		}
+		if found == nil {
+			found = &group{key: key, duration: duration}
+			groups = append(groups, found)
+		}
+		if found.duration == 0 {
+			found.duration = duration
+		}
+		found.songs = append(found.songs, song)
+	}
+
+	less := canonicalRules[rule]
+	deduped := make([]map[string]interface{}, 0, len(groups))
+	for _, g := range groups {
+		sort.SliceStable(g.songs, func(i, j int) bool {
+			return less(g.songs[i], g.songs[j])
+		})
+
+		canonical := make(map[string]interface{}, len(g.songs[0])+1)
+		for k, v := range g.songs[0] {
+			canonical[k] = v
+		}
+		canonical["versions"] = g.songs[1:]
+		deduped = append(deduped, canonical)
+	}
+	return deduped
+}
+
+// songDedupeKey returns the grouping key and duration of a song. It accepts the
+// detailed song format as well as the lightweight autocomplete one.
+func songDedupeKey(song map[string]interface{}) (string, int) {
+	title := utils.GetString(song, "name")
+	if title == "" {
+		title = utils.GetString(song, "title")
+	}
+
+	var artists []string
+	switch credits := song["artists"].(type) {
+	case map[string]interface{}:
+		primary, _ := credits["primary"].([]map[string]interface{})
+		for _, artist := range primary {
+			artists = append(artists, match.NormalizeText(utils.GetString(artist, "name")))
+		}
+	case string:
+		for _, name := range match.SplitArtists(credits) {
+			artists = append(artists, match.NormalizeText(name))
+		}
+	}
+	sort.Strings(artists)
+
+	key := match.NormalizeTitle(title) + "|" + strings.Join(match.VersionTags(title), "+") + "|" + strings.Join(artists, ",")
+	return key, utils.GetInt(song, "duration")
+}
+
+// songRelease returns a sortable release date, falling back to the year
+func songRelease(song map[string]interface{}) string {
+	if date := utils.GetString(song, "releaseDate"); date != "" {
+		return date
+	}
+	return utils.GetString(song, "year")
+}
+
+// releasedBefore orders songs by release date, with undated songs last
+func releasedBefore(a, b map[string]interface{}) bool {
+	ra, rb := songRelease(a), songRelease(b)
+	if ra == "" || rb == "" {
+		return ra != ""
+	}
+	return ra < rb
+}
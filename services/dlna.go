@@ -0,0 +1,555 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"jioSaavnAPI/dlna"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dlnaPrefix is the route prefix of the device description, service
+// descriptions, control and event URLs
+const dlnaPrefix = "/dlna"
+
+// dlnaContentFeatures describes the proxied streams to renderers: byte range
+// seeking, not transcoded, streaming transfer mode
+const dlnaContentFeatures = "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000"
+
+// dlnaProtocolInfo advertises the proxied M4A streams served over HTTP
+const dlnaProtocolInfo = "http-get:*:audio/mp4:" + dlnaContentFeatures
+
+// dlnaSearchCapabilities are the properties understood in Search criteria
+const dlnaSearchCapabilities = "dc:title,dc:creator,upnp:artist,upnp:album"
+
+// dlnaMaxRecentSearches bounds the searches listed in the Search container
+const dlnaMaxRecentSearches = 20
+
+// dlnaFolders are the containers at the root of the content directory
+var dlnaFolders = []dlna.Object{
+	{ID: "charts", ParentID: "0", Title: "Charts", Class: dlna.ClassFolder, ChildCount: -1},
+	{ID: "new", ParentID: "0", Title: "New Releases", Class: dlna.ClassFolder, ChildCount: -1},
+	{ID: "playlists", ParentID: "0", Title: "Playlists", Class: dlna.ClassFolder, ChildCount: -1},
+	{ID: "search", ParentID: "0", Title: "Search", Class: dlna.ClassFolder, ChildCount: -1, Searchable: true},
+}
+
+// dlnaRecentSearches are the latest queries of Search actions, most recent first
+var dlnaRecentSearches struct {
+	sync.Mutex
+	queries []string
+}
+
+// dlnaFault is a UPnP error returned by an action
+type dlnaFault struct {
+	code    int
+	message string
+}
+
+func (f *dlnaFault) Error() string {
+	return f.message
+}
+
+// errDLNANoSuchObject is returned for object IDs outside of the content directory
+var errDLNANoSuchObject = &dlnaFault{dlna.ErrNoSuchObject, "No such object"}
+
+// dlnaAction runs a SOAP action and returns its output arguments
+type dlnaAction func(c *gin.Context, args map[string]string) ([]dlna.Arg, error)
+
+// dlnaServices are the UPnP services of the media server, by name
+var dlnaServices = map[string]struct {
+	serviceType string
+	scpd        string
+	actions     map[string]dlnaAction
+}{
+	"ContentDirectory": {dlna.ContentDirectoryType, dlna.ContentDirectorySCPD, map[string]dlnaAction{
+		"Browse": dlnaBrowse,
+		"Search": dlnaSearch,
+		"GetSearchCapabilities": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			return []dlna.Arg{{Name: "SearchCaps", Value: dlnaSearchCapabilities}}, nil
+		},
+		"GetSortCapabilities": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			return []dlna.Arg{{Name: "SortCaps", Value: ""}}, nil
+		},
+		"GetSystemUpdateID": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			return []dlna.Arg{{Name: "Id", Value: "1"}}, nil
+		},
+	}},
+	"ConnectionManager": {dlna.ConnectionManagerType, dlna.ConnectionManagerSCPD, map[string]dlnaAction{
+		"GetProtocolInfo": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			return []dlna.Arg{{Name: "Source", Value: dlnaProtocolInfo}, {Name: "Sink", Value: ""}}, nil
+		},
+		"GetCurrentConnectionIDs": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			return []dlna.Arg{{Name: "ConnectionIDs", Value: "0"}}, nil
+		},
+		"GetCurrentConnectionInfo": func(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+			if args["ConnectionID"] != "0" {
+				return nil, &dlnaFault{706, "Invalid connection reference"}
+			}
+			return []dlna.Arg{
+				{Name: "RcsID", Value: "-1"},
+				{Name: "AVTransportID", Value: "-1"},
+				{Name: "ProtocolInfo", Value: ""},
+				{Name: "PeerConnectionManager", Value: ""},
+				{Name: "PeerConnectionID", Value: "-1"},
+				{Name: "Direction", Value: "Output"},
+				{Name: "Status", Value: "OK"},
+			}, nil
+		},
+	}},
+}
+
+// StartDLNA announces the media server on the local network over SSDP when
+// DLNA_ENABLED is set, until ctx is done. The device is reachable at
+// PUBLIC_BASE_URL, or at the LAN address of this host when it is not set. The
+// returned channel is closed once the announcements have stopped and the
+// goodbye has been sent.
+func StartDLNA(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if !cfg.DLNAEnabled {
+		close(done)
+		return done
+	}
+
+	baseURL, err := lanBaseURL()
+	if err != nil {
+		log.Printf("⚠️ DLNA disabled, no LAN address: %v", err)
+		close(done)
+		return done
+	}
+
+	advertiser := &dlna.Advertiser{
+		UUID:     dlnaUUID(),
+		Location: baseURL + dlnaPrefix + "/description.xml",
+		Server:   fmt.Sprintf("%s/%s UPnP/1.0 jioSaavnAPI/1.0", runtime.GOOS, runtime.GOARCH),
+	}
+	go func() {
+		defer close(done)
+		if err := advertiser.Run(ctx); err != nil {
+			log.Printf("⚠️ DLNA announcements stopped: %v", err)
+		}
+	}()
+	log.Printf("DLNA media server %q announced at %s", cfg.DLNAFriendlyName, advertiser.Location)
+	return done
+}
+
+// lanBaseURL returns PUBLIC_BASE_URL, or the URL of this server at its LAN
+// address for clients on the local network
+func lanBaseURL() (string, error) {
+	if cfg.PublicBaseURL != "" {
+		return cfg.PublicBaseURL, nil
+	}
+	ip, err := dlna.LocalIP()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s:%s", ip, cfg.ServerPort), nil
+}
+
+// dlnaUUID derives the device UUID from the host name and friendly name, so
+// control points recognise the server across restarts
+func dlnaUUID() string {
+	host, _ := os.Hostname()
+	sum := md5.Sum([]byte(host + "/" + cfg.DLNAFriendlyName))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// dlnaEnabled responds with 404 and returns false when DLNA is disabled
+func dlnaEnabled(c *gin.Context) bool {
+	if !cfg.DLNAEnabled {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "DLNA is disabled",
+		})
+		return false
+	}
+	return true
+}
+
+// DLNADescriptionHandler serves the UPnP device description
+func DLNADescriptionHandler(c *gin.Context) {
+	if !dlnaEnabled(c) {
+		return
+	}
+	c.Data(http.StatusOK, `text/xml; charset="utf-8"`, []byte(dlna.DeviceDescription(cfg.DLNAFriendlyName, dlnaUUID(), dlnaPrefix)))
+}
+
+// DLNASCPDHandler serves the description of a UPnP service
+func DLNASCPDHandler(c *gin.Context) {
+	if !dlnaEnabled(c) {
+		return
+	}
+	service, ok := dlnaServices[strings.TrimSuffix(c.Param("service"), ".xml")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Service not found",
+		})
+		return
+	}
+	c.Data(http.StatusOK, `text/xml; charset="utf-8"`, []byte(service.scpd))
+}
+
+// DLNAControlHandler runs the SOAP actions of the ContentDirectory and ConnectionManager services
+func DLNAControlHandler(c *gin.Context) {
+	if !dlnaEnabled(c) {
+		return
+	}
+	service, ok := dlnaServices[c.Param("service")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Service not found",
+		})
+		return
+	}
+
+	c.Header("Content-Type", `text/xml; charset="utf-8"`)
+	c.Header("EXT", "")
+	action, err := dlna.ReadAction(c.Request.Body)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		dlna.WriteFault(c.Writer, dlna.ErrInvalidAction, err.Error())
+		return
+	}
+	run, ok := service.actions[action.Name]
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		dlna.WriteFault(c.Writer, dlna.ErrInvalidAction, "Invalid action")
+		return
+	}
+
+	out, err := run(c, action.Args)
+	if err != nil {
+		fault := &dlnaFault{dlna.ErrActionFailed, "Action failed"}
+		switch {
+		case errors.As(err, &fault):
+		case errors.Is(err, saavn.ErrSongNotFound), errors.Is(err, saavn.ErrAlbumNotFound), errors.Is(err, saavn.ErrPlaylistNotFound):
+			fault = errDLNANoSuchObject
+		default:
+			log.Printf("⚠️ DLNA %s failed: %v", action.Name, err)
+		}
+		c.Status(http.StatusInternalServerError)
+		dlna.WriteFault(c.Writer, fault.code, fault.message)
+		return
+	}
+	c.Status(http.StatusOK)
+	dlna.WriteResponse(c.Writer, service.serviceType, action.Name, out)
+}
+
+// DLNAEventHandler accepts event subscriptions. The content directory never
+// changes state, so no events are sent, but some control points refuse
+// servers whose subscriptions fail.
+func DLNAEventHandler(c *gin.Context) {
+	if !dlnaEnabled(c) {
+		return
+	}
+	if c.Request.Method == "SUBSCRIBE" {
+		sid := c.GetHeader("SID")
+		if sid == "" {
+			b := make([]byte, 16)
+			rand.Read(b)
+			sid = fmt.Sprintf("uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		}
+		c.Header("SID", sid)
+		c.Header("TIMEOUT", "Second-1800")
+	}
+	c.Status(http.StatusOK)
+}
+
+// dlnaBrowse lists the children of a container, or describes an object with BrowseMetadata
+func dlnaBrowse(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+	self, children, err := dlnaObject(c.Request.Context(), args["ObjectID"], dlnaBaseURL(c))
+	if err != nil {
+		return nil, err
+	}
+
+	switch args["BrowseFlag"] {
+	case "BrowseMetadata":
+		return dlnaResult([]dlna.Object{self}, 1), nil
+	case "BrowseDirectChildren":
+		return dlnaResult(dlnaPage(children, args), len(children)), nil
+	}
+	return nil, &dlnaFault{dlna.ErrInvalidArgs, "Invalid BrowseFlag"}
+}
+
+// dlnaCriteriaTerm matches the text conditions of Search criteria, e.g. dc:title contains "tum hi ho"
+var dlnaCriteriaTerm = regexp.MustCompile(`(?:dc:title|dc:creator|upnp:artist|upnp:album)\s+(?:contains|=)\s+"((?:[^"\\]|\\.)*)"`)
+
+// dlnaSearch searches the catalog for songs with the text conditions of the
+// criteria and remembers the query in the Search container
+func dlnaSearch(c *gin.Context, args map[string]string) ([]dlna.Arg, error) {
+	var terms []string
+	for _, m := range dlnaCriteriaTerm.FindAllStringSubmatch(args["SearchCriteria"], -1) {
+		terms = append(terms, strings.ReplaceAll(m[1], `\"`, `"`))
+	}
+	query := strings.Join(terms, " ")
+	if query == "" {
+		return dlnaResult(nil, 0), nil
+	}
+
+	dlnaRecentSearches.Lock()
+	queries := []string{query}
+	for _, q := range dlnaRecentSearches.queries {
+		if !strings.EqualFold(q, query) && len(queries) < dlnaMaxRecentSearches {
+			queries = append(queries, q)
+		}
+	}
+	dlnaRecentSearches.queries = queries
+	dlnaRecentSearches.Unlock()
+
+	_, results, err := dlnaObject(c.Request.Context(), "search:"+query, dlnaBaseURL(c))
+	if err != nil {
+		return nil, err
+	}
+	return dlnaResult(dlnaPage(results, args), len(results)), nil
+}
+
+// dlnaResult returns the output arguments of Browse and Search
+func dlnaResult(objects []dlna.Object, total int) []dlna.Arg {
+	return []dlna.Arg{
+		{Name: "Result", Value: dlna.DIDL(objects)},
+		{Name: "NumberReturned", Value: strconv.Itoa(len(objects))},
+		{Name: "TotalMatches", Value: strconv.Itoa(total)},
+		{Name: "UpdateID", Value: "1"},
+	}
+}
+
+// dlnaPage applies StartingIndex and RequestedCount, where a count of 0 means all
+func dlnaPage(objects []dlna.Object, args map[string]string) []dlna.Object {
+	start, _ := strconv.Atoi(args["StartingIndex"])
+	count, _ := strconv.Atoi(args["RequestedCount"])
+	start = min(max(start, 0), len(objects))
+	end := len(objects)
+	if count > 0 {
+		end = min(start+count, end)
+	}
+	return objects[start:end]
+}
+
+// dlnaBaseURL returns the URL renderers reach this server at, for stream URLs
+func dlnaBaseURL(c *gin.Context) string {
+	if cfg.PublicBaseURL != "" {
+		return cfg.PublicBaseURL
+	}
+	return "http://" + c.Request.Host
+}
+
+// dlnaObject resolves an object ID of the content directory to the object and,
+// for containers, its children. IDs are "0" for the root, the folder IDs, or
+// "album:<id>", "playlist:<id>", "search:<query>" and "song:<id>".
+func dlnaObject(ctx context.Context, objectID, baseURL string) (dlna.Object, []dlna.Object, error) {
+	if objectID == "0" {
+		root := dlna.Object{ID: "0", ParentID: "-1", Title: cfg.DLNAFriendlyName, Class: dlna.ClassFolder, ChildCount: len(dlnaFolders)}
+		return root, dlnaFolders, nil
+	}
+	for _, folder := range dlnaFolders {
+		if folder.ID == objectID {
+			children, err := dlnaFolderChildren(ctx, objectID)
+			folder.ChildCount = len(children)
+			return folder, children, err
+		}
+	}
+
+	kind, id, _ := strings.Cut(objectID, ":")
+	if id == "" {
+		return dlna.Object{}, nil, errDLNANoSuchObject
+	}
+	switch kind {
+	case "album":
+		raw, err := library.FetchAlbum(ctx, id)
+		if err != nil {
+			return dlna.Object{}, nil, err
+		}
+		album := utils.FormatAlbum(raw)
+		self := dlnaContainer(objectID, "new", album, dlna.ClassAlbum)
+		if self.Title == "" {
+			self.Title = utils.GetString(raw, "title")
+		}
+		songs, _ := album["songs"].([]map[string]interface{})
+		children := make([]dlna.Object, 0, len(songs))
+		for i, song := range songs {
+			children = append(children, dlnaTrack(song, objectID, baseURL, i+1))
+		}
+		self.ChildCount = len(children)
+		return self, children, nil
+
+	case "playlist":
+		raw, err := fetchPlaylistByID(ctx, id)
+		if err != nil {
+			return dlna.Object{}, nil, err
+		}
+		self := dlnaPlaylistContainer(raw, "playlists")
+		list, _ := raw["list"].([]interface{})
+		children := make([]dlna.Object, 0, len(list))
+		for _, entry := range list {
+			if songMap, ok := entry.(map[string]interface{}); ok {
+				children = append(children, dlnaTrack(utils.FormatSearchSong(songMap), objectID, baseURL, 0))
+			}
+		}
+		self.ChildCount = len(children)
+		return self, children, nil
+
+	case "search":
+		results, err := library.FetchSearch(ctx, id, "song", cfg.SearchScripts)
+		if err != nil {
+			return dlna.Object{}, nil, err
+		}
+		data, _ := utils.FormatSongSearch(results)["data"].(map[string]interface{})
+		songs, _ := data["results"].([]map[string]interface{})
+		children := make([]dlna.Object, 0, len(songs))
+		for _, song := range songs {
+			children = append(children, dlnaTrack(song, objectID, baseURL, 0))
+		}
+		self := dlna.Object{ID: objectID, ParentID: "search", Title: id, Class: dlna.ClassFolder, ChildCount: len(children)}
+		return self, children, nil
+
+	case "song":
+		raw, err := library.FetchSong(ctx, id)
+		if err != nil {
+			return dlna.Object{}, nil, err
+		}
+		song := utils.FormatSongDetailed(raw)
+		album, _ := song["album"].(map[string]interface{})
+		parentID := "0"
+		if albumID := utils.GetString(album, "id"); albumID != "" {
+			parentID = "album:" + albumID
+		}
+		return dlnaTrack(song, parentID, baseURL, 0), nil, nil
+	}
+	return dlna.Object{}, nil, errDLNANoSuchObject
+}
+
+// dlnaFolderChildren lists the containers of a root folder
+func dlnaFolderChildren(ctx context.Context, folderID string) ([]dlna.Object, error) {
+	children := []dlna.Object{}
+	switch folderID {
+	case "charts":
+		charts, err := fetchCharts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, chart := range charts {
+			children = append(children, dlnaPlaylistContainer(chart, folderID))
+		}
+
+	case "playlists":
+		playlists, err := fetchContentList(ctx, "content.getFeaturedPlaylists", url.Values{"fetch_from_serialized_files": {"true"}, "p": {"1"}, "n": {"50"}})
+		if err != nil {
+			return nil, err
+		}
+		for _, playlist := range playlists {
+			children = append(children, dlnaPlaylistContainer(playlist, folderID))
+		}
+
+	case "new":
+		albums, err := fetchContentList(ctx, "content.getAlbums", url.Values{"p": {"1"}, "n": {"50"}})
+		if err != nil {
+			return nil, err
+		}
+		for _, album := range albums {
+			children = append(children, dlnaContainer("album:"+utils.GetString(album, "id"), folderID, album, dlna.ClassAlbum))
+		}
+
+	case "search":
+		// Recent searches first, then the searches trending upstream
+		dlnaRecentSearches.Lock()
+		queries := append([]string{}, dlnaRecentSearches.queries...)
+		dlnaRecentSearches.Unlock()
+		if trending, err := fetchContentList(ctx, "content.getTopSearches", nil); err == nil {
+			for _, entry := range trending {
+				queries = append(queries, utils.GetString(entry, "title"))
+			}
+		}
+		seen := map[string]bool{}
+		for _, query := range queries {
+			if query == "" || seen[strings.ToLower(query)] {
+				continue
+			}
+			seen[strings.ToLower(query)] = true
+			children = append(children, dlna.Object{ID: "search:" + query, ParentID: folderID, Title: query, Class: dlna.ClassFolder, ChildCount: -1})
+		}
+	}
+	return children, nil
+}
+
+// dlnaContainer describes an album, formatted or a raw listing entry, as a container
+func dlnaContainer(objectID, parentID string, album map[string]interface{}, class string) dlna.Object {
+	container := dlna.Object{
+		ID:          objectID,
+		ParentID:    parentID,
+		Title:       utils.GetString(album, "name"),
+		Class:       class,
+		ChildCount:  -1,
+		AlbumArtURI: songCoverURL(album),
+	}
+	if container.Title == "" {
+		container.Title = utils.GetString(album, "title")
+	}
+	if image, ok := album["image"].(string); ok {
+		container.AlbumArtURI = utils.SanitizeImageURL(image, "500x500")
+	}
+	names, _ := subsonicArtists(album)
+	container.Artist = strings.Join(names, ", ")
+	if container.Artist == "" {
+		container.Artist = utils.GetString(album, "subtitle")
+	}
+	return container
+}
+
+// dlnaPlaylistContainer describes a raw playlist or chart entry as a container
+func dlnaPlaylistContainer(raw map[string]interface{}, parentID string) dlna.Object {
+	playlist := newSubsonicPlaylist(raw)
+	container := dlna.Object{
+		ID:          "playlist:" + playlist.ID,
+		ParentID:    parentID,
+		Title:       playlist.Name,
+		Class:       dlna.ClassPlaylist,
+		ChildCount:  playlist.SongCount,
+		AlbumArtURI: utils.SanitizeImageURL(utils.GetString(raw, "image"), "500x500"),
+	}
+	if container.ChildCount == 0 {
+		container.ChildCount = -1
+	}
+	return container
+}
+
+// dlnaTrack describes a formatted song as an item streamed through the proxy
+func dlnaTrack(song map[string]interface{}, parentID, baseURL string, trackNumber int) dlna.Object {
+	id := utils.GetString(song, "id")
+	album, _ := song["album"].(map[string]interface{})
+	names, _ := subsonicArtists(song)
+	return dlna.Object{
+		ID:          "song:" + id,
+		ParentID:    parentID,
+		Title:       utils.GetString(song, "name"),
+		Class:       dlna.ClassTrack,
+		Artist:      strings.Join(names, ", "),
+		Album:       utils.GetString(album, "name"),
+		AlbumArtURI: songCoverURL(song),
+		TrackNumber: trackNumber,
+		Resource: &dlna.Resource{
+			URL:          utils.StreamURL(baseURL, id, "320"),
+			ProtocolInfo: dlnaProtocolInfo,
+			Duration:     utils.GetInt(song, "duration"),
+		},
+	}
+}
+
+// fetchContentList returns the raw entries of a content.* listing
+func fetchContentList(ctx context.Context, call string, params url.Values) ([]map[string]interface{}, error) {
+	return library.FetchContentList(ctx, call, params)
+}
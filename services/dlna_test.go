@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jioSaavnAPI/dlna"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newDLNATestServer serves the DLNA control routes backed by a fake upstream
+// listing albums album-1 to album-<albums>, and returns the ContentDirectory
+// control URL
+func newDLNATestServer(t *testing.T, albums int) string {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("__call") {
+		case "content.getAlbums":
+			list := []map[string]interface{}{}
+			for i := 1; i <= albums; i++ {
+				list = append(list, map[string]interface{}{"id": strconv.Itoa(i), "title": fmt.Sprintf("Album %d", i), "type": "album"})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": list})
+		case "content.getAlbumDetails":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":    r.URL.Query().Get("albumid"),
+				"title": "Album " + r.URL.Query().Get("albumid"),
+				"songs": []map[string]interface{}{
+					{"id": "s1", "song": "First Song", "duration": "200"},
+					{"id": "s2", "song": "Second Song", "duration": "180"},
+				},
+			})
+		default:
+			json.NewEncoder(w).Encode([]map[string]interface{}{})
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	enabled, baseURL, publicURL := cfg.DLNAEnabled, library.BaseURL, cfg.PublicBaseURL
+	cfg.DLNAEnabled, library.BaseURL, cfg.PublicBaseURL = true, upstream.URL, ""
+	t.Cleanup(func() {
+		cfg.DLNAEnabled, library.BaseURL, cfg.PublicBaseURL = enabled, baseURL, publicURL
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/dlna/control/:service", DLNAControlHandler)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server.URL + "/dlna/control/ContentDirectory"
+}
+
+// browse calls Browse and returns the parsed objects and TotalMatches
+func browse(t *testing.T, controlURL, objectID, flag string, start, count int) ([]dlna.Object, int) {
+	t.Helper()
+	out, err := dlna.Call(context.Background(), controlURL, dlna.ContentDirectoryType, "Browse", []dlna.Arg{
+		{Name: "ObjectID", Value: objectID},
+		{Name: "BrowseFlag", Value: flag},
+		{Name: "Filter", Value: "*"},
+		{Name: "StartingIndex", Value: strconv.Itoa(start)},
+		{Name: "RequestedCount", Value: strconv.Itoa(count)},
+		{Name: "SortCriteria", Value: ""},
+	})
+	if err != nil {
+		t.Fatalf("Browse %s: %v", objectID, err)
+	}
+	objects, err := dlna.ParseDIDL(out["Result"])
+	if err != nil {
+		t.Fatalf("Browse %s: %v", objectID, err)
+	}
+	if returned, _ := strconv.Atoi(out["NumberReturned"]); returned != len(objects) {
+		t.Errorf("Browse %s: NumberReturned = %d, Result has %d objects", objectID, returned, len(objects))
+	}
+	total, _ := strconv.Atoi(out["TotalMatches"])
+	return objects, total
+}
+
+func objectIDs(objects []dlna.Object) string {
+	ids := make([]string, len(objects))
+	for i, o := range objects {
+		ids[i] = o.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestDLNABrowseRoot(t *testing.T) {
+	controlURL := newDLNATestServer(t, 3)
+
+	objects, total := browse(t, controlURL, "0", "BrowseDirectChildren", 0, 0)
+	if got, want := objectIDs(objects), "charts,new,playlists,search"; got != want {
+		t.Errorf("root children = %s, want %s", got, want)
+	}
+	if total != len(dlnaFolders) {
+		t.Errorf("TotalMatches = %d, want %d", total, len(dlnaFolders))
+	}
+	for _, o := range objects {
+		if o.ParentID != "0" || !o.IsContainer() {
+			t.Errorf("root child %+v is not a container of the root", o)
+		}
+	}
+	if search := objects[3]; !search.Searchable {
+		t.Errorf("search folder is not searchable")
+	}
+}
+
+func TestDLNABrowseMetadata(t *testing.T) {
+	controlURL := newDLNATestServer(t, 3)
+
+	objects, total := browse(t, controlURL, "0", "BrowseMetadata", 0, 0)
+	if len(objects) != 1 || total != 1 {
+		t.Fatalf("BrowseMetadata returned %d objects, TotalMatches %d, want 1", len(objects), total)
+	}
+	root := objects[0]
+	if root.ID != "0" || root.ParentID != "-1" || root.ChildCount != len(dlnaFolders) {
+		t.Errorf("root = %+v", root)
+	}
+}
+
+func TestDLNABrowseFolder(t *testing.T) {
+	controlURL := newDLNATestServer(t, 3)
+
+	objects, total := browse(t, controlURL, "new", "BrowseDirectChildren", 0, 0)
+	if got, want := objectIDs(objects), "album:1,album:2,album:3"; got != want {
+		t.Errorf("new releases = %s, want %s", got, want)
+	}
+	if total != 3 {
+		t.Errorf("TotalMatches = %d, want 3", total)
+	}
+	if objects[0].Class != dlna.ClassAlbum || objects[0].Title != "Album 1" {
+		t.Errorf("album = %+v", objects[0])
+	}
+
+	tracks, _ := browse(t, controlURL, "album:2", "BrowseDirectChildren", 0, 0)
+	if got, want := objectIDs(tracks), "song:s1,song:s2"; got != want {
+		t.Fatalf("album tracks = %s, want %s", got, want)
+	}
+	track := tracks[0]
+	if track.Title != "First Song" || track.ParentID != "album:2" || track.TrackNumber != 1 {
+		t.Errorf("track = %+v", track)
+	}
+	if track.Resource == nil || !strings.Contains(track.Resource.URL, "/stream/s1") || track.Resource.Duration != 200 {
+		t.Errorf("track resource = %+v", track.Resource)
+	}
+}
+
+func TestDLNABrowsePaging(t *testing.T) {
+	controlURL := newDLNATestServer(t, 5)
+
+	objects, total := browse(t, controlURL, "new", "BrowseDirectChildren", 1, 2)
+	if got, want := objectIDs(objects), "album:2,album:3"; got != want {
+		t.Errorf("page = %s, want %s", got, want)
+	}
+	if total != 5 {
+		t.Errorf("TotalMatches = %d, want 5", total)
+	}
+
+	objects, _ = browse(t, controlURL, "new", "BrowseDirectChildren", 4, 10)
+	if got, want := objectIDs(objects), "album:5"; got != want {
+		t.Errorf("last page = %s, want %s", got, want)
+	}
+	objects, _ = browse(t, controlURL, "new", "BrowseDirectChildren", 9, 10)
+	if len(objects) != 0 {
+		t.Errorf("page past the end = %s, want none", objectIDs(objects))
+	}
+}
+
+func TestDLNABrowseInvalidObject(t *testing.T) {
+	controlURL := newDLNATestServer(t, 3)
+
+	for _, objectID := range []string{"bogus", "album:", "folder:1"} {
+		_, err := dlna.Call(context.Background(), controlURL, dlna.ContentDirectoryType, "Browse", []dlna.Arg{
+			{Name: "ObjectID", Value: objectID},
+			{Name: "BrowseFlag", Value: "BrowseDirectChildren"},
+		})
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("UPnP error %d", dlna.ErrNoSuchObject)) {
+			t.Errorf("Browse %q: err = %v, want UPnP error %d", objectID, err, dlna.ErrNoSuchObject)
+		}
+	}
+
+	_, err := dlna.Call(context.Background(), controlURL, dlna.ContentDirectoryType, "Browse", []dlna.Arg{
+		{Name: "ObjectID", Value: "0"},
+		{Name: "BrowseFlag", Value: "BrowseEverything"},
+	})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("UPnP error %d", dlna.ErrInvalidArgs)) {
+		t.Errorf("invalid BrowseFlag: err = %v, want UPnP error %d", err, dlna.ErrInvalidArgs)
+	}
+}
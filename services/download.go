@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"jioSaavnAPI/utils"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadHandler serves the audio file of a song
+// @Summary      Download a song
+// @Description  Redirects to (or proxies, depending on DOWNLOAD_MODE) the media file of a song. With tagged=true the file is always proxied with iTunes metadata written into it. When signed downloads are enabled, exp and sig must be those of a link issued by this API
+// @Tags         Media
+// @Produce      audio/mp4
+// @Param        id       path      string  true   "Song ID"
+// @Param        quality  query     string  false  "Bitrate in kbps: 12, 48, 96, 160, 320" default(320)
+// @Param        exp      query     int     false  "Link expiry as a Unix timestamp (signed links)"
+// @Param        sig      query     string  false  "Link signature (signed links)"
+// @Param        tagged   query     bool    false  "Embed title, artists, album, year, track number, copyright, lyrics and cover art into the M4A"
+// @Success      200  {file}    binary
+// @Success      302  {string}  string
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      410  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /download/{id} [get]
+func DownloadHandler(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing song ID",
+		})
+		return
+	}
+
+	quality := strings.TrimSuffix(c.DefaultQuery("quality", "320"), "kbps")
+	if !validQuality(quality) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid quality. Use one of: " + strings.Join(utils.MediaQualities, ", "),
+		})
+		return
+	}
+
+	if !verifySignedLink(c, "download", id, quality) {
+		return
+	}
+
+	mediaURL, selected, songData, err := resolveSongMedia(c.Request.Context(), id, quality)
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+
+	c.Header("X-Media-Quality", selected+"kbps")
+	if c.Query("tagged") == "true" {
+		serveTaggedDownload(c, mediaURL, songData)
+		return
+	}
+	if cfg.DownloadMode != "proxy" {
+		c.Redirect(http.StatusFound, mediaURL)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadFilename(songData, ".m4a")}))
+	proxyMedia(c, mediaURL, 0)
+}
+
+// serveTaggedDownload streams the media file with the song metadata embedded
+func serveTaggedDownload(c *gin.Context, mediaURL string, songData map[string]interface{}) {
+	ctx := c.Request.Context()
+	filename := downloadFilename(songData, ".m4a")
+	song := utils.FormatSongDetailed(songData)
+	tags := fetchSongTags(ctx, song)
+
+	body, size, err := openTaggedMedia(ctx, mediaURL, tags)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.Abort()
+			return
+		}
+		status := http.StatusBadGateway
+		message := "Failed to fetch media"
+		if errors.Is(err, utils.ErrNotMP4) {
+			status = http.StatusUnprocessableEntity
+			message = "Media file cannot be tagged"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+	defer body.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "audio/mp4")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if size >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, body); err != nil && ctx.Err() == nil {
+		c.Error(err)
+	}
+}
+
+// openTaggedMedia fetches an M4A file and returns it with tags written in.
+// The returned size is -1 when it cannot be known before streaming.
+func openTaggedMedia(ctx context.Context, mediaURL string, tags utils.M4ATags) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := mediaClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("media server returned status: %d", resp.StatusCode)
+	}
+
+	tagged, delta, sizeKnown, err := utils.TagM4A(resp.Body, tags)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+
+	size := int64(-1)
+	if sizeKnown && resp.ContentLength >= 0 {
+		size = resp.ContentLength + delta
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{tagged, resp.Body}, size, nil
+}
+
+// songTags maps a formatted song onto M4A tags
+func songTags(song map[string]interface{}) utils.M4ATags {
+	tags := utils.M4ATags{
+		Title:     html.UnescapeString(utils.GetString(song, "name")),
+		Year:      utils.GetString(song, "year"),
+		Copyright: html.UnescapeString(utils.GetString(song, "copyright")),
+	}
+	if album, ok := song["album"].(map[string]interface{}); ok {
+		tags.Album = html.UnescapeString(utils.GetString(album, "name"))
+	}
+	if artists, ok := song["artists"].(map[string]interface{}); ok {
+		if primary, ok := artists["primary"].([]map[string]interface{}); ok {
+			names := make([]string, 0, len(primary))
+			for _, artist := range primary {
+				names = append(names, html.UnescapeString(utils.GetString(artist, "name")))
+			}
+			tags.Artist = strings.Join(names, ", ")
+		}
+	}
+	return tags
+}
+
+// fetchSongTags builds the tags of a formatted song, fetching the cover art,
+// lyrics and album track number. Missing extras are left out rather than failing.
+func fetchSongTags(ctx context.Context, song map[string]interface{}) utils.M4ATags {
+	tags := songTags(song)
+	tags.Cover = fetchCoverArt(ctx, songCoverURL(song))
+	tags.Lyrics = songLyricsText(ctx, song)
+
+	if album, ok := song["album"].(map[string]interface{}); ok {
+		if albumData, err := library.FetchAlbum(ctx, utils.GetString(album, "id")); err == nil {
+			tags.AlbumArtist = html.UnescapeString(utils.GetString(albumData, "primary_artists"))
+			songs, _ := albumData["songs"].([]interface{})
+			for i, s := range songs {
+				if songMap, ok := s.(map[string]interface{}); ok && utils.GetString(songMap, "id") == utils.GetString(song, "id") {
+					tags.TrackNumber = i + 1
+					tags.TrackTotal = len(songs)
+					break
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// songLyricsText returns the plain text lyrics of a formatted song, if it has any
+func songLyricsText(ctx context.Context, song map[string]interface{}) string {
+	if hasLyrics, _ := song["hasLyrics"].(bool); !hasLyrics {
+		return ""
+	}
+	lyrics, err := fetchLyrics(ctx, utils.GetString(song, "id"))
+	if err != nil {
+		return ""
+	}
+	return lyrics.Text()
+}
+
+// songCoverURL returns the 500x500 image of a formatted song
+func songCoverURL(song map[string]interface{}) string {
+	images, _ := song["image"].([]map[string]string)
+	for _, image := range images {
+		if image["quality"] == "500x500" {
+			return image["url"]
+		}
+	}
+	if len(images) > 0 {
+		return images[len(images)-1]["url"]
+	}
+	return ""
+}
+
+// fetchCoverArt downloads a cover image, returning nil when it is unavailable
+func fetchCoverArt(ctx context.Context, imageURL string) []byte {
+	if imageURL == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := mediaClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	cover, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverSize))
+	if err != nil {
+		return nil
+	}
+	return cover
+}
+
+// maxCoverSize bounds the cover art embedded into a file
+const maxCoverSize = 5 << 20
+
+// downloadFilename builds an "Artist - Title.ext" filename for a song
+func downloadFilename(songData map[string]interface{}, ext string) string {
+	name := utils.GetString(songData, "song")
+	if artists := utils.GetString(songData, "primary_artists"); artists != "" {
+		name = artists + " - " + name
+	}
+	if name == "" {
+		name = utils.GetString(songData, "id")
+	}
+	return sanitizeFilename(name) + ext
+}
+
+// sanitizeFilename drops characters that are not allowed in file names on common platforms
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`<>:"/\|?*`, r):
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "track"
+	}
+	return name
+}
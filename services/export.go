@@ -0,0 +1,338 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"jioSaavnAPI/utils"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormats maps each playlist export format to its content type and file extension
+var exportFormats = map[string]struct {
+	contentType string
+	ext         string
+}{
+	"m3u8": {"audio/x-mpegurl; charset=utf-8", ".m3u8"},
+	"xspf": {"application/xspf+xml; charset=utf-8", ".xspf"},
+	"jspf": {"application/json; charset=utf-8", ".jspf"},
+	"csv":  {"text/csv; charset=utf-8", ".csv"},
+}
+
+// exportTrack is a song as listed in an exported playlist
+type exportTrack struct {
+	Position int
+	ID       string
+	Title    string
+	Artists  string
+	Album    string
+	Duration int
+	Image    string
+	Location string
+	URL      string
+}
+
+// exportParams reads the export format and track location from the query. It
+// writes a 400 response and returns false when either is invalid. An empty
+// format means the regular JSON response.
+func exportParams(c *gin.Context) (string, string, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" || format == "json" {
+		return "", "", true
+	}
+	if _, ok := exportFormats[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid format. Use one of: json, m3u8, xspf, jspf, csv",
+		})
+		return "", "", false
+	}
+
+	location := c.DefaultQuery("location", "stream")
+	if location != "stream" && location != "download" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid location. Use stream or download",
+		})
+		return "", "", false
+	}
+	return format, location, true
+}
+
+// exportCollection renders the songs of a formatted album or playlist in an
+// export format. Songs that only carry an ID are hydrated with song.getDetails first.
+func exportCollection(c *gin.Context, format, location string, collection map[string]interface{}) {
+	songs, err := hydrateSongs(c.Request.Context(), collectionSongs(collection))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch songs",
+		})
+		return
+	}
+
+	name := strings.TrimSpace(utils.GetString(collection, "name"))
+	if name == "" {
+		name = strings.TrimSpace(utils.GetString(collection, "title"))
+	}
+	name = html.UnescapeString(name)
+
+	tracks := make([]exportTrack, 0, len(songs))
+	for i, song := range songs {
+		tracks = append(tracks, newExportTrack(i+1, song, location))
+	}
+
+	var body []byte
+	switch format {
+	case "m3u8":
+		body = renderM3U8(name, tracks)
+	case "xspf":
+		body, err = renderXSPF(name, utils.GetString(collection, "url"), tracks)
+	case "jspf":
+		body, err = renderJSPF(name, utils.GetString(collection, "url"), tracks)
+	case "csv":
+		body, err = renderCSV(tracks)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to render playlist",
+		})
+		return
+	}
+
+	filename := sanitizeFilename(name) + exportFormats[format].ext
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, exportFormats[format].contentType, body)
+}
+
+// collectionSongs returns the songs of a formatted album or playlist, which
+// are either full song objects or ID-only entries
+func collectionSongs(collection map[string]interface{}) []map[string]interface{} {
+	switch songs := collection["songs"].(type) {
+	case []map[string]interface{}:
+		return songs
+	case []map[string]string:
+		converted := make([]map[string]interface{}, 0, len(songs))
+		for _, song := range songs {
+			entry := map[string]interface{}{}
+			for k, v := range song {
+				entry[k] = v
+			}
+			converted = append(converted, entry)
+		}
+		return converted
+	}
+	return []map[string]interface{}{}
+}
+
+// hydrateSongs replaces ID-only song entries by fully formatted songs. Entries
+// that cannot be hydrated are kept as they are.
+func hydrateSongs(ctx context.Context, songs []map[string]interface{}) ([]map[string]interface{}, error) {
+	missing := []string{}
+	for _, song := range songs {
+		if _, hasArtists := song["artists"]; !hasArtists {
+			if id := strings.TrimSpace(utils.GetString(song, "id")); id != "" {
+				missing = append(missing, id)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return songs, nil
+	}
+
+	details, err := library.FetchSongs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	utils.ResolveSongMedia(ctx, details)
+
+	hydrated := make([]map[string]interface{}, 0, len(songs))
+	for _, song := range songs {
+		if _, hasArtists := song["artists"]; !hasArtists {
+			if songData, ok := details[strings.TrimSpace(utils.GetString(song, "id"))]; ok {
+				song = utils.FormatSongDetailed(songData)
+			}
+		}
+		hydrated = append(hydrated, song)
+	}
+	return hydrated, nil
+}
+
+// newExportTrack builds the export entry of a formatted song
+func newExportTrack(position int, song map[string]interface{}, location string) exportTrack {
+	id := utils.GetString(song, "id")
+	track := exportTrack{
+		Position: position,
+		ID:       id,
+		Title:    html.UnescapeString(utils.GetString(song, "name")),
+		Duration: utils.GetInt(song, "duration"),
+		Image:    songCoverURL(song),
+		URL:      utils.GetString(song, "url"),
+	}
+	if album, ok := song["album"].(map[string]interface{}); ok {
+		track.Album = html.UnescapeString(utils.GetString(album, "name"))
+	}
+	track.Artists = songTags(song).Artist
+
+	switch location {
+	case "download":
+		if links, ok := song["downloadUrl"].([]map[string]string); ok && len(links) > 0 {
+			track.Location = links[len(links)-1]["url"]
+		}
+	default:
+		track.Location = utils.StreamURL(cfg.PublicBaseURL, id, defaultStreamQuality)
+	}
+	return track
+}
+
+// renderM3U8 renders an extended M3U playlist
+func renderM3U8(name string, tracks []exportTrack) []byte {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if name != "" {
+		fmt.Fprintf(&b, "#PLAYLIST:%s\n", hlsTitle(name))
+	}
+	for _, track := range tracks {
+		if track.Location == "" {
+			continue
+		}
+		title := track.Title
+		if track.Artists != "" {
+			title = track.Artists + " - " + title
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", track.Duration, hlsTitle(title))
+		if track.Album != "" {
+			fmt.Fprintf(&b, "#EXTALB:%s\n", hlsTitle(track.Album))
+		}
+		if track.Image != "" {
+			fmt.Fprintf(&b, "#EXTIMG:%s\n", track.Image)
+		}
+		b.WriteString(track.Location + "\n")
+	}
+	return []byte(b.String())
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"playlist"`
+	Version   string      `xml:"version,attr"`
+	Namespace string      `xml:"xmlns,attr"`
+	Title     string      `xml:"title,omitempty"`
+	Info      string      `xml:"info,omitempty"`
+	Tracks    []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location,omitempty"`
+	Identifier string `xml:"identifier,omitempty"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	TrackNum   int    `xml:"trackNum,omitempty"`
+	Duration   int    `xml:"duration,omitempty"`
+	Image      string `xml:"image,omitempty"`
+	Info       string `xml:"info,omitempty"`
+}
+
+// renderXSPF renders an XSPF playlist. Durations are in milliseconds.
+func renderXSPF(name, info string, tracks []exportTrack) ([]byte, error) {
+	playlist := xspfPlaylist{
+		Version:   "1",
+		Namespace: "http://xspf.org/ns/0/",
+		Title:     name,
+		Info:      info,
+		Tracks:    []xspfTrack{},
+	}
+	for _, track := range tracks {
+		playlist.Tracks = append(playlist.Tracks, xspfTrack{
+			Location:   track.Location,
+			Identifier: "jiosaavn:song:" + track.ID,
+			Title:      track.Title,
+			Creator:    track.Artists,
+			Album:      track.Album,
+			TrackNum:   track.Position,
+			Duration:   track.Duration * 1000,
+			Image:      track.Image,
+			Info:       track.URL,
+		})
+	}
+
+	body, err := xml.MarshalIndent(playlist, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
+
+type jspfTrack struct {
+	Location   []string `json:"location,omitempty"`
+	Identifier []string `json:"identifier,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Creator    string   `json:"creator,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	TrackNum   int      `json:"trackNum,omitempty"`
+	Duration   int      `json:"duration,omitempty"`
+	Image      string   `json:"image,omitempty"`
+	Info       string   `json:"info,omitempty"`
+}
+
+// renderJSPF renders the JSON form of XSPF
+func renderJSPF(name, info string, tracks []exportTrack) ([]byte, error) {
+	jspfTracks := make([]jspfTrack, 0, len(tracks))
+	for _, track := range tracks {
+		entry := jspfTrack{
+			Identifier: []string{"jiosaavn:song:" + track.ID},
+			Title:      track.Title,
+			Creator:    track.Artists,
+			Album:      track.Album,
+			TrackNum:   track.Position,
+			Duration:   track.Duration * 1000,
+			Image:      track.Image,
+			Info:       track.URL,
+		}
+		if track.Location != "" {
+			entry.Location = []string{track.Location}
+		}
+		jspfTracks = append(jspfTracks, entry)
+	}
+
+	playlist := map[string]interface{}{
+		"title": name,
+		"track": jspfTracks,
+	}
+	if info != "" {
+		playlist["info"] = info
+	}
+	return json.MarshalIndent(map[string]interface{}{"playlist": playlist}, "", "  ")
+}
+
+// renderCSV renders one row per track with a header row
+func renderCSV(tracks []exportTrack) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"position", "id", "title", "artists", "album", "duration", "image", "location", "url"})
+	for _, track := range tracks {
+		w.Write([]string{
+			strconv.Itoa(track.Position),
+			track.ID,
+			track.Title,
+			track.Artists,
+			track.Album,
+			strconv.Itoa(track.Duration),
+			track.Image,
+			track.Location,
+			track.URL,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// graphqlSchema is the schema served on /graphql
+var graphqlSchema = func() graphql.Schema {
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		log.Fatalf("invalid GraphQL schema: %v", err)
+	}
+	return schema
+}()
+
+// graphqlListSizes are the lengths assumed for lists without a limit argument
+// when estimating the complexity of a query. Other lists, such as images,
+// only hold scalars and count as one object.
+var graphqlListSizes = map[string]int{
+	"Song.artists":         3,
+	"Song.featuredArtists": 3,
+	"Album.artists":        3,
+	"Album.songs":          25,
+	"Artist.topSongs":      10,
+	"Artist.topAlbums":     10,
+	"Playlist.songs":       50,
+}
+
+// graphqlRequest is a GraphQL request, sent as JSON or as query parameters
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLHandler executes a GraphQL query
+// @Summary      GraphQL endpoint
+// @Description  Executes a GraphQL query over songs, albums, artists, playlists, lyrics and search. Nested objects are resolved from their details, and the details of all objects at one level of the query are fetched together, with songs batched into single song.getDetails calls. Queries estimated to resolve more than GRAPHQL_MAX_COMPLEXITY objects or nested deeper than GRAPHQL_MAX_DEPTH are rejected before execution.
+// @Tags         GraphQL
+// @Accept       json
+// @Produce      json
+// @Param        query          query     string  false  "GraphQL query, for GET requests"
+// @Param        variables      query     string  false  "JSON object of variables, for GET requests"
+// @Param        operationName  query     string  false  "Operation to execute, for GET requests"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /graphql [get]
+// @Router       /graphql [post]
+func GraphQLHandler(c *gin.Context) {
+	var req graphqlRequest
+	if c.Request.Method == http.MethodPost {
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("invalid request body: %w", err))})
+			return
+		}
+	} else {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("invalid variables: %w", err))})
+				return
+			}
+		}
+	}
+
+	result := executeGraphQL(c.Request.Context(), req)
+	if result.Data == nil && result.HasErrors() {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// executeGraphQL parses, validates and checks the complexity of a query
+// before executing it with fresh loaders
+func executeGraphQL(ctx context.Context, req graphqlRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if validation := graphql.ValidateDocument(&graphqlSchema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if err := checkGraphQLComplexity(doc, req.OperationName, req.Variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        graphqlSchema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, graphqlLoadersKey{}, newGraphQLLoaders(ctx)),
+	})
+}
+
+// graphqlComplexity estimates the number of objects a query resolves: every
+// field counts as one, and the fields selected on a list count once per item
+type graphqlComplexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	depth     int
+}
+
+// checkGraphQLComplexity rejects the operation when its estimated complexity
+// or its depth is over the configured limits
+func checkGraphQLComplexity(doc *ast.Document, operationName string, variables map[string]interface{}) error {
+	gc := graphqlComplexity{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			gc.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		// graphql-go reports the missing operation
+		return nil
+	}
+
+	cost := gc.selectionCost(operation.SelectionSet, graphqlSchema.QueryType(), 1, map[string]bool{})
+	if gc.depth > cfg.GraphQLMaxDepth {
+		return fmt.Errorf("query is nested %d levels deep, the limit is %d", gc.depth, cfg.GraphQLMaxDepth)
+	}
+	if cost > cfg.GraphQLMaxComplexity {
+		return fmt.Errorf("query complexity is %d, the limit is %d; select fewer fields or lower the limits of lists", cost, cfg.GraphQLMaxComplexity)
+	}
+	return nil
+}
+
+// selectionCost returns the cost of the fields selected on an object type.
+// Fragments already being expanded are skipped, as are introspection fields.
+func (gc *graphqlComplexity) selectionCost(set *ast.SelectionSet, parent *graphql.Object, depth int, expanding map[string]bool) int {
+	if set == nil || parent == nil {
+		return 0
+	}
+	gc.depth = max(gc.depth, depth)
+	if depth > cfg.GraphQLMaxDepth {
+		return 0
+	}
+
+	cost := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			def, ok := parent.Fields()[name]
+			if !ok || strings.HasPrefix(name, "__") {
+				continue
+			}
+			fieldType, isList := graphqlNamedType(def.Type)
+			object, _ := fieldType.(*graphql.Object)
+			items := 1
+			if isList && object != nil {
+				items = gc.listSize(parent.Name()+"."+name, def, selection)
+			}
+			cost = addCost(cost, addCost(1, multiplyCost(items, gc.selectionCost(selection.SelectionSet, object, depth+1, expanding))))
+		case *ast.InlineFragment:
+			cost = addCost(cost, gc.selectionCost(selection.SelectionSet, parent, depth, expanding))
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if fragment := gc.fragments[name]; fragment != nil && !expanding[name] {
+				expanding[name] = true
+				cost = addCost(cost, gc.selectionCost(fragment.SelectionSet, parent, depth, expanding))
+				delete(expanding, name)
+			}
+		}
+	}
+	return cost
+}
+
+// listSize returns the number of items assumed for a list field: its limit
+// argument or the number of IDs requested, else its default size
+func (gc *graphqlComplexity) listSize(key string, def *graphql.FieldDefinition, field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case "limit":
+			if limit, ok := gc.intValue(arg.Value); ok {
+				return max(limit, 0)
+			}
+		case "ids":
+			if n, ok := gc.listLength(arg.Value); ok {
+				return n
+			}
+		}
+	}
+	for _, arg := range def.Args {
+		if limit, ok := arg.DefaultValue.(int); ok && arg.Name() == "limit" {
+			return limit
+		}
+	}
+	if size, ok := graphqlListSizes[key]; ok {
+		return size
+	}
+	return 1
+}
+
+func (gc *graphqlComplexity) intValue(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := gc.variables[value.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func (gc *graphqlComplexity) listLength(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.ListValue:
+		return len(value.Values), true
+	case *ast.Variable:
+		if list, ok := gc.variables[value.Name.Value].([]interface{}); ok {
+			return len(list), true
+		}
+	}
+	return 0, false
+}
+
+// graphqlNamedType unwraps the non-null and list wrappers of a type
+func graphqlNamedType(t graphql.Type) (named graphql.Type, isList bool) {
+	for {
+		switch wrapper := t.(type) {
+		case *graphql.NonNull:
+			t = wrapper.OfType
+		case *graphql.List:
+			isList = true
+			t = wrapper.OfType
+		default:
+			return t, isList
+		}
+	}
+}
+
+// graphqlCostCap keeps the arithmetic of huge estimates from overflowing
+const graphqlCostCap = 1 << 30
+
+func addCost(a, b int) int {
+	return min(a+b, graphqlCostCap)
+}
+
+func multiplyCost(a, b int) int {
+	if a != 0 && b > graphqlCostCap/a {
+		return graphqlCostCap
+	}
+	return a * b
+}
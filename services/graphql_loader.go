@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"slices"
+	"sync"
+)
+
+// fetchConcurrency is how many upstream calls are made at once for entities
+// that can only be fetched one at a time
+const fetchConcurrency = 8
+
+// graphqlLoader batches and caches the lookups of one kind of entity during a
+// GraphQL request. Resolvers queue IDs with load and return thunks; graphql-go
+// runs the thunks of a level of the query only after resolving all of its
+// fields, so the first thunk to run fetches every queued ID at once.
+type graphqlLoader[T any] struct {
+	fetch    func(ids []string) (map[string]T, map[string]error)
+	notFound error
+
+	mu      sync.Mutex
+	queued  []string
+	results map[string]T
+	errs    map[string]error
+}
+
+func newGraphQLLoader[T any](notFound error, fetch func(ids []string) (map[string]T, map[string]error)) *graphqlLoader[T] {
+	return &graphqlLoader[T]{
+		fetch:    fetch,
+		notFound: notFound,
+		results:  map[string]T{},
+		errs:     map[string]error{},
+	}
+}
+
+// load queues an ID and returns a thunk that waits for its value
+func (l *graphqlLoader[T]) load(id string) func() (T, error) {
+	l.mu.Lock()
+	if !l.doneLocked(id) && !slices.Contains(l.queued, id) {
+		l.queued = append(l.queued, id)
+	}
+	l.mu.Unlock()
+
+	return func() (T, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.doneLocked(id) {
+			l.dispatchLocked()
+		}
+		if err := l.errs[id]; err != nil {
+			var zero T
+			return zero, err
+		}
+		return l.results[id], nil
+	}
+}
+
+// cached returns the value of an ID that was already fetched
+func (l *graphqlLoader[T]) cached(id string) (T, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value, ok := l.results[id]
+	return value, ok
+}
+
+func (l *graphqlLoader[T]) doneLocked(id string) bool {
+	_, found := l.results[id]
+	return found || l.errs[id] != nil
+}
+
+// dispatchLocked fetches all queued IDs in one batch
+func (l *graphqlLoader[T]) dispatchLocked() {
+	ids := l.queued
+	l.queued = nil
+	if len(ids) == 0 {
+		return
+	}
+
+	found, errs := l.fetch(ids)
+	for _, id := range ids {
+		if value, ok := found[id]; ok {
+			l.results[id] = value
+		} else if err := errs[id]; err != nil {
+			l.errs[id] = err
+		} else {
+			l.errs[id] = l.notFound
+		}
+	}
+}
+
+// graphqlLoaders are the loaders of one request
+type graphqlLoaders struct {
+	songs     *graphqlLoader[map[string]interface{}]
+	albums    *graphqlLoader[map[string]interface{}]
+	artists   *graphqlLoader[map[string]interface{}]
+	playlists *graphqlLoader[map[string]interface{}]
+	lyrics    *graphqlLoader[utils.Lyrics]
+}
+
+type graphqlLoadersKey struct{}
+
+// newGraphQLLoaders returns the loaders of a request, whose fetches are
+// cancelled with ctx
+func newGraphQLLoaders(ctx context.Context) *graphqlLoaders {
+	return &graphqlLoaders{
+		songs: newGraphQLLoader(saavn.ErrSongNotFound, func(ids []string) (map[string]map[string]interface{}, map[string]error) {
+			return fetchGraphQLSongs(ctx, ids)
+		}),
+		albums: newGraphQLLoader(saavn.ErrAlbumNotFound, graphqlFetchEach(ctx, func(ctx context.Context, id string) (map[string]interface{}, error) {
+			raw, err := library.FetchAlbum(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return utils.FormatAlbum(raw), nil
+		})),
+		artists: newGraphQLLoader(saavn.ErrArtistNotFound, graphqlFetchEach(ctx, func(ctx context.Context, id string) (map[string]interface{}, error) {
+			raw, err := library.FetchArtist(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return utils.FormatArtistDetails(raw), nil
+		})),
+		playlists: newGraphQLLoader(saavn.ErrPlaylistNotFound, graphqlFetchEach(ctx, func(ctx context.Context, id string) (map[string]interface{}, error) {
+			raw, err := fetchPlaylistByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return saavn.FormatPlaylistDetails(raw), nil
+		})),
+		lyrics: newGraphQLLoader(saavn.ErrSongNotFound, graphqlFetchEach(ctx, fetchLyrics)),
+	}
+}
+
+// loadersFrom returns the loaders of the request being executed
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	loaders, _ := ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+	return loaders
+}
+
+// fetchGraphQLSongs fetches songs with batched song.getDetails calls
+func fetchGraphQLSongs(ctx context.Context, ids []string) (map[string]map[string]interface{}, map[string]error) {
+	raw, err := library.FetchSongs(ctx, ids)
+	if err != nil {
+		errs := map[string]error{}
+		for _, id := range ids {
+			errs[id] = err
+		}
+		return nil, errs
+	}
+
+	songs := make(map[string]map[string]interface{}, len(raw))
+	for id, song := range raw {
+		songs[id] = utils.FormatSongDetailed(song)
+	}
+	return songs, nil
+}
+
+// graphqlFetchEach adapts an upstream call taking a single ID to a loader,
+// fetching the IDs of a batch concurrently with ctx
+func graphqlFetchEach[T any](ctx context.Context, fetch func(ctx context.Context, id string) (T, error)) func(ids []string) (map[string]T, map[string]error) {
+	return func(ids []string) (map[string]T, map[string]error) {
+		values := make([]T, len(ids))
+		errs := make([]error, len(ids))
+		sem := make(chan struct{}, fetchConcurrency)
+		var wg sync.WaitGroup
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				values[i], errs[i] = fetch(ctx, id)
+			}()
+		}
+		wg.Wait()
+
+		found := map[string]T{}
+		failed := map[string]error{}
+		for i, id := range ids {
+			if errs[i] != nil {
+				failed[id] = errs[i]
+			} else {
+				found[id] = values[i]
+			}
+		}
+		return found, failed
+	}
+}
@@ -0,0 +1,504 @@
+package services
+
+import (
+	"jioSaavnAPI/utils"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// defaultSearchLimit is the number of results of a search field without a limit
+const defaultSearchLimit = 10
+
+// graphqlDetails picks the loader that fetches the details of a type
+type graphqlDetails func(*graphqlLoaders) *graphqlLoader[map[string]interface{}]
+
+func songDetails(l *graphqlLoaders) *graphqlLoader[map[string]interface{}]     { return l.songs }
+func albumDetails(l *graphqlLoaders) *graphqlLoader[map[string]interface{}]    { return l.albums }
+func artistDetails(l *graphqlLoaders) *graphqlLoader[map[string]interface{}]   { return l.artists }
+func playlistDetails(l *graphqlLoaders) *graphqlLoader[map[string]interface{}] { return l.playlists }
+
+// newGraphQLSchema builds the schema served on /graphql. Objects are the
+// formatted maps of the REST endpoints; an object reached through another one,
+// such as the album of a song or the artists of an album, carries only some of
+// its fields and the others are resolved from its details.
+func newGraphQLSchema() (graphql.Schema, error) {
+	imageType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Image",
+		Description: "An image at one size",
+		Fields: graphql.Fields{
+			"quality": &graphql.Field{Type: graphql.String, Description: "Size, such as 500x500"},
+			"url":     &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	downloadURLType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "DownloadUrl",
+		Description: "A media URL at one bitrate",
+		Fields: graphql.Fields{
+			"quality": &graphql.Field{Type: graphql.String, Description: "Bitrate, such as 320kbps"},
+			"url":     &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	lyricsLineType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LyricsLine",
+		Fields: graphql.Fields{
+			"time": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Offset in seconds from the start of the song, for synced lyrics",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if line := p.Source.(utils.LyricsLine); line.Time != nil {
+						return *line.Time, nil
+					}
+					return nil, nil
+				},
+			},
+			"text": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(utils.LyricsLine).Text, nil
+				},
+			},
+		},
+	})
+
+	lyricsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Lyrics",
+		Fields: graphql.Fields{
+			"id":        lyricsField(graphql.ID, func(l utils.Lyrics) interface{} { return l.ID }),
+			"text":      lyricsField(graphql.String, func(l utils.Lyrics) interface{} { return lyricsText(l) }),
+			"lines":     lyricsField(graphql.NewList(lyricsLineType), func(l utils.Lyrics) interface{} { return l.Lines }),
+			"synced":    lyricsField(graphql.Boolean, func(l utils.Lyrics) interface{} { return l.Synced }),
+			"copyright": lyricsField(graphql.String, func(l utils.Lyrics) interface{} { return l.Copyright }),
+			"snippet":   lyricsField(graphql.String, func(l utils.Lyrics) interface{} { return l.Snippet }),
+		},
+	})
+
+	var songType, albumType, artistType, playlistType *graphql.Object
+	limitArgs := graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Maximum number of items"},
+	}
+
+	songType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Song",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":            detailField(songDetails, "name", graphql.String),
+				"year":            detailField(songDetails, "year", graphql.String),
+				"releaseDate":     detailField(songDetails, "releaseDate", graphql.String),
+				"duration":        detailField(songDetails, "duration", graphql.Int),
+				"label":           detailField(songDetails, "label", graphql.String),
+				"copyright":       detailField(songDetails, "copyright", graphql.String),
+				"explicitContent": detailField(songDetails, "explicitContent", graphql.Boolean),
+				"playCount":       detailField(songDetails, "playCount", graphql.Int),
+				"language":        detailField(songDetails, "language", graphql.String),
+				"hasLyrics":       detailField(songDetails, "hasLyrics", graphql.Boolean),
+				"url":             detailField(songDetails, "url", graphql.String),
+				"previewUrl":      detailField(songDetails, "previewUrl", graphql.String),
+				"image":           imagesField(songDetails, imageType),
+				"downloadUrl": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(downloadURLType)),
+					Resolve: mapResolved(detailResolver(songDetails, "downloadUrl"), formattedList),
+				},
+				"album": &graphql.Field{
+					Type:    albumType,
+					Resolve: detailResolver(songDetails, "album"),
+				},
+				"artists": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(artistType)),
+					Description: "Primary artists",
+					Resolve:     mapResolved(detailResolver(songDetails, "artists"), creditsWithRole("primary")),
+				},
+				"featuredArtists": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(artistType)),
+					Resolve: mapResolved(detailResolver(songDetails, "artists"), creditsWithRole("featured")),
+				},
+				"lyrics": &graphql.Field{
+					Type:        lyricsType,
+					Description: "Lyrics of the song, or null when it has none",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).lyrics.load(utils.GetString(p.Source.(map[string]interface{}), "id"))
+						return func() (interface{}, error) {
+							lyrics, err := thunk()
+							if err != nil || len(lyrics.Lines) == 0 {
+								return nil, err
+							}
+							return lyrics, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	albumType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Album",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":            detailField(albumDetails, "name", graphql.String),
+				"year":            detailField(albumDetails, "year", graphql.String),
+				"language":        detailField(albumDetails, "language", graphql.String),
+				"explicitContent": detailField(albumDetails, "explicitContent", graphql.Boolean),
+				"songCount":       detailField(albumDetails, "songCount", graphql.Int),
+				"url":             detailField(albumDetails, "url", graphql.String),
+				"image":           imagesField(albumDetails, imageType),
+				"artists": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(artistType)),
+					Description: "Primary artists",
+					Resolve:     mapResolved(detailResolver(albumDetails, "artists"), creditsWithRole("primary")),
+				},
+				"songs": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(songType)),
+					Resolve: mapResolved(detailResolver(albumDetails, "songs"), formattedList),
+				},
+			}
+		}),
+	})
+
+	artistType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Artist",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":               &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":             detailField(artistDetails, "name", graphql.String),
+				"role":             &graphql.Field{Type: graphql.String, Description: "Credit of the artist on the song or album it was reached from"},
+				"url":              detailField(artistDetails, "url", graphql.String),
+				"image":            imagesField(artistDetails, imageType),
+				"followerCount":    detailField(artistDetails, "followerCount", graphql.Int),
+				"isVerified":       detailField(artistDetails, "isVerified", graphql.Boolean),
+				"dominantLanguage": detailField(artistDetails, "dominantLanguage", graphql.String),
+				"dominantType":     detailField(artistDetails, "dominantType", graphql.String),
+				"bio":              detailField(artistDetails, "bio", graphql.String),
+				"topSongs": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(songType)),
+					Args:    limitArgs,
+					Resolve: limited(mapResolved(detailResolver(artistDetails, "topSongs"), formattedList)),
+				},
+				"topAlbums": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(albumType)),
+					Args:    limitArgs,
+					Resolve: limited(mapResolved(detailResolver(artistDetails, "topAlbums"), formattedList)),
+				},
+			}
+		}),
+	})
+
+	playlistType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Playlist",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":        detailField(playlistDetails, "name", graphql.String),
+				"description": detailField(playlistDetails, "description", graphql.String),
+				"songCount":   detailField(playlistDetails, "songCount", graphql.Int),
+				"url":         detailField(playlistDetails, "url", graphql.String),
+				"image":       imagesField(playlistDetails, imageType),
+				"songs": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(songType)),
+					Args:    limitArgs,
+					Resolve: limited(mapResolved(detailResolver(playlistDetails, "songs"), formattedList)),
+				},
+			}
+		}),
+	})
+
+	searchArgs := graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultSearchLimit, Description: "Maximum number of results"},
+	}
+	searchType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Search",
+		Description: "Results of a search, by type; only the requested types are searched",
+		Fields: graphql.Fields{
+			"songs": &graphql.Field{
+				Type:    graphql.NewList(graphql.NewNonNull(songType)),
+				Args:    searchArgs,
+				Resolve: searchResolver("song", func(raw map[string]interface{}) map[string]interface{} { return utils.FormatSongSearch(raw) }),
+			},
+			"albums": &graphql.Field{
+				Type:    graphql.NewList(graphql.NewNonNull(albumType)),
+				Args:    searchArgs,
+				Resolve: searchResolver("album", func(raw map[string]interface{}) map[string]interface{} { return utils.FormatAlbumSearch(raw) }),
+			},
+			"artists": &graphql.Field{
+				Type:    graphql.NewList(graphql.NewNonNull(artistType)),
+				Args:    searchArgs,
+				Resolve: searchResolver("artist", func(raw map[string]interface{}) map[string]interface{} { return utils.FormatArtistSearch(raw) }),
+			},
+			"playlists": &graphql.Field{
+				Type:    graphql.NewList(graphql.NewNonNull(playlistType)),
+				Args:    searchArgs,
+				Resolve: searchResolver("playlist", func(raw map[string]interface{}) map[string]interface{} { return utils.FormatPlaylistSearch(raw) }),
+			},
+		},
+	})
+
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"song": &graphql.Field{
+				Type:    songType,
+				Args:    idArgs,
+				Resolve: byID(songDetails),
+			},
+			"songs": &graphql.Field{
+				Type:        graphql.NewList(songType),
+				Description: "Songs by ID, fetched together; null for unknown IDs",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ids, _ := p.Args["ids"].([]interface{})
+					thunks := make([]func() (map[string]interface{}, error), len(ids))
+					for i, id := range ids {
+						thunks[i] = loadersFrom(p.Context).songs.load(id.(string))
+					}
+					return func() (interface{}, error) {
+						songs := make([]interface{}, len(thunks))
+						for i, thunk := range thunks {
+							if song, err := thunk(); err == nil {
+								songs[i] = song
+							}
+						}
+						return songs, nil
+					}, nil
+				},
+			},
+			"album": &graphql.Field{
+				Type:    albumType,
+				Args:    idArgs,
+				Resolve: byID(albumDetails),
+			},
+			"artist": &graphql.Field{
+				Type:    artistType,
+				Args:    idArgs,
+				Resolve: byID(artistDetails),
+			},
+			"playlist": &graphql.Field{
+				Type:    playlistType,
+				Args:    idArgs,
+				Resolve: byID(playlistDetails),
+			},
+			"lyrics": &graphql.Field{
+				Type:        lyricsType,
+				Description: "Lyrics of a song, by song ID",
+				Args:        idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					thunk := loadersFrom(p.Context).lyrics.load(p.Args["id"].(string))
+					return func() (interface{}, error) { return thunk() }, nil
+				},
+			},
+			"search": &graphql.Field{
+				Type: graphql.NewNonNull(searchType),
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return map[string]interface{}{"query": p.Args["query"]}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// detailResolver resolves a field from the source object, or from its details
+// when the source lacks it. The details of all objects of a level of the query
+// are fetched together.
+func detailResolver(details graphqlDetails, key string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		source, _ := p.Source.(map[string]interface{})
+		if value, ok := source[key]; ok && !emptyValue(value) {
+			return value, nil
+		}
+
+		id := utils.GetString(source, "id")
+		loader := details(loadersFrom(p.Context))
+		if fetched, ok := loader.cached(id); ok || id == "" {
+			return fetched[key], nil
+		}
+		thunk := loader.load(id)
+		return func() (interface{}, error) {
+			fetched, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			return fetched[key], nil
+		}, nil
+	}
+}
+
+// detailField is a scalar field resolved with detailResolver
+func detailField(details graphqlDetails, key string, fieldType graphql.Output) *graphql.Field {
+	return &graphql.Field{Type: fieldType, Resolve: detailResolver(details, key)}
+}
+
+// imagesField is an image field resolved with detailResolver
+func imagesField(details graphqlDetails, imageType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:    graphql.NewList(graphql.NewNonNull(imageType)),
+		Resolve: mapResolved(detailResolver(details, "image"), formattedImages),
+	}
+}
+
+// mapResolved converts the value of a resolver, once its thunk has run
+func mapResolved(resolve graphql.FieldResolveFn, convert func(interface{}) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		value, err := resolve(p)
+		if err != nil {
+			return nil, err
+		}
+		if thunk, ok := value.(func() (interface{}, error)); ok {
+			return func() (interface{}, error) {
+				value, err := thunk()
+				if err != nil {
+					return nil, err
+				}
+				return convert(value), nil
+			}, nil
+		}
+		return convert(value), nil
+	}
+}
+
+// limited applies the limit argument to the list returned by a resolver
+func limited(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		limit, ok := p.Args["limit"].(int)
+		if !ok {
+			return resolve(p)
+		}
+		return mapResolved(resolve, func(value interface{}) interface{} {
+			if items, ok := value.([]map[string]interface{}); ok && limit >= 0 && limit < len(items) {
+				return items[:limit]
+			}
+			return value
+		})(p)
+	}
+}
+
+// byID resolves a root field to the details of the object with the id argument
+func byID(details graphqlDetails) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		thunk := details(loadersFrom(p.Context)).load(p.Args["id"].(string))
+		return func() (interface{}, error) { return thunk() }, nil
+	}
+}
+
+// searchResolver searches for the query of the parent Search object. Each
+// search starts right away, so the searches of several types run together.
+func searchResolver(searchType string, format func(map[string]interface{}) map[string]interface{}) graphql.FieldResolveFn {
+	return limited(func(p graphql.ResolveParams) (interface{}, error) {
+		query := utils.GetString(p.Source.(map[string]interface{}), "query")
+		var results []map[string]interface{}
+		var err error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			var raw map[string]interface{}
+			if raw, err = library.FetchSearch(p.Context, query, searchType, cfg.SearchScripts); err == nil {
+				data, _ := format(raw)["data"].(map[string]interface{})
+				results, _ = data["results"].([]map[string]interface{})
+			}
+		}()
+		return func() (interface{}, error) {
+			<-done
+			return results, err
+		}, nil
+	})
+}
+
+// lyricsField resolves a field of the Lyrics type
+func lyricsField(fieldType graphql.Output, get func(utils.Lyrics) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(utils.Lyrics)), nil
+		},
+	}
+}
+
+// lyricsText joins the lines of lyrics
+func lyricsText(lyrics utils.Lyrics) string {
+	lines := make([]string, len(lyrics.Lines))
+	for i, line := range lyrics.Lines {
+		lines[i] = line.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// creditsWithRole returns the artists of a formatted "artists" object with the given role
+func creditsWithRole(role string) func(interface{}) interface{} {
+	return func(value interface{}) interface{} {
+		artists, _ := value.(map[string]interface{})
+		return formattedList(artists[role])
+	}
+}
+
+// formattedList returns a list of objects as a []map[string]interface{},
+// whichever way the formatter built it
+func formattedList(value interface{}) interface{} {
+	switch list := value.(type) {
+	case []map[string]interface{}:
+		return list
+	case []map[string]string:
+		maps := make([]map[string]interface{}, len(list))
+		for i, item := range list {
+			maps[i] = map[string]interface{}{}
+			for k, v := range item {
+				maps[i][k] = v
+			}
+		}
+		return maps
+	case []interface{}:
+		maps := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				maps = append(maps, m)
+			}
+		}
+		return maps
+	}
+	return []map[string]interface{}{}
+}
+
+// formattedImages returns the images of an object, which may also be given as a single URL
+func formattedImages(value interface{}) interface{} {
+	if url, ok := value.(string); ok && url != "" {
+		return formattedList(utils.BuildImageArray(url))
+	}
+	return formattedList(value)
+}
+
+// emptyValue reports whether a value of a source object is missing, in which
+// case it is resolved from the object's details. Booleans are never missing.
+func emptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	case []map[string]interface{}:
+		return len(v) == 0
+	case []map[string]string:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, item := range v {
+			if !emptyValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
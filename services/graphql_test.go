@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// graphqlTestUpstream is a fake upstream with album al1 of songs s1, s2 and
+// s3, which carries no artists, and the details of those songs
+type graphqlTestUpstream struct {
+	mu sync.Mutex
+	// detailCalls are the pids of the song.getDetails calls
+	detailCalls []string
+}
+
+func (u *graphqlTestUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	artists := map[string][2]string{
+		"s1": {"Arijit Singh", "459320"},
+		"s2": {"Ankit Tiwari", "1025431"},
+		"s3": {"Arijit Singh, Mithoon", "459320, 462427"},
+	}
+	switch query.Get("__call") {
+	case "content.getAlbumDetails":
+		if query.Get("albumid") != "al1" {
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+			return
+		}
+		songs := []map[string]interface{}{}
+		for _, id := range []string{"s1", "s2", "s3"} {
+			songs = append(songs, map[string]interface{}{"id": id, "song": "Song " + id})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"albumid": "al1", "title": "Aashiqui 2", "name": "Aashiqui 2", "songs": songs})
+
+	case "song.getDetails":
+		u.mu.Lock()
+		u.detailCalls = append(u.detailCalls, query.Get("pids"))
+		u.mu.Unlock()
+		songs := map[string]interface{}{}
+		for _, id := range strings.Split(query.Get("pids"), ",") {
+			if credit, ok := artists[id]; ok {
+				songs[id] = map[string]interface{}{
+					"id":                 id,
+					"song":               "Song " + id,
+					"primary_artists":    credit[0],
+					"primary_artists_id": credit[1],
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(songs)
+
+	default:
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}
+}
+
+// newGraphQLTestUpstream points the library to a fake upstream and sets the
+// query limits to their defaults
+func newGraphQLTestUpstream(t *testing.T) *graphqlTestUpstream {
+	t.Helper()
+	upstream := &graphqlTestUpstream{}
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	baseURL, maxComplexity, maxDepth := library.BaseURL, cfg.GraphQLMaxComplexity, cfg.GraphQLMaxDepth
+	library.BaseURL, cfg.GraphQLMaxComplexity, cfg.GraphQLMaxDepth = server.URL, 1000, 8
+	t.Cleanup(func() {
+		library.BaseURL, cfg.GraphQLMaxComplexity, cfg.GraphQLMaxDepth = baseURL, maxComplexity, maxDepth
+	})
+	return upstream
+}
+
+func TestGraphQLBatchesNestedSongDetails(t *testing.T) {
+	upstream := newGraphQLTestUpstream(t)
+
+	result := executeGraphQL(context.Background(), graphqlRequest{
+		Query: `{ album(id: "al1") { name songs { id artists { name } } } }`,
+	})
+	if result.HasErrors() {
+		t.Fatalf("errors: %v", result.Errors)
+	}
+
+	var data struct {
+		Album struct {
+			Name  string
+			Songs []struct {
+				ID      string
+				Artists []struct{ Name string }
+			}
+		}
+	}
+	raw, _ := json.Marshal(result.Data)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Album.Name != "Aashiqui 2" {
+		t.Errorf("album name = %q", data.Album.Name)
+	}
+	var credits []string
+	for _, song := range data.Album.Songs {
+		var names []string
+		for _, artist := range song.Artists {
+			names = append(names, artist.Name)
+		}
+		credits = append(credits, song.ID+"="+strings.Join(names, "+"))
+	}
+	if got, want := strings.Join(credits, " "), "s1=Arijit Singh s2=Ankit Tiwari s3=Arijit Singh+Mithoon"; got != want {
+		t.Errorf("artists = %s, want %s", got, want)
+	}
+
+	if len(upstream.detailCalls) != 1 {
+		t.Fatalf("song.getDetails called %d times (%q), want once", len(upstream.detailCalls), upstream.detailCalls)
+	}
+	pids := strings.Split(upstream.detailCalls[0], ",")
+	sort.Strings(pids)
+	if got := strings.Join(pids, ","); got != "s1,s2,s3" {
+		t.Errorf("song.getDetails pids = %s, want s1,s2,s3", got)
+	}
+}
+
+// nestedArtistQuery selects topSongs.artists.topSongs... levels deep inside
+// an artist, with lists short enough to stay within the complexity limit
+func nestedArtistQuery(levels int) string {
+	selection := "id"
+	for i := levels - 1; i >= 0; i-- {
+		if i%2 == 0 {
+			selection = "topSongs(limit: 1) { " + selection + " }"
+		} else {
+			selection = "artists { " + selection + " }"
+		}
+	}
+	return selection
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	newGraphQLTestUpstream(t)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		// rejection is part of the error, or "" when the query is accepted
+		rejection string
+	}{
+		{
+			name:  "nested within the depth",
+			query: `{ artist(id: "a1") { ` + nestedArtistQuery(6) + ` } }`,
+		},
+		{
+			name:      "nested too deep",
+			query:     `{ artist(id: "a1") { ` + nestedArtistQuery(8) + ` } }`,
+			rejection: "nested 9 levels deep, the limit is 8",
+		},
+		{
+			name:      "nested too deep through fragments",
+			query:     `{ artist(id: "a1") { ...Level1 } } fragment Level1 on Artist { topSongs(limit: 1) { ...Level2 } } fragment Level2 on Song { artists { ` + nestedArtistQuery(6) + ` } }`,
+			rejection: "nested 9 levels deep",
+		},
+		{
+			name:      "nested too deep through inline fragments",
+			query:     `{ artist(id: "a1") { ... on Artist { topSongs(limit: 1) { ... on Song { artists { ` + nestedArtistQuery(6) + ` } } } } } }`,
+			rejection: "nested 9 levels deep",
+		},
+		{
+			name:  "list limit within the complexity",
+			query: `{ artist(id: "a1") { topSongs(limit: 100) { name } } }`,
+		},
+		{
+			name:      "list limit over the complexity",
+			query:     `{ artist(id: "a1") { topSongs(limit: 5000) { name } } }`,
+			rejection: "query complexity is 5002, the limit is 1000",
+		},
+		{
+			name:      "list limit from a variable",
+			query:     `query Top($n: Int) { artist(id: "a1") { topSongs(limit: $n) { name } } }`,
+			variables: map[string]interface{}{"n": float64(5000)},
+			rejection: "query complexity is 5002",
+		},
+		{
+			name:      "song IDs from a variable",
+			query:     `query Songs($ids: [ID!]!) { songs(ids: $ids) { name album { name } } }`,
+			variables: map[string]interface{}{"ids": make([]interface{}, 400)},
+			rejection: "query complexity is 1201",
+		},
+		{
+			name:      "default list sizes multiplied through a fragment",
+			query:     `{ playlist(id: "p1") { songs { ...Credits } } } fragment Credits on Song { album { songs { artists { name } } } }`,
+			rejection: "query complexity is",
+		},
+		{
+			name:      "fragments spread many times",
+			query:     `{ a: search(query: "x") { ...Results } b: search(query: "y") { ...Results } c: search(query: "z") { ...Results } } fragment Results on Search { songs(limit: 50) { name album { name year } artists { name } } }`,
+			rejection: "query complexity is",
+		},
+		{
+			name:  "search within the complexity",
+			query: `{ search(query: "x") { songs { name album { name } } } }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := executeGraphQL(context.Background(), graphqlRequest{Query: tt.query, Variables: tt.variables})
+			var messages []string
+			for _, err := range result.Errors {
+				messages = append(messages, err.Message)
+			}
+			rejected := strings.Join(messages, "; ")
+			switch {
+			case tt.rejection == "" && (strings.Contains(rejected, "deep") || strings.Contains(rejected, "complexity")):
+				t.Errorf("rejected: %s", rejected)
+			case tt.rejection != "" && !strings.Contains(rejected, tt.rejection):
+				t.Errorf("errors = %q, want %q", rejected, tt.rejection)
+			case tt.rejection != "" && result.Data != nil:
+				t.Errorf("rejected query executed: %v", result.Data)
+			}
+		})
+	}
+}
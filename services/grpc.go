@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/saavnpb"
+	"jioSaavnAPI/utils"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// discographyPageSize is the number of albums fetched per artist.getArtistMoreAlbum call
+const discographyPageSize = 50
+
+// discographyMaxPages bounds the pages fetched for one discography
+const discographyMaxPages = 40
+
+// grpcSearchTypes are the search types of the Search method
+var grpcSearchTypes = map[saavnpb.SearchType]string{
+	saavnpb.SearchType_SEARCH_TYPE_SONG:     "song",
+	saavnpb.SearchType_SEARCH_TYPE_ALBUM:    "album",
+	saavnpb.SearchType_SEARCH_TYPE_ARTIST:   "artist",
+	saavnpb.SearchType_SEARCH_TYPE_PLAYLIST: "playlist",
+}
+
+// saavnServer implements the Saavn gRPC service with the same upstream calls
+// and formatters as the REST handlers
+type saavnServer struct {
+	saavnpb.UnimplementedSaavnServer
+}
+
+// grpcStopTimeout bounds the wait for the calls in flight on shutdown, which
+// streams could otherwise hold up
+const grpcStopTimeout = 10 * time.Second
+
+// StartGRPC serves the Saavn gRPC service on GRPC_PORT, if set, until ctx is
+// done. The returned channel is closed once the server has stopped, after the
+// calls in flight finished or grpcStopTimeout passed.
+func StartGRPC(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if cfg.GRPCPort == "" {
+		close(done)
+		return done
+	}
+
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Printf("⚠️ gRPC server not started: %v", err)
+		close(done)
+		return done
+	}
+	server := newGRPCServer()
+	go func() {
+		log.Printf("gRPC server listening on %s", lis.Addr())
+		if err := server.Serve(lis); err != nil {
+			log.Printf("⚠️ gRPC server stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(grpcStopTimeout):
+			server.Stop()
+		}
+	}()
+	return done
+}
+
+// newGRPCServer returns a server of the Saavn gRPC service
+func newGRPCServer() *grpc.Server {
+	server := grpc.NewServer()
+	saavnpb.RegisterSaavnServer(server, &saavnServer{})
+	// Lets tools such as grpcurl list and call the methods without the .proto file
+	reflection.Register(server)
+	return server
+}
+
+func (s *saavnServer) GetSong(ctx context.Context, req *saavnpb.GetSongRequest) (*saavnpb.Song, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	raw, err := library.FetchSong(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbSong(utils.FormatSongDetailed(raw)), nil
+}
+
+func (s *saavnServer) BatchGetSongs(ctx context.Context, req *saavnpb.BatchGetSongsRequest) (*saavnpb.BatchGetSongsResponse, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, id := range req.GetIds() {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ids are required")
+	}
+
+	raw, err := library.FetchSongs(ctx, ids)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &saavnpb.BatchGetSongsResponse{}
+	for _, id := range ids {
+		if song, ok := raw[id]; ok {
+			resp.Songs = append(resp.Songs, pbSong(utils.FormatSongDetailed(song)))
+		} else {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+func (s *saavnServer) GetAlbum(ctx context.Context, req *saavnpb.GetAlbumRequest) (*saavnpb.Album, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	raw, err := library.FetchAlbum(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbAlbum(utils.FormatAlbum(raw)), nil
+}
+
+func (s *saavnServer) GetArtist(ctx context.Context, req *saavnpb.GetArtistRequest) (*saavnpb.Artist, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	raw, err := library.FetchArtist(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbArtist(utils.FormatArtistDetails(raw)), nil
+}
+
+func (s *saavnServer) GetPlaylist(ctx context.Context, req *saavnpb.GetPlaylistRequest) (*saavnpb.Playlist, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	raw, err := library.FetchPlaylistByID(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbPlaylist(saavn.FormatPlaylistDetails(raw)), nil
+}
+
+func (s *saavnServer) GetLyrics(ctx context.Context, req *saavnpb.GetLyricsRequest) (*saavnpb.Lyrics, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	raw, err := library.FetchLyrics(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	lyrics := utils.FormatLyrics(req.GetId(), raw)
+	if len(lyrics.Lines) == 0 {
+		return nil, status.Error(codes.NotFound, "lyrics not found")
+	}
+
+	resp := &saavnpb.Lyrics{Id: lyrics.ID, Synced: lyrics.Synced, Copyright: lyrics.Copyright, Snippet: lyrics.Snippet}
+	for _, line := range lyrics.Lines {
+		resp.Lines = append(resp.Lines, &saavnpb.LyricsLine{Time: line.Time, Text: line.Text})
+	}
+	return resp, nil
+}
+
+// Search runs the searches of all requested types in parallel
+func (s *saavnServer) Search(ctx context.Context, req *saavnpb.SearchRequest) (*saavnpb.SearchResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	types := req.GetTypes()
+	if len(types) == 0 {
+		types = []saavnpb.SearchType{
+			saavnpb.SearchType_SEARCH_TYPE_SONG, saavnpb.SearchType_SEARCH_TYPE_ALBUM,
+			saavnpb.SearchType_SEARCH_TYPE_ARTIST, saavnpb.SearchType_SEARCH_TYPE_PLAYLIST,
+		}
+	}
+
+	resp := &saavnpb.SearchResponse{}
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for _, searchType := range types {
+		name, ok := grpcSearchTypes[searchType]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown search type %v", searchType)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := searchFormatted(ctx, query, name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			results = results[:min(limit, len(results))]
+			for _, item := range results {
+				switch name {
+				case "song":
+					resp.Songs = append(resp.Songs, pbSong(item))
+				case "album":
+					resp.Albums = append(resp.Albums, pbAlbum(item))
+				case "artist":
+					resp.Artists = append(resp.Artists, pbArtistRef(item))
+				case "playlist":
+					resp.Playlists = append(resp.Playlists, pbPlaylist(item))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, grpcError(firstErr)
+	}
+	return resp, nil
+}
+
+// ArtistDiscography streams the albums of an artist, newest first, as the
+// pages of artist.getArtistMoreAlbum arrive
+func (s *saavnServer) ArtistDiscography(req *saavnpb.ArtistDiscographyRequest, stream grpc.ServerStreamingServer[saavnpb.Album]) error {
+	if req.GetArtistId() == "" {
+		return status.Error(codes.InvalidArgument, "artist_id is required")
+	}
+
+	sent := 0
+	for page := 0; page < discographyMaxPages; page++ {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		albums, total, err := fetchArtistAlbums(stream.Context(), req.GetArtistId(), page, discographyPageSize)
+		if err != nil {
+			return grpcError(err)
+		}
+		if req.GetIncludeSongs() {
+			albums = albumsWithSongs(stream.Context(), albums)
+		}
+		for _, album := range albums {
+			if err := stream.Send(pbAlbum(album)); err != nil {
+				return err
+			}
+		}
+		sent += len(albums)
+		if len(albums) == 0 || sent >= total {
+			return nil
+		}
+	}
+	return nil
+}
+
+// fetchArtistAlbums retrieves one page of the albums of an artist, formatted
+// as search results, with the total number of albums
+func fetchArtistAlbums(ctx context.Context, artistID string, page, count int) ([]map[string]interface{}, int, error) {
+	raw, total, err := library.FetchArtistAlbums(ctx, artistID, page, count)
+	if err != nil {
+		return nil, 0, err
+	}
+	albums := make([]map[string]interface{}, len(raw))
+	for i, album := range raw {
+		albums[i] = formatDiscographyAlbum(album)
+	}
+	return albums, total, nil
+}
+
+// formatDiscographyAlbum formats an api_version 4 album entry
+func formatDiscographyAlbum(raw map[string]interface{}) map[string]interface{} {
+	moreInfo, _ := raw["more_info"].(map[string]interface{})
+	songCount := utils.GetInt(moreInfo, "song_count")
+	if songCount == 0 {
+		songCount = utils.GetInt(raw, "list_count")
+	}
+	return map[string]interface{}{
+		"id":              utils.GetString(raw, "id"),
+		"name":            strings.TrimSpace(utils.GetString(raw, "title")),
+		"year":            utils.GetString(raw, "year"),
+		"language":        utils.GetString(raw, "language"),
+		"explicitContent": utils.GetString(raw, "explicit_content") == "1",
+		"songCount":       songCount,
+		"url":             utils.GetString(raw, "perma_url"),
+		"image":           utils.BuildImageArray(strings.Replace(utils.GetString(raw, "image"), "150x150", "500x500", 1)),
+		"artists":         utils.BuildSongArtists(raw, moreInfo),
+	}
+}
+
+// albumsWithSongs replaces albums by their details, fetched in parallel.
+// Albums whose details cannot be fetched are kept without songs.
+func albumsWithSongs(ctx context.Context, albums []map[string]interface{}) []map[string]interface{} {
+	detailed := make([]map[string]interface{}, len(albums))
+	sem := make(chan struct{}, fetchConcurrency)
+	var wg sync.WaitGroup
+	for i, album := range albums {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			detailed[i] = album
+			if raw, err := library.FetchAlbum(ctx, utils.GetString(album, "id")); err == nil {
+				detailed[i] = utils.FormatAlbum(raw)
+			}
+		}()
+	}
+	wg.Wait()
+	return detailed
+}
+
+// searchFormatted searches one type and returns the formatted results
+func searchFormatted(ctx context.Context, query, searchType string) ([]map[string]interface{}, error) {
+	raw, err := library.FetchSearch(ctx, query, searchType, cfg.SearchScripts)
+	if err != nil {
+		return nil, err
+	}
+	var formatted map[string]interface{}
+	switch searchType {
+	case "song":
+		formatted = utils.FormatSongSearch(raw)
+	case "album":
+		formatted = utils.FormatAlbumSearch(raw)
+	case "artist":
+		formatted = utils.FormatArtistSearch(raw)
+	case "playlist":
+		formatted = utils.FormatPlaylistSearch(raw)
+	}
+	data, _ := formatted["data"].(map[string]interface{})
+	results, _ := data["results"].([]map[string]interface{})
+	return results, nil
+}
+
+// grpcError reports the not found errors of the upstream fetches as NotFound,
+// and other failures as Unavailable
+func grpcError(err error) error {
+	if errors.Is(err, saavn.ErrSongNotFound) || errors.Is(err, saavn.ErrAlbumNotFound) ||
+		errors.Is(err, saavn.ErrArtistNotFound) || errors.Is(err, saavn.ErrPlaylistNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// pbSong converts a formatted song, from details, search or an album
+func pbSong(song map[string]interface{}) *saavnpb.Song {
+	artists, _ := song["artists"].(map[string]interface{})
+	resp := &saavnpb.Song{
+		Id:              utils.GetString(song, "id"),
+		Name:            utils.GetString(song, "name"),
+		Year:            utils.GetString(song, "year"),
+		ReleaseDate:     utils.GetString(song, "releaseDate"),
+		Duration:        int32(utils.GetInt(song, "duration")),
+		Label:           utils.GetString(song, "label"),
+		Copyright:       utils.GetString(song, "copyright"),
+		ExplicitContent: utils.GetBool(song, "explicitContent"),
+		PlayCount:       int64(utils.GetInt(song, "playCount")),
+		Language:        utils.GetString(song, "language"),
+		HasLyrics:       utils.GetBool(song, "hasLyrics"),
+		Url:             utils.GetString(song, "url"),
+		PrimaryArtists:  pbArtistRefs(artists["primary"]),
+		FeaturedArtists: pbArtistRefs(artists["featured"]),
+		AllArtists:      pbArtistRefs(artists["all"]),
+		Images:          pbImages(song["image"]),
+		DownloadUrls:    pbDownloadURLs(song["downloadUrl"]),
+		PreviewUrl:      utils.GetString(song, "previewUrl"),
+	}
+	if album, _ := song["album"].(map[string]interface{}); utils.GetString(album, "id") != "" {
+		resp.Album = &saavnpb.AlbumRef{
+			Id:   utils.GetString(album, "id"),
+			Name: utils.GetString(album, "name"),
+			Url:  utils.GetString(album, "url"),
+		}
+	}
+	return resp
+}
+
+// pbAlbum converts a formatted album, from details, search, a discography or
+// the top albums of an artist
+func pbAlbum(album map[string]interface{}) *saavnpb.Album {
+	artists, _ := album["artists"].(map[string]interface{})
+	resp := &saavnpb.Album{
+		Id:              utils.GetString(album, "id"),
+		Name:            utils.GetString(album, "name"),
+		Year:            utils.GetString(album, "year"),
+		Language:        utils.GetString(album, "language"),
+		ExplicitContent: utils.GetBool(album, "explicitContent"),
+		SongCount:       int32(utils.GetInt(album, "songCount")),
+		Url:             utils.GetString(album, "url"),
+		Images:          pbImages(album["image"]),
+		PrimaryArtists:  pbArtistRefs(artists["primary"]),
+	}
+	for _, song := range formattedList(album["songs"]).([]map[string]interface{}) {
+		resp.Songs = append(resp.Songs, pbSong(song))
+	}
+	return resp
+}
+
+// pbArtist converts formatted artist details
+func pbArtist(artist map[string]interface{}) *saavnpb.Artist {
+	resp := &saavnpb.Artist{
+		Id:               utils.GetString(artist, "id"),
+		Name:             utils.GetString(artist, "name"),
+		Url:              utils.GetString(artist, "url"),
+		Images:           pbImages(artist["image"]),
+		FollowerCount:    int64(utils.GetInt(artist, "followerCount")),
+		IsVerified:       utils.GetBool(artist, "isVerified"),
+		DominantLanguage: utils.GetString(artist, "dominantLanguage"),
+		DominantType:     utils.GetString(artist, "dominantType"),
+		Bio:              utils.GetString(artist, "bio"),
+	}
+	for _, song := range formattedList(artist["topSongs"]).([]map[string]interface{}) {
+		resp.TopSongs = append(resp.TopSongs, pbSong(song))
+	}
+	for _, album := range formattedList(artist["topAlbums"]).([]map[string]interface{}) {
+		resp.TopAlbums = append(resp.TopAlbums, pbAlbum(album))
+	}
+	return resp
+}
+
+// pbPlaylist converts a formatted playlist, from details or search
+func pbPlaylist(playlist map[string]interface{}) *saavnpb.Playlist {
+	resp := &saavnpb.Playlist{
+		Id:          utils.GetString(playlist, "id"),
+		Name:        utils.GetString(playlist, "name"),
+		Description: utils.GetString(playlist, "description"),
+		SongCount:   int32(utils.GetInt(playlist, "songCount")),
+		Url:         utils.GetString(playlist, "url"),
+		Images:      pbImages(playlist["image"]),
+	}
+	for _, song := range formattedList(playlist["songs"]).([]map[string]interface{}) {
+		resp.Songs = append(resp.Songs, pbSong(song))
+	}
+	return resp
+}
+
+func pbArtistRef(artist map[string]interface{}) *saavnpb.ArtistRef {
+	return &saavnpb.ArtistRef{
+		Id:     utils.GetString(artist, "id"),
+		Name:   utils.GetString(artist, "name"),
+		Role:   utils.GetString(artist, "role"),
+		Url:    utils.GetString(artist, "url"),
+		Images: pbImages(artist["image"]),
+	}
+}
+
+func pbArtistRefs(value interface{}) []*saavnpb.ArtistRef {
+	var refs []*saavnpb.ArtistRef
+	for _, artist := range formattedList(value).([]map[string]interface{}) {
+		refs = append(refs, pbArtistRef(artist))
+	}
+	return refs
+}
+
+func pbImages(value interface{}) []*saavnpb.Image {
+	var images []*saavnpb.Image
+	for _, image := range formattedImages(value).([]map[string]interface{}) {
+		images = append(images, &saavnpb.Image{Quality: utils.GetString(image, "quality"), Url: utils.GetString(image, "url")})
+	}
+	return images
+}
+
+func pbDownloadURLs(value interface{}) []*saavnpb.DownloadUrl {
+	var links []*saavnpb.DownloadUrl
+	for _, link := range formattedList(value).([]map[string]interface{}) {
+		links = append(links, &saavnpb.DownloadUrl{Quality: utils.GetString(link, "quality"), Url: utils.GetString(link, "url")})
+	}
+	return links
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jioSaavnAPI/saavnpb"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcDiscographySize is the number of albums of artist a1 on the fake upstream
+const grpcDiscographySize = 60
+
+// newGRPCTestClient serves the gRPC service over an in-memory connection,
+// backed by a fake upstream with songs s1 and s2, artist a1 and a song "down"
+// whose details fail, and returns a client of it with the number of
+// song.getDetails calls
+func newGRPCTestClient(t *testing.T) (saavnpb.SaavnClient, *atomic.Int32) {
+	t.Helper()
+	var detailCalls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch query.Get("__call") {
+		case "song.getDetails":
+			detailCalls.Add(1)
+			songs := map[string]interface{}{}
+			for _, id := range strings.Split(query.Get("pids"), ",") {
+				switch id {
+				case "down":
+					http.Error(w, "upstream down", http.StatusInternalServerError)
+					return
+				case "s1", "s2":
+					songs[id] = map[string]interface{}{"id": id, "song": "Song " + id, "duration": "200", "year": "2013"}
+				}
+			}
+			json.NewEncoder(w).Encode(songs)
+
+		case "artist.getArtistMoreAlbum":
+			if query.Get("artistId") != "a1" {
+				json.NewEncoder(w).Encode(map[string]interface{}{})
+				return
+			}
+			page, _ := strconv.Atoi(query.Get("page"))
+			count, _ := strconv.Atoi(query.Get("n_album"))
+			albums := []map[string]interface{}{}
+			for i := page * count; i < min((page+1)*count, grpcDiscographySize); i++ {
+				albums = append(albums, map[string]interface{}{"id": fmt.Sprintf("al%d", i+1), "title": fmt.Sprintf("Album %d", i+1)})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"topAlbums": map[string]interface{}{"albums": albums, "total": grpcDiscographySize},
+			})
+
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	baseURL := library.BaseURL
+	library.BaseURL = upstream.URL
+	t.Cleanup(func() { library.BaseURL = baseURL })
+
+	lis := bufconn.Listen(1 << 20)
+	server := newGRPCServer()
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return saavnpb.NewSaavnClient(conn), &detailCalls
+}
+
+func TestGRPCGetSong(t *testing.T) {
+	client, _ := newGRPCTestClient(t)
+
+	song, err := client.GetSong(context.Background(), &saavnpb.GetSongRequest{Id: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.GetId() != "s1" || song.GetName() != "Song s1" || song.GetDuration() != 200 || song.GetYear() != "2013" {
+		t.Errorf("song = %v", song)
+	}
+}
+
+func TestGRPCBatchGetSongs(t *testing.T) {
+	client, detailCalls := newGRPCTestClient(t)
+
+	resp, err := client.BatchGetSongs(context.Background(), &saavnpb.BatchGetSongsRequest{Ids: []string{"s1", "missing", "s2", "s1", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, song := range resp.GetSongs() {
+		ids = append(ids, song.GetId())
+	}
+	if got := strings.Join(ids, ","); got != "s1,s2" {
+		t.Errorf("songs = %s, want s1,s2", got)
+	}
+	if got := strings.Join(resp.GetMissingIds(), ","); got != "missing" {
+		t.Errorf("missing ids = %s, want missing", got)
+	}
+	if n := detailCalls.Load(); n != 1 {
+		t.Errorf("song.getDetails called %d times, want once", n)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	client, _ := newGRPCTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"missing id", func() error {
+			_, err := client.GetSong(ctx, &saavnpb.GetSongRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"no ids", func() error {
+			_, err := client.BatchGetSongs(ctx, &saavnpb.BatchGetSongsRequest{Ids: []string{""}})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown song", func() error {
+			_, err := client.GetSong(ctx, &saavnpb.GetSongRequest{Id: "unknown"})
+			return err
+		}, codes.NotFound},
+		{"upstream failure", func() error {
+			_, err := client.GetSong(ctx, &saavnpb.GetSongRequest{Id: "down"})
+			return err
+		}, codes.Unavailable},
+		{"unknown search type", func() error {
+			_, err := client.Search(ctx, &saavnpb.SearchRequest{Query: "tum hi ho", Types: []saavnpb.SearchType{99}})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown artist", func() error {
+			stream, err := client.ArtistDiscography(ctx, &saavnpb.ArtistDiscographyRequest{ArtistId: "unknown"})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCArtistDiscography(t *testing.T) {
+	client, _ := newGRPCTestClient(t)
+
+	stream, err := client.ArtistDiscography(context.Background(), &saavnpb.ArtistDiscographyRequest{ArtistId: "a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for {
+		album, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, album.GetId())
+	}
+	if len(ids) != grpcDiscographySize {
+		t.Fatalf("streamed %d albums, want %d over two pages", len(ids), grpcDiscographySize)
+	}
+	if ids[0] != "al1" || ids[grpcDiscographySize-1] != fmt.Sprintf("al%d", grpcDiscographySize) {
+		t.Errorf("albums run from %s to %s", ids[0], ids[len(ids)-1])
+	}
+}
@@ -0,0 +1,596 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hlsContentType is the MIME type of HLS playlists
+const hlsContentType = "application/vnd.apple.mpegurl"
+
+// hlsSegmentDuration is the target duration of the segments the songs are cut into
+const hlsSegmentDuration = 6 * time.Second
+
+// hlsMediaTTL is how long the sample table of a media file is kept, so that
+// the segment requests following a playlist do not read it again
+const hlsMediaTTL = 10 * time.Minute
+
+// hlsMediaCacheSize bounds the number of sample tables kept
+const hlsMediaCacheSize = 256
+
+// hlsLoadConcurrency bounds the media files of an album read at once
+const hlsLoadConcurrency = 4
+
+// hlsTrack is a song listed in an HLS media playlist
+type hlsTrack struct {
+	ID           string
+	Title        string
+	DownloadURLs []map[string]string
+}
+
+// newHLSTrack builds an HLS track from raw song data, whose media was resolved
+// with utils.ResolveSongMedia
+func newHLSTrack(songData map[string]interface{}) hlsTrack {
+	title := strings.TrimSpace(utils.GetString(songData, "song"))
+	if artists := strings.TrimSpace(utils.GetString(songData, "primary_artists")); artists != "" {
+		title = artists + " - " + title
+	}
+	media := utils.SongMedia(songData)
+	return hlsTrack{
+		ID:           utils.GetString(songData, "id"),
+		Title:        html.UnescapeString(title),
+		DownloadURLs: media.DownloadURLs,
+	}
+}
+
+// mediaURL returns the URL of the track's media file of exactly the given
+// quality, or "" when there is none. Variants never fall back to another
+// quality, which would not match their BANDWIDTH.
+func (t hlsTrack) mediaURL(quality string) string {
+	for _, entry := range t.DownloadURLs {
+		if strings.TrimSuffix(entry["quality"], "kbps") == quality {
+			return entry["url"]
+		}
+	}
+	return ""
+}
+
+// HLSSongHandler serves the HLS playlists of a song
+// @Summary      HLS playlist for a song
+// @Description  /hls/song/{id}.m3u8 returns a master playlist with one variant per quality of the media ladder; /hls/song/{id}/{quality}.m3u8 returns the media playlist of one variant. The song is served as fragmented MP4 segments of about 6 seconds from /hls/song/{id}/{quality}/{segment}
+// @Tags         Media
+// @Produce      application/vnd.apple.mpegurl
+// @Param        id       path      string  true   "Song ID, followed by .m3u8"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      502  {object}  map[string]interface{}
+// @Router       /hls/song/{id}.m3u8 [get]
+func HLSSongHandler(c *gin.Context) {
+	id, ok := hlsPlaylistName(c.Param("id"), c.Param("quality") == "")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing song ID",
+		})
+		return
+	}
+
+	songData, err := library.FetchSong(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, saavn.ErrSongNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Song not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch song",
+		})
+		return
+	}
+
+	utils.ResolveSongMedia(c.Request.Context(), songData)
+	serveHLS(c, "/hls/song/"+url.PathEscape(id), []hlsTrack{newHLSTrack(songData)})
+}
+
+// HLSAlbumHandler serves the HLS playlists of an album
+// @Summary      HLS playlist for an album
+// @Description  /hls/album/{id}.m3u8 returns a master playlist with one variant per quality available for every track; each variant lists the segments of the album tracks in order, separated by discontinuities
+// @Tags         Media
+// @Produce      application/vnd.apple.mpegurl
+// @Param        id       path      string  true   "Album ID, followed by .m3u8"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      502  {object}  map[string]interface{}
+// @Router       /hls/album/{id}.m3u8 [get]
+func HLSAlbumHandler(c *gin.Context) {
+	id, ok := hlsPlaylistName(c.Param("id"), c.Param("quality") == "")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing album ID",
+		})
+		return
+	}
+
+	albumData, err := library.FetchAlbum(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, saavn.ErrAlbumNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Album not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch album",
+		})
+		return
+	}
+
+	utils.ResolveSongMedia(c.Request.Context(), albumData["songs"])
+	tracks := []hlsTrack{}
+	if songs, ok := albumData["songs"].([]interface{}); ok {
+		for _, s := range songs {
+			if songMap, ok := s.(map[string]interface{}); ok {
+				tracks = append(tracks, newHLSTrack(songMap))
+			}
+		}
+	}
+
+	serveHLS(c, "/hls/album/"+url.PathEscape(id), tracks)
+}
+
+// HLSSegmentHandler serves the init segment and the media segments of a song
+// @Summary      HLS segment of a song
+// @Description  Serves init.mp4, the fragmented MP4 header of a song at one quality, or {n}.m4s, its n-th segment from 0, as listed in the HLS media playlists. When signed links are enabled, exp and sig must be those of a link issued by this API
+// @Tags         Media
+// @Produce      audio/mp4
+// @Param        id       path      string  true   "Song ID"
+// @Param        quality  path      string  true   "Bitrate in kbps: 12, 48, 96, 160 or 320"
+// @Param        segment  path      string  true   "init.mp4 or {n}.m4s"
+// @Param        exp      query     int     false  "Link expiry as a Unix timestamp (signed links)"
+// @Param        sig      query     string  false  "Link signature (signed links)"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      410  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Failure      502  {object}  map[string]interface{}
+// @Router       /hls/song/{id}/{quality}/{segment} [get]
+func HLSSegmentHandler(c *gin.Context) {
+	id := c.Param("id")
+	quality := strings.TrimSuffix(c.Param("quality"), "kbps")
+	if !validQuality(quality) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid quality. Use one of: " + strings.Join(utils.MediaQualities, ", "),
+		})
+		return
+	}
+	if !verifySignedLink(c, "hls", id, quality) {
+		return
+	}
+
+	segment := c.Param("segment")
+	number := -1
+	if name, found := strings.CutSuffix(segment, ".m4s"); found {
+		if n, err := strconv.Atoi(name); err == nil && n >= 0 {
+			number = n
+		}
+	}
+	if segment != "init.mp4" && number < 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Segment not found",
+		})
+		return
+	}
+
+	media, err := loadHLSMedia(c.Request.Context(), id, quality, "")
+	if err != nil {
+		respondHLSMediaError(c, err)
+		return
+	}
+	if number < 0 {
+		c.Data(http.StatusOK, "audio/mp4", media.index.InitSegment())
+		return
+	}
+	if number >= len(media.segments) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Segment not found",
+		})
+		return
+	}
+
+	r := &rangeReader{ctx: c.Request.Context(), url: media.url}
+	fragment, err := media.index.ReadFragment(r, media.segments[number], uint32(number+1))
+	if err != nil {
+		if c.Request.Context().Err() != nil {
+			c.Abort()
+			return
+		}
+		// The CDN URL may have expired, so it is resolved again next time
+		forgetHLSMedia(id, quality)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   "Failed to fetch media",
+		})
+		return
+	}
+	c.Data(http.StatusOK, "audio/mp4", fragment)
+}
+
+// respondHLSMediaError writes the error response for media that could not be
+// read for HLS
+func respondHLSMediaError(c *gin.Context, err error) {
+	var mediaErr *hlsMediaError
+	if errors.As(err, &mediaErr) {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   "Failed to read media",
+		})
+		return
+	}
+	respondMediaError(c, err)
+}
+
+// hlsPlaylistName strips the .m3u8 extension from a path parameter. The
+// extension is required on the last path segment only.
+func hlsPlaylistName(param string, last bool) (string, bool) {
+	name := param
+	if last {
+		var found bool
+		if name, found = strings.CutSuffix(param, ".m3u8"); !found {
+			return "", false
+		}
+	}
+	return name, name != ""
+}
+
+// serveHLS writes the master playlist, or the media playlist of the quality
+// in the request path. Tracks without media are left out.
+func serveHLS(c *gin.Context, basePath string, tracks []hlsTrack) {
+	playable := []hlsTrack{}
+	for _, track := range tracks {
+		if len(track.DownloadURLs) > 0 {
+			playable = append(playable, track)
+		}
+	}
+	qualities := hlsQualities(playable)
+	if len(qualities) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Media not available",
+		})
+		return
+	}
+
+	if c.Param("quality") == "" {
+		playlist, err := hlsMasterPlaylist(c.Request.Context(), basePath, playable, qualities)
+		if err != nil {
+			respondHLSMediaError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, hlsContentType, []byte(playlist))
+		return
+	}
+
+	quality, ok := hlsPlaylistName(c.Param("quality"), true)
+	quality = strings.TrimSuffix(quality, "kbps")
+	if !ok || !validQuality(quality) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid quality. Use one of: " + strings.Join(utils.MediaQualities, ", "),
+		})
+		return
+	}
+	if !containsString(qualities, quality) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Quality not available. Use one of: " + strings.Join(qualities, ", "),
+		})
+		return
+	}
+
+	media, err := loadHLSTracks(c.Request.Context(), playable, quality)
+	if err != nil {
+		respondHLSMediaError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, hlsContentType, []byte(hlsMediaPlaylist(playable, media, quality)))
+}
+
+// hlsQualities returns the qualities of the media ladder available for every track
+func hlsQualities(tracks []hlsTrack) []string {
+	qualities := []string{}
+	if len(tracks) == 0 {
+		return qualities
+	}
+	for _, quality := range utils.MediaQualities {
+		available := true
+		for _, track := range tracks {
+			if track.mediaURL(quality) == "" {
+				available = false
+				break
+			}
+		}
+		if available {
+			qualities = append(qualities, quality)
+		}
+	}
+	return qualities
+}
+
+// hlsMasterPlaylist lists one variant playlist per quality, highest first.
+// The bandwidths and codecs are those of the segments of the variant, so the
+// media of every track is read at every quality.
+func hlsMasterPlaylist(ctx context.Context, basePath string, tracks []hlsTrack, qualities []string) (string, error) {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for i := len(qualities) - 1; i >= 0; i-- {
+		quality := qualities[i]
+		media, err := loadHLSTracks(ctx, tracks, quality)
+		if err != nil {
+			return "", err
+		}
+
+		var peak, total, seconds float64
+		codecs := []string{}
+		for _, m := range media {
+			for _, segment := range m.segments {
+				size := float64(m.index.FragmentSize(segment))
+				duration := m.index.SegmentDuration(segment).Seconds()
+				peak = max(peak, size*8/duration)
+				total += size
+				seconds += duration
+			}
+			codec := m.index.Codec
+			if codec == "" {
+				// The JioSaavn media is AAC-LC
+				codec = "mp4a.40.2"
+			}
+			if !containsString(codecs, codec) {
+				codecs = append(codecs, codec)
+			}
+		}
+
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d,CODECS=\"%s\"\n",
+			int64(math.Ceil(peak)), int64(math.Ceil(total*8/seconds)), strings.Join(codecs, ","))
+		fmt.Fprintf(&b, "%s%s/%s.m3u8\n", cfg.PublicBaseURL, basePath, quality)
+	}
+	return b.String(), nil
+}
+
+// hlsMediaPlaylist lists the segments of the tracks in order, each track
+// starting with its init segment after a discontinuity
+func hlsMediaPlaylist(tracks []hlsTrack, media []*hlsMedia, quality string) string {
+	targetDuration := 1
+	for _, m := range media {
+		for _, segment := range m.segments {
+			// EXTINF durations rounded to the nearest integer must not exceed it
+			targetDuration = max(targetDuration, int(math.Round(m.index.SegmentDuration(segment).Seconds())))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", targetDuration)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+	for i, track := range tracks {
+		if i > 0 {
+			// Every track has its own init segment and timestamps
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		base := fmt.Sprintf("%s/hls/song/%s/%s/", cfg.PublicBaseURL, url.PathEscape(track.ID), quality)
+		signature := ""
+		if query := utils.LinkSignature("hls", track.ID, quality); len(query) > 0 {
+			signature = "?" + query.Encode()
+		}
+
+		fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"%sinit.mp4%s\"\n", base, signature)
+		for n, segment := range media[i].segments {
+			seconds := media[i].index.SegmentDuration(segment).Seconds()
+			fmt.Fprintf(&b, "#EXTINF:%.3f,%s\n%s%d.m4s%s\n", seconds, hlsTitle(track.Title), base, n, signature)
+		}
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+// hlsTitle keeps a title on a single playlist line
+func hlsTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hlsMedia is the media file of a song at one quality with its sample table
+type hlsMedia struct {
+	url      string
+	index    *utils.M4AIndex
+	segments []utils.M4ASegment
+	expires  time.Time
+}
+
+// hlsMediaError reports a media file that could not be read or is not an M4A file
+type hlsMediaError struct {
+	err error
+}
+
+func (e *hlsMediaError) Error() string {
+	return "failed to read media: " + e.err.Error()
+}
+
+func (e *hlsMediaError) Unwrap() error {
+	return e.err
+}
+
+var hlsMediaCache = struct {
+	sync.Mutex
+	entries map[string]*hlsMedia
+}{entries: map[string]*hlsMedia{}}
+
+// loadHLSTracks reads the media of every track at the given quality
+func loadHLSTracks(ctx context.Context, tracks []hlsTrack, quality string) ([]*hlsMedia, error) {
+	media := make([]*hlsMedia, len(tracks))
+	errs := make([]error, len(tracks))
+	slots := make(chan struct{}, hlsLoadConcurrency)
+	var wg sync.WaitGroup
+	for i, track := range tracks {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, track hlsTrack) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			media[i], errs[i] = loadHLSMedia(ctx, track.ID, quality, track.mediaURL(quality))
+		}(i, track)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// loadHLSMedia returns the media file of a song at exactly the given quality
+// with its sample table, from the cache or read from mediaURL. The media is
+// resolved when mediaURL is empty.
+func loadHLSMedia(ctx context.Context, id, quality, mediaURL string) (*hlsMedia, error) {
+	key := id + "|" + quality
+	hlsMediaCache.Lock()
+	cached, ok := hlsMediaCache.entries[key]
+	hlsMediaCache.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached, nil
+	}
+
+	if mediaURL == "" {
+		resolved, err := library.ResolveMedia(ctx, id, quality)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.Quality != quality {
+			return nil, fmt.Errorf("%w: no %skbps media", saavn.ErrMediaUnavailable, quality)
+		}
+		mediaURL = resolved.URL
+	}
+
+	index, err := utils.ReadM4AIndex(&rangeReader{ctx: ctx, url: mediaURL})
+	if err == nil && len(index.Samples) == 0 {
+		err = errors.New("no samples")
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("HLS: failed to read the %skbps media of %s: %v", quality, id, err)
+		return nil, &hlsMediaError{err: err}
+	}
+	media := &hlsMedia{
+		url:      mediaURL,
+		index:    index,
+		segments: index.Segments(hlsSegmentDuration),
+		expires:  time.Now().Add(hlsMediaTTL),
+	}
+
+	hlsMediaCache.Lock()
+	defer hlsMediaCache.Unlock()
+	if len(hlsMediaCache.entries) >= hlsMediaCacheSize {
+		now := time.Now()
+		for k, entry := range hlsMediaCache.entries {
+			if now.After(entry.expires) {
+				delete(hlsMediaCache.entries, k)
+			}
+		}
+		// Still full: drop an arbitrary entry
+		for k := range hlsMediaCache.entries {
+			if len(hlsMediaCache.entries) < hlsMediaCacheSize {
+				break
+			}
+			delete(hlsMediaCache.entries, k)
+		}
+	}
+	hlsMediaCache.entries[key] = media
+	return media, nil
+}
+
+// forgetHLSMedia drops the cached media of a song at one quality
+func forgetHLSMedia(id, quality string) {
+	hlsMediaCache.Lock()
+	delete(hlsMediaCache.entries, id+"|"+quality)
+	hlsMediaCache.Unlock()
+}
+
+// rangeReader reads a media file from the CDN with HTTP Range requests
+type rangeReader struct {
+	ctx context.Context
+	url string
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+
+	resp, err := mediaClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != off {
+			return 0, fmt.Errorf("unexpected Content-Range %q for offset %d", resp.Header.Get("Content-Range"), off)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		return 0, io.EOF
+	default:
+		return 0, fmt.Errorf("media server returned status: %d", resp.StatusCode)
+	}
+
+	n, err := io.ReadFull(resp.Body, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// The file ends within the range
+		return n, io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hlsTestBaseURL is the public URL of the server in the playlists
+const hlsTestBaseURL = "http://api.test"
+
+// newHLSTestServer serves testdata/song.m4a, AAC at 44.1 kHz, from a fake CDN
+// at every path, and returns a router serving the playlists of tracks under
+// /test and the segments of the songs. requests counts the CDN requests.
+func newHLSTestServer(t *testing.T, tracks func(cdn string) []hlsTrack) (router *gin.Engine, requests *atomic.Int64) {
+	t.Helper()
+	song, err := os.ReadFile("testdata/song.m4a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests = new(atomic.Int64)
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, r, "song.mp4", time.Time{}, bytes.NewReader(song))
+	}))
+	t.Cleanup(cdn.Close)
+
+	publicURL := cfg.PublicBaseURL
+	cfg.PublicBaseURL = hlsTestBaseURL
+	hlsMediaCache.Lock()
+	hlsMediaCache.entries = map[string]*hlsMedia{}
+	hlsMediaCache.Unlock()
+	t.Cleanup(func() {
+		cfg.PublicBaseURL = publicURL
+		hlsMediaCache.Lock()
+		hlsMediaCache.entries = map[string]*hlsMedia{}
+		hlsMediaCache.Unlock()
+	})
+
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	playlists := func(c *gin.Context) { serveHLS(c, "/test/"+c.Param("id"), tracks(cdn.URL)) }
+	router.GET("/test/:id", playlists)
+	router.GET("/test/:id/:quality", playlists)
+	router.GET("/hls/song/:id/:quality/:segment", HLSSegmentHandler)
+	return router, requests
+}
+
+// hlsGet requests a URL of the playlists from router
+func hlsGet(t *testing.T, router *gin.Engine, uri string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(uri, hlsTestBaseURL), nil))
+	return w
+}
+
+// hlsSegment is a segment listed in a media playlist
+type hlsSegment struct {
+	uri      string
+	init     string
+	duration float64
+}
+
+// checkMediaPlaylist checks a media playlist against RFC 8216 and returns its
+// segments with the init segment they follow
+func checkMediaPlaylist(t *testing.T, playlist string) (segments []hlsSegment, discontinuities int) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(playlist, "\n"), "\n")
+	if lines[0] != "#EXTM3U" {
+		t.Fatalf("playlist starts with %q", lines[0])
+	}
+	if lines[len(lines)-1] != "#EXT-X-ENDLIST" {
+		t.Errorf("playlist ends with %q, want #EXT-X-ENDLIST", lines[len(lines)-1])
+	}
+
+	tags := map[string]string{}
+	var init string
+	duration := -1.0
+	for _, line := range lines[1:] {
+		name, value, _ := strings.Cut(line, ":")
+		switch {
+		case line == "#EXT-X-DISCONTINUITY":
+			if duration >= 0 {
+				t.Errorf("discontinuity between EXTINF and its URI")
+			}
+			discontinuities++
+			// Every track has its own init segment
+			init = ""
+		case name == "#EXT-X-MAP":
+			uri, found := strings.CutPrefix(value, `URI="`)
+			if !found || !strings.HasSuffix(uri, `"`) {
+				t.Fatalf("malformed EXT-X-MAP %q", line)
+			}
+			init = strings.TrimSuffix(uri, `"`)
+		case name == "#EXTINF":
+			seconds, _, _ := strings.Cut(value, ",")
+			var err error
+			if duration, err = strconv.ParseFloat(seconds, 64); err != nil || duration <= 0 {
+				t.Fatalf("malformed EXTINF %q", line)
+			}
+		case strings.HasPrefix(line, "#"):
+			if _, seen := tags[name]; seen && name != "#EXT-X-ENDLIST" {
+				t.Errorf("tag %s repeated", name)
+			}
+			tags[name] = value
+		default:
+			if duration < 0 {
+				t.Fatalf("segment %q without EXTINF", line)
+			}
+			if init == "" {
+				t.Fatalf("segment %q without EXT-X-MAP", line)
+			}
+			segments = append(segments, hlsSegment{uri: line, init: init, duration: duration})
+			duration = -1
+		}
+	}
+
+	// EXT-X-MAP outside of I-frame playlists requires version 6
+	if version, err := strconv.Atoi(tags["#EXT-X-VERSION"]); err != nil || version < 6 {
+		t.Errorf("version = %q, want 6 or higher", tags["#EXT-X-VERSION"])
+	}
+	if tags["#EXT-X-PLAYLIST-TYPE"] != "VOD" || tags["#EXT-X-MEDIA-SEQUENCE"] != "0" {
+		t.Errorf("playlist type %q from sequence %q, want VOD from 0", tags["#EXT-X-PLAYLIST-TYPE"], tags["#EXT-X-MEDIA-SEQUENCE"])
+	}
+	target, err := strconv.Atoi(tags["#EXT-X-TARGETDURATION"])
+	if err != nil {
+		t.Fatalf("target duration = %q", tags["#EXT-X-TARGETDURATION"])
+	}
+	longest := 0
+	for _, segment := range segments {
+		longest = max(longest, int(math.Round(segment.duration)))
+	}
+	// Every EXTINF rounded to the nearest integer is at most the target
+	// duration, which is not inflated beyond the longest segment
+	if longest > target || target > max(longest, 1) {
+		t.Errorf("target duration = %d, longest segment rounds to %d", target, longest)
+	}
+	return segments, discontinuities
+}
+
+// fragmentDuration returns the duration in seconds of a moof and mdat fragment
+// of the test song, checking that it starts at start seconds
+func fragmentDuration(t *testing.T, fragment []byte, start float64) float64 {
+	t.Helper()
+	if len(fragment) < 16 || string(fragment[4:8]) != "moof" {
+		t.Fatalf("fragment does not start with moof")
+	}
+	moofSize := int(binary.BigEndian.Uint32(fragment[:4]))
+	if moofSize+8 > len(fragment) || string(fragment[moofSize+4:moofSize+8]) != "mdat" || int(binary.BigEndian.Uint32(fragment[moofSize:])) != len(fragment)-moofSize {
+		t.Fatalf("fragment is not a moof and an mdat box")
+	}
+
+	tfdt := bytes.Index(fragment[:moofSize], []byte("tfdt"))
+	if base := float64(binary.BigEndian.Uint64(fragment[tfdt+8:])) / 44100; math.Abs(base-start) > 0.0005 {
+		t.Errorf("fragment starts at %.3fs, want %.3fs", base, start)
+	}
+	trun := bytes.Index(fragment[:moofSize], []byte("trun")) + 4
+	count := int(binary.BigEndian.Uint32(fragment[trun+4:]))
+	var total uint64
+	for i := 0; i < count; i++ {
+		total += uint64(binary.BigEndian.Uint32(fragment[trun+12+i*8:]))
+	}
+	return float64(total) / 44100
+}
+
+// hlsTestTrack is a track of the test song available at the given qualities
+func hlsTestTrack(cdn, id string, qualities ...string) hlsTrack {
+	track := hlsTrack{ID: id, Title: "Arijit Singh -\tTum Hi Ho"}
+	for _, quality := range qualities {
+		track.DownloadURLs = append(track.DownloadURLs, map[string]string{
+			"quality": quality + "kbps",
+			"url":     cdn + "/" + id + "_" + quality + ".mp4",
+		})
+	}
+	return track
+}
+
+// streamInf matches the attributes of a variant in a master playlist
+var streamInf = regexp.MustCompile(`^#EXT-X-STREAM-INF:BANDWIDTH=(\d+),AVERAGE-BANDWIDTH=(\d+),CODECS="([^"]+)"$`)
+
+func TestHLSSong(t *testing.T) {
+	router, _ := newHLSTestServer(t, func(cdn string) []hlsTrack {
+		return []hlsTrack{hlsTestTrack(cdn, "s1", "96", "160")}
+	})
+
+	w := hlsGet(t, router, "/test/s1")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != hlsContentType {
+		t.Fatalf("master playlist: %d %s", w.Code, w.Body)
+	}
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != 7 || lines[0] != "#EXTM3U" || lines[1] != "#EXT-X-VERSION:7" || lines[2] != "#EXT-X-INDEPENDENT-SEGMENTS" {
+		t.Fatalf("master playlist:\n%s", w.Body)
+	}
+
+	// Only the qualities of the song are listed, highest first
+	variants := []string{lines[4], lines[6]}
+	if want := []string{hlsTestBaseURL + "/test/s1/160.m3u8", hlsTestBaseURL + "/test/s1/96.m3u8"}; strings.Join(variants, " ") != strings.Join(want, " ") {
+		t.Errorf("variants = %q, want %q", variants, want)
+	}
+
+	for i, variant := range variants {
+		m := streamInf.FindStringSubmatch(lines[3+i*2])
+		if m == nil {
+			t.Fatalf("malformed variant %q", lines[3+i*2])
+		}
+		bandwidth, _ := strconv.ParseFloat(m[1], 64)
+		average, _ := strconv.ParseFloat(m[2], 64)
+		if m[3] != "mp4a.40.2" {
+			t.Errorf("codecs = %q, want mp4a.40.2", m[3])
+		}
+
+		w := hlsGet(t, router, variant)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", variant, w.Code, w.Body)
+		}
+		segments, discontinuities := checkMediaPlaylist(t, w.Body.String())
+		if discontinuities != 0 || len(segments) != 2 {
+			t.Fatalf("%s: %d segments after %d discontinuities, want 2 after none", variant, len(segments), discontinuities)
+		}
+		quality := strings.TrimSuffix(path.Base(variant), ".m3u8")
+		if want := hlsTestBaseURL + "/hls/song/s1/" + quality + "/"; segments[0].init != want+"init.mp4" || segments[1].uri != want+"1.m4s" {
+			t.Errorf("segments = %q, %q, want them under %s", segments[0].init, segments[1].uri, want)
+		}
+
+		init := hlsGet(t, router, segments[0].init)
+		if init.Code != http.StatusOK || !bytes.Contains(init.Body.Bytes(), []byte("mvex")) {
+			t.Fatalf("init segment: %d", init.Code)
+		}
+
+		var peak, total, seconds, start float64
+		for _, segment := range segments {
+			w := hlsGet(t, router, segment.uri)
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "audio/mp4" {
+				t.Fatalf("%s: %d %s", segment.uri, w.Code, w.Body)
+			}
+			duration := fragmentDuration(t, w.Body.Bytes(), start)
+			if math.Abs(duration-segment.duration) > 0.0005 {
+				t.Errorf("%s lasts %.4fs, EXTINF says %.3f", segment.uri, duration, segment.duration)
+			}
+			size := float64(w.Body.Len())
+			peak = max(peak, size*8/duration)
+			total += size
+			seconds += duration
+			start += duration
+		}
+
+		// BANDWIDTH is the peak segment bit rate and AVERAGE-BANDWIDTH the
+		// average, both rounded up
+		if bandwidth < peak || bandwidth > peak*1.01+1 {
+			t.Errorf("BANDWIDTH = %.0f, segments peak at %.0f bit/s", bandwidth, peak)
+		}
+		if average < total*8/seconds || average > bandwidth {
+			t.Errorf("AVERAGE-BANDWIDTH = %.0f, segments average %.0f bit/s", average, total*8/seconds)
+		}
+		if math.Abs(seconds-12.005) > 0.001 {
+			t.Errorf("segments last %.3fs, want the 12.005s of the song", seconds)
+		}
+	}
+
+	// Variants do not fall back to another quality
+	if w := hlsGet(t, router, "/test/s1/320.m3u8"); w.Code != http.StatusNotFound {
+		t.Errorf("320 kbps playlist: %d, want 404", w.Code)
+	}
+	if w := hlsGet(t, router, "/test/s1/12.m3u8"); w.Code != http.StatusNotFound {
+		t.Errorf("12 kbps playlist: %d, want 404", w.Code)
+	}
+	if w := hlsGet(t, router, "/hls/song/s1/160/2.m4s"); w.Code != http.StatusNotFound {
+		t.Errorf("segment past the end: %d, want 404", w.Code)
+	}
+	if w := hlsGet(t, router, "/hls/song/s1/160/first.m4s"); w.Code != http.StatusNotFound {
+		t.Errorf("malformed segment name: %d, want 404", w.Code)
+	}
+}
+
+func TestHLSAlbum(t *testing.T) {
+	router, requests := newHLSTestServer(t, func(cdn string) []hlsTrack {
+		return []hlsTrack{
+			hlsTestTrack(cdn, "s1", "96", "160"),
+			{ID: "s2", Title: "No media"},
+			hlsTestTrack(cdn, "s3", "96"),
+		}
+	})
+
+	w := hlsGet(t, router, "/test/al1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("master playlist: %d %s", w.Code, w.Body)
+	}
+	// Only the qualities of every track are listed
+	if variants := strings.Count(w.Body.String(), "#EXT-X-STREAM-INF"); variants != 1 || !strings.Contains(w.Body.String(), "/test/al1/96.m3u8") {
+		t.Errorf("master playlist:\n%s", w.Body)
+	}
+	if w := hlsGet(t, router, "/test/al1/160.m3u8"); w.Code != http.StatusNotFound {
+		t.Errorf("160 kbps playlist: %d, want 404", w.Code)
+	}
+
+	w = hlsGet(t, router, "/test/al1/96.m3u8")
+	if w.Code != http.StatusOK {
+		t.Fatalf("media playlist: %d %s", w.Code, w.Body)
+	}
+	segments, discontinuities := checkMediaPlaylist(t, w.Body.String())
+	if discontinuities != 1 || len(segments) != 4 {
+		t.Fatalf("%d segments after %d discontinuities, want 4 after 1:\n%s", len(segments), discontinuities, w.Body)
+	}
+	if segments[0].init != hlsTestBaseURL+"/hls/song/s1/96/init.mp4" || segments[2].init != hlsTestBaseURL+"/hls/song/s3/96/init.mp4" {
+		t.Errorf("init segments = %q, %q", segments[0].init, segments[2].init)
+	}
+	if !strings.Contains(w.Body.String(), ",Arijit Singh - Tum Hi Ho\n") {
+		t.Errorf("titles are not on one line:\n%s", w.Body)
+	}
+
+	// The segments reuse the sample tables read for the playlists
+	before := requests.Load()
+	for _, segment := range segments {
+		if w := hlsGet(t, router, segment.uri); w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", segment.uri, w.Code, w.Body)
+		}
+	}
+	if got := requests.Load() - before; got != int64(len(segments)) {
+		t.Errorf("%d CDN requests for %d segments, want one each", got, len(segments))
+	}
+}
+
+func TestHLSSignedSegments(t *testing.T) {
+	router, _ := newHLSTestServer(t, func(cdn string) []hlsTrack {
+		return []hlsTrack{hlsTestTrack(cdn, "s1", "160")}
+	})
+	secret := cfg.DownloadSigningSecret
+	cfg.DownloadSigningSecret = "secret"
+	Configure()
+	t.Cleanup(func() {
+		cfg.DownloadSigningSecret = secret
+		Configure()
+	})
+
+	w := hlsGet(t, router, "/test/s1/160.m3u8")
+	if w.Code != http.StatusOK {
+		t.Fatalf("media playlist: %d %s", w.Code, w.Body)
+	}
+	segments, _ := checkMediaPlaylist(t, w.Body.String())
+	for _, uri := range []string{segments[0].init, segments[0].uri} {
+		if !strings.Contains(uri, "sig=") {
+			t.Errorf("%s is not signed", uri)
+		}
+		if w := hlsGet(t, router, uri); w.Code != http.StatusOK {
+			t.Errorf("%s: %d %s", uri, w.Code, w.Body)
+		}
+		unsigned, _, _ := strings.Cut(uri, "?")
+		if w := hlsGet(t, router, unsigned); w.Code != http.StatusForbidden {
+			t.Errorf("%s: %d, want 403", unsigned, w.Code)
+		}
+	}
+	// The signature covers the song and the quality
+	if w := hlsGet(t, router, strings.Replace(segments[0].uri, "/s1/", "/s2/", 1)); w.Code != http.StatusForbidden {
+		t.Errorf("segment of another song: %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,614 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/utils"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize bounds the size of an uploaded playlist file
+const maxImportSize = 5 << 20
+
+// importFormats are the playlist formats accepted by the importer
+var importFormats = []string{"csv", "m3u", "xspf", "jspf", "json"}
+
+// ErrImportFormat is returned for playlist files that cannot be parsed
+var ErrImportFormat = errors.New("unsupported playlist format")
+
+// ImportTrack is a track read from an external playlist
+type ImportTrack = match.Track
+
+// ImportCandidate is a JioSaavn song considered for an imported track
+type ImportCandidate struct {
+	Song       map[string]interface{}   `json:"song"`
+	Confidence float64                  `json:"confidence"`
+	Breakdown  map[string]match.Feature `json:"breakdown"`
+	Penalties  []string                 `json:"penalties"`
+}
+
+// ImportMatch is the outcome of resolving one imported track
+type ImportMatch struct {
+	Index        int               `json:"index"`
+	Input        ImportTrack       `json:"input"`
+	Match        *ImportCandidate  `json:"match"`
+	Alternatives []ImportCandidate `json:"alternatives"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// ImportResult is the outcome of importing a playlist
+type ImportResult struct {
+	Format    string        `json:"format"`
+	Total     int           `json:"total"`
+	Matched   int           `json:"matched"`
+	Tracks    []ImportMatch `json:"tracks"`
+	Unmatched []ImportMatch `json:"unmatched"`
+}
+
+// ImportOptions tune how imported tracks are matched
+type ImportOptions struct {
+	// Threshold is the minimum confidence for a candidate to count as a match
+	Threshold float64
+	// Alternatives is the number of other candidates reported per track
+	Alternatives int
+}
+
+// DefaultImportOptions returns the options used when none are given
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{Threshold: 0.6, Alternatives: 3}
+}
+
+// ImportHandler matches an uploaded playlist against the JioSaavn catalog
+// @Summary      Import a playlist
+// @Description  Accepts a CSV, M3U/M3U8, XSPF, JSPF or JSON playlist, either as the raw request body or as a multipart "file" field, and resolves every track to a JioSaavn song using search and fuzzy scoring on title, artist, album and duration
+// @Tags         Import
+// @Accept       plain
+// @Accept       mpfd
+// @Produce      json
+// @Param        format        query     string  false  "Playlist format: csv, m3u, xspf, jspf, json (detected when omitted)"
+// @Param        threshold     query     number  false  "Minimum confidence for a match, between 0 and 1" default(0.6)
+// @Param        alternatives  query     int     false  "Number of alternative candidates per track" default(3)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      413  {object}  map[string]interface{}
+// @Router       /import [post]
+func ImportHandler(c *gin.Context) {
+	opts := DefaultImportOptions()
+	if value := c.Query("threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid threshold. Use a number between 0 and 1",
+			})
+			return
+		}
+		opts.Threshold = threshold
+	}
+	if value := c.Query("alternatives"); value != "" {
+		alternatives, err := strconv.Atoi(value)
+		if err != nil || alternatives < 0 || alternatives > 10 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid alternatives. Use a number between 0 and 10",
+			})
+			return
+		}
+		opts.Alternatives = alternatives
+	}
+
+	var body io.Reader = c.Request.Body
+	filename := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Missing file field",
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = header.Filename
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImportSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to read playlist",
+		})
+		return
+	}
+	if len(data) > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Playlist is larger than %d bytes", maxImportSize),
+		})
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = DetectImportFormat(filename, data)
+	}
+
+	result, err := ImportPlaylist(c.Request.Context(), data, format, opts)
+	if c.Request.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// ImportPlaylist parses a playlist file and matches every track to a JioSaavn
+// song. It stops searching and returns the context's error once ctx is done.
+func ImportPlaylist(ctx context.Context, data []byte, format string, opts ImportOptions) (*ImportResult, error) {
+	tracks, err := ParseImport(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, errors.New("playlist has no tracks")
+	}
+	if cfg.ImportMaxTracks > 0 && len(tracks) > cfg.ImportMaxTracks {
+		return nil, fmt.Errorf("playlist has %d tracks, the limit is %d", len(tracks), cfg.ImportMaxTracks)
+	}
+
+	matches := make([]ImportMatch, len(tracks))
+	concurrency := max(cfg.ImportConcurrency, 1)
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+tracks:
+	for i, track := range tracks {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			break tracks
+		}
+		wg.Add(1)
+		go func(i int, track ImportTrack) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			matches[i] = matchImportTrack(ctx, i, track, opts)
+		}(i, track)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{
+		Format:    format,
+		Total:     len(tracks),
+		Tracks:    matches,
+		Unmatched: []ImportMatch{},
+	}
+	for _, track := range matches {
+		if track.Match != nil {
+			result.Matched++
+		} else {
+			result.Unmatched = append(result.Unmatched, track)
+		}
+	}
+	return result, nil
+}
+
+// matchImportTrack searches for an imported track and scores the results
+func matchImportTrack(ctx context.Context, index int, track ImportTrack, opts ImportOptions) ImportMatch {
+	result := ImportMatch{Index: index, Input: track, Alternatives: []ImportCandidate{}}
+
+	matches, err := findMatches(ctx, track)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	candidates := make([]ImportCandidate, 0, len(matches))
+	for _, m := range matches {
+		candidates = append(candidates, ImportCandidate{
+			Song:       m.Song,
+			Confidence: m.Score.Score,
+			Breakdown:  m.Breakdown,
+			Penalties:  m.Penalties,
+		})
+	}
+
+	if len(candidates) > 0 && candidates[0].Confidence >= opts.Threshold {
+		result.Match = &candidates[0]
+		candidates = candidates[1:]
+	}
+	if len(candidates) > opts.Alternatives {
+		candidates = candidates[:opts.Alternatives]
+	}
+	result.Alternatives = append(result.Alternatives, candidates...)
+	return result
+}
+
+// DetectImportFormat guesses the format of a playlist from its file name or content
+func DetectImportFormat(filename string, data []byte) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv", ".tsv":
+		return "csv"
+	case ".m3u", ".m3u8":
+		return "m3u"
+	case ".xspf", ".xml":
+		return "xspf"
+	case ".jspf":
+		return "jspf"
+	case ".json":
+		if bytes.Contains(data, []byte(`"playlist"`)) && bytes.Contains(data, []byte(`"track"`)) {
+			return "jspf"
+		}
+		return "json"
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("#EXTM3U")):
+		return "m3u"
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return "xspf"
+	case bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")):
+		if bytes.Contains(trimmed, []byte(`"playlist"`)) && bytes.Contains(trimmed, []byte(`"track"`)) {
+			return "jspf"
+		}
+		return "json"
+	}
+	return "csv"
+}
+
+// ParseImport reads the tracks of a playlist file in the given format
+func ParseImport(data []byte, format string) ([]ImportTrack, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var tracks []ImportTrack
+	var err error
+	switch format {
+	case "csv":
+		tracks, err = parseImportCSV(data)
+	case "m3u", "m3u8":
+		tracks = parseImportM3U(data)
+	case "xspf":
+		tracks, err = parseImportXSPF(data)
+	case "jspf":
+		tracks, err = parseImportJSPF(data)
+	case "json":
+		tracks, err = parseImportJSON(data)
+	default:
+		return nil, fmt.Errorf("%w %q, use one of: %s", ErrImportFormat, format, strings.Join(importFormats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s playlist: %w", format, err)
+	}
+
+	// Tracks without a title cannot be searched for
+	valid := make([]ImportTrack, 0, len(tracks))
+	for _, track := range tracks {
+		track.Title = strings.TrimSpace(track.Title)
+		if track.Title != "" {
+			valid = append(valid, track)
+		}
+	}
+	return valid, nil
+}
+
+// Column names recognized in CSV exports, e.g. from Exportify or TuneMyMusic
+var (
+	csvTitleColumns    = []string{"title", "track name", "track", "name", "song", "song name", "track title"}
+	csvArtistColumns   = []string{"artist", "artists", "artist name", "artist name(s)", "artist names", "creator"}
+	csvAlbumColumns    = []string{"album", "album name", "album title"}
+	csvDurationColumns = []string{"duration", "duration (ms)", "duration_ms", "length", "time"}
+)
+
+func parseImportCSV(data []byte) ([]ImportTrack, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if bytes.Count(data, []byte("\t")) > bytes.Count(data, []byte(",")) {
+		r.Comma = '\t'
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	find := func(names []string) (int, string) {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i, name
+			}
+		}
+		return -1, ""
+	}
+
+	titleCol, _ := find(csvTitleColumns)
+	if titleCol < 0 {
+		return nil, errors.New("no title column found")
+	}
+	artistCol, _ := find(csvArtistColumns)
+	albumCol, _ := find(csvAlbumColumns)
+	durationCol, durationName := find(csvDurationColumns)
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	tracks := []ImportTrack{}
+	for _, record := range records[1:] {
+		tracks = append(tracks, ImportTrack{
+			Title:    field(record, titleCol),
+			Artists:  match.SplitArtists(field(record, artistCol)),
+			Album:    field(record, albumCol),
+			Duration: parseImportDuration(field(record, durationCol), strings.Contains(durationName, "ms")),
+		})
+	}
+	return tracks, nil
+}
+
+// m3uExtinf matches "#EXTINF:duration,Artist - Title"
+var m3uExtinf = regexp.MustCompile(`^#EXTINF:\s*(-?\d+(?:\.\d+)?)[^,]*,(.*)$`)
+
+func parseImportM3U(data []byte) []ImportTrack {
+	tracks := []ImportTrack{}
+	var pending *ImportTrack
+	album := ""
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF"):
+			track := ImportTrack{}
+			if m := m3uExtinf.FindStringSubmatch(line); m != nil {
+				track = importTrackFromLabel(m[2])
+				if seconds, err := strconv.ParseFloat(m[1], 64); err == nil && seconds > 0 {
+					track.Duration = int(seconds + 0.5)
+				}
+			}
+			pending = &track
+		case strings.HasPrefix(line, "#EXTALB:"):
+			album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			// A location line closes the entry; without #EXTINF the file name is all there is
+			track := ImportTrack{}
+			if pending != nil {
+				track = *pending
+			}
+			if track.Title == "" {
+				name := path.Base(strings.ReplaceAll(line, "\\", "/"))
+				track = importTrackFromLabel(strings.TrimSuffix(name, path.Ext(name)))
+				if pending != nil {
+					track.Duration = pending.Duration
+				}
+			}
+			track.Album = album
+			tracks = append(tracks, track)
+			pending = nil
+			album = ""
+		}
+	}
+	return tracks
+}
+
+// importTrackFromLabel splits an "Artist - Title" label
+func importTrackFromLabel(label string) ImportTrack {
+	label = strings.TrimSpace(label)
+	if artist, title, found := strings.Cut(label, " - "); found {
+		return ImportTrack{Title: strings.TrimSpace(title), Artists: match.SplitArtists(artist)}
+	}
+	return ImportTrack{Title: label}
+}
+
+func parseImportXSPF(data []byte) ([]ImportTrack, error) {
+	var playlist struct {
+		Tracks []struct {
+			Title    string `xml:"title"`
+			Creator  string `xml:"creator"`
+			Album    string `xml:"album"`
+			Duration string `xml:"duration"`
+		} `xml:"trackList>track"`
+	}
+	if err := xml.Unmarshal(data, &playlist); err != nil {
+		return nil, err
+	}
+
+	tracks := []ImportTrack{}
+	for _, t := range playlist.Tracks {
+		tracks = append(tracks, ImportTrack{
+			Title:    t.Title,
+			Artists:  match.SplitArtists(t.Creator),
+			Album:    t.Album,
+			Duration: parseImportDuration(t.Duration, true),
+		})
+	}
+	return tracks, nil
+}
+
+func parseImportJSPF(data []byte) ([]ImportTrack, error) {
+	var doc struct {
+		Playlist struct {
+			Track []map[string]interface{} `json:"track"`
+		} `json:"playlist"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	tracks := []ImportTrack{}
+	for _, t := range doc.Playlist.Track {
+		tracks = append(tracks, ImportTrack{
+			Title:    utils.GetString(t, "title"),
+			Artists:  match.SplitArtists(utils.GetString(t, "creator")),
+			Album:    utils.GetString(t, "album"),
+			Duration: parseImportDuration(utils.GetString(t, "duration"), true),
+		})
+	}
+	return tracks, nil
+}
+
+// parseImportJSON reads a generic JSON export: either an array of tracks or an
+// object holding one under a common key. Spotify-style {"track": {...}} items
+// are unwrapped.
+func parseImportJSON(data []byte) ([]ImportTrack, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	items, ok := doc.([]interface{})
+	for depth := 0; !ok && depth < 3; depth++ {
+		obj, isObj := doc.(map[string]interface{})
+		if !isObj {
+			break
+		}
+		doc = nil
+		for _, key := range []string{"tracks", "items", "songs", "data", "playlist", "track", "list", "results"} {
+			if value, found := obj[key]; found {
+				doc = value
+				break
+			}
+		}
+		items, ok = doc.([]interface{})
+	}
+	if !ok {
+		return nil, errors.New("no track list found")
+	}
+
+	tracks := []ImportTrack{}
+	for _, item := range items {
+		obj, isObj := item.(map[string]interface{})
+		if !isObj {
+			continue
+		}
+		if inner, isObj := obj["track"].(map[string]interface{}); isObj {
+			obj = inner
+		}
+		tracks = append(tracks, importTrackFromJSON(obj))
+	}
+	return tracks, nil
+}
+
+// importTrackFromJSON maps the common field names of JSON exports onto a track
+func importTrackFromJSON(obj map[string]interface{}) ImportTrack {
+	track := ImportTrack{}
+	for _, key := range []string{"title", "name", "track", "song", "trackName"} {
+		if track.Title = jsonString(obj[key]); track.Title != "" {
+			break
+		}
+	}
+	for _, key := range []string{"artists", "artist", "artistName", "creator", "primary_artists"} {
+		if track.Artists = jsonStrings(obj[key]); len(track.Artists) > 0 {
+			break
+		}
+	}
+	for _, key := range []string{"album", "albumName", "album_name"} {
+		if track.Album = jsonString(obj[key]); track.Album != "" {
+			break
+		}
+	}
+	for _, key := range []string{"duration_ms", "durationMs"} {
+		if value := jsonString(obj[key]); value != "" {
+			track.Duration = parseImportDuration(value, true)
+			break
+		}
+	}
+	if track.Duration == 0 {
+		for _, key := range []string{"duration", "length"} {
+			if value := jsonString(obj[key]); value != "" {
+				track.Duration = parseImportDuration(value, false)
+				break
+			}
+		}
+	}
+	return track
+}
+
+// jsonString reads a string, number or {"name": ...} value
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		return jsonString(v["name"])
+	}
+	return ""
+}
+
+// jsonStrings reads a list of artists given as a string or an array of strings or objects
+func jsonStrings(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return match.SplitArtists(jsonString(value))
+	}
+	names := []string{}
+	for _, item := range list {
+		if name := jsonString(item); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseImportDuration reads a duration given in seconds, milliseconds or as m:ss / h:mm:ss
+func parseImportDuration(value string, millis bool) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if strings.Contains(value, ":") {
+		seconds := 0
+		for _, part := range strings.Split(value, ":") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return 0
+			}
+			seconds = seconds*60 + n
+		}
+		return seconds
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if millis {
+		n /= 1000
+	}
+	return int(n + 0.5)
+}
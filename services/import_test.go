@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// formatImportTrack describes a track as "title|artist+artist|album|duration"
+func formatImportTrack(track ImportTrack) string {
+	return fmt.Sprintf("%s|%s|%s|%d", track.Title, strings.Join(track.Artists, "+"), track.Album, track.Duration)
+}
+
+func TestParseImport(t *testing.T) {
+	// Every fixture holds the same playlist, the duration of Kesariya left out
+	want := []string{
+		"Tum Hi Ho|Arijit Singh|Aashiqui 2|262",
+		"Raataan Lambiyan|Jubin Nautiyal+Asees Kaur|Shershaah|230",
+		"Kesariya|Arijit Singh|Brahmastra|0",
+	}
+
+	for _, file := range []string{"playlist.m3u", "playlist.csv", "playlist.xspf", "playlist.jspf", "playlist.json"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			format := DetectImportFormat(file, data)
+			if want := strings.TrimPrefix(filepath.Ext(file), "."); format != want {
+				t.Errorf("DetectImportFormat = %q, want %q", format, want)
+			}
+
+			tracks, err := ParseImport(data, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, track := range tracks {
+				got = append(got, formatImportTrack(track))
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("tracks =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseImportVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []string
+	}{
+		{
+			name:   "m3u without extinf",
+			format: "m3u",
+			data:   "/music/Tum Hi Ho.mp3\r\n/music/Arijit Singh - Kesariya.flac\r\n",
+			want:   []string{"Tum Hi Ho|||0", "Kesariya|Arijit Singh||0"},
+		},
+		{
+			name:   "csv with a byte order mark and tabs",
+			format: "csv",
+			data:   "\xef\xbb\xbfTitle\tArtist\tTime\nTum Hi Ho\tArijit Singh\t4:22\n",
+			want:   []string{"Tum Hi Ho|Arijit Singh||262"},
+		},
+		{
+			name:   "csv with short rows",
+			format: "csv",
+			data:   "song,artist,album\nTum Hi Ho\n",
+			want:   []string{"Tum Hi Ho|||0"},
+		},
+		{
+			name:   "json array",
+			format: "json",
+			data:   `[{"song": "Tum Hi Ho", "primary_artists": "Arijit Singh, Mithoon", "duration": "262"}, "ignored"]`,
+			want:   []string{"Tum Hi Ho|Arijit Singh+Mithoon||262"},
+		},
+		{
+			name:   "json nested under data",
+			format: "json",
+			data:   `{"data": {"songs": [{"trackName": "Kesariya", "artistName": "Arijit Singh", "length": "4:28"}]}}`,
+			want:   []string{"Kesariya|Arijit Singh||268"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := ParseImport([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, track := range tracks {
+				got = append(got, formatImportTrack(track))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("tracks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   string
+	}{
+		{"unknown format", "pls", "[playlist]", "unsupported"},
+		{"csv without a title column", "csv", "artist,album\nArijit Singh,Aashiqui 2\n", "no title column found"},
+		{"malformed xspf", "xspf", "<playlist><trackList>", "failed to parse xspf playlist"},
+		{"malformed jspf", "jspf", `{"playlist": `, "failed to parse jspf playlist"},
+		{"json without tracks", "json", `{"name": "Favourites"}`, "no track list found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseImport([]byte(tt.data), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseImport(nil, "pls"); !errors.Is(err, ErrImportFormat) {
+		t.Errorf("error = %v, want ErrImportFormat", err)
+	}
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		data     string
+		want     string
+	}{
+		{"list.tsv", "", "csv"},
+		{"list.M3U8", "", "m3u"},
+		{"list.xml", "", "xspf"},
+		{"list.json", `{"playlist": {"track": []}}`, "jspf"},
+		{"list.json", `{"tracks": []}`, "json"},
+		{"upload", "\xef\xbb\xbf#EXTM3U\n", "m3u"},
+		{"upload", "  <?xml version=\"1.0\"?>", "xspf"},
+		{"upload", `{"playlist": {"track": []}}`, "jspf"},
+		{"upload", `[{"title": "Tum Hi Ho"}]`, "json"},
+		{"upload", "title,artist\n", "csv"},
+	}
+	for _, tt := range tests {
+		if got := DetectImportFormat(tt.filename, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectImportFormat(%q, %q) = %q, want %q", tt.filename, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseImportDuration(t *testing.T) {
+	tests := []struct {
+		value  string
+		millis bool
+		want   int
+	}{
+		{"262", false, 262},
+		{"262.6", false, 263},
+		{"262000", true, 262},
+		{"230400", true, 230},
+		{"4:22", false, 262},
+		{"4:22", true, 262},
+		{"1:02:03", false, 3723},
+		{" 4 : 22 ", false, 262},
+		{"", false, 0},
+		{"-1", false, 0},
+		{"4:2x", false, 0},
+		{"unknown", false, 0},
+	}
+	for _, tt := range tests {
+		if got := parseImportDuration(tt.value, tt.millis); got != tt.want {
+			t.Errorf("parseImportDuration(%q, %v) = %d, want %d", tt.value, tt.millis, got, tt.want)
+		}
+	}
+}
+
+// newImportTestUpstream points the library to a fake upstream whose song
+// search finds Tum Hi Ho and, for Kesariya, only its remix and a cover
+func newImportTestUpstream(t *testing.T) {
+	t.Helper()
+	song := func(id, title, artists string, duration int) map[string]interface{} {
+		return map[string]interface{}{
+			"id":    id,
+			"title": title,
+			"more_info": map[string]interface{}{
+				"primary_artists": artists,
+				"duration":        fmt.Sprint(duration),
+			},
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := strings.ToLower(r.URL.Query().Get("q"))
+		results := []interface{}{}
+		if r.URL.Query().Get("__call") == "search.getResults" {
+			switch {
+			case strings.Contains(query, "tum hi ho"):
+				results = append(results, song("s1", "Tum Hi Ho", "Arijit Singh", 262))
+			case strings.Contains(query, "kesariya"):
+				results = append(results,
+					song("s2", "Kesariya - Remix", "Arijit Singh, DJ Chetas", 250),
+					song("s3", "Kesariya (Female Version)", "Shilpa Rao", 268),
+				)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total": len(results), "results": results})
+	}))
+	t.Cleanup(server.Close)
+
+	baseURL := library.BaseURL
+	library.BaseURL = server.URL
+	t.Cleanup(func() { library.BaseURL = baseURL })
+}
+
+func TestImportPlaylistThreshold(t *testing.T) {
+	newImportTestUpstream(t)
+	playlist := []byte("title,artist,duration\nTum Hi Ho,Arijit Singh,262\nKesariya,Arijit Singh,268\nUnknown Song,Nobody,200\n")
+
+	tests := []struct {
+		name         string
+		opts         ImportOptions
+		matched      []string
+		alternatives []int
+	}{
+		{
+			name:         "default threshold",
+			opts:         DefaultImportOptions(),
+			matched:      []string{"s1", "s2", ""},
+			alternatives: []int{0, 1, 0},
+		},
+		{
+			name:         "strict threshold",
+			opts:         ImportOptions{Threshold: 0.9, Alternatives: 3},
+			matched:      []string{"s1", "", ""},
+			alternatives: []int{0, 2, 0},
+		},
+		{
+			name:         "fewer alternatives",
+			opts:         ImportOptions{Threshold: 0.9, Alternatives: 1},
+			matched:      []string{"s1", "", ""},
+			alternatives: []int{0, 1, 0},
+		},
+		{
+			name:         "no threshold",
+			opts:         ImportOptions{Threshold: 0, Alternatives: 0},
+			matched:      []string{"s1", "s2", ""},
+			alternatives: []int{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ImportPlaylist(context.Background(), playlist, "csv", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Total != 3 || len(result.Tracks) != 3 {
+				t.Fatalf("total = %d with %d tracks, want 3", result.Total, len(result.Tracks))
+			}
+
+			matched := 0
+			for i, track := range result.Tracks {
+				id := ""
+				if track.Match != nil {
+					id, _ = track.Match.Song["id"].(string)
+					matched++
+					if track.Match.Confidence < tt.opts.Threshold {
+						t.Errorf("track %d matched with confidence %g under the threshold", i, track.Match.Confidence)
+					}
+				}
+				if id != tt.matched[i] {
+					t.Errorf("track %d matched %q, want %q", i, id, tt.matched[i])
+				}
+				if len(track.Alternatives) != tt.alternatives[i] {
+					t.Errorf("track %d has %d alternatives, want %d", i, len(track.Alternatives), tt.alternatives[i])
+				}
+				if track.Index != i {
+					t.Errorf("track %d has index %d", i, track.Index)
+				}
+			}
+			if result.Matched != matched || len(result.Unmatched) != 3-matched {
+				t.Errorf("matched = %d with %d unmatched, want %d", result.Matched, len(result.Unmatched), matched)
+			}
+		})
+	}
+}
+
+func TestImportPlaylistLimits(t *testing.T) {
+	newImportTestUpstream(t)
+
+	if _, err := ImportPlaylist(context.Background(), []byte("title\n\n"), "csv", DefaultImportOptions()); err == nil {
+		t.Error("empty playlist imported")
+	}
+
+	maxTracks := cfg.ImportMaxTracks
+	cfg.ImportMaxTracks = 2
+	t.Cleanup(func() { cfg.ImportMaxTracks = maxTracks })
+	_, err := ImportPlaylist(context.Background(), []byte("title\na\nb\nc\n"), "csv", DefaultImportOptions())
+	if err == nil || !strings.Contains(err.Error(), "the limit is 2") {
+		t.Errorf("error = %v, want the track limit", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ImportPlaylist(ctx, []byte("title\nTum Hi Ho\n"), "csv", DefaultImportOptions()); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jioSaavnAPI/utils"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lyricsIndex holds the lyrics fetched by this instance for full-text search
+var lyricsIndex = NewLyricsIndex("")
+
+// LyricsDocument is the lyrics of a single song as stored in the index file
+type LyricsDocument struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Artists string   `json:"artists"`
+	Lines   []string `json:"lines"`
+}
+
+// LyricsSearchResult is a single song matching a lyrics search
+type LyricsSearchResult struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Artists    string `json:"artists"`
+	Line       string `json:"line"`
+	LineNumber int    `json:"lineNumber"`
+	Highlight  string `json:"highlight"`
+	Score      int    `json:"score"`
+}
+
+// lyricsPosting points at a line of an indexed song
+type lyricsPosting struct {
+	doc  string
+	line int
+}
+
+// LyricsIndex is an in-memory inverted index over lyrics lines.
+// Only the documents are persisted; the postings are rebuilt on load,
+// so the index can be rebuilt from the file without network access.
+type LyricsIndex struct {
+	mu       sync.RWMutex
+	path     string
+	docs     map[string]*LyricsDocument
+	postings map[string][]lyricsPosting
+	dirty    bool
+}
+
+// NewLyricsIndex creates an empty index persisted at path.
+// An empty path keeps the index in memory only.
+func NewLyricsIndex(path string) *LyricsIndex {
+	return &LyricsIndex{
+		path:     path,
+		docs:     map[string]*LyricsDocument{},
+		postings: map[string][]lyricsPosting{},
+	}
+}
+
+// InitLyricsIndex loads the persisted lyrics index, starts the background
+// flush and optionally warms the index by crawling charts and playlists until
+// ctx is done
+func InitLyricsIndex(ctx context.Context) {
+	lyricsIndex = NewLyricsIndex(cfg.LyricsIndexPath)
+	if err := lyricsIndex.Load(); err != nil {
+		log.Printf("⚠️ Failed to load lyrics index: %v", err)
+	} else {
+		log.Printf("Lyrics index loaded with %d songs", lyricsIndex.Len())
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := lyricsIndex.Save(); err != nil {
+				log.Printf("⚠️ Failed to save lyrics index: %v", err)
+			}
+		}
+	}()
+
+	if cfg.LyricsIndexWarm {
+		go WarmLyricsIndex(ctx, cfg.LyricsIndexPlaylists)
+	}
+}
+
+// Len returns the number of indexed songs
+func (idx *LyricsIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Has reports whether the lyrics of a song are already indexed
+func (idx *LyricsIndex) Has(id string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.docs[id]
+	return ok
+}
+
+// Add indexes the lyrics of a song, replacing any previous entry
+func (idx *LyricsIndex) Add(title, artists string, lyrics utils.Lyrics) {
+	if lyrics.ID == "" || len(lyrics.Lines) == 0 {
+		return
+	}
+
+	lines := make([]string, len(lyrics.Lines))
+	for i, line := range lyrics.Lines {
+		lines[i] = line.Text
+	}
+	doc := &LyricsDocument{ID: lyrics.ID, Title: title, Artists: artists, Lines: lines}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if existing, ok := idx.docs[doc.ID]; ok {
+		if existing.Title == doc.Title && existing.Artists == doc.Artists && equalLines(existing.Lines, doc.Lines) {
+			return
+		}
+		idx.removeLocked(existing)
+	}
+	idx.addLocked(doc)
+	idx.dirty = true
+}
+
+func (idx *LyricsIndex) addLocked(doc *LyricsDocument) {
+	idx.docs[doc.ID] = doc
+	for i, line := range doc.Lines {
+		seen := map[string]bool{}
+		for _, term := range lyricsTerms(line) {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			idx.postings[term] = append(idx.postings[term], lyricsPosting{doc: doc.ID, line: i})
+		}
+	}
+}
+
+func (idx *LyricsIndex) removeLocked(doc *LyricsDocument) {
+	for _, line := range doc.Lines {
+		for _, term := range lyricsTerms(line) {
+			postings := idx.postings[term]
+			kept := postings[:0]
+			for _, p := range postings {
+				if p.doc != doc.ID {
+					kept = append(kept, p)
+				}
+			}
+			if len(kept) == 0 {
+				delete(idx.postings, term)
+			} else {
+				idx.postings[term] = kept
+			}
+		}
+	}
+	delete(idx.docs, doc.ID)
+}
+
+// Search returns the songs with a lyrics line containing every query term.
+// The last term also matches as a prefix so partially typed words are found.
+func (idx *LyricsIndex) Search(query string, limit int) []LyricsSearchResult {
+	terms := lyricsTerms(query)
+	if len(terms) == 0 {
+		return []LyricsSearchResult{}
+	}
+	phrase := strings.Join(terms, " ")
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Intersect the lines matching every term
+	var matches map[lyricsPosting]bool
+	for i, term := range terms {
+		current := map[lyricsPosting]bool{}
+		keys := []string{term}
+		if i == len(terms)-1 {
+			keys = idx.prefixTermsLocked(term)
+		}
+		for _, key := range keys {
+			for _, p := range idx.postings[key] {
+				if matches == nil || matches[p] {
+					current[p] = true
+				}
+			}
+		}
+		matches = current
+		if len(matches) == 0 {
+			return []LyricsSearchResult{}
+		}
+	}
+
+	// Keep the best matching line per song
+	best := map[string]LyricsSearchResult{}
+	for p := range matches {
+		doc := idx.docs[p.doc]
+		line := doc.Lines[p.line]
+		normalized := strings.Join(lyricsTerms(line), " ")
+
+		score := len(terms) * 10
+		if normalized == phrase {
+			score += 100
+		} else if strings.Contains(" "+normalized+" ", " "+phrase+" ") {
+			score += 50
+		} else if strings.Contains(normalized, phrase) {
+			score += 25
+		}
+		// Prefer tighter matches
+		score -= len(strings.Fields(normalized)) - len(terms)
+
+		current, ok := best[p.doc]
+		if ok && (current.Score > score || (current.Score == score && current.LineNumber < p.line+1)) {
+			continue
+		}
+		best[p.doc] = LyricsSearchResult{
+			ID:         doc.ID,
+			Title:      doc.Title,
+			Artists:    doc.Artists,
+			Line:       line,
+			LineNumber: p.line + 1,
+			Highlight:  highlightLyricsLine(line, terms),
+			Score:      score,
+		}
+	}
+
+	results := make([]LyricsSearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (idx *LyricsIndex) prefixTermsLocked(prefix string) []string {
+	keys := []string{}
+	for term := range idx.postings {
+		if strings.HasPrefix(term, prefix) {
+			keys = append(keys, term)
+		}
+	}
+	return keys
+}
+
+// Load reads the persisted documents and rebuilds the postings.
+// A missing index file is not an error.
+func (idx *LyricsIndex) Load() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var docs []*LyricsDocument
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", idx.path, err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = map[string]*LyricsDocument{}
+	idx.postings = map[string][]lyricsPosting{}
+	for _, doc := range docs {
+		if doc != nil && doc.ID != "" {
+			idx.addLocked(doc)
+		}
+	}
+	idx.dirty = false
+	return nil
+}
+
+// Save writes the indexed documents to disk if anything changed since the last save.
+// The file is replaced atomically so a crash never leaves a truncated index.
+func (idx *LyricsIndex) Save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	docs := make([]*LyricsDocument, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		docs = append(docs, doc)
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	data, err := json.Marshal(docs)
+	if err == nil {
+		err = writeFileAtomic(idx.path, data)
+	}
+	if err != nil {
+		idx.mu.Lock()
+		idx.dirty = true
+		idx.mu.Unlock()
+	}
+	return err
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lyricsTerms splits text into lowercase search terms.
+// Combining marks are kept so Indic vowel signs stay part of their word.
+func lyricsTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r)
+	})
+}
+
+// highlightLyricsLine wraps the words of line matching the query terms in <em> tags
+func highlightLyricsLine(line string, terms []string) string {
+	var b strings.Builder
+	runes := []rune(line)
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+	}
+
+	for i := 0; i < len(runes); {
+		if !isWord(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWord(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if lyricsTermMatches(strings.ToLower(word), terms) {
+			b.WriteString("<em>" + word + "</em>")
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func lyricsTermMatches(word string, terms []string) bool {
+	for i, term := range terms {
+		if word == term || (i == len(terms)-1 && strings.HasPrefix(word, term)) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LyricsSearchHandler searches the lyrics already fetched by this instance
+// @Summary      Search lyrics
+// @Description  Full-text search over the lyrics fetched by this instance. Returns matching songs with the best matching line and a highlight
+// @Tags         Lyrics
+// @Accept       json
+// @Produce      json
+// @Param        q      query     string  true   "A line or fragment of the lyrics"
+// @Param        limit  query     int     false  "Number of results (max 50)" default(10)
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Router       /lyrics/search [get]
+func LyricsSearchHandler(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing query parameter",
+		})
+		return
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	results := lyricsIndex.Search(query, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": map[string]interface{}{
+			"total":   len(results),
+			"indexed": lyricsIndex.Len(),
+			"results": results,
+		},
+	})
+}
+
+// WarmLyricsIndex crawls the charts and the given playlists and indexes the
+// lyrics of every song that has them. Requests are spaced out to stay polite.
+// It stops when ctx is done, saving the lyrics indexed so far.
+func WarmLyricsIndex(ctx context.Context, playlistIDs []string) {
+	ids := append([]string{}, playlistIDs...)
+	charts, err := fetchChartIDs(ctx)
+	if err != nil {
+		log.Printf("⚠️ Lyrics index warm-up: failed to fetch charts: %v", err)
+	}
+	ids = append(ids, charts...)
+
+	indexed := 0
+crawl:
+	for _, listID := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		songs, err := fetchPlaylistSongs(ctx, listID)
+		if err != nil {
+			log.Printf("⚠️ Lyrics index warm-up: failed to fetch playlist %s: %v", listID, err)
+			continue
+		}
+
+		for _, song := range songs {
+			moreInfo, _ := song["more_info"].(map[string]interface{})
+			id := utils.GetString(song, "id")
+			if id == "" || utils.GetString(moreInfo, "has_lyrics") != "true" || lyricsIndex.Has(id) {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				break crawl
+			case <-time.After(500 * time.Millisecond):
+			}
+			lyrics, err := fetchLyrics(ctx, id)
+			if err != nil {
+				continue
+			}
+			lyricsIndex.Add(utils.GetString(song, "title"), primaryArtistNames(moreInfo), lyrics)
+			indexed++
+		}
+	}
+
+	if err := lyricsIndex.Save(); err != nil {
+		log.Printf("⚠️ Failed to save lyrics index: %v", err)
+	}
+	log.Printf("Lyrics index warm-up finished: %d songs added, %d total", indexed, lyricsIndex.Len())
+}
+
+// fetchChartIDs returns the playlist IDs of the current charts
+func fetchChartIDs(ctx context.Context) ([]string, error) {
+	charts, err := fetchCharts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, chart := range charts {
+		if id := utils.GetString(chart, "id"); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// fetchCharts returns the raw content.getCharts entries, one per chart playlist
+func fetchCharts(ctx context.Context) ([]map[string]interface{}, error) {
+	return library.FetchCharts(ctx)
+}
+
+// fetchPlaylistSongs returns the raw song entries of a playlist
+func fetchPlaylistSongs(ctx context.Context, listID string) ([]map[string]interface{}, error) {
+	playlist, err := fetchPlaylistByID(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	songs := []map[string]interface{}{}
+	list, _ := playlist["list"].([]interface{})
+	for _, entry := range list {
+		if song, ok := entry.(map[string]interface{}); ok {
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+// fetchPlaylistByID returns the raw playlist.getDetails response for a playlist ID
+func fetchPlaylistByID(ctx context.Context, listID string) (map[string]interface{}, error) {
+	return library.FetchPlaylistByID(ctx, listID)
+}
+
+// primaryArtistNames joins the primary artist names from an artistMap
+func primaryArtistNames(moreInfo map[string]interface{}) string {
+	artistMap, _ := moreInfo["artistMap"].(map[string]interface{})
+	primary, _ := artistMap["primary_artists"].([]interface{})
+
+	names := []string{}
+	for _, artist := range primary {
+		if a, ok := artist.(map[string]interface{}); ok {
+			names = append(names, utils.GetString(a, "name"))
+		}
+	}
+	return strings.Join(names, ", ")
+}
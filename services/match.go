@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MatchCandidate is a JioSaavn song scored against a track description
+type MatchCandidate struct {
+	Song map[string]interface{} `json:"song"`
+	match.Score
+}
+
+// MatchHandler finds the JioSaavn song that best matches a title, artist and duration
+// @Summary      Match a song
+// @Description  Searches for a song by title and artist and scores every result against the given title, artists, album and duration. Titles are normalized ("(From ...)", feat. credits and version tags are stripped, Indic scripts are transliterated and diacritics folded) and each score comes with a per-feature breakdown.
+// @Tags         Match
+// @Produce      json
+// @Param        title      query     string  true   "Song title"
+// @Param        artist     query     string  false  "Artist names, separated by commas, & or feat."
+// @Param        album      query     string  false  "Album name"
+// @Param        duration   query     int     false  "Duration in seconds"
+// @Param        threshold  query     number  false  "Minimum score for the best candidate to count as a match" default(0.6)
+// @Param        limit      query     int     false  "Number of candidates to return" default(5)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /match [get]
+func MatchHandler(c *gin.Context) {
+	title := strings.TrimSpace(c.Query("title"))
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Title parameter is required"})
+		return
+	}
+
+	track := match.Track{Title: title, Album: c.Query("album")}
+	if artist := c.Query("artist"); artist != "" {
+		track.Artists = match.SplitArtists(artist)
+	}
+	if value := c.Query("duration"); value != "" {
+		duration, err := strconv.Atoi(value)
+		if err != nil || duration < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid duration"})
+			return
+		}
+		track.Duration = duration
+	}
+
+	threshold := DefaultImportOptions().Threshold
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Threshold must be between 0 and 1"})
+			return
+		}
+		threshold = parsed
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Limit must be between 1 and 20"})
+		return
+	}
+
+	candidates, err := findMatches(c.Request.Context(), track)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to search songs"})
+		return
+	}
+
+	var best *MatchCandidate
+	if len(candidates) > 0 && candidates[0].Score.Score >= threshold {
+		best = &candidates[0]
+	}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"input":      track,
+			"match":      best,
+			"candidates": candidates,
+		},
+	})
+}
+
+// findMatches searches for a track by title and first artist, falling back to
+// the title alone, and returns the results sorted by score
+func findMatches(ctx context.Context, track match.Track) ([]MatchCandidate, error) {
+	queries := []string{track.Title}
+	if len(track.Artists) > 0 {
+		queries = []string{track.Title + " " + track.Artists[0], track.Title}
+	}
+
+	candidates := []MatchCandidate{}
+	for _, query := range queries {
+		songs, err := searchSongs(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, song := range songs {
+			candidates = append(candidates, MatchCandidate{Song: song, Score: match.Compare(track, songMatchTrack(song))})
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score.Score > candidates[j].Score.Score
+	})
+	return candidates, nil
+}
+
+// searchSongs returns the formatted song search results for a query
+func searchSongs(ctx context.Context, query string) ([]map[string]interface{}, error) {
+	results, err := library.FetchSearch(ctx, query, "song", nil)
+	if err != nil {
+		return nil, err
+	}
+	formatted := utils.FormatSongSearch(results)
+	data, _ := formatted["data"].(map[string]interface{})
+	songs, _ := data["results"].([]map[string]interface{})
+	return songs, nil
+}
+
+// songMatchTrack describes a formatted song for matching
+func songMatchTrack(song map[string]interface{}) match.Track {
+	track := match.Track{
+		Title:    utils.GetString(song, "name"),
+		Duration: utils.GetInt(song, "duration"),
+	}
+	if album, ok := song["album"].(map[string]interface{}); ok {
+		track.Album = utils.GetString(album, "name")
+	}
+	artists, _ := song["artists"].(map[string]interface{})
+	for _, key := range []string{"primary", "featured"} {
+		list, _ := artists[key].([]map[string]interface{})
+		for _, artist := range list {
+			track.Artists = append(track.Artists, utils.GetString(artist, "name"))
+		}
+	}
+	return track
+}
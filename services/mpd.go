@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jioSaavnAPI/mpd"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mpdFolders are the directories at the root of the MPD library
+var mpdFolders = []string{"Charts", "New Releases", "Playlists"}
+
+// mpdChartsTTL is how long the songs of the charts, listed by an unfiltered
+// list command, are kept
+const mpdChartsTTL = 10 * time.Minute
+
+// mpdLibrary serves the catalog to MPD clients. Its directories are the charts,
+// new releases and featured playlists, and its songs are identified by their
+// URL on the streaming proxy.
+type mpdLibrary struct {
+	baseURL string
+
+	mu            sync.Mutex
+	chartSongs    []mpd.Song
+	chartsFetched time.Time
+}
+
+// StartMPD serves the catalog over the MPD protocol on MPD_ADDR, if set,
+// until ctx is done. The returned channel is closed once the listener and the
+// connections of the clients are closed.
+func StartMPD(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if cfg.MPDAddr == "" {
+		close(done)
+		return done
+	}
+
+	lis, err := net.Listen("tcp", cfg.MPDAddr)
+	if err != nil {
+		log.Printf("⚠️ MPD server not started: %v", err)
+		close(done)
+		return done
+	}
+	baseURL, err := lanBaseURL()
+	if err != nil {
+		baseURL = "http://localhost:" + cfg.ServerPort
+	}
+	server := mpd.NewServer(&mpdLibrary{baseURL: baseURL})
+	go func() {
+		defer close(done)
+		log.Printf("MPD server listening on %s", lis.Addr())
+		if err := server.Serve(ctx, lis); err != nil {
+			log.Printf("⚠️ MPD server stopped: %v", err)
+		}
+	}()
+	return done
+}
+
+// LsInfo lists the root, a folder, or the songs of a chart, album or playlist
+// as "Folder/Name", or describes a song given its stream URL
+func (l *mpdLibrary) LsInfo(ctx context.Context, uri string) ([]mpd.Entry, error) {
+	if uri == "" {
+		entries := make([]mpd.Entry, 0, len(mpdFolders))
+		for _, folder := range mpdFolders {
+			entries = append(entries, mpd.Entry{Directory: folder})
+		}
+		return entries, nil
+	}
+
+	if id, ok := mpdSongID(uri); ok {
+		raw, err := library.FetchSong(ctx, id)
+		if err != nil {
+			return nil, mpdError(err)
+		}
+		song := l.song(utils.FormatSongDetailed(raw))
+		return []mpd.Entry{{Song: &song}}, nil
+	}
+
+	folder, name, _ := strings.Cut(uri, "/")
+	listing, err := mpdFolderListing(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		entries := make([]mpd.Entry, 0, len(listing))
+		for _, raw := range listing {
+			entries = append(entries, mpd.Entry{Directory: folder + "/" + mpdName(raw)})
+		}
+		return entries, nil
+	}
+
+	for _, raw := range listing {
+		if mpdName(raw) != name {
+			continue
+		}
+		songs, err := l.listingSongs(ctx, folder, utils.GetString(raw, "id"))
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]mpd.Entry, 0, len(songs))
+		for i := range songs {
+			entries = append(entries, mpd.Entry{Song: &songs[i]})
+		}
+		return entries, nil
+	}
+	return nil, &mpd.Ack{Code: mpd.AckErrorNoExist, Message: "No such directory"}
+}
+
+// Search searches the catalog for the values of the filters and keeps the
+// songs matching all of them. Exact album and artist filters also bring in
+// the songs of matching albums and the top songs of the matching artist.
+func (l *mpdLibrary) Search(ctx context.Context, filters []mpd.Filter) ([]mpd.Song, error) {
+	var terms []string
+	var candidates []mpd.Song
+	for _, f := range filters {
+		if f.Op == "!=" {
+			continue
+		}
+		switch strings.ToLower(f.Tag) {
+		case "file":
+			entries, err := l.LsInfo(ctx, f.Value)
+			if err == nil && len(entries) == 1 && entries[0].Song != nil {
+				candidates = append(candidates, *entries[0].Song)
+			}
+		case "album":
+			if f.Op == "==" {
+				candidates = append(candidates, l.albumSongs(ctx, f.Value)...)
+			}
+			terms = append(terms, f.Value)
+		case "artist", "albumartist":
+			if f.Op == "==" {
+				candidates = append(candidates, l.artistSongs(ctx, f.Value)...)
+			}
+			terms = append(terms, f.Value)
+		case "any", "title":
+			terms = append(terms, f.Value)
+		}
+	}
+
+	if query := strings.Join(terms, " "); query != "" {
+		results, err := library.FetchSearch(ctx, query, "song", cfg.SearchScripts)
+		if err != nil && len(candidates) == 0 {
+			return nil, err
+		}
+		if err == nil {
+			data, _ := utils.FormatSongSearch(results)["data"].(map[string]interface{})
+			songs, _ := data["results"].([]map[string]interface{})
+			for _, song := range songs {
+				candidates = append(candidates, l.song(song))
+			}
+		}
+	}
+
+	// Results of "any" searches are left to the catalog's own relevance, which
+	// also finds transliterated titles
+	var strict []mpd.Filter
+	for _, f := range filters {
+		if !strings.EqualFold(f.Tag, "any") || f.Op != "contains" {
+			strict = append(strict, f)
+		}
+	}
+	matches := []mpd.Song{}
+	seen := map[string]bool{}
+	for _, song := range candidates {
+		if !seen[song.File] && mpd.MatchAll(strict, song) {
+			seen[song.File] = true
+			matches = append(matches, song)
+		}
+	}
+	return matches, nil
+}
+
+// List returns the sorted values of a tag among the songs matching the filters.
+// Without filters it lists the songs of the current charts, since the whole
+// catalog cannot be enumerated.
+func (l *mpdLibrary) List(ctx context.Context, tag string, filters []mpd.Filter) ([]string, error) {
+	var songs []mpd.Song
+	var err error
+	if len(filters) == 0 {
+		songs, err = l.chartsSongs(ctx)
+	} else {
+		songs, err = l.Search(ctx, filters)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	seen := map[string]bool{}
+	for _, song := range songs {
+		for _, value := range song.Values(tag) {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// listingSongs returns the songs of an entry of a folder listing
+func (l *mpdLibrary) listingSongs(ctx context.Context, folder, id string) ([]mpd.Song, error) {
+	var formatted []map[string]interface{}
+	if folder == "New Releases" {
+		raw, err := library.FetchAlbum(ctx, id)
+		if err != nil {
+			return nil, mpdError(err)
+		}
+		formatted, _ = utils.FormatAlbum(raw)["songs"].([]map[string]interface{})
+	} else {
+		list, err := fetchPlaylistSongs(ctx, id)
+		if err != nil {
+			return nil, mpdError(err)
+		}
+		for _, song := range list {
+			formatted = append(formatted, utils.FormatSearchSong(song))
+		}
+	}
+
+	songs := make([]mpd.Song, 0, len(formatted))
+	for _, song := range formatted {
+		songs = append(songs, l.song(song))
+	}
+	return songs, nil
+}
+
+// albumSongs returns the songs of the albums named exactly name
+func (l *mpdLibrary) albumSongs(ctx context.Context, name string) []mpd.Song {
+	results, err := library.FetchSearch(ctx, name, "album", nil)
+	if err != nil {
+		return nil
+	}
+	data, _ := utils.FormatAlbumSearch(results)["data"].(map[string]interface{})
+	albums, _ := data["results"].([]map[string]interface{})
+
+	var songs []mpd.Song
+	for _, album := range albums {
+		if !strings.EqualFold(utils.GetString(album, "name"), name) {
+			continue
+		}
+		raw, err := library.FetchAlbum(ctx, utils.GetString(album, "id"))
+		if err != nil {
+			continue
+		}
+		formatted, _ := utils.FormatAlbum(raw)["songs"].([]map[string]interface{})
+		for _, song := range formatted {
+			songs = append(songs, l.song(song))
+		}
+	}
+	return songs
+}
+
+// artistSongs returns the top songs of the artist named exactly name
+func (l *mpdLibrary) artistSongs(ctx context.Context, name string) []mpd.Song {
+	results, err := library.FetchSearch(ctx, name, "artist", nil)
+	if err != nil {
+		return nil
+	}
+	data, _ := utils.FormatArtistSearch(results)["data"].(map[string]interface{})
+	artists, _ := data["results"].([]map[string]interface{})
+
+	for _, artist := range artists {
+		if !strings.EqualFold(utils.GetString(artist, "name"), name) {
+			continue
+		}
+		raw, err := library.FetchArtist(ctx, utils.GetString(artist, "id"))
+		if err != nil {
+			return nil
+		}
+		topSongs, _ := utils.FormatArtistDetails(raw)["topSongs"].([]map[string]interface{})
+		songs := make([]mpd.Song, 0, len(topSongs))
+		for _, song := range topSongs {
+			songs = append(songs, l.song(song))
+		}
+		return songs
+	}
+	return nil
+}
+
+// chartsSongs returns the songs of all current charts, refreshed every mpdChartsTTL
+func (l *mpdLibrary) chartsSongs(ctx context.Context) ([]mpd.Song, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.chartsFetched) < mpdChartsTTL {
+		return l.chartSongs, nil
+	}
+
+	ids, err := fetchChartIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	charts := make([][]mpd.Song, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			charts[i], _ = l.listingSongs(ctx, "Charts", id)
+		}()
+	}
+	wg.Wait()
+
+	l.chartSongs = nil
+	for _, songs := range charts {
+		l.chartSongs = append(l.chartSongs, songs...)
+	}
+	l.chartsFetched = time.Now()
+	return l.chartSongs, nil
+}
+
+// song describes a formatted song, from details or search, for MPD clients
+func (l *mpdLibrary) song(song map[string]interface{}) mpd.Song {
+	album, _ := song["album"].(map[string]interface{})
+	names, _ := subsonicArtists(song)
+	return mpd.Song{
+		File:     utils.StreamURL(l.baseURL, utils.GetString(song, "id"), "320"),
+		Title:    utils.GetString(song, "name"),
+		Artists:  names,
+		Album:    utils.GetString(album, "name"),
+		Date:     utils.GetString(song, "year"),
+		Duration: utils.GetInt(song, "duration"),
+	}
+}
+
+// mpdFolderListing returns the raw entries of a root folder
+func mpdFolderListing(ctx context.Context, folder string) ([]map[string]interface{}, error) {
+	switch folder {
+	case "Charts":
+		return fetchCharts(ctx)
+	case "New Releases":
+		return fetchContentList(ctx, "content.getAlbums", url.Values{"p": {"1"}, "n": {"50"}})
+	case "Playlists":
+		return fetchContentList(ctx, "content.getFeaturedPlaylists", url.Values{"fetch_from_serialized_files": {"true"}, "p": {"1"}, "n": {"50"}})
+	}
+	return nil, &mpd.Ack{Code: mpd.AckErrorNoExist, Message: "No such directory"}
+}
+
+// mpdName returns the directory name of a raw listing entry, which cannot contain slashes
+func mpdName(raw map[string]interface{}) string {
+	return strings.ReplaceAll(strings.TrimSpace(utils.GetString(raw, "title")), "/", "-")
+}
+
+// mpdSongID returns the song ID of a stream URL
+func mpdSongID(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return "", false
+	}
+	_, id, ok := strings.Cut(u.Path, "/stream/")
+	return id, ok && id != ""
+}
+
+// mpdError reports the not found errors of the upstream fetches as missing songs or directories
+func mpdError(err error) error {
+	if errors.Is(err, saavn.ErrSongNotFound) || errors.Is(err, saavn.ErrAlbumNotFound) || errors.Is(err, saavn.ErrPlaylistNotFound) {
+		return &mpd.Ack{Code: mpd.AckErrorNoExist, Message: "No such song or directory"}
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"jioSaavnAPI/match"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchScripts reads the scripts query parameter, defaulting to SEARCH_SCRIPTS.
+// It responds with 400 and returns ok false for unknown scripts.
+func searchScripts(c *gin.Context) (scripts []string, ok bool) {
+	value, set := c.GetQuery("scripts")
+	if !set {
+		value = strings.Join(cfg.SearchScripts, ",")
+	}
+	scripts, err := match.ParseScripts(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid scripts parameter: " + err.Error(),
+		})
+		return nil, false
+	}
+	return scripts, true
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mediaClient fetches media from the CDN. It has no overall timeout because
+// streams can be long; requests are bound to the client's request context instead.
+var mediaClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// streamRequestHeaders are forwarded from the client to the CDN
+var streamRequestHeaders = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+
+// streamResponseHeaders are forwarded from the CDN to the client
+var streamResponseHeaders = []string{"Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified", "Cache-Control", "Expires"}
+
+// resolveSongMedia resolves the media URL of a song for the requested quality.
+// It returns the URL, the quality actually selected and the raw song details.
+func resolveSongMedia(ctx context.Context, id, quality string) (string, string, map[string]interface{}, error) {
+	media, err := library.ResolveMedia(ctx, id, quality)
+	if media == nil {
+		return "", "", nil, err
+	}
+	return media.URL, media.Quality, media.Song, err
+}
+
+// resolvePreview resolves the URL of the 30 second preview of a song
+func resolvePreview(ctx context.Context, id string) (string, error) {
+	songData, err := library.FetchSong(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	media := utils.ResolveMediaContext(ctx, utils.GetString(songData, "encrypted_media_url"), utils.GetString(songData, "320kbps") == "true")
+	if media.PreviewURL == "" {
+		return "", saavn.ErrMediaUnavailable
+	}
+	return media.PreviewURL, nil
+}
+
+// verifySignedLink checks the signature of a link to a media route when signed
+// links are enabled, writing the error response when it is not valid
+func verifySignedLink(c *gin.Context, route, id, quality string) bool {
+	if !utils.SignedLinksEnabled() {
+		return true
+	}
+	if err := utils.VerifyLink(route, id, quality, c.Query("exp"), c.Query("sig")); err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, utils.ErrLinkExpired) {
+			status = http.StatusGone
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// validQuality reports whether quality is a step of the media ladder
+func validQuality(quality string) bool {
+	for _, q := range utils.MediaQualities {
+		if q == quality {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultStreamQuality is the bitrate streamed when none is requested
+const defaultStreamQuality = "160"
+
+// StreamHandler proxies the audio of a song from the CDN
+// @Summary      Stream a song
+// @Description  Resolves the media URL of a song and proxies it with full HTTP Range support. If the requested quality is not available, the closest lower quality is streamed. When signed links are enabled, exp and sig must be those of a link issued by this API
+// @Tags         Media
+// @Produce      audio/mp4
+// @Param        id       path      string  true   "Song ID"
+// @Param        quality  query     string  false  "Bitrate in kbps: 12, 48, 96, 160, 320, or preview for the 30 second preview" default(160)
+// @Param        exp      query     int     false  "Link expiry as a Unix timestamp (signed links)"
+// @Param        sig      query     string  false  "Link signature (signed links)"
+// @Param        Range    header    string  false  "Byte range, e.g. bytes=0-1023"
+// @Success      200  {file}    binary
+// @Success      206  {file}    binary
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      410  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /stream/{id} [get]
+func StreamHandler(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Missing song ID",
+		})
+		return
+	}
+
+	quality := strings.TrimSuffix(c.DefaultQuery("quality", defaultStreamQuality), "kbps")
+	if !validQuality(quality) && quality != utils.PreviewQuality {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid quality. Use one of: " + strings.Join(utils.MediaQualities, ", ") + " or " + utils.PreviewQuality,
+		})
+		return
+	}
+	if !verifySignedLink(c, "stream", id, quality) {
+		return
+	}
+
+	var mediaURL, selected string
+	var err error
+	if quality == utils.PreviewQuality {
+		mediaURL, err = resolvePreview(c.Request.Context(), id)
+	} else {
+		mediaURL, selected, _, err = resolveSongMedia(c.Request.Context(), id, quality)
+	}
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+
+	if selected != "" {
+		c.Header("X-Media-Quality", selected+"kbps")
+	}
+	if c.GetHeader("getcontentFeatures.dlna.org") == "1" {
+		// DLNA renderers ask how the stream can be played before seeking in it
+		c.Header("contentFeatures.dlna.org", dlnaContentFeatures)
+		c.Header("transferMode.dlna.org", "Streaming")
+	}
+	proxyMedia(c, mediaURL, cfg.StreamBandwidthKbps)
+}
+
+// respondMediaError writes the error response for a failed media resolution
+func respondMediaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, saavn.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Song not found",
+		})
+	case errors.Is(err, saavn.ErrMediaUnavailable):
+		body := gin.H{
+			"success": false,
+			"error":   "Media not available for this song",
+		}
+		if reason := utils.MediaFailure(err); reason != "" {
+			body["reason"] = reason
+		}
+		c.JSON(http.StatusNotFound, body)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch song",
+		})
+	}
+}
+
+// proxyMedia streams mediaURL to the client, forwarding range and caching headers
+// both ways. The upstream request is cancelled as soon as the client goes away.
+// A positive bandwidthKbps caps the transfer rate of this stream.
+func proxyMedia(c *gin.Context, mediaURL string, bandwidthKbps int) {
+	ctx := c.Request.Context()
+
+	req, err := http.NewRequestWithContext(ctx, c.Request.Method, mediaURL, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to build media request",
+		})
+		return
+	}
+	for _, header := range streamRequestHeaders {
+		if value := c.GetHeader(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+
+	resp, err := mediaClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			// The client went away before the CDN answered
+			c.Abort()
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   "Failed to fetch media",
+		})
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusNotModified, http.StatusRequestedRangeNotSatisfiable:
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Media server returned status: %d", resp.StatusCode),
+		})
+		return
+	}
+
+	header := c.Writer.Header()
+	for _, name := range streamResponseHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	header.Set("Content-Type", mediaContentType(resp.Header.Get("Content-Type"), mediaURL))
+	if header.Get("Accept-Ranges") == "" {
+		header.Set("Accept-Ranges", "bytes")
+	}
+	c.Status(resp.StatusCode)
+
+	if c.Request.Method == http.MethodHead || resp.StatusCode == http.StatusNotModified {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	var dst io.Writer = c.Writer
+	if bandwidthKbps > 0 {
+		dst = newThrottledWriter(ctx, c.Writer, bandwidthKbps)
+	}
+	if _, err := io.Copy(dst, resp.Body); err != nil && ctx.Err() == nil {
+		// Headers are already sent, so the only option left is to cut the stream
+		c.Error(err)
+	}
+}
+
+// mediaContentType returns the content type to serve, since the CDN does not
+// always send an audio type for its MP4 files
+func mediaContentType(upstream, mediaURL string) string {
+	if strings.HasPrefix(upstream, "audio/") {
+		return upstream
+	}
+	if strings.Contains(mediaURL, ".mp3") {
+		return "audio/mpeg"
+	}
+	return "audio/mp4"
+}
+
+// throttledWriter caps the rate at which bytes are written
+type throttledWriter struct {
+	ctx         context.Context
+	w           io.Writer
+	bytesPerSec float64
+	start       time.Time
+	written     int64
+}
+
+func newThrottledWriter(ctx context.Context, w io.Writer, kbps int) *throttledWriter {
+	return &throttledWriter{ctx: ctx, w: w, bytesPerSec: float64(kbps) * 1000 / 8, start: time.Now()}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	t.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	expected := time.Duration(float64(t.written) / t.bytesPerSec * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		}
+	}
+	return n, nil
+}
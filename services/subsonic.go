@@ -0,0 +1,710 @@
+package services
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"jioSaavnAPI/match"
+	"jioSaavnAPI/saavn"
+	"jioSaavnAPI/utils"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subsonicAPIVersion is the version of the Subsonic REST API served under /rest
+const subsonicAPIVersion = "1.16.1"
+
+// Subsonic error codes
+const (
+	subsonicErrGeneric      = 0
+	subsonicErrMissingParam = 10
+	subsonicErrAuth         = 40
+	subsonicErrNotFound     = 70
+)
+
+// subsonicResponse is the <subsonic-response> envelope. Exactly one of the
+// payload fields is set, depending on the method.
+type subsonicResponse struct {
+	XMLName       xml.Name `xml:"subsonic-response" json:"-"`
+	Xmlns         string   `xml:"xmlns,attr" json:"-"`
+	Status        string   `xml:"status,attr" json:"status"`
+	Version       string   `xml:"version,attr" json:"version"`
+	Type          string   `xml:"type,attr" json:"type"`
+	ServerVersion string   `xml:"serverVersion,attr" json:"serverVersion"`
+	OpenSubsonic  bool     `xml:"openSubsonic,attr" json:"openSubsonic"`
+
+	Error         *subsonicError        `xml:"error,omitempty" json:"error,omitempty"`
+	License       *subsonicLicense      `xml:"license,omitempty" json:"license,omitempty"`
+	SearchResult3 *subsonicSearchResult `xml:"searchResult3,omitempty" json:"searchResult3,omitempty"`
+	Album         *subsonicAlbum        `xml:"album,omitempty" json:"album,omitempty"`
+	Artist        *subsonicArtist       `xml:"artist,omitempty" json:"artist,omitempty"`
+	Song          *subsonicSong         `xml:"song,omitempty" json:"song,omitempty"`
+	Lyrics        *subsonicLyrics       `xml:"lyrics,omitempty" json:"lyrics,omitempty"`
+	Playlists     *subsonicPlaylists    `xml:"playlists,omitempty" json:"playlists,omitempty"`
+	Playlist      *subsonicPlaylist     `xml:"playlist,omitempty" json:"playlist,omitempty"`
+}
+
+type subsonicError struct {
+	Code    int    `xml:"code,attr" json:"code"`
+	Message string `xml:"message,attr" json:"message"`
+}
+
+type subsonicLicense struct {
+	Valid bool `xml:"valid,attr" json:"valid"`
+}
+
+type subsonicSearchResult struct {
+	Artists []subsonicArtist `xml:"artist" json:"artist,omitempty"`
+	Albums  []subsonicAlbum  `xml:"album" json:"album,omitempty"`
+	Songs   []subsonicSong   `xml:"song" json:"song,omitempty"`
+}
+
+// subsonicSong is a Subsonic Child element describing a song
+type subsonicSong struct {
+	ID          string `xml:"id,attr" json:"id"`
+	Parent      string `xml:"parent,attr,omitempty" json:"parent,omitempty"`
+	IsDir       bool   `xml:"isDir,attr" json:"isDir"`
+	Title       string `xml:"title,attr" json:"title"`
+	Album       string `xml:"album,attr,omitempty" json:"album,omitempty"`
+	Artist      string `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	Track       int    `xml:"track,attr,omitempty" json:"track,omitempty"`
+	Year        int    `xml:"year,attr,omitempty" json:"year,omitempty"`
+	CoverArt    string `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	ContentType string `xml:"contentType,attr" json:"contentType"`
+	Suffix      string `xml:"suffix,attr" json:"suffix"`
+	Duration    int    `xml:"duration,attr,omitempty" json:"duration,omitempty"`
+	BitRate     int    `xml:"bitRate,attr,omitempty" json:"bitRate,omitempty"`
+	PlayCount   int    `xml:"playCount,attr,omitempty" json:"playCount,omitempty"`
+	AlbumID     string `xml:"albumId,attr,omitempty" json:"albumId,omitempty"`
+	ArtistID    string `xml:"artistId,attr,omitempty" json:"artistId,omitempty"`
+	Type        string `xml:"type,attr" json:"type"`
+	MediaType   string `xml:"mediaType,attr" json:"mediaType"`
+}
+
+type subsonicAlbum struct {
+	ID        string         `xml:"id,attr" json:"id"`
+	Name      string         `xml:"name,attr" json:"name"`
+	Artist    string         `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	ArtistID  string         `xml:"artistId,attr,omitempty" json:"artistId,omitempty"`
+	CoverArt  string         `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	SongCount int            `xml:"songCount,attr" json:"songCount"`
+	Duration  int            `xml:"duration,attr" json:"duration"`
+	Year      int            `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Created   string         `xml:"created,attr,omitempty" json:"created,omitempty"`
+	Songs     []subsonicSong `xml:"song" json:"song,omitempty"`
+}
+
+type subsonicArtist struct {
+	ID             string          `xml:"id,attr" json:"id"`
+	Name           string          `xml:"name,attr" json:"name"`
+	CoverArt       string          `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	ArtistImageURL string          `xml:"artistImageUrl,attr,omitempty" json:"artistImageUrl,omitempty"`
+	AlbumCount     int             `xml:"albumCount,attr" json:"albumCount"`
+	Albums         []subsonicAlbum `xml:"album" json:"album,omitempty"`
+}
+
+type subsonicLyrics struct {
+	Artist string `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	Title  string `xml:"title,attr,omitempty" json:"title,omitempty"`
+	Value  string `xml:",chardata" json:"value"`
+}
+
+type subsonicPlaylists struct {
+	Playlists []subsonicPlaylist `xml:"playlist" json:"playlist"`
+}
+
+type subsonicPlaylist struct {
+	ID        string         `xml:"id,attr" json:"id"`
+	Name      string         `xml:"name,attr" json:"name"`
+	Comment   string         `xml:"comment,attr,omitempty" json:"comment,omitempty"`
+	Owner     string         `xml:"owner,attr" json:"owner"`
+	Public    bool           `xml:"public,attr" json:"public"`
+	SongCount int            `xml:"songCount,attr" json:"songCount"`
+	Duration  int            `xml:"duration,attr" json:"duration"`
+	CoverArt  string         `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	Entries   []subsonicSong `xml:"entry" json:"entry,omitempty"`
+}
+
+// subsonicMethods are the supported /rest methods
+var subsonicMethods = map[string]func(c *gin.Context){
+	"ping":         subsonicPing,
+	"getLicense":   subsonicGetLicense,
+	"search3":      subsonicSearch3,
+	"getAlbum":     subsonicGetAlbum,
+	"getArtist":    subsonicGetArtist,
+	"getSong":      subsonicGetSong,
+	"getCoverArt":  subsonicGetCoverArt,
+	"stream":       subsonicStream,
+	"getLyrics":    subsonicGetLyrics,
+	"getPlaylists": subsonicGetPlaylists,
+	"getPlaylist":  subsonicGetPlaylist,
+}
+
+// SubsonicHandler serves the Subsonic-compatible API used by Subsonic and OpenSubsonic clients
+// @Summary      Subsonic API
+// @Description  Subsonic REST API compatibility layer for clients such as DSub, Symfonium and Feishin. Supported methods: ping, getLicense, search3, getAlbum, getArtist, getSong, getCoverArt, stream, getLyrics, getPlaylists and getPlaylist, with or without the .view suffix. Users sign in with token (t and s) or password (p) authentication against SUBSONIC_USERS. Responses are XML, or JSON with f=json.
+// @Tags         Subsonic
+// @Produce      xml
+// @Produce      json
+// @Param        method  path      string  true   "Method name, e.g. ping or search3.view"
+// @Param        u       query     string  true   "Username"
+// @Param        t       query     string  false  "Authentication token: md5(password + salt)"
+// @Param        s       query     string  false  "Salt used for the token"
+// @Param        p       query     string  false  "Password, in clear or hex encoded with an enc: prefix"
+// @Param        f       query     string  false  "Response format: xml, json or jsonp" default(xml)
+// @Success      200  {object}  map[string]interface{}
+// @Router       /rest/{method} [get]
+// @Router       /rest/{method} [post]
+func SubsonicHandler(c *gin.Context) {
+	method, ok := subsonicMethods[strings.TrimSuffix(c.Param("method"), ".view")]
+	if !ok {
+		writeSubsonic(c, http.StatusNotFound, subsonicFailure(subsonicErrGeneric, "Unknown method"))
+		return
+	}
+	if err := subsonicAuthenticate(c); err != nil {
+		writeSubsonic(c, http.StatusOK, &subsonicResponse{Status: "failed", Error: err})
+		return
+	}
+	method(c)
+}
+
+// subsonicUsers are the passwords of the SUBSONIC_USERS, by user name. They
+// are parsed by Configure.
+var subsonicUsers map[string]string
+
+// parseSubsonicUsers parses the user:password pairs of SUBSONIC_USERS
+func parseSubsonicUsers(pairs []string) map[string]string {
+	users := map[string]string{}
+	for _, pair := range pairs {
+		if user, password, ok := strings.Cut(pair, ":"); ok && user != "" {
+			users[user] = password
+		}
+	}
+	return users
+}
+
+// subsonicAuthenticate checks the credentials of a request against SUBSONIC_USERS
+func subsonicAuthenticate(c *gin.Context) *subsonicError {
+	users := subsonicUsers
+	if len(users) == 0 {
+		return &subsonicError{subsonicErrAuth, "Subsonic API is disabled: no users configured"}
+	}
+
+	user := subsonicParam(c, "u")
+	if user == "" {
+		return &subsonicError{subsonicErrMissingParam, "Required parameter is missing: u"}
+	}
+	password, known := users[user]
+
+	var valid bool
+	switch token, salt, plain := subsonicParam(c, "t"), subsonicParam(c, "s"), subsonicParam(c, "p"); {
+	case token != "" && salt != "":
+		sum := md5.Sum([]byte(password + salt))
+		valid = subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(token))) == 1
+	case plain != "":
+		if encoded, ok := strings.CutPrefix(plain, "enc:"); ok {
+			decoded, err := hex.DecodeString(encoded)
+			if err != nil {
+				return &subsonicError{subsonicErrAuth, "Wrong username or password"}
+			}
+			plain = string(decoded)
+		}
+		valid = subtle.ConstantTimeCompare([]byte(password), []byte(plain)) == 1
+	default:
+		return &subsonicError{subsonicErrMissingParam, "Required parameter is missing: t and s, or p"}
+	}
+
+	if !known || !valid {
+		return &subsonicError{subsonicErrAuth, "Wrong username or password"}
+	}
+	return nil
+}
+
+// subsonicParam reads a parameter from the query string or a posted form
+func subsonicParam(c *gin.Context, name string) string {
+	if value, ok := c.GetQuery(name); ok {
+		return value
+	}
+	return c.PostForm(name)
+}
+
+// subsonicIntParam reads an integer parameter, returning defaultValue when it is missing or invalid
+func subsonicIntParam(c *gin.Context, name string, defaultValue int) int {
+	if value, err := strconv.Atoi(subsonicParam(c, name)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// subsonicFailure builds a failed response
+func subsonicFailure(code int, message string) *subsonicResponse {
+	return &subsonicResponse{Status: "failed", Error: &subsonicError{code, message}}
+}
+
+// subsonicCallback matches the JavaScript identifiers accepted as a jsonp callback
+var subsonicCallback = regexp.MustCompile(`^[A-Za-z_$][\w$.]*$`)
+
+// writeSubsonic writes a response in the format requested with f. A jsonp
+// callback that is not a plain identifier is answered with a JSON error
+// instead, so the parameter cannot inject script into the response.
+func writeSubsonic(c *gin.Context, status int, resp *subsonicResponse) {
+	format := subsonicParam(c, "f")
+	callback := subsonicParam(c, "callback")
+	if format == "jsonp" {
+		if callback == "" {
+			callback = "callback"
+		}
+		if !subsonicCallback.MatchString(callback) {
+			format, status = "json", http.StatusBadRequest
+			resp = subsonicFailure(subsonicErrGeneric, "Invalid callback")
+		}
+	}
+
+	if resp.Status == "" {
+		resp.Status = "ok"
+	}
+	resp.Xmlns = "http://subsonic.org/restapi"
+	resp.Version = subsonicAPIVersion
+	resp.Type = "jiosaavnapi"
+	resp.ServerVersion = "1.0"
+	resp.OpenSubsonic = true
+
+	switch format {
+	case "json":
+		c.JSON(status, gin.H{"subsonic-response": resp})
+	case "jsonp":
+		body, _ := json.Marshal(gin.H{"subsonic-response": resp})
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(status, "application/javascript; charset=utf-8", []byte(fmt.Sprintf("%s(%s);", callback, body)))
+	default:
+		body, err := xml.Marshal(resp)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Data(status, "text/xml; charset=utf-8", append([]byte(xml.Header), body...))
+	}
+}
+
+// writeSubsonicError maps an upstream error to a Subsonic error response
+func writeSubsonicError(c *gin.Context, err error, what string) {
+	switch {
+	case errors.Is(err, saavn.ErrSongNotFound), errors.Is(err, saavn.ErrAlbumNotFound),
+		errors.Is(err, saavn.ErrArtistNotFound), errors.Is(err, saavn.ErrPlaylistNotFound),
+		errors.Is(err, saavn.ErrMediaUnavailable):
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrNotFound, what+" not found"))
+	default:
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrGeneric, "Failed to fetch "+strings.ToLower(what)))
+	}
+}
+
+func subsonicPing(c *gin.Context) {
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{})
+}
+
+func subsonicGetLicense(c *gin.Context) {
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{License: &subsonicLicense{Valid: true}})
+}
+
+// subsonicSearch3 searches songs, albums and artists. The upstream search
+// returns one page of results, so offsets only reach into that page.
+func subsonicSearch3(c *gin.Context) {
+	query := strings.Trim(subsonicParam(c, "query"), `" `)
+	result := &subsonicSearchResult{}
+	if query == "" {
+		// Clients syncing a whole library search for "", which has no equivalent here
+		writeSubsonic(c, http.StatusOK, &subsonicResponse{SearchResult3: result})
+		return
+	}
+
+	types := []struct {
+		searchType    string
+		count, offset int
+	}{
+		{"artist", subsonicIntParam(c, "artistCount", 20), subsonicIntParam(c, "artistOffset", 0)},
+		{"album", subsonicIntParam(c, "albumCount", 20), subsonicIntParam(c, "albumOffset", 0)},
+		{"song", subsonicIntParam(c, "songCount", 20), subsonicIntParam(c, "songOffset", 0)},
+	}
+	pages := make([][]map[string]interface{}, len(types))
+	errs := make([]error, len(types))
+
+	var wg sync.WaitGroup
+	for i, t := range types {
+		if t.count == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := library.FetchSearch(c.Request.Context(), query, t.searchType, cfg.SearchScripts)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			var formatted map[string]interface{}
+			switch t.searchType {
+			case "artist":
+				formatted = utils.FormatArtistSearch(results)
+			case "album":
+				formatted = utils.FormatAlbumSearch(results)
+			default:
+				formatted = utils.FormatSongSearch(results)
+			}
+			data, _ := formatted["data"].(map[string]interface{})
+			items, _ := data["results"].([]map[string]interface{})
+			start := min(t.offset, len(items))
+			pages[i] = items[start:min(start+t.count, len(items))]
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrGeneric, "Search failed"))
+			return
+		}
+	}
+
+	for _, artist := range pages[0] {
+		result.Artists = append(result.Artists, newSubsonicArtist(artist))
+	}
+	for _, album := range pages[1] {
+		result.Albums = append(result.Albums, newSubsonicAlbum(album))
+	}
+	for _, song := range pages[2] {
+		result.Songs = append(result.Songs, newSubsonicSong(song))
+	}
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{SearchResult3: result})
+}
+
+func subsonicGetAlbum(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+	raw, err := library.FetchAlbum(c.Request.Context(), id)
+	if err != nil {
+		writeSubsonicError(c, err, "Album")
+		return
+	}
+
+	formatted := utils.FormatAlbum(raw)
+	album := newSubsonicAlbum(formatted)
+	songs, _ := formatted["songs"].([]map[string]interface{})
+	for i, song := range songs {
+		entry := newSubsonicSong(song)
+		entry.Track = i + 1
+		album.Songs = append(album.Songs, entry)
+		album.Duration += entry.Duration
+	}
+	album.SongCount = len(album.Songs)
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Album: &album})
+}
+
+func subsonicGetArtist(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+	raw, err := library.FetchArtist(c.Request.Context(), id)
+	if err != nil {
+		writeSubsonicError(c, err, "Artist")
+		return
+	}
+
+	formatted := utils.FormatArtistDetails(raw)
+	artist := newSubsonicArtist(formatted)
+	if artist.ID == "" {
+		artist.ID = id
+		artist.CoverArt = "ar-" + id
+	}
+	albums, _ := formatted["topAlbums"].([]map[string]interface{})
+	for _, album := range albums {
+		entry := newSubsonicAlbum(album)
+		entry.Artist, entry.ArtistID = artist.Name, artist.ID
+		artist.Albums = append(artist.Albums, entry)
+	}
+	artist.AlbumCount = len(artist.Albums)
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Artist: &artist})
+}
+
+func subsonicGetSong(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+	raw, err := library.FetchSong(c.Request.Context(), id)
+	if err != nil {
+		writeSubsonicError(c, err, "Song")
+		return
+	}
+	song := newSubsonicSong(utils.FormatSongDetailed(raw))
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Song: &song})
+}
+
+// subsonicGetCoverArt serves the image of a song, or of an album, artist or
+// playlist for cover IDs prefixed with al-, ar- or pl-
+func subsonicGetCoverArt(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+
+	var raw map[string]interface{}
+	var err error
+	kind, entityID, _ := strings.Cut(id, "-")
+	switch kind {
+	case "al":
+		raw, err = library.FetchAlbum(c.Request.Context(), entityID)
+	case "ar":
+		raw, err = library.FetchArtist(c.Request.Context(), entityID)
+	case "pl":
+		raw, err = fetchPlaylistByID(c.Request.Context(), entityID)
+	default:
+		raw, err = library.FetchSong(c.Request.Context(), strings.TrimPrefix(id, "mf-"))
+	}
+	if err != nil {
+		writeSubsonicError(c, err, "Cover art")
+		return
+	}
+
+	size := "500x500"
+	switch requested := subsonicIntParam(c, "size", 0); {
+	case requested > 0 && requested <= 50:
+		size = "50x50"
+	case requested > 0 && requested <= 150:
+		size = "150x150"
+	}
+	cover := fetchCoverArt(c.Request.Context(), utils.SanitizeImageURL(utils.GetString(raw, "image"), size))
+	if cover == nil {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrNotFound, "Cover art not found"))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, http.DetectContentType(cover), cover)
+}
+
+// subsonicStream proxies a song at the highest quality within maxBitRate
+func subsonicStream(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+
+	quality := utils.MediaQualities[len(utils.MediaQualities)-1]
+	if maxBitRate := subsonicIntParam(c, "maxBitRate", 0); maxBitRate > 0 {
+		quality = utils.MediaQualities[0]
+		for _, q := range utils.MediaQualities {
+			if kbps, _ := strconv.Atoi(q); kbps <= maxBitRate {
+				quality = q
+			}
+		}
+	}
+
+	mediaURL, selected, _, err := resolveSongMedia(c.Request.Context(), id, quality)
+	if err != nil {
+		writeSubsonicError(c, err, "Song")
+		return
+	}
+	c.Header("X-Media-Quality", selected+"kbps")
+	proxyMedia(c, mediaURL, cfg.StreamBandwidthKbps)
+}
+
+// subsonicGetLyrics finds the song matching an artist and title and returns its
+// lyrics as plain text, or an empty lyrics element when there are none
+func subsonicGetLyrics(c *gin.Context) {
+	lyrics := &subsonicLyrics{}
+	title := subsonicParam(c, "title")
+	if title == "" {
+		writeSubsonic(c, http.StatusOK, &subsonicResponse{Lyrics: lyrics})
+		return
+	}
+
+	track := match.Track{Title: title, Artists: match.SplitArtists(subsonicParam(c, "artist"))}
+	candidates, err := findMatches(c.Request.Context(), track)
+	if err != nil {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrGeneric, "Failed to search songs"))
+		return
+	}
+	if len(candidates) > 0 && candidates[0].Score.Score >= DefaultImportOptions().Threshold {
+		song := newSubsonicSong(candidates[0].Song)
+		if text, err := fetchLyrics(c.Request.Context(), song.ID); err == nil {
+			lyrics = &subsonicLyrics{Artist: song.Artist, Title: song.Title, Value: text.Text()}
+		}
+	}
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Lyrics: lyrics})
+}
+
+// subsonicGetPlaylists lists the charts and the playlists in SUBSONIC_PLAYLISTS
+func subsonicGetPlaylists(c *gin.Context) {
+	playlists := &subsonicPlaylists{Playlists: []subsonicPlaylist{}}
+
+	for _, id := range cfg.SubsonicPlaylists {
+		raw, err := fetchPlaylistByID(c.Request.Context(), id)
+		if err != nil {
+			continue
+		}
+		playlists.Playlists = append(playlists.Playlists, newSubsonicPlaylist(raw))
+	}
+
+	charts, err := fetchCharts(c.Request.Context())
+	if err != nil && len(playlists.Playlists) == 0 {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrGeneric, "Failed to fetch playlists"))
+		return
+	}
+	for _, chart := range charts {
+		playlists.Playlists = append(playlists.Playlists, newSubsonicPlaylist(chart))
+	}
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Playlists: playlists})
+}
+
+func subsonicGetPlaylist(c *gin.Context) {
+	id := subsonicParam(c, "id")
+	if id == "" {
+		writeSubsonic(c, http.StatusOK, subsonicFailure(subsonicErrMissingParam, "Required parameter is missing: id"))
+		return
+	}
+	raw, err := fetchPlaylistByID(c.Request.Context(), id)
+	if err != nil {
+		writeSubsonicError(c, err, "Playlist")
+		return
+	}
+
+	playlist := newSubsonicPlaylist(raw)
+	list, _ := raw["list"].([]interface{})
+	for _, entry := range list {
+		if songMap, ok := entry.(map[string]interface{}); ok {
+			song := newSubsonicSong(utils.FormatSearchSong(songMap))
+			playlist.Entries = append(playlist.Entries, song)
+			playlist.Duration += song.Duration
+		}
+	}
+	playlist.SongCount = len(playlist.Entries)
+	writeSubsonic(c, http.StatusOK, &subsonicResponse{Playlist: &playlist})
+}
+
+// newSubsonicSong describes a formatted song as a Subsonic child
+func newSubsonicSong(song map[string]interface{}) subsonicSong {
+	album, _ := song["album"].(map[string]interface{})
+	names, ids := subsonicArtists(song)
+	entry := subsonicSong{
+		ID:          utils.GetString(song, "id"),
+		Title:       utils.GetString(song, "name"),
+		Album:       utils.GetString(album, "name"),
+		Artist:      strings.Join(names, ", "),
+		Year:        utils.GetInt(song, "year"),
+		ContentType: "audio/mp4",
+		Suffix:      "m4a",
+		Duration:    utils.GetInt(song, "duration"),
+		BitRate:     subsonicBitRate(song),
+		PlayCount:   utils.GetInt(song, "playCount"),
+		AlbumID:     utils.GetString(album, "id"),
+		Type:        "music",
+		MediaType:   "song",
+	}
+	if entry.Title == "" {
+		entry.Title = utils.GetString(song, "title")
+	}
+	if len(ids) > 0 {
+		entry.ArtistID = ids[0]
+	}
+	entry.Parent = entry.AlbumID
+	entry.CoverArt = entry.ID
+	if entry.AlbumID != "" {
+		// Songs share the cover of their album, so clients can cache it once
+		entry.CoverArt = "al-" + entry.AlbumID
+	}
+	return entry
+}
+
+// newSubsonicAlbum describes a formatted album, from details or search, without its songs
+func newSubsonicAlbum(album map[string]interface{}) subsonicAlbum {
+	names, ids := subsonicArtists(album)
+	entry := subsonicAlbum{
+		ID:        utils.GetString(album, "id"),
+		Name:      utils.GetString(album, "name"),
+		Artist:    strings.Join(names, ", "),
+		SongCount: utils.GetInt(album, "songCount"),
+		Year:      utils.GetInt(album, "year"),
+	}
+	if entry.Artist == "" {
+		entry.Artist = utils.GetString(album, "description")
+	}
+	if len(ids) > 0 {
+		entry.ArtistID = ids[0]
+	}
+	if entry.ID != "" {
+		entry.CoverArt = "al-" + entry.ID
+	}
+	if entry.Year > 0 {
+		entry.Created = fmt.Sprintf("%04d-01-01T00:00:00Z", entry.Year)
+	}
+	return entry
+}
+
+// newSubsonicArtist describes a formatted artist, from details or search, without albums
+func newSubsonicArtist(artist map[string]interface{}) subsonicArtist {
+	entry := subsonicArtist{
+		ID:             utils.GetString(artist, "id"),
+		Name:           utils.GetString(artist, "name"),
+		ArtistImageURL: songCoverURL(artist),
+	}
+	if entry.ID != "" {
+		entry.CoverArt = "ar-" + entry.ID
+	}
+	return entry
+}
+
+// newSubsonicPlaylist describes a raw playlist or chart entry without its songs
+func newSubsonicPlaylist(raw map[string]interface{}) subsonicPlaylist {
+	playlist := subsonicPlaylist{
+		ID:        utils.GetString(raw, "id"),
+		Name:      utils.GetString(raw, "title"),
+		Comment:   utils.GetString(raw, "subtitle"),
+		Owner:     "JioSaavn",
+		Public:    true,
+		SongCount: saavn.PlaylistSongCount(raw),
+	}
+	if playlist.ID != "" {
+		playlist.CoverArt = "pl-" + playlist.ID
+	}
+	return playlist
+}
+
+// subsonicArtists returns the names and IDs of the primary artists of a formatted song or album
+func subsonicArtists(item map[string]interface{}) (names, ids []string) {
+	artists, _ := item["artists"].(map[string]interface{})
+	primary, _ := artists["primary"].([]map[string]interface{})
+	for _, artist := range primary {
+		names = append(names, utils.GetString(artist, "name"))
+		if id := utils.GetString(artist, "id"); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return names, ids
+}
+
+// subsonicBitRate returns the highest bitrate in kbps among the download URLs of a formatted song
+func subsonicBitRate(song map[string]interface{}) int {
+	bitRate := 0
+	links, _ := song["downloadUrl"].([]map[string]string)
+	for _, link := range links {
+		if kbps, err := strconv.Atoi(strings.TrimSuffix(link["quality"], "kbps")); err == nil {
+			bitRate = max(bitRate, kbps)
+		}
+	}
+	return bitRate
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subsonicRequest calls a Subsonic method with the given parameters
+func subsonicRequest(t *testing.T, method string, params url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/rest/:method", SubsonicHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rest/"+method+"?"+params.Encode(), nil))
+	return w
+}
+
+// withSubsonicUsers configures the Subsonic users for a test
+func withSubsonicUsers(t *testing.T, pairs ...string) {
+	t.Helper()
+	users := subsonicUsers
+	subsonicUsers = parseSubsonicUsers(pairs)
+	t.Cleanup(func() { subsonicUsers = users })
+}
+
+func subsonicToken(password, salt string) string {
+	sum := md5.Sum([]byte(password + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSubsonicAuthenticate(t *testing.T) {
+	withSubsonicUsers(t, "alice:s3cret", "bob:hunter2", ":nobody", "malformed")
+
+	tests := []struct {
+		name   string
+		params url.Values
+		// code is the Subsonic error code, or -1 for success
+		code int
+	}{
+		{"token", url.Values{"u": {"alice"}, "t": {subsonicToken("s3cret", "abc")}, "s": {"abc"}}, -1},
+		{"upper case token", url.Values{"u": {"alice"}, "t": {strings.ToUpper(subsonicToken("s3cret", "abc"))}, "s": {"abc"}}, -1},
+		{"token of another salt", url.Values{"u": {"alice"}, "t": {subsonicToken("s3cret", "abc")}, "s": {"xyz"}}, subsonicErrAuth},
+		{"token of another user", url.Values{"u": {"alice"}, "t": {subsonicToken("hunter2", "abc")}, "s": {"abc"}}, subsonicErrAuth},
+		{"token without salt", url.Values{"u": {"alice"}, "t": {subsonicToken("s3cret", "")}}, subsonicErrMissingParam},
+		{"password", url.Values{"u": {"bob"}, "p": {"hunter2"}}, -1},
+		{"hex password", url.Values{"u": {"bob"}, "p": {"enc:" + hex.EncodeToString([]byte("hunter2"))}}, -1},
+		{"invalid hex password", url.Values{"u": {"bob"}, "p": {"enc:zz"}}, subsonicErrAuth},
+		{"wrong password", url.Values{"u": {"bob"}, "p": {"hunter3"}}, subsonicErrAuth},
+		{"unknown user", url.Values{"u": {"carol"}, "p": {""}, "t": {subsonicToken("", "abc")}, "s": {"abc"}}, subsonicErrAuth},
+		{"user without name", url.Values{"u": {""}, "p": {"nobody"}}, subsonicErrMissingParam},
+		{"pair without password", url.Values{"u": {"malformed"}, "p": {""}}, subsonicErrMissingParam},
+		{"no credentials", url.Values{"u": {"alice"}}, subsonicErrMissingParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Set("f", "json")
+			w := subsonicRequest(t, "ping.view", tt.params)
+			var body struct {
+				Response subsonicResponse `json:"subsonic-response"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid response %q: %v", w.Body, err)
+			}
+			resp := body.Response
+			switch {
+			case tt.code < 0 && resp.Status != "ok":
+				t.Errorf("status = %s (%+v), want ok", resp.Status, resp.Error)
+			case tt.code >= 0 && (resp.Status != "failed" || resp.Error == nil || resp.Error.Code != tt.code):
+				t.Errorf("response = %s %+v, want error %d", resp.Status, resp.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestSubsonicAuthenticateWithoutUsers(t *testing.T) {
+	withSubsonicUsers(t)
+
+	w := subsonicRequest(t, "ping", url.Values{"u": {"alice"}, "p": {""}, "f": {"json"}})
+	if !strings.Contains(w.Body.String(), `"code":40`) || !strings.Contains(w.Body.String(), "no users configured") {
+		t.Errorf("response = %s, want the disabled error", w.Body)
+	}
+}
+
+func TestSubsonicJSONP(t *testing.T) {
+	withSubsonicUsers(t, "alice:s3cret")
+	auth := func(extra url.Values) url.Values {
+		params := url.Values{"u": {"alice"}, "p": {"s3cret"}, "f": {"jsonp"}}
+		for name, values := range extra {
+			params[name] = values
+		}
+		return params
+	}
+
+	tests := []struct {
+		name     string
+		callback []string
+		status   int
+		prefix   string
+	}{
+		{"callback", []string{"onPing"}, http.StatusOK, "onPing({"},
+		{"namespaced callback", []string{"jQuery_1.cb$"}, http.StatusOK, "jQuery_1.cb$({"},
+		{"default callback", nil, http.StatusOK, "callback({"},
+		{"script injection", []string{"alert(document.cookie)//"}, http.StatusBadRequest, `{"subsonic-response"`},
+		{"leading digit", []string{"1cb"}, http.StatusBadRequest, `{"subsonic-response"`},
+		{"markup", []string{"</script><script>"}, http.StatusBadRequest, `{"subsonic-response"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := subsonicRequest(t, "ping", auth(url.Values{"callback": tt.callback}))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.HasPrefix(w.Body.String(), tt.prefix) {
+				t.Errorf("body = %s, want prefix %s", w.Body, tt.prefix)
+			}
+			if tt.status == http.StatusOK {
+				if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/javascript") {
+					t.Errorf("Content-Type = %s", got)
+				}
+				if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+					t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+				}
+			} else if !strings.Contains(w.Body.String(), "Invalid callback") {
+				t.Errorf("body = %s, want the invalid callback error", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Artist roles used in song credits
+const (
+	RoleSinger   = "singer"
+	RoleMusic    = "music"
+	RoleLyricist = "lyricist"
+	RoleStarring = "starring"
+	RoleFeatured = "featured"
+)
+
+// creditFields maps the comma-separated name fields of a song to their ID field and role.
+// These are used when the song has no artistMap with roles.
+var creditFields = []struct {
+	nameKey string
+	idKey   string
+	role    string
+}{
+	{"singers", "singers_id", RoleSinger},
+	{"music", "music_id", RoleMusic},
+	{"lyricists", "lyricists_id", RoleLyricist},
+	{"lyricist", "lyricist_id", RoleLyricist},
+	{"starring", "starring_id", RoleStarring},
+	{"featured_artists", "featured_artists_id", RoleFeatured},
+}
+
+// BuildSongArtists builds the credits of a song as the "artists" object shared by
+// all song formatters: the primary and featured artists plus a role-tagged list of
+// everyone credited. The artistMap is used when present, otherwise the comma-separated
+// name and ID fields. Fields are looked up in moreInfo first, then in data.
+func BuildSongArtists(data, moreInfo map[string]interface{}) map[string]interface{} {
+	credits := &creditList{all: []map[string]interface{}{}, seen: map[string]bool{}}
+	artistMap, _ := lookupValue("artistMap", moreInfo, data).(map[string]interface{})
+
+	primary := []map[string]interface{}{}
+	featured := []map[string]interface{}{}
+
+	if hasRoleArtistMap(artistMap) {
+		// api_version 4 responses carry role information in the artistMap
+		for _, artist := range artistMapEntries(artistMap, "artists") {
+			credits.add(artist, normalizeRole(GetString(artist, "role")))
+		}
+		for _, artist := range artistMapEntries(artistMap, "primary_artists") {
+			role := creditRole(credits, artist, RoleSinger)
+			primary = append(primary, formatCreditArtist(artist, role))
+			credits.add(artist, role)
+		}
+		for _, artist := range artistMapEntries(artistMap, "featured_artists") {
+			featured = append(featured, formatCreditArtist(artist, RoleFeatured))
+			credits.add(artist, RoleFeatured)
+		}
+	} else {
+		// Older responses only carry comma-separated names and IDs. Their artistMap,
+		// when present, maps artist names to IDs.
+		nameToID := map[string]string{}
+		for name, id := range artistMap {
+			if idStr, ok := id.(string); ok {
+				nameToID[strings.TrimSpace(name)] = idStr
+			}
+		}
+
+		for _, field := range creditFields {
+			for _, artist := range splitCreditField(data, moreInfo, field.nameKey, field.idKey, nameToID) {
+				credits.add(artist, field.role)
+				if field.role == RoleFeatured {
+					featured = append(featured, formatCreditArtist(artist, RoleFeatured))
+				}
+			}
+		}
+
+		for _, artist := range splitCreditField(data, moreInfo, "primary_artists", "primary_artists_id", nameToID) {
+			role := creditRole(credits, artist, RoleSinger)
+			primary = append(primary, formatCreditArtist(artist, role))
+			credits.add(artist, role)
+		}
+	}
+
+	// Fall back to the singers when no primary artists are credited
+	if len(primary) == 0 {
+		for _, artist := range credits.all {
+			if artist["role"] == RoleSinger {
+				primary = append(primary, artist)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"primary":  primary,
+		"featured": featured,
+		"all":      credits.all,
+	}
+}
+
+// creditList collects role-tagged artists, skipping repeated artist and role pairs
+type creditList struct {
+	all  []map[string]interface{}
+	seen map[string]bool
+}
+
+func (c *creditList) add(artist map[string]interface{}, role string) {
+	name := GetString(artist, "name")
+	if name == "" {
+		return
+	}
+	key := creditKey(artist) + "|" + role
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.all = append(c.all, formatCreditArtist(artist, role))
+}
+
+// creditRole returns the role already recorded for an artist, or the fallback role
+func creditRole(credits *creditList, artist map[string]interface{}, fallback string) string {
+	key := creditKey(artist)
+	for _, existing := range credits.all {
+		if creditKey(existing) == key {
+			return GetString(existing, "role")
+		}
+	}
+	return fallback
+}
+
+func creditKey(artist map[string]interface{}) string {
+	if id := GetString(artist, "id"); id != "" {
+		return id
+	}
+	return strings.ToLower(GetString(artist, "name"))
+}
+
+// formatCreditArtist formats a raw artist entry with the given role
+func formatCreditArtist(artist map[string]interface{}, role string) map[string]interface{} {
+	url := GetString(artist, "perma_url")
+	if url == "" {
+		url = GetString(artist, "url")
+	}
+	return map[string]interface{}{
+		"id":    GetString(artist, "id"),
+		"name":  GetString(artist, "name"),
+		"role":  role,
+		"type":  "artist",
+		"image": BuildImageArray(GetString(artist, "image")),
+		"url":   url,
+	}
+}
+
+// normalizeRole maps the upstream role names onto the credit roles
+func normalizeRole(role string) string {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch {
+	case strings.Contains(role, "lyric"):
+		return RoleLyricist
+	case strings.Contains(role, "music"), strings.Contains(role, "composer"):
+		return RoleMusic
+	case strings.Contains(role, "star"), strings.Contains(role, "actor"), strings.Contains(role, "cast"):
+		return RoleStarring
+	case strings.Contains(role, "featur"):
+		return RoleFeatured
+	case role == "", strings.Contains(role, "sing"), strings.Contains(role, "primary"), strings.Contains(role, "vocal"):
+		return RoleSinger
+	}
+	return role
+}
+
+// hasRoleArtistMap reports whether the artistMap holds artist lists rather than name to ID pairs
+func hasRoleArtistMap(artistMap map[string]interface{}) bool {
+	for _, key := range []string{"artists", "primary_artists", "featured_artists"} {
+		if _, ok := artistMap[key].([]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func artistMapEntries(artistMap map[string]interface{}, key string) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	list, _ := artistMap[key].([]interface{})
+	for _, item := range list {
+		if artist, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, artist)
+		}
+	}
+	return entries
+}
+
+// splitCreditField pairs the comma-separated names and IDs of a credit field.
+// Missing IDs are resolved through the name to ID map when possible.
+func splitCreditField(data, moreInfo map[string]interface{}, nameKey, idKey string, nameToID map[string]string) []map[string]interface{} {
+	names := lookupString(nameKey, moreInfo, data)
+	if names == "" {
+		return nil
+	}
+	ids := strings.Split(lookupString(idKey, moreInfo, data), ",")
+
+	artists := []map[string]interface{}{}
+	for i, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id := ""
+		if i < len(ids) {
+			id = strings.TrimSpace(ids[i])
+		}
+		if id == "" {
+			id = nameToID[name]
+		}
+		artists = append(artists, map[string]interface{}{"id": id, "name": name})
+	}
+	return artists
+}
+
+func lookupValue(key string, maps ...map[string]interface{}) interface{} {
+	for _, m := range maps {
+		if val, ok := m[key]; ok && val != nil {
+			return val
+		}
+	}
+	return nil
+}
+
+func lookupString(key string, maps ...map[string]interface{}) string {
+	for _, m := range maps {
+		if val := GetString(m, key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
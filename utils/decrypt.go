@@ -3,52 +3,67 @@ package utils
 import (
 	"crypto/des"
 	"encoding/base64"
-	"jioSaavnAPI/config"
+	"fmt"
 	"strings"
 )
 
-var cfg = config.LoadConfig()
-
-// DecryptURL decrypts the encrypted media URL from JioSaavn
+// DecryptURL decrypts the encrypted media URL from JioSaavn.
+// It returns an empty string on failure; use DecryptMediaURL to get the reason.
 func DecryptURL(encrypted string) string {
+	url, err := DecryptMediaURL(encrypted)
+	if err != nil {
+		return ""
+	}
+	return strings.Replace(url, "_96.mp4", "_320.mp4", 1)
+}
+
+// DecryptMediaURL decrypts the encrypted media URL from JioSaavn with the
+// DecryptionKey of the Settings.
+// Failures are reported as a *MediaError carrying the reason.
+func DecryptMediaURL(encrypted string) (string, error) {
+	return DecryptMediaURLWithKey(encrypted, settings.DecryptionKey)
+}
+
+// DecryptMediaURLWithKey decrypts the encrypted media URL from JioSaavn with
+// the given DES key. Failures are reported as a *MediaError carrying the reason.
+func DecryptMediaURLWithKey(encrypted, decryptionKey string) (string, error) {
 
 	if encrypted == "" {
-		return ""
+		return "", &MediaError{Reason: MediaNoEncryptedURL}
 	}
 
-	key := []byte(cfg.DecryptionKey)
+	key := []byte(decryptionKey)
 
 	encData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encrypted))
 	if err != nil {
-		return ""
+		return "", &MediaError{Reason: MediaInvalidBase64, Err: err}
 	}
 
 	// Validate data length is valid for DES
 	if len(encData) == 0 || len(encData)%8 != 0 {
-		return ""
+		return "", &MediaError{Reason: MediaInvalidBlockSize, Err: fmt.Errorf("%d bytes is not a multiple of the DES block size", len(encData))}
 	}
 
 	block, err := des.NewCipher(key)
 	if err != nil {
-		return ""
+		return "", &MediaError{Reason: MediaInvalidKey, Err: err}
 	}
 
 	decrypted := make([]byte, len(encData))
 	for bs, be := 0, block.BlockSize(); bs < len(encData); bs, be = bs+block.BlockSize(), be+block.BlockSize() {
-		if be > len(encData) {
-			return "" // Invalid block size
-		}
 		block.Decrypt(decrypted[bs:be], encData[bs:be])
 	}
 
 	// Remove PKCS5 padding
-	if len(decrypted) > 0 {
-		padLen := int(decrypted[len(decrypted)-1])
-		if padLen > 0 && padLen <= len(decrypted) {
-			decrypted = decrypted[:len(decrypted)-padLen]
-		}
+	padLen := int(decrypted[len(decrypted)-1])
+	if padLen == 0 || padLen > block.BlockSize() {
+		return "", &MediaError{Reason: MediaInvalidPadding, Err: fmt.Errorf("invalid padding length %d", padLen)}
 	}
+	decrypted = decrypted[:len(decrypted)-padLen]
 
 	url := string(decrypted)
-	return strings.Replace(url, "_96.mp4", "_320.mp4", 1)
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", &MediaError{Reason: MediaNotAURL, Err: fmt.Errorf("decrypted data is not a URL")}
+	}
+	return url, nil
 }
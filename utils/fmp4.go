@@ -0,0 +1,557 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// maxM4ASamples bounds the sample tables read from a file, about 27 hours
+// of AAC at 44.1 kHz
+const maxM4ASamples = 1 << 22
+
+// maxFragmentSize bounds the file range read for one fragment
+const maxFragmentSize = 16 << 20
+
+// M4ASample is a sample of the audio track of an M4A file
+type M4ASample struct {
+	Offset   int64
+	Size     uint32
+	Duration uint32
+}
+
+// M4ASegment is a run of samples cut from an M4A file as one fragment
+type M4ASegment struct {
+	// First is the index of the first sample and Count the number of samples
+	First, Count int
+	// Start and Duration are in units of the timescale of the track
+	Start, Duration uint64
+}
+
+// M4AIndex is the sample table of the audio track of an M4A file, from which
+// the file is served as fragmented MP4: an init segment followed by moof and
+// mdat fragments that HLS and DASH players can play
+type M4AIndex struct {
+	// Timescale is the number of time units per second of the track
+	Timescale uint32
+	// Samples are the samples of the track in decoding order
+	Samples []M4ASample
+	// Codec is the RFC 6381 codec of the track, e.g. "mp4a.40.2", or "" when unknown
+	Codec string
+
+	trackID uint32
+	mvhd    []byte
+	trak    []mp4Box
+}
+
+// ReadM4AIndex reads the sample table of the first audio track of the M4A
+// file read from r. Only the box headers and moov are read, so r can fetch
+// its ranges from the network.
+func ReadM4AIndex(r io.ReaderAt) (*M4AIndex, error) {
+	var offset int64
+	for first := true; ; first = false {
+		header := make([]byte, 16)
+		n, err := r.ReadAt(header, offset)
+		if n < 8 {
+			if first {
+				return nil, ErrNotMP4
+			}
+			if err == nil || errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("moov box not found")
+			}
+			return nil, err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:8])
+		if first && typ != "ftyp" {
+			return nil, ErrNotMP4
+		}
+		headerLen := int64(8)
+		switch size {
+		case 0:
+			// The box extends to the end of the file, whose size is not known
+			return nil, fmt.Errorf("moov box not found")
+		case 1:
+			if n < 16 {
+				return nil, fmt.Errorf("truncated %q box header", typ)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+		if size < headerLen {
+			return nil, fmt.Errorf("invalid size for %q box", typ)
+		}
+
+		if typ == "moov" {
+			if size-headerLen > maxMoovSize {
+				return nil, fmt.Errorf("moov box too large")
+			}
+			payload := make([]byte, size-headerLen)
+			if n, err := r.ReadAt(payload, offset+headerLen); n < len(payload) {
+				if err == nil || errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			return parseM4AIndex(payload)
+		}
+		offset += size
+	}
+}
+
+// parseM4AIndex reads the sample table of the first audio track of a moov payload
+func parseM4AIndex(moov []byte) (*M4AIndex, error) {
+	children, err := parseBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &M4AIndex{}
+	for _, child := range children {
+		switch child.typ {
+		case "mvhd":
+			index.mvhd = child.payload
+		case "trak":
+			if index.trak != nil {
+				continue
+			}
+			trak, err := parseBoxes(child.payload)
+			if err != nil {
+				return nil, err
+			}
+			if handler, _ := boxPath(trak, "mdia", "hdlr"); len(handler) >= 12 && string(handler[8:12]) == "soun" {
+				index.trak = trak
+			}
+		}
+	}
+	if index.mvhd == nil || index.trak == nil {
+		return nil, fmt.Errorf("no audio track found")
+	}
+
+	tkhd, err := boxPath(index.trak, "tkhd")
+	if err != nil {
+		return nil, err
+	}
+	mdhd, err := boxPath(index.trak, "mdia", "mdhd")
+	if err != nil {
+		return nil, err
+	}
+	// Version 1 headers have 64-bit creation and modification times
+	if fullBoxVersion(tkhd) == 1 {
+		index.trackID, err = readUint32(tkhd, 20)
+	} else {
+		index.trackID, err = readUint32(tkhd, 12)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("truncated tkhd box")
+	}
+	if fullBoxVersion(mdhd) == 1 {
+		index.Timescale, err = readUint32(mdhd, 20)
+	} else {
+		index.Timescale, err = readUint32(mdhd, 12)
+	}
+	if err != nil || index.Timescale == 0 {
+		return nil, fmt.Errorf("invalid mdhd box")
+	}
+
+	stbl, err := boxPath(index.trak, "mdia", "minf", "stbl")
+	if err != nil {
+		return nil, err
+	}
+	if index.Samples, err = readSampleTable(stbl); err != nil {
+		return nil, err
+	}
+	if stsd, err := boxPath(index.trak, "mdia", "minf", "stbl", "stsd"); err == nil {
+		index.Codec = audioCodec(stsd)
+	}
+	return index, nil
+}
+
+// readSampleTable combines the sample sizes, durations and chunk offsets of
+// an stbl payload into the list of samples
+func readSampleTable(stbl []byte) ([]M4ASample, error) {
+	boxes, err := parseBoxes(stbl)
+	if err != nil {
+		return nil, err
+	}
+	table := map[string][]byte{}
+	for _, box := range boxes {
+		table[box.typ] = box.payload
+	}
+
+	// stsz: a constant sample size, or one size per sample
+	stsz := table["stsz"]
+	constant, err1 := readUint32(stsz, 4)
+	count, err2 := readUint32(stsz, 8)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("missing or truncated stsz box")
+	}
+	if count > maxM4ASamples || (constant == 0 && len(stsz) < 12+int(count)*4) {
+		return nil, fmt.Errorf("invalid stsz box")
+	}
+	samples := make([]M4ASample, count)
+	for i := range samples {
+		samples[i].Size = constant
+		if constant == 0 {
+			samples[i].Size = binary.BigEndian.Uint32(stsz[12+i*4:])
+		}
+	}
+
+	// stts: runs of samples with the same duration
+	stts := table["stts"]
+	entries, err := readUint32(stts, 4)
+	if err != nil || len(stts) < 8+int(entries)*8 {
+		return nil, fmt.Errorf("missing or truncated stts box")
+	}
+	sample := 0
+	for i := 0; i < int(entries); i++ {
+		run := int(binary.BigEndian.Uint32(stts[8+i*8:]))
+		delta := binary.BigEndian.Uint32(stts[12+i*8:])
+		for ; run > 0 && sample < len(samples); run-- {
+			samples[sample].Duration = delta
+			sample++
+		}
+	}
+	if sample != len(samples) {
+		return nil, fmt.Errorf("stts box covers %d of %d samples", sample, len(samples))
+	}
+
+	// stco or co64: the file offset of every chunk
+	var chunks []int64
+	if stco, ok := table["stco"]; ok {
+		n, err := readUint32(stco, 4)
+		if err != nil || len(stco) < 8+int(n)*4 {
+			return nil, fmt.Errorf("truncated stco box")
+		}
+		chunks = make([]int64, n)
+		for i := range chunks {
+			chunks[i] = int64(binary.BigEndian.Uint32(stco[8+i*4:]))
+		}
+	} else if co64, ok := table["co64"]; ok {
+		n, err := readUint32(co64, 4)
+		if err != nil || len(co64) < 8+int(n)*8 {
+			return nil, fmt.Errorf("truncated co64 box")
+		}
+		chunks = make([]int64, n)
+		for i := range chunks {
+			chunks[i] = int64(binary.BigEndian.Uint64(co64[8+i*8:]))
+		}
+	} else {
+		return nil, fmt.Errorf("missing chunk offset table")
+	}
+
+	// stsc: runs of chunks with the same number of samples, from a 1-based chunk
+	stsc := table["stsc"]
+	entries, err = readUint32(stsc, 4)
+	if err != nil || entries == 0 || len(stsc) < 8+int(entries)*12 {
+		return nil, fmt.Errorf("missing or truncated stsc box")
+	}
+	sample = 0
+	for i := 0; i < int(entries); i++ {
+		firstChunk := int(binary.BigEndian.Uint32(stsc[8+i*12:])) - 1
+		perChunk := int(binary.BigEndian.Uint32(stsc[12+i*12:]))
+		lastChunk := len(chunks)
+		if i+1 < int(entries) {
+			lastChunk = int(binary.BigEndian.Uint32(stsc[8+(i+1)*12:])) - 1
+		}
+		if firstChunk < 0 || lastChunk > len(chunks) {
+			return nil, fmt.Errorf("invalid stsc box")
+		}
+		for chunk := firstChunk; chunk < lastChunk; chunk++ {
+			offset := chunks[chunk]
+			for j := 0; j < perChunk && sample < len(samples); j++ {
+				samples[sample].Offset = offset
+				offset += int64(samples[sample].Size)
+				sample++
+			}
+		}
+	}
+	if sample != len(samples) {
+		return nil, fmt.Errorf("chunks hold %d of %d samples", sample, len(samples))
+	}
+	return samples, nil
+}
+
+// audioCodec returns the RFC 6381 codec of the first sample entry of an stsd
+// payload when it is MPEG-4 audio
+func audioCodec(stsd []byte) string {
+	if len(stsd) < 8 {
+		return ""
+	}
+	entries, err := parseBoxes(stsd[8:])
+	if err != nil || len(entries) == 0 || entries[0].typ != "mp4a" || len(entries[0].payload) < 28 {
+		return ""
+	}
+	// The audio sample entry fields take 28 bytes before the child boxes
+	children, err := parseBoxes(entries[0].payload[28:])
+	if err != nil {
+		return ""
+	}
+	esds, err := boxPath(children, "esds")
+	if err != nil || len(esds) < 4 {
+		return ""
+	}
+
+	// ES_Descriptor > DecoderConfigDescriptor > DecoderSpecificInfo
+	tag, es := readDescriptor(esds[4:])
+	if tag != 0x03 || len(es) < 3 {
+		return ""
+	}
+	flags := es[2]
+	es = es[3:]
+	if flags&0x80 != 0 {
+		es = skipBytes(es, 2)
+	}
+	if flags&0x40 != 0 && len(es) > 0 {
+		es = skipBytes(es, 1+int(es[0]))
+	}
+	if flags&0x20 != 0 {
+		es = skipBytes(es, 2)
+	}
+	tag, config := readDescriptor(es)
+	if tag != 0x04 || len(config) < 13 {
+		return ""
+	}
+	if objectType := config[0]; objectType != 0x40 {
+		return fmt.Sprintf("mp4a.%02X", objectType)
+	}
+	tag, info := readDescriptor(config[13:])
+	if tag != 0x05 || len(info) < 1 {
+		return "mp4a.40"
+	}
+	audioObjectType := int(info[0] >> 3)
+	if audioObjectType == 31 && len(info) >= 2 {
+		audioObjectType = 32 + (int(info[0]&0x07)<<3 | int(info[1]>>5))
+	}
+	return fmt.Sprintf("mp4a.40.%d", audioObjectType)
+}
+
+// readDescriptor splits an MPEG-4 descriptor into its tag and payload
+func readDescriptor(data []byte) (byte, []byte) {
+	if len(data) < 2 {
+		return 0, nil
+	}
+	tag, size, i := data[0], 0, 1
+	// The size takes up to four bytes of seven bits each
+	for ; i < len(data) && i <= 4; i++ {
+		size = size<<7 | int(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			i++
+			break
+		}
+	}
+	if i > len(data) || size > len(data)-i {
+		return 0, nil
+	}
+	return tag, data[i : i+size]
+}
+
+func skipBytes(data []byte, n int) []byte {
+	if n > len(data) {
+		return nil
+	}
+	return data[n:]
+}
+
+// boxPath returns the payload of the box reached along a path of box types
+func boxPath(boxes []mp4Box, path ...string) ([]byte, error) {
+	for i, typ := range path {
+		var payload []byte
+		found := false
+		for _, box := range boxes {
+			if box.typ == typ {
+				payload, found = box.payload, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no %q box", typ)
+		}
+		if i == len(path)-1 {
+			return payload, nil
+		}
+		var err error
+		if boxes, err = parseBoxes(payload); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("empty box path")
+}
+
+func fullBoxVersion(payload []byte) byte {
+	if len(payload) == 0 {
+		return 0
+	}
+	return payload[0]
+}
+
+func readUint32(data []byte, offset int) (uint32, error) {
+	if offset < 0 || len(data) < offset+4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return binary.BigEndian.Uint32(data[offset:]), nil
+}
+
+// Duration returns the duration of the track
+func (x *M4AIndex) Duration() time.Duration {
+	var total uint64
+	for _, sample := range x.Samples {
+		total += uint64(sample.Duration)
+	}
+	return x.timeOf(total)
+}
+
+// timeOf converts a duration in units of the timescale of the track
+func (x *M4AIndex) timeOf(units uint64) time.Duration {
+	return time.Duration(float64(units) / float64(x.Timescale) * float64(time.Second))
+}
+
+// Segments cuts the track into segments of at least target, the last one
+// excepted. Segments start on sample boundaries, so they run over target by
+// less than a sample.
+func (x *M4AIndex) Segments(target time.Duration) []M4ASegment {
+	limit := uint64(target.Seconds() * float64(x.Timescale))
+	segments := []M4ASegment{}
+	var start uint64
+	for first := 0; first < len(x.Samples); {
+		segment := M4ASegment{First: first, Start: start}
+		for first < len(x.Samples) && (segment.Duration < limit || segment.Count == 0) {
+			segment.Duration += uint64(x.Samples[first].Duration)
+			segment.Count++
+			first++
+		}
+		start += segment.Duration
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// SegmentDuration returns the duration of a segment
+func (x *M4AIndex) SegmentDuration(segment M4ASegment) time.Duration {
+	return x.timeOf(segment.Duration)
+}
+
+// FragmentSize returns the size of the moof and mdat boxes of a segment
+func (x *M4AIndex) FragmentSize(segment M4ASegment) int64 {
+	size := int64(fragmentHeaderSize(segment.Count)) + 8
+	for _, sample := range x.Samples[segment.First : segment.First+segment.Count] {
+		size += int64(sample.Size)
+	}
+	return size
+}
+
+// InitSegment returns the ftyp and moov boxes that precede the fragments. The
+// moov keeps the track and sample description of the file with empty sample
+// tables, and declares the fragments in mvex.
+func (x *M4AIndex) InitSegment() []byte {
+	ftyp := makeBox("ftyp", []byte("iso6"), make([]byte, 4), []byte("iso6mp41"))
+
+	trex := make([]byte, 24)
+	binary.BigEndian.PutUint32(trex[4:8], x.trackID)
+	// Samples refer to the first sample description
+	binary.BigEndian.PutUint32(trex[8:12], 1)
+	mvex := makeBox("mvex", makeBox("trex", trex))
+
+	moov := makeBox("moov", makeBox("mvhd", x.mvhd), makeBox("trak", emptySampleTables(x.trak)...), mvex)
+	return append(ftyp, moov...)
+}
+
+// emptySampleTables rebuilds the boxes of a track with its sample tables
+// emptied, the sample descriptions kept
+func emptySampleTables(boxes []mp4Box) [][]byte {
+	parts := make([][]byte, 0, len(boxes))
+	for _, box := range boxes {
+		switch box.typ {
+		case "mdia", "minf", "stbl":
+			children, err := parseBoxes(box.payload)
+			if err != nil {
+				continue
+			}
+			parts = append(parts, makeBox(box.typ, emptySampleTables(children)...))
+		case "stts", "stsc":
+			parts = append(parts, makeBox(box.typ, make([]byte, 8)))
+		case "stsz":
+			parts = append(parts, makeBox(box.typ, make([]byte, 12)))
+		case "stco", "co64":
+			parts = append(parts, makeBox("stco", make([]byte, 8)))
+		case "stss", "ctts", "sdtp", "udta":
+		default:
+			parts = append(parts, makeBox(box.typ, box.payload))
+		}
+	}
+	return parts
+}
+
+// fragmentHeaderSize is the size of the moof box of a fragment of count samples
+func fragmentHeaderSize(count int) int {
+	const mfhd, tfhd, tfdt = 16, 16, 20
+	trun := 20 + count*8
+	return 8 + mfhd + 8 + tfhd + tfdt + trun
+}
+
+// ReadFragment reads the samples of a segment from the file read from r and
+// returns them as a moof and mdat fragment with the given sequence number.
+// The samples are read with a single ReadAt call.
+func (x *M4AIndex) ReadFragment(r io.ReaderAt, segment M4ASegment, sequence uint32) ([]byte, error) {
+	if segment.First < 0 || segment.Count <= 0 || segment.First+segment.Count > len(x.Samples) {
+		return nil, fmt.Errorf("invalid segment")
+	}
+	samples := x.Samples[segment.First : segment.First+segment.Count]
+
+	start, end := int64(math.MaxInt64), int64(0)
+	var mdatSize int64 = 8
+	for _, sample := range samples {
+		start = min(start, sample.Offset)
+		end = max(end, sample.Offset+int64(sample.Size))
+		mdatSize += int64(sample.Size)
+	}
+	if end-start > maxFragmentSize || mdatSize > math.MaxUint32 {
+		return nil, fmt.Errorf("segment too large")
+	}
+	data := make([]byte, end-start)
+	if n, err := r.ReadAt(data, start); n < len(data) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	mfhd := make([]byte, 8)
+	binary.BigEndian.PutUint32(mfhd[4:8], sequence)
+
+	// default-base-is-moof: data offsets count from the start of moof
+	tfhd := make([]byte, 8)
+	binary.BigEndian.PutUint32(tfhd[0:4], 0x020000)
+	binary.BigEndian.PutUint32(tfhd[4:8], x.trackID)
+
+	tfdt := make([]byte, 12)
+	tfdt[0] = 1
+	binary.BigEndian.PutUint64(tfdt[4:12], segment.Start)
+
+	// trun with a data offset and the duration and size of every sample
+	trun := make([]byte, 12+len(samples)*8)
+	binary.BigEndian.PutUint32(trun[0:4], 0x000301)
+	binary.BigEndian.PutUint32(trun[4:8], uint32(len(samples)))
+	binary.BigEndian.PutUint32(trun[8:12], uint32(fragmentHeaderSize(len(samples))+8))
+	for i, sample := range samples {
+		binary.BigEndian.PutUint32(trun[12+i*8:], sample.Duration)
+		binary.BigEndian.PutUint32(trun[16+i*8:], sample.Size)
+	}
+
+	moof := makeBox("moof",
+		makeBox("mfhd", mfhd),
+		makeBox("traf", makeBox("tfhd", tfhd), makeBox("tfdt", tfdt), makeBox("trun", trun)),
+	)
+	fragment := make([]byte, 0, len(moof)+int(mdatSize))
+	fragment = append(fragment, moof...)
+	fragment = binary.BigEndian.AppendUint32(fragment, uint32(mdatSize))
+	fragment = append(fragment, "mdat"...)
+	for _, sample := range samples {
+		fragment = append(fragment, data[sample.Offset-start:sample.Offset-start+int64(sample.Size)]...)
+	}
+	return fragment, nil
+}
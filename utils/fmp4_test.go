@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// aacSamples is the number of samples of the testdata/aac-*.m4a files, AAC
+// at 44.1 kHz whose sample i is filled with byte i%256
+const aacSamples = 517
+
+// countingReaderAt counts the bytes read from a file
+type countingReaderAt struct {
+	r    *bytes.Reader
+	read int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += n
+	return n, err
+}
+
+func readTestIndex(t *testing.T, file string) ([]byte, *M4AIndex) {
+	t.Helper()
+	input, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := ReadM4AIndex(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadM4AIndex: %v", err)
+	}
+	return input, index
+}
+
+func TestReadM4AIndex(t *testing.T) {
+	for _, file := range []string{"aac-moov-first.m4a", "aac-mdat-first-co64.m4a"} {
+		t.Run(file, func(t *testing.T) {
+			input, err := os.ReadFile("testdata/" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &countingReaderAt{r: bytes.NewReader(input)}
+			index, err := ReadM4AIndex(r)
+			if err != nil {
+				t.Fatalf("ReadM4AIndex: %v", err)
+			}
+			if r.read >= len(input)/2 {
+				t.Errorf("read %d of %d bytes, want only the box headers and moov", r.read, len(input))
+			}
+
+			if index.Timescale != 44100 || index.Codec != "mp4a.40.2" {
+				t.Errorf("timescale = %d, codec = %q, want 44100 and mp4a.40.2", index.Timescale, index.Codec)
+			}
+			if len(index.Samples) != aacSamples {
+				t.Fatalf("%d samples, want %d", len(index.Samples), aacSamples)
+			}
+			for i, sample := range index.Samples {
+				data := input[sample.Offset : sample.Offset+int64(sample.Size)]
+				if !bytes.Equal(data, bytes.Repeat([]byte{byte(i)}, int(sample.Size))) || sample.Duration != 1024 {
+					t.Fatalf("sample %d at offset %d does not point at its data", i, sample.Offset)
+				}
+			}
+			// 517 samples of 1024 at 44.1 kHz
+			if got := index.Duration().Round(time.Millisecond); got != 12005*time.Millisecond {
+				t.Errorf("duration = %v, want 12.005s", got)
+			}
+		})
+	}
+}
+
+func TestReadM4AIndexErrors(t *testing.T) {
+	input, err := os.ReadFile("testdata/moov-first.m4a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	boxes, err := parseBoxes(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ftyp := makeBox("ftyp", findBox(t, boxes, "ftyp").payload)
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"not an mp4 file", []byte("ID3\x04 not an mp4 file")},
+		{"no moov", append(ftyp, makeBox("mdat", []byte("data"))...)},
+		{"truncated moov", input[:len(input)-100]},
+		{"no audio track", append(ftyp, makeBox("moov", makeBox("mvhd", make([]byte, 100)))...)},
+	}
+	for _, tt := range tests {
+		if _, err := ReadM4AIndex(bytes.NewReader(tt.input)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if _, err := ReadM4AIndex(bytes.NewReader(tests[0].input)); !errors.Is(err, ErrNotMP4) {
+		t.Errorf("err = %v, want ErrNotMP4", err)
+	}
+}
+
+func TestM4ASegments(t *testing.T) {
+	_, index := readTestIndex(t, "aac-moov-first.m4a")
+	sampleDuration := uint64(1024)
+
+	segments := index.Segments(4 * time.Second)
+	if len(segments) != 3 {
+		t.Fatalf("%d segments, want 3", len(segments))
+	}
+	next, start := 0, uint64(0)
+	for i, segment := range segments {
+		if segment.First != next || segment.Start != start {
+			t.Errorf("segment %d starts at sample %d and time %d, want %d and %d", i, segment.First, segment.Start, next, start)
+		}
+		if segment.Duration != uint64(segment.Count)*sampleDuration {
+			t.Errorf("segment %d lasts %d for %d samples", i, segment.Duration, segment.Count)
+		}
+		limit := uint64(4 * index.Timescale)
+		if i < len(segments)-1 && (segment.Duration < limit || segment.Duration >= limit+sampleDuration) {
+			t.Errorf("segment %d lasts %v, want 4s and less than a sample", i, index.SegmentDuration(segment))
+		}
+		next, start = next+segment.Count, start+segment.Duration
+	}
+	if next != aacSamples {
+		t.Errorf("segments cover %d samples, want %d", next, aacSamples)
+	}
+}
+
+func TestM4AFragments(t *testing.T) {
+	input, index := readTestIndex(t, "aac-moov-first.m4a")
+
+	for i, segment := range index.Segments(4 * time.Second) {
+		fragment, err := index.ReadFragment(bytes.NewReader(input), segment, uint32(i+1))
+		if err != nil {
+			t.Fatalf("segment %d: %v", i, err)
+		}
+		if int64(len(fragment)) != index.FragmentSize(segment) {
+			t.Errorf("segment %d is %d bytes, FragmentSize = %d", i, len(fragment), index.FragmentSize(segment))
+		}
+
+		boxes, err := parseBoxes(fragment)
+		if err != nil || len(boxes) != 2 || boxes[0].typ != "moof" || boxes[1].typ != "mdat" {
+			t.Fatalf("segment %d is not a moof and an mdat box: %v", i, err)
+		}
+		moofSize := 8 + len(boxes[0].payload)
+
+		mfhd := findBox(t, boxes, "moof", "mfhd").payload
+		if got := binary.BigEndian.Uint32(mfhd[4:8]); got != uint32(i+1) {
+			t.Errorf("segment %d has sequence number %d", i, got)
+		}
+		tfhd := findBox(t, boxes, "moof", "traf", "tfhd").payload
+		if flags, track := binary.BigEndian.Uint32(tfhd[0:4]), binary.BigEndian.Uint32(tfhd[4:8]); flags != 0x020000 || track != 1 {
+			t.Errorf("segment %d: tfhd flags %#x for track %d, want default-base-is-moof for track 1", i, flags, track)
+		}
+		tfdt := findBox(t, boxes, "moof", "traf", "tfdt").payload
+		if tfdt[0] != 1 || binary.BigEndian.Uint64(tfdt[4:12]) != segment.Start {
+			t.Errorf("segment %d: tfdt = %x, want version 1 at %d", i, tfdt, segment.Start)
+		}
+
+		trun := findBox(t, boxes, "moof", "traf", "trun").payload
+		count := int(binary.BigEndian.Uint32(trun[4:8]))
+		dataOffset := int(binary.BigEndian.Uint32(trun[8:12]))
+		if count != segment.Count || dataOffset != moofSize+8 {
+			t.Fatalf("segment %d: trun of %d samples at offset %d, want %d at %d", i, count, dataOffset, segment.Count, moofSize+8)
+		}
+		mdat := fragment[dataOffset:]
+		var duration uint64
+		for j := 0; j < count; j++ {
+			sampleDuration := binary.BigEndian.Uint32(trun[12+j*8:])
+			size := int(binary.BigEndian.Uint32(trun[16+j*8:]))
+			duration += uint64(sampleDuration)
+			want := bytes.Repeat([]byte{byte(segment.First + j)}, size)
+			if len(mdat) < size || !bytes.Equal(mdat[:size], want) {
+				t.Fatalf("segment %d: sample %d is not in mdat", i, segment.First+j)
+			}
+			mdat = mdat[size:]
+		}
+		if len(mdat) != 0 || duration != segment.Duration {
+			t.Errorf("segment %d: %d bytes left in mdat, duration %d, want 0 and %d", i, len(mdat), duration, segment.Duration)
+		}
+	}
+
+	if _, err := index.ReadFragment(bytes.NewReader(input[:len(input)-10]), index.Segments(4 * time.Second)[2], 3); err == nil {
+		t.Error("fragment read from a truncated file")
+	}
+}
+
+func TestM4AInitSegment(t *testing.T) {
+	input, index := readTestIndex(t, "aac-moov-first.m4a")
+	original, err := parseBoxes(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxes, err := parseBoxes(index.InitSegment())
+	if err != nil {
+		t.Fatalf("parsing init segment: %v", err)
+	}
+	if len(boxes) != 2 || boxes[0].typ != "ftyp" || boxes[1].typ != "moov" {
+		t.Fatalf("init segment is not an ftyp and a moov box")
+	}
+	if brand := string(boxes[0].payload[:4]); brand != "iso6" {
+		t.Errorf("major brand = %q, want iso6", brand)
+	}
+
+	trex := findBox(t, boxes, "moov", "mvex", "trex").payload
+	if track := binary.BigEndian.Uint32(trex[4:8]); track != 1 {
+		t.Errorf("trex track = %d, want 1", track)
+	}
+	stsd := findBox(t, boxes, "moov", "trak", "mdia", "minf", "stbl", "stsd").payload
+	if want := findBox(t, original, "moov", "trak", "mdia", "minf", "stbl", "stsd").payload; !bytes.Equal(stsd, want) {
+		t.Error("stsd differs from the file")
+	}
+	for _, table := range []string{"stts", "stsc", "stco"} {
+		if entries := binary.BigEndian.Uint32(findBox(t, boxes, "moov", "trak", "mdia", "minf", "stbl", table).payload[4:8]); entries != 0 {
+			t.Errorf("%s has %d entries, want none", table, entries)
+		}
+	}
+	if count := binary.BigEndian.Uint32(findBox(t, boxes, "moov", "trak", "mdia", "minf", "stbl", "stsz").payload[8:12]); count != 0 {
+		t.Errorf("stsz has %d samples, want none", count)
+	}
+	moov, err := parseBoxes(boxes[1].payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, box := range moov {
+		if box.typ == "udta" {
+			t.Error("init segment keeps the metadata")
+		}
+	}
+}
@@ -9,26 +9,15 @@ import (
 
 // FormatSong formats the raw song data from JioSaavn API
 func FormatSong(data map[string]interface{}) map[string]interface{} {
-	// Safe check for encrypted_media_url
-	encryptedURL := ""
-	if val, ok := data["encrypted_media_url"]; ok && val != nil {
-		encryptedURL = fmt.Sprintf("%v", val)
+	// Use the best available quality as the media URL
+	media := SongMedia(data)
+	mediaURL := ""
+	if len(media.DownloadURLs) > 0 {
+		mediaURL = media.DownloadURLs[len(media.DownloadURLs)-1]["url"]
 	}
-	mediaURL := DecryptURL(encryptedURL)
-	// Check if 320kbps is available
-	is320kbps := false
-	if val, ok := data["320kbps"]; ok && val != nil {
-		is320kbps = fmt.Sprintf("%v", val) == "true"
-	}
-
-	if !is320kbps {
-		mediaURL = strings.Replace(mediaURL, "_320.mp4", "_160.mp4", 1)
-	}
-	previewURL := strings.Replace(mediaURL, "_320.mp4", "_96_p.mp4", 1)
-	previewURL = strings.Replace(previewURL, "_160.mp4", "_96_p.mp4", 1)
-	previewURL = strings.Replace(previewURL, "//aac.", "//preview.", 1)
 	data["media_url"] = mediaURL
-	data["media_preview_url"] = previewURL
+	data["media_preview_url"] = media.PreviewURL
+	data["media_resolution"] = media
 	for _, key := range []string{"song", "music", "singers", "starring", "album", "primary_artists"} {
 		if val, ok := data[key]; ok {
 			data[key] = strings.TrimSpace(fmt.Sprintf("%v", val))
@@ -46,29 +35,8 @@ func FormatSongFromToken(data map[string]interface{}) map[string]interface{} {
 	// Extract more_info object
 	moreInfo, _ := data["more_info"].(map[string]interface{})
 
-	// Get encrypted media URL and decrypt it
-	encryptedURL := GetString(moreInfo, "encrypted_media_url")
-	mediaURL := DecryptURL(encryptedURL)
-
-	// Determine max available quality
-	has320 := GetString(moreInfo, "320kbps") == "true"
-	maxQuality := "_160.mp4"
-	if has320 {
-		maxQuality = "_320.mp4"
-	}
-
-	// Build download URLs
-	downloadURLs := []map[string]string{
-		{"quality": "96kbps", "url": strings.Replace(mediaURL, maxQuality, "_96.mp4", 1)},
-		{"quality": "160kbps", "url": strings.Replace(mediaURL, maxQuality, "_160.mp4", 1)},
-	}
-
-	if has320 {
-		downloadURLs = append(downloadURLs, map[string]string{
-			"quality": "320kbps",
-			"url":     mediaURL,
-		})
-	}
+	// Build download URLs from the encrypted media URL
+	media := SongMedia(data)
 
 	// Build image array with multiple sizes
 	imageURL := GetString(data, "image")
@@ -99,25 +67,7 @@ func FormatSongFromToken(data map[string]interface{}) map[string]interface{} {
 	// Parse has lyrics
 	hasLyrics := GetString(moreInfo, "has_lyrics") == "true"
 
-	// Build artists from artistMap
-	artistMap, _ := moreInfo["artistMap"].(map[string]interface{})
-
-	primaryArtists := buildArtistsFromMap(artistMap, "primary_artists")
-	featuredArtists := buildArtistsFromMap(artistMap, "featured_artists")
-	allArtists := buildArtistsFromMap(artistMap, "artists")
-
-	// Ensure arrays are not nil
-	if primaryArtists == nil {
-		primaryArtists = []map[string]interface{}{}
-	}
-	if featuredArtists == nil {
-		featuredArtists = []map[string]interface{}{}
-	}
-	if allArtists == nil {
-		allArtists = []map[string]interface{}{}
-	}
-
-	return map[string]interface{}{
+	return addMediaDiagnostics(map[string]interface{}{
 		"id":              GetString(data, "id"),
 		"name":            GetString(data, "title"),
 		"type":            "song",
@@ -137,63 +87,11 @@ func FormatSongFromToken(data map[string]interface{}) map[string]interface{} {
 			"name": GetString(moreInfo, "album"),
 			"url":  GetString(moreInfo, "album_url"),
 		},
-		"artists": map[string]interface{}{
-			"primary":  primaryArtists,
-			"featured": featuredArtists,
-			"all":      allArtists,
-		},
+		"artists":     BuildSongArtists(data, moreInfo),
 		"image":       images,
-		"downloadUrl": downloadURLs,
-	}
-}
-
-// buildArtistsFromMap extracts artist data from the artistMap structure
-func buildArtistsFromMap(artistMap map[string]interface{}, key string) []map[string]interface{} {
-	if artistMap == nil {
-		return []map[string]interface{}{}
-	}
-
-	artistsRaw, ok := artistMap[key]
-	if !ok {
-		return []map[string]interface{}{}
-	}
-
-	artistsArray, ok := artistsRaw.([]interface{})
-	if !ok {
-		return []map[string]interface{}{}
-	}
-
-	result := make([]map[string]interface{}, 0, len(artistsArray))
-	for _, artistRaw := range artistsArray {
-		artist, ok := artistRaw.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		result = append(result, map[string]interface{}{
-			"id":   GetString(artist, "id"),
-			"name": GetString(artist, "name"),
-			"role": GetString(artist, "role"),
-			"image": []map[string]string{
-				{
-					"quality": "50x50",
-					"url":     strings.Replace(GetString(artist, "image"), "150x150", "50x50", 1),
-				},
-				{
-					"quality": "150x150",
-					"url":     GetString(artist, "image"),
-				},
-				{
-					"quality": "500x500",
-					"url":     strings.Replace(GetString(artist, "image"), "150x150", "500x500", 1),
-				},
-			},
-			"type": GetString(artist, "type"),
-			"url":  GetString(artist, "perma_url"),
-		})
-	}
-
-	return result
+		"downloadUrl": DownloadLinks(GetString(data, "id"), media.DownloadURLs),
+		"previewUrl":  PreviewLink(GetString(data, "id"), media.PreviewURL),
+	}, media)
 }
 
 // FormatSongDetailed transforms raw JioSaavn API data into a clean, structured format
@@ -202,28 +100,7 @@ func FormatSongDetailed(data map[string]interface{}) map[string]interface{} {
 	data = FormatSong(data)
 
 	// Now build the detailed structure
-	baseURL := data["media_url"].(string)
-
-	// Determine max available quality
-	has320 := GetString(data, "320kbps") == "true"
-	maxQuality := "_160.mp4"
-	if has320 {
-		maxQuality = "_320.mp4"
-	}
-
-	// Build download URLs - only include good quality options (96kbps and above)
-	downloadURLs := []map[string]string{
-		{"quality": "96kbps", "url": strings.Replace(baseURL, maxQuality, "_96.mp4", 1)},
-		{"quality": "160kbps", "url": strings.Replace(baseURL, maxQuality, "_160.mp4", 1)},
-	}
-
-	// Only add 320kbps if actually available
-	if has320 {
-		downloadURLs = append(downloadURLs, map[string]string{
-			"quality": "320kbps",
-			"url":     baseURL,
-		})
-	}
+	media, _ := data["media_resolution"].(MediaResolution)
 
 	// Build image array with multiple sizes
 	imageURL := GetString(data, "image")
@@ -245,19 +122,7 @@ func FormatSongDetailed(data map[string]interface{}) map[string]interface{} {
 	// Parse has lyrics
 	hasLyrics := GetString(data, "has_lyrics") == "true"
 
-	// Build artists - avoid duplicates by using primary artists for "all" if no separate singers data
-	primaryArtists := buildArtists(data, "primary_artists", "primary_artists_id", "primary_artists")
-	featuredArtists := buildArtists(data, "featured_artists", "featured_artists_id", "featured_artists")
-
-	// Use primary artists as "all" artists to avoid confusion
-	allArtists := primaryArtists
-
-	// Ensure featured artists is an array, not nil
-	if len(featuredArtists) == 0 {
-		featuredArtists = []map[string]interface{}{}
-	}
-
-	return map[string]interface{}{
+	return addMediaDiagnostics(map[string]interface{}{
 		"id":              GetString(data, "id"),
 		"name":            GetString(data, "song"),
 		"type":            "song",
@@ -265,6 +130,7 @@ func FormatSongDetailed(data map[string]interface{}) map[string]interface{} {
 		"releaseDate":     GetString(data, "release_date"),
 		"duration":        duration,
 		"label":           GetString(data, "label"),
+		"copyright":       GetString(data, "copyright_text"),
 		"explicitContent": explicitContent,
 		"playCount":       playCount,
 		"language":        GetString(data, "language"),
@@ -276,14 +142,11 @@ func FormatSongDetailed(data map[string]interface{}) map[string]interface{} {
 			"name": GetString(data, "album"),
 			"url":  GetString(data, "album_url"),
 		},
-		"artists": map[string]interface{}{
-			"primary":  primaryArtists,
-			"featured": featuredArtists,
-			"all":      allArtists,
-		},
+		"artists":     BuildSongArtists(data, nil),
 		"image":       images,
-		"downloadUrl": downloadURLs,
-	}
+		"downloadUrl": DownloadLinks(GetString(data, "id"), media.DownloadURLs),
+		"previewUrl":  PreviewLink(GetString(data, "id"), media.PreviewURL),
+	}, media)
 }
 
 // BuildImageArray creates an array of image qualities from a base image URL
@@ -510,62 +373,13 @@ func FormatArtistDetails(data map[string]interface{}) map[string]interface{} {
 	}
 }
 
-// Helper function to build artist arrays
-func buildArtists(data map[string]interface{}, nameKey, idKey, role string) []map[string]interface{} {
-	names := GetString(data, nameKey)
-	ids := GetString(data, idKey)
-
-	if names == "" || ids == "" {
-		return []map[string]interface{}{}
-	}
-
-	nameList := strings.Split(names, ", ")
-	idList := strings.Split(ids, ", ")
-
-	artists := []map[string]interface{}{}
-	for i, name := range nameList {
-		name = strings.TrimSpace(name)
-		id := ""
-		if i < len(idList) {
-			id = strings.TrimSpace(idList[i])
-		}
-
-		artists = append(artists, map[string]interface{}{
-			"id":    id,
-			"name":  name,
-			"role":  role,
-			"image": []string{},
-			"type":  "artist",
-		})
-	}
-
-	return artists
-}
-
 // FormatSearchSong formats search result songs to match the detailed song format
 func FormatSearchSong(data map[string]interface{}) map[string]interface{} {
 	// Get more_info nested object
 	moreInfo, _ := data["more_info"].(map[string]interface{})
 
 	// Decrypt and build download URLs
-	encryptedURL := GetString(moreInfo, "encrypted_media_url")
-	if encryptedURL == "" {
-		encryptedURL = GetString(data, "encrypted_media_url")
-	}
-
-	baseURL := DecryptURL(encryptedURL)
-	has320 := GetString(moreInfo, "320kbps") == "true"
-
-	downloadURLs := []map[string]string{
-		{"quality": "96kbps", "url": strings.Replace(baseURL, "_320.mp4", "_96.mp4", 1)},
-		{"quality": "160kbps", "url": strings.Replace(baseURL, "_320.mp4", "_160.mp4", 1)},
-	}
-	if has320 {
-		downloadURLs = append(downloadURLs, map[string]string{
-			"quality": "320kbps",
-			"url":     baseURL,
-		})
-	}
+	media := SongMedia(data)
 
 	// Build image array
 	imageURL := GetString(data, "image")
@@ -590,76 +404,7 @@ func FormatSearchSong(data map[string]interface{}) map[string]interface{} {
 	// Parse has lyrics
 	hasLyrics := GetString(moreInfo, "has_lyrics") == "true"
 
-	// Build artists from artistMap in more_info
-	primaryArtists := []map[string]interface{}{}
-	featuredArtists := []map[string]interface{}{}
-	allArtists := []map[string]interface{}{}
-
-	if artistMap, ok := moreInfo["artistMap"].(map[string]interface{}); ok {
-		// Primary artists
-		if primary, ok := artistMap["primary_artists"].([]interface{}); ok {
-			for _, artist := range primary {
-				if a, ok := artist.(map[string]interface{}); ok {
-					artistImage := GetString(a, "image")
-					primaryArtists = append(primaryArtists, map[string]interface{}{
-						"id":    GetString(a, "id"),
-						"name":  GetString(a, "name"),
-						"role":  GetString(a, "role"),
-						"type":  "artist",
-						"image": BuildImageArray(artistImage),
-						"url":   GetString(a, "perma_url"),
-					})
-				}
-			}
-		}
-
-		// Featured artists
-		if featured, ok := artistMap["featured_artists"].([]interface{}); ok {
-			for _, artist := range featured {
-				if a, ok := artist.(map[string]interface{}); ok {
-					artistImage := GetString(a, "image")
-					featuredArtists = append(featuredArtists, map[string]interface{}{
-						"id":    GetString(a, "id"),
-						"name":  GetString(a, "name"),
-						"role":  GetString(a, "role"),
-						"type":  "artist",
-						"image": BuildImageArray(artistImage),
-						"url":   GetString(a, "perma_url"),
-					})
-				}
-			}
-		}
-
-		// All artists
-		if artists, ok := artistMap["artists"].([]interface{}); ok {
-			for _, artist := range artists {
-				if a, ok := artist.(map[string]interface{}); ok {
-					artistImage := GetString(a, "image")
-					allArtists = append(allArtists, map[string]interface{}{
-						"id":    GetString(a, "id"),
-						"name":  GetString(a, "name"),
-						"role":  GetString(a, "role"),
-						"type":  "artist",
-						"image": BuildImageArray(artistImage),
-						"url":   GetString(a, "perma_url"),
-					})
-				}
-			}
-		}
-	}
-
-	// Ensure arrays are not nil
-	if len(featuredArtists) == 0 {
-		featuredArtists = []map[string]interface{}{}
-	}
-	if len(primaryArtists) == 0 {
-		primaryArtists = []map[string]interface{}{}
-	}
-	if len(allArtists) == 0 {
-		allArtists = []map[string]interface{}{}
-	}
-
-	return map[string]interface{}{
+	return addMediaDiagnostics(map[string]interface{}{
 		"id":              GetString(data, "id"),
 		"name":            strings.TrimSpace(GetString(data, "title")),
 		"type":            "song",
@@ -679,14 +424,11 @@ func FormatSearchSong(data map[string]interface{}) map[string]interface{} {
 			"name": GetString(moreInfo, "album"),
 			"url":  GetString(moreInfo, "album_url"),
 		},
-		"artists": map[string]interface{}{
-			"primary":  primaryArtists,
-			"featured": featuredArtists,
-			"all":      allArtists,
-		},
+		"artists":     BuildSongArtists(data, moreInfo),
 		"image":       images,
-		"downloadUrl": downloadURLs,
-	}
+		"downloadUrl": DownloadLinks(GetString(data, "id"), media.DownloadURLs),
+		"previewUrl":  PreviewLink(GetString(data, "id"), media.PreviewURL),
+	}, media)
 }
 
 // FormatSearchArtist formats search result artists
@@ -923,29 +665,13 @@ func FormatAlbum(data map[string]interface{}) map[string]interface{} {
 	}
 }
 
-// formatAlbumSong formats songs from album endpoint which have a different structure
+// formatAlbumSong formats songs from album endpoint which have a different structure.
+// It also formats the songs of playlists, which carry their credits in more_info.
 func formatAlbumSong(data map[string]interface{}) map[string]interface{} {
-	// Decrypt and build download URLs
-	encryptedURL := GetString(data, "encrypted_media_url")
-	baseURL := DecryptURL(encryptedURL)
-	has320 := GetString(data, "320kbps") == "true"
-
-	maxQuality := "_160.mp4"
-	if has320 {
-		maxQuality = "_320.mp4"
-		baseURL = strings.Replace(baseURL, "_160.mp4", "_320.mp4", 1)
-	}
+	moreInfo, _ := data["more_info"].(map[string]interface{})
 
-	downloadURLs := []map[string]string{
-		{"quality": "96kbps", "url": strings.Replace(baseURL, maxQuality, "_96.mp4", 1)},
-		{"quality": "160kbps", "url": strings.Replace(baseURL, maxQuality, "_160.mp4", 1)},
-	}
-	if has320 {
-		downloadURLs = append(downloadURLs, map[string]string{
-			"quality": "320kbps",
-			"url":     baseURL,
-		})
-	}
+	// Decrypt and build download URLs
+	media := SongMedia(data)
 
 	// Build image array
 	imageURL := GetString(data, "image")
@@ -964,7 +690,7 @@ func formatAlbumSong(data map[string]interface{}) map[string]interface{} {
 	// Parse has lyrics
 	hasLyrics := GetString(data, "has_lyrics") == "true"
 
-	return map[string]interface{}{
+	return addMediaDiagnostics(map[string]interface{}{
 		"id":              GetString(data, "id"),
 		"name":            strings.TrimSpace(GetString(data, "song")),
 		"type":            "song",
@@ -972,6 +698,7 @@ func formatAlbumSong(data map[string]interface{}) map[string]interface{} {
 		"releaseDate":     GetString(data, "release_date"),
 		"duration":        duration,
 		"label":           GetString(data, "label"),
+		"copyright":       GetString(data, "copyright_text"),
 		"explicitContent": explicitContent,
 		"playCount":       playCount,
 		"language":        GetString(data, "language"),
@@ -983,9 +710,11 @@ func formatAlbumSong(data map[string]interface{}) map[string]interface{} {
 			"name": GetString(data, "album"),
 			"url":  GetString(data, "album_url"),
 		},
+		"artists":     BuildSongArtists(data, moreInfo),
 		"image":       images,
-		"downloadUrl": downloadURLs,
-	}
+		"downloadUrl": DownloadLinks(GetString(data, "id"), media.DownloadURLs),
+		"previewUrl":  PreviewLink(GetString(data, "id"), media.PreviewURL),
+	}, media)
 }
 
 // formatAlbumSong formats songs from album endpoint which have a different structure
@@ -1127,24 +856,6 @@ func FormatSongSearch(data map[string]interface{}) map[string]interface{} {
 		// Parse play count
 		playCount := GetInt(itemMap, "play_count")
 
-		// Build primary artists
-		primaryArtists := []map[string]interface{}{}
-		if artistMap, ok := moreInfo["artistMap"].(map[string]interface{}); ok {
-			if primary, ok := artistMap["primary_artists"].([]interface{}); ok {
-				for _, artist := range primary {
-					if a, ok := artist.(map[string]interface{}); ok {
-						primaryArtists = append(primaryArtists, map[string]interface{}{
-							"id":   GetString(a, "id"),
-							"name": GetString(a, "name"),
-							"role": GetString(a, "role"),
-							"type": "artist",
-							"url":  GetString(a, "perma_url"),
-						})
-					}
-				}
-			}
-		}
-
 		// Build album info
 		album := map[string]interface{}{
 			"id":   GetString(moreInfo, "album_id"),
@@ -1164,9 +875,7 @@ func FormatSongSearch(data map[string]interface{}) map[string]interface{} {
 			"url":             GetString(itemMap, "perma_url"),
 			"image":           images,
 			"album":           album,
-			"artists": map[string]interface{}{
-				"primary": primaryArtists,
-			},
+			"artists":         BuildSongArtists(itemMap, moreInfo),
 		}
 
 		formattedResults = append(formattedResults, formatted)
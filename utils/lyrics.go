@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"html"
+	"jioSaavnAPI/models"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LyricsLine is a single line of lyrics. Time is the offset in seconds from
+// the start of the song and is only set when the lyrics are time-synced.
+type LyricsLine = models.LyricsLine
+
+// Lyrics is the structured lyrics response returned by the lyrics endpoint.
+// Its Text and LRC methods render it as plain text and as an LRC file.
+type Lyrics = models.Lyrics
+
+var (
+	// lyricsBreakPattern matches the <br>, <br/> and <br /> variants used upstream
+	lyricsBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+	// lyricsTagPattern matches any remaining HTML markup
+	lyricsTagPattern = regexp.MustCompile(`<[^>]*>`)
+	// lrcTimePattern matches LRC timestamps such as [01:23.45] or [01:23]
+	lrcTimePattern = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// lrcTagPattern matches LRC ID tags such as [ar:Artist] or [offset:+100]
+	lrcTagPattern = regexp.MustCompile(`^\[[a-zA-Z]+:[^\]]*\]$`)
+)
+
+// SanitizeLyrics converts the upstream lyrics markup into plain text.
+// Line breaks are preserved, all other tags are stripped and HTML entities are decoded.
+func SanitizeLyrics(raw string) string {
+	text := lyricsBreakPattern.ReplaceAllString(raw, "\n")
+	text = lyricsTagPattern.ReplaceAllString(text, "")
+	// Entities are sometimes double-encoded (e.g. &amp;#39;)
+	for i := 0; i < 2; i++ {
+		text = html.UnescapeString(text)
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	return strings.TrimSpace(text)
+}
+
+// FormatLyrics transforms the raw lyrics.getLyrics response into structured lyrics.
+// Lines carrying LRC timestamps are parsed as time-synced lyrics.
+func FormatLyrics(id string, data map[string]interface{}) Lyrics {
+	text := SanitizeLyrics(GetString(data, "lyrics"))
+
+	lines := []LyricsLine{}
+	synced := false
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSpace(raw)
+		if lrcTagPattern.MatchString(raw) {
+			continue
+		}
+
+		// A single LRC line may carry several timestamps for a repeated verse
+		times := []float64{}
+		for {
+			match := lrcTimePattern.FindStringSubmatch(raw)
+			if match == nil {
+				break
+			}
+			times = append(times, parseLRCTime(match[1], match[2], match[3]))
+			raw = strings.TrimSpace(raw[len(match[0]):])
+		}
+
+		if len(times) == 0 {
+			lines = append(lines, LyricsLine{Text: raw})
+			continue
+		}
+		synced = true
+		for _, t := range times {
+			t := t
+			lines = append(lines, LyricsLine{Time: &t, Text: raw})
+		}
+	}
+
+	if synced {
+		lines = sortSyncedLines(lines)
+	} else {
+		lines = trimBlankLines(lines)
+	}
+
+	snippet := SanitizeLyrics(GetString(data, "snippet"))
+	if snippet == "" {
+		for _, line := range lines {
+			if line.Text != "" {
+				snippet = line.Text
+				break
+			}
+		}
+	}
+
+	return Lyrics{
+		ID:        id,
+		Lines:     lines,
+		Copyright: SanitizeLyrics(GetString(data, "lyrics_copyright")),
+		Snippet:   snippet,
+		Synced:    synced,
+	}
+}
+
+// parseLRCTime converts the captured minute, second and fraction groups into seconds
+func parseLRCTime(min, sec, frac string) float64 {
+	m, _ := strconv.Atoi(min)
+	s, _ := strconv.Atoi(sec)
+	t := float64(m*60 + s)
+	if frac != "" {
+		f, _ := strconv.Atoi(frac)
+		t += float64(f) / math.Pow10(len(frac))
+	}
+	return t
+}
+
+// sortSyncedLines orders synced lines by timestamp, keeping the relative
+// order of lines that share a timestamp
+func sortSyncedLines(lines []LyricsLine) []LyricsLine {
+	timed := make([]LyricsLine, 0, len(lines))
+	for _, line := range lines {
+		if line.Time != nil {
+			timed = append(timed, line)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return *timed[i].Time < *timed[j].Time
+	})
+	return timed
+}
+
+// trimBlankLines drops empty lines at the start and end of the lyrics
+func trimBlankLines(lines []LyricsLine) []LyricsLine {
+	for len(lines) > 0 && lines[0].Text == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1].Text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,435 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// M4ATags is the iTunes-style metadata written into an M4A file
+type M4ATags struct {
+	Title       string
+	Artist      string
+	AlbumArtist string
+	Album       string
+	Year        string
+	Genre       string
+	Copyright   string
+	Lyrics      string
+	TrackNumber int
+	TrackTotal  int
+	Cover       []byte // JPEG or PNG image data
+}
+
+// ErrNotMP4 is returned when the input does not look like an MP4 file
+var ErrNotMP4 = errors.New("not an MP4 file")
+
+// mp4Containers are the boxes that are descended into when rewriting the
+// chunk offset tables inside moov
+var mp4Containers = map[string]bool{
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+}
+
+// maxMoovSize bounds how much of a file is buffered while looking for moov
+const maxMoovSize = 64 << 20
+
+// TagM4A returns a reader producing the MP4 file read from r with the tags
+// written into moov/udta/meta/ilst. Any existing iTunes metadata is replaced.
+//
+// The file is streamed: only the boxes up to and including moov are held in
+// memory. When moov precedes the media data, the chunk offsets are shifted to
+// account for the new metadata and sizeDelta reports how many bytes the output
+// differs from the input, so the caller can compute the Content-Length.
+// When the media data comes first, moov is tagged once it is reached and
+// sizeKnown is false.
+func TagM4A(r io.Reader, tags M4ATags) (tagged io.Reader, sizeDelta int64, sizeKnown bool, err error) {
+	var prefix bytes.Buffer
+	var offset int64
+
+	for first := true; ; first = false {
+		header, typ, size, err := readBoxHeader(r)
+		if err != nil {
+			if first || errors.Is(err, io.EOF) {
+				return nil, 0, false, ErrNotMP4
+			}
+			return nil, 0, false, err
+		}
+		if first && typ != "ftyp" {
+			return nil, 0, false, ErrNotMP4
+		}
+
+		switch {
+		case typ == "moov":
+			if size < 0 || size-int64(len(header)) > maxMoovSize {
+				return nil, 0, false, fmt.Errorf("moov box too large")
+			}
+			payload := make([]byte, size-int64(len(header)))
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return nil, 0, false, err
+			}
+			moov, err := rewriteMoov(payload, tags)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			delta := int64(len(moov)) - size
+			// Shift the chunk offsets now that the new moov size is known
+			if delta != 0 {
+				if moov, err = rewriteMoovOffsets(moov, offset, delta); err != nil {
+					return nil, 0, false, err
+				}
+			}
+			return io.MultiReader(&prefix, bytes.NewReader(moov), r), delta, true, nil
+
+		case typ == "mdat":
+			// The media data comes before moov, so the offsets are not affected.
+			// Stream everything up to moov and tag it when it is reached.
+			if size < 0 {
+				return nil, 0, false, fmt.Errorf("moov box not found")
+			}
+			prefix.Write(header)
+			body := io.LimitReader(r, size-int64(len(header)))
+			rest := &trailingMoovReader{r: r, tags: tags, offset: offset + size}
+			return io.MultiReader(&prefix, body, rest), 0, false, nil
+
+		default:
+			if size < 0 || size > maxMoovSize || int64(prefix.Len())+size > maxMoovSize {
+				return nil, 0, false, fmt.Errorf("unexpected %q box before moov", typ)
+			}
+			prefix.Write(header)
+			if _, err := io.CopyN(&prefix, r, size-int64(len(header))); err != nil {
+				return nil, 0, false, err
+			}
+			offset += size
+		}
+	}
+}
+
+// trailingMoovReader copies the boxes following the media data, tagging moov on the way
+type trailingMoovReader struct {
+	r      io.Reader
+	tags   M4ATags
+	offset int64
+	buf    io.Reader
+	done   bool
+}
+
+func (t *trailingMoovReader) Read(p []byte) (int, error) {
+	for {
+		if t.buf != nil {
+			n, err := t.buf.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			t.buf = nil
+			if n > 0 {
+				return n, nil
+			}
+		}
+		if t.done {
+			return 0, io.EOF
+		}
+
+		header, typ, size, err := readBoxHeader(t.r)
+		if errors.Is(err, io.EOF) {
+			t.done = true
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if typ != "moov" {
+			if size < 0 {
+				// Box extends to the end of the file
+				t.buf = io.MultiReader(bytes.NewReader(header), t.r)
+				t.done = true
+				continue
+			}
+			t.buf = io.MultiReader(bytes.NewReader(header), io.LimitReader(t.r, size-int64(len(header))))
+			t.offset += size
+			continue
+		}
+
+		if size < 0 || size-int64(len(header)) > maxMoovSize {
+			return 0, fmt.Errorf("moov box too large")
+		}
+		payload := make([]byte, size-int64(len(header)))
+		if _, err := io.ReadFull(t.r, payload); err != nil {
+			return 0, err
+		}
+		moov, err := rewriteMoov(payload, t.tags)
+		if err != nil {
+			return 0, err
+		}
+		t.buf = bytes.NewReader(moov)
+		t.offset += size
+	}
+}
+
+// readBoxHeader reads a box header, returning the raw header bytes, the box
+// type and the total box size. A size of -1 means the box extends to EOF.
+func readBoxHeader(r io.Reader) ([]byte, string, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, "", 0, io.ErrUnexpectedEOF
+		}
+		return nil, "", 0, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	typ := string(header[4:8])
+	switch size {
+	case 0:
+		return header, typ, -1, nil
+	case 1:
+		large := make([]byte, 8)
+		if _, err := io.ReadFull(r, large); err != nil {
+			return nil, "", 0, err
+		}
+		header = append(header, large...)
+		size = int64(binary.BigEndian.Uint64(large))
+	}
+	if size < int64(len(header)) {
+		return nil, "", 0, fmt.Errorf("invalid size for %q box", typ)
+	}
+	return header, typ, size, nil
+}
+
+// mp4Box is a parsed box held in memory
+type mp4Box struct {
+	typ     string
+	payload []byte
+}
+
+// parseBoxes splits an in-memory box payload into its child boxes
+func parseBoxes(data []byte) ([]mp4Box, error) {
+	boxes := []mp4Box{}
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box header")
+		}
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		typ := string(data[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated box header")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerLen = 16
+		}
+		if size < headerLen || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size for %q box", typ)
+		}
+		boxes = append(boxes, mp4Box{typ: typ, payload: data[headerLen:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// makeBox serializes a box from its type and payload parts
+func makeBox(typ string, parts ...[]byte) []byte {
+	size := 8
+	for _, part := range parts {
+		size += len(part)
+	}
+	if size > math.MaxUint32 {
+		// Boxes built here are small, so this only guards against misuse
+		panic("mp4 box too large")
+	}
+
+	box := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(box[:4], uint32(size))
+	copy(box[4:8], typ)
+	for _, part := range parts {
+		box = append(box, part...)
+	}
+	return box
+}
+
+// rewriteMoov returns the moov box with the udta metadata replaced by tags
+func rewriteMoov(payload []byte, tags M4ATags) ([]byte, error) {
+	children, err := parseBoxes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := [][]byte{}
+	var udta []byte
+	for _, child := range children {
+		if child.typ == "udta" {
+			udta = child.payload
+			continue
+		}
+		parts = append(parts, makeBox(child.typ, child.payload))
+	}
+
+	newUdta, err := rewriteUdta(udta, tags)
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, newUdta)
+	return makeBox("moov", parts...), nil
+}
+
+// rewriteUdta keeps the user data boxes other than meta and appends the new metadata
+func rewriteUdta(payload []byte, tags M4ATags) ([]byte, error) {
+	children, err := parseBoxes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := [][]byte{}
+	for _, child := range children {
+		if child.typ != "meta" {
+			parts = append(parts, makeBox(child.typ, child.payload))
+		}
+	}
+	parts = append(parts, buildMeta(tags))
+	return makeBox("udta", parts...), nil
+}
+
+// rewriteMoovOffsets shifts every chunk offset of a serialized moov box that
+// points past moovOffset by delta bytes
+func rewriteMoovOffsets(moov []byte, moovOffset, delta int64) ([]byte, error) {
+	children, err := parseBoxes(moov[8:])
+	if err != nil {
+		return nil, err
+	}
+	parts, err := shiftChunkOffsets(children, moovOffset, delta)
+	if err != nil {
+		return nil, err
+	}
+	return makeBox("moov", parts...), nil
+}
+
+func shiftChunkOffsets(boxes []mp4Box, moovOffset, delta int64) ([][]byte, error) {
+	parts := make([][]byte, 0, len(boxes))
+	for _, box := range boxes {
+		switch {
+		case mp4Containers[box.typ]:
+			children, err := parseBoxes(box.payload)
+			if err != nil {
+				return nil, err
+			}
+			childParts, err := shiftChunkOffsets(children, moovOffset, delta)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, makeBox(box.typ, childParts...))
+
+		case box.typ == "stco" || box.typ == "co64":
+			shifted, err := shiftOffsetTable(box, moovOffset, delta)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, makeBox(box.typ, shifted))
+
+		default:
+			parts = append(parts, makeBox(box.typ, box.payload))
+		}
+	}
+	return parts, nil
+}
+
+// shiftOffsetTable shifts the entries of an stco (32-bit) or co64 (64-bit) table
+func shiftOffsetTable(box mp4Box, moovOffset, delta int64) ([]byte, error) {
+	if len(box.payload) < 8 {
+		return nil, fmt.Errorf("truncated %s box", box.typ)
+	}
+	payload := append([]byte{}, box.payload...)
+	count := int(binary.BigEndian.Uint32(payload[4:8]))
+
+	width := 4
+	if box.typ == "co64" {
+		width = 8
+	}
+	if len(payload) < 8+count*width {
+		return nil, fmt.Errorf("truncated %s box", box.typ)
+	}
+
+	for i := 0; i < count; i++ {
+		entry := payload[8+i*width : 8+(i+1)*width]
+		if width == 4 {
+			value := int64(binary.BigEndian.Uint32(entry))
+			if value > moovOffset {
+				value += delta
+			}
+			if value < 0 || value > math.MaxUint32 {
+				return nil, fmt.Errorf("chunk offset out of range for stco")
+			}
+			binary.BigEndian.PutUint32(entry, uint32(value))
+		} else {
+			value := int64(binary.BigEndian.Uint64(entry))
+			if value > moovOffset {
+				value += delta
+			}
+			binary.BigEndian.PutUint64(entry, uint64(value))
+		}
+	}
+	return payload, nil
+}
+
+// Data type indicators of iTunes metadata items
+const (
+	itunesImplicit = 0
+	itunesUTF8     = 1
+	itunesJPEG     = 13
+	itunesPNG      = 14
+)
+
+// buildMeta builds the meta box with the iTunes item list for tags
+func buildMeta(tags M4ATags) []byte {
+	items := [][]byte{}
+	text := func(atom, value string) {
+		if value != "" {
+			items = append(items, itunesItem(atom, itunesUTF8, []byte(value)))
+		}
+	}
+
+	text("\xa9nam", tags.Title)
+	text("\xa9ART", tags.Artist)
+	text("aART", tags.AlbumArtist)
+	text("\xa9alb", tags.Album)
+	text("\xa9day", tags.Year)
+	text("\xa9gen", tags.Genre)
+	text("cprt", tags.Copyright)
+	text("\xa9lyr", tags.Lyrics)
+
+	if tags.TrackNumber > 0 {
+		trkn := make([]byte, 8)
+		binary.BigEndian.PutUint16(trkn[2:4], uint16(tags.TrackNumber))
+		binary.BigEndian.PutUint16(trkn[4:6], uint16(tags.TrackTotal))
+		items = append(items, itunesItem("trkn", itunesImplicit, trkn))
+	}
+
+	if len(tags.Cover) > 0 {
+		dataType := uint32(itunesJPEG)
+		if bytes.HasPrefix(tags.Cover, []byte("\x89PNG")) {
+			dataType = itunesPNG
+		}
+		items = append(items, itunesItem("covr", dataType, tags.Cover))
+	}
+
+	// hdlr: version/flags, pre_defined, handler type "mdir", reserved ("appl" + 8 zero bytes), empty name
+	hdlr := makeBox("hdlr", []byte{0, 0, 0, 0, 0, 0, 0, 0}, []byte("mdirappl"), make([]byte, 9))
+
+	return makeBox("meta", []byte{0, 0, 0, 0}, hdlr, makeBox("ilst", items...))
+}
+
+// itunesItem builds an ilst item holding a single data box
+func itunesItem(atom string, dataType uint32, value []byte) []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], dataType)
+	return makeBox(atom, makeBox("data", header, value))
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+var testTags = M4ATags{
+	Title:       "Tum Hi Ho",
+	Artist:      "Arijit Singh",
+	Album:       "Aashiqui 2",
+	Year:        "2013",
+	TrackNumber: 2,
+	TrackTotal:  11,
+	Cover:       []byte("\xff\xd8\xff\xe0cover"),
+}
+
+// samples are the media data of the files in testdata, which hold two chunks
+var samples = []string{"sample-one-data!", "sample-two-data!"}
+
+func TestTagM4A(t *testing.T) {
+	tests := []struct {
+		file      string
+		sizeKnown bool
+		table     string
+	}{
+		{"moov-first.m4a", true, "stco"},
+		{"moov-first-co64.m4a", true, "co64"},
+		{"mdat-first.m4a", false, "stco"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			input, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tagged, delta, sizeKnown, err := TagM4A(bytes.NewReader(input), testTags)
+			if err != nil {
+				t.Fatalf("TagM4A: %v", err)
+			}
+			output, err := io.ReadAll(tagged)
+			if err != nil {
+				t.Fatalf("reading tagged file: %v", err)
+			}
+
+			if sizeKnown != tt.sizeKnown {
+				t.Errorf("sizeKnown = %v, want %v", sizeKnown, tt.sizeKnown)
+			}
+			if sizeKnown && int64(len(output)) != int64(len(input))+delta {
+				t.Errorf("output is %d bytes, want %d + %d", len(output), len(input), delta)
+			}
+
+			boxes, err := parseBoxes(output)
+			if err != nil {
+				t.Fatalf("parsing output: %v", err)
+			}
+			moov := findBox(t, boxes, "moov")
+
+			// Every chunk offset still points at its sample
+			offsets := chunkOffsets(t, moov, tt.table)
+			if len(offsets) != len(samples) {
+				t.Fatalf("%d chunk offsets, want %d", len(offsets), len(samples))
+			}
+			for i, offset := range offsets {
+				end := offset + int64(len(samples[i]))
+				if end > int64(len(output)) || string(output[offset:end]) != samples[i] {
+					t.Errorf("chunk %d at offset %d does not point at its sample", i, offset)
+				}
+			}
+
+			ilst := findBox(t, []mp4Box{moov}, "moov", "udta", "meta", "ilst")
+			items, err := parseBoxes(ilst.payload)
+			if err != nil {
+				t.Fatalf("parsing ilst: %v", err)
+			}
+			values := map[string]string{}
+			for _, item := range items {
+				data := findBox(t, []mp4Box{item}, item.typ, "data")
+				values[item.typ] = string(data.payload[8:])
+			}
+			for atom, want := range map[string]string{"\xa9nam": testTags.Title, "\xa9ART": testTags.Artist, "\xa9alb": testTags.Album, "\xa9day": testTags.Year, "covr": string(testTags.Cover)} {
+				if values[atom] != want {
+					t.Errorf("%q = %q, want %q", atom, values[atom], want)
+				}
+			}
+			if trkn := values["trkn"]; len(trkn) != 8 || binary.BigEndian.Uint16([]byte(trkn[2:4])) != 2 || binary.BigEndian.Uint16([]byte(trkn[4:6])) != 11 {
+				t.Errorf("trkn = %x, want track 2 of 11", trkn)
+			}
+		})
+	}
+}
+
+func TestTagM4ANotMP4(t *testing.T) {
+	if _, _, _, err := TagM4A(bytes.NewReader([]byte("ID3\x04 not an mp4 file")), testTags); !errors.Is(err, ErrNotMP4) {
+		t.Errorf("err = %v, want ErrNotMP4", err)
+	}
+}
+
+func TestTagM4AReadError(t *testing.T) {
+	input, err := os.ReadFile("testdata/mdat-first.m4a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body fails in the middle of the free box following the media data
+	boxes, err := parseBoxes(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moov, free := findBox(t, boxes, "moov"), findBox(t, boxes, "free")
+	cut := len(input) - 8 - len(moov.payload) - len(free.payload)/2
+	errBroken := errors.New("connection reset")
+	r := io.MultiReader(bytes.NewReader(input[:cut]), &failingReader{err: errBroken})
+
+	tagged, _, _, err := TagM4A(r, testTags)
+	if err != nil {
+		t.Fatalf("TagM4A: %v", err)
+	}
+	// Read used to return (0, nil) forever after such errors
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(tagged)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errBroken) {
+			t.Errorf("err = %v, want %v", err, errBroken)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading the tagged file did not return the error")
+	}
+}
+
+// failingReader fails every read
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read([]byte) (int, error) {
+	return 0, f.err
+}
+
+// findBox descends into boxes along a path of box types
+func findBox(t *testing.T, boxes []mp4Box, path ...string) mp4Box {
+	t.Helper()
+	for i, typ := range path {
+		var found *mp4Box
+		for j := range boxes {
+			if boxes[j].typ == typ {
+				found = &boxes[j]
+				break
+			}
+		}
+		if found == nil {
+			t.Fatalf("no %q box", typ)
+		}
+		if i == len(path)-1 {
+			return *found
+		}
+
+		payload := found.payload
+		if typ == "meta" {
+			// meta is a full box: version and flags precede its children
+			payload = payload[4:]
+		}
+		var err error
+		if boxes, err = parseBoxes(payload); err != nil {
+			t.Fatalf("parsing %q: %v", typ, err)
+		}
+	}
+	t.Fatal("empty path")
+	return mp4Box{}
+}
+
+// chunkOffsets returns the entries of the chunk offset table of the track in moov
+func chunkOffsets(t *testing.T, moov mp4Box, table string) []int64 {
+	t.Helper()
+	box := findBox(t, []mp4Box{moov}, "moov", "trak", "mdia", "minf", "stbl", table)
+	count := int(binary.BigEndian.Uint32(box.payload[4:8]))
+	offsets := make([]int64, count)
+	for i := range offsets {
+		if table == "co64" {
+			offsets[i] = int64(binary.BigEndian.Uint64(box.payload[8+i*8:]))
+		} else {
+			offsets[i] = int64(binary.BigEndian.Uint32(box.payload[8+i*4:]))
+		}
+	}
+	return offsets
+}
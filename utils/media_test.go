@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"crypto/des"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// encryptMediaURL encrypts a media URL the way upstream does, with DES in ECB
+// mode and PKCS5 padding
+func encryptMediaURL(t *testing.T, mediaURL, key string) string {
+	t.Helper()
+	block, err := des.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	padLen := block.BlockSize() - len(mediaURL)%block.BlockSize()
+	data := []byte(mediaURL + strings.Repeat(string(rune(padLen)), padLen))
+	encrypted := make([]byte, len(data))
+	for i := 0; i < len(data); i += block.BlockSize() {
+		block.Encrypt(encrypted[i:i+block.BlockSize()], data[i:i+block.BlockSize()])
+	}
+	return base64.StdEncoding.EncodeToString(encrypted)
+}
+
+func TestMediaResolverKey(t *testing.T) {
+	const mediaURL = "https://aac.saavncdn.com/123/abc_96.mp4"
+	encrypted := encryptMediaURL(t, mediaURL, "12345678")
+
+	resolution := (&MediaResolver{DecryptionKey: "12345678"}).Resolve(encrypted, true)
+	if resolution.Err != nil {
+		t.Fatalf("Resolve: %v", resolution.Err)
+	}
+	if len(resolution.DownloadURLs) != len(MediaQualities) {
+		t.Fatalf("got %d download URLs, want %d", len(resolution.DownloadURLs), len(MediaQualities))
+	}
+	if got, want := resolution.DownloadURLs[4]["url"], "https://aac.saavncdn.com/123/abc_320.mp4"; got != want {
+		t.Errorf("320kbps URL = %q, want %q", got, want)
+	}
+	if got, want := resolution.PreviewURL, "https://preview.saavncdn.com/123/abc_96_p.mp4"; got != want {
+		t.Errorf("preview URL = %q, want %q", got, want)
+	}
+
+	other := (&MediaResolver{DecryptionKey: "87654321"}).Resolve(encrypted, true)
+	if other.Err == nil || len(other.DownloadURLs) != 0 {
+		t.Errorf("another key resolved %v", other.DownloadURLs)
+	}
+}
+
+func TestMediaResolverAuthToken(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		query := r.URL.Query()
+		if query.Get("__call") != "song.generateAuthToken" || query.Get("url") != "tokenized" {
+			http.Error(w, "unexpected call", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth_url": "https://aac.saavncdn.com/song_" + query.Get("bitrate") + ".mp4?token=1",
+			"status":   "success",
+		})
+	}))
+	defer upstream.Close()
+
+	r := &MediaResolver{DecryptionKey: "12345678", BaseURL: upstream.URL, HTTPClient: upstream.Client()}
+	resolution := r.ResolveContext(context.Background(), "tokenized", false)
+	if resolution.Err != nil {
+		t.Fatalf("ResolveContext: %v", resolution.Err)
+	}
+	if resolution.Source != MediaSourceAuthToken {
+		t.Errorf("source = %q, want %q", resolution.Source, MediaSourceAuthToken)
+	}
+	if got, want := len(resolution.DownloadURLs), len(MediaQualities)-1; got != want {
+		t.Fatalf("got %d download URLs without 320kbps, want %d", got, want)
+	}
+	if got, want := resolution.DownloadURLs[0]["url"], "https://aac.saavncdn.com/song_12.mp4?token=1"; got != want {
+		t.Errorf("12kbps URL = %q, want %q", got, want)
+	}
+
+	// The auth URLs are cached per upstream
+	r.ResolveContext(context.Background(), "tokenized", false)
+	if got, want := calls.Load(), int32(len(MediaQualities)-1); got != want {
+		t.Errorf("upstream called %d times, want %d", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package utils
+
+import "time"
+
+// Settings are the server settings used by the package functions: the media
+// resolution of ResolveMedia, ResolveSongMedia and the formatters, and the
+// signed links. Programs embedding the library keep the defaults and resolve
+// media with a MediaResolver of their own.
+type Settings struct {
+	// DecryptionKey is the DES key of the encrypted media URLs
+	DecryptionKey string
+	// BaseURL is the upstream API endpoint of the auth-token fallback
+	BaseURL string
+	// MediaVerify checks every variant with a HEAD request, caching the
+	// results for MediaVerifyTTL
+	MediaVerify    bool
+	MediaVerifyTTL time.Duration
+	// MediaDebug logs the resolutions that fail and adds their diagnostics
+	// to the formatted songs
+	MediaDebug bool
+	// PublicBaseURL is the URL of the server in the links handed out
+	PublicBaseURL string
+	// DownloadSigningSecret signs the /download and /stream links, which are
+	// valid for DownloadLinkTTL. Links are only signed when it is set.
+	DownloadSigningSecret string
+	DownloadLinkTTL       time.Duration
+}
+
+// settings are the settings given to Configure
+var settings = Settings{
+	DecryptionKey:   "38346591",
+	BaseURL:         "https://www.jiosaavn.com/api.php",
+	MediaVerifyTTL:  defaultMediaVerifyTTL,
+	DownloadLinkTTL: time.Hour,
+}
+
+// Configure sets the settings of the package functions. The server calls it
+// with its configuration before serving.
+func Configure(s Settings) {
+	settings = s
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signed link verification errors
+var (
+	ErrLinkInvalid = errors.New("invalid link signature")
+	ErrLinkExpired = errors.New("link has expired")
+)
+
+// PreviewQuality is the quality of /stream links to the 30 second preview of a song
+const PreviewQuality = "preview"
+
+// SignedLinksEnabled reports whether the /download and /stream links handed
+// out are signed, and required to be
+func SignedLinksEnabled() bool {
+	return settings.DownloadSigningSecret != ""
+}
+
+// SignLink returns the HMAC-SHA256 signature of a link to a media route,
+// "download", "stream" or "hls"
+func SignLink(route, id, quality string, exp int64) string {
+	mac := hmac.New(sha256.New, []byte(settings.DownloadSigningSecret))
+	fmt.Fprintf(mac, "%s|%s|%s|%d", route, id, quality, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyLink checks the signature and expiry of a link to a media route
+func VerifyLink(route, id, quality, exp, sig string) error {
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || sig == "" {
+		return ErrLinkInvalid
+	}
+	if !hmac.Equal([]byte(SignLink(route, id, quality, expires)), []byte(strings.ToLower(sig))) {
+		return ErrLinkInvalid
+	}
+	if time.Now().Unix() > expires {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// LinkSignature returns the exp and sig query parameters of a link to a media
+// route, valid for DownloadLinkTTL, or none when signed links are disabled
+func LinkSignature(route, id, quality string) url.Values {
+	query := url.Values{}
+	if SignedLinksEnabled() {
+		exp := time.Now().Add(settings.DownloadLinkTTL).Unix()
+		query.Set("exp", strconv.FormatInt(exp, 10))
+		query.Set("sig", SignLink(route, id, quality, exp))
+	}
+	return query
+}
+
+// mediaLink builds a link to a media route of the server at baseURL, signed
+// and valid for DownloadLinkTTL when signed links are enabled
+func mediaLink(baseURL, route, id, quality string) string {
+	query := LinkSignature(route, id, quality)
+	query.Set("quality", quality)
+	return fmt.Sprintf("%s/%s/%s?%s", baseURL, route, url.PathEscape(id), query.Encode())
+}
+
+// SignedDownloadURL builds a /download link, signed when signed links are enabled
+func SignedDownloadURL(id, quality string) string {
+	return mediaLink(settings.PublicBaseURL, "download", id, quality)
+}
+
+// StreamURL builds the /stream link of a song on the server at baseURL,
+// signed when signed links are enabled
+func StreamURL(baseURL, id, quality string) string {
+	return mediaLink(baseURL, "stream", id, quality)
+}
+
+// DownloadLinks returns the downloadUrl entries to hand out for a song. When
+// signed links are enabled the raw CDN URLs are replaced by signed links.
+func DownloadLinks(id string, downloadURLs []map[string]string) []map[string]string {
+	if !SignedLinksEnabled() || id == "" {
+		return downloadURLs
+	}
+
+	links := make([]map[string]string, 0, len(downloadURLs))
+	for _, entry := range downloadURLs {
+		quality := strings.TrimSuffix(entry["quality"], "kbps")
+		links = append(links, map[string]string{
+			"quality": entry["quality"],
+			"url":     SignedDownloadURL(id, quality),
+		})
+	}
+	return links
+}
+
+// PreviewLink returns the previewUrl to hand out for a song. When signed
+// links are enabled the CDN URL, from which the URLs of the full song can be
+// derived, is replaced by a signed /stream link to the preview.
+func PreviewLink(id, previewURL string) string {
+	if !SignedLinksEnabled() || id == "" || previewURL == "" {
+		return previewURL
+	}
+	return StreamURL(settings.PublicBaseURL, id, PreviewQuality)
+}